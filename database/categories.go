@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Categorías que comparten un nombre sin distinguir mayúsculas, de la más antigua a la más nueva
+type duplicateCategoryName struct {
+	Name string               `bson:"_id"`
+	IDs  []primitive.ObjectID `bson:"ids"`
+}
+
+/** Describe cómo se van a renombrar las categorías con nombres repetidos
+ *
+ * Los nombres son únicos en todo el árbol, igual que en CategoryService, porque los
+ * slugs se generan a partir del nombre y se resuelven sin la categoría padre.
+ *
+ * @param ctx context.Context "El contexto de la base de datos"
+ * @param db *mongo.Database "La base de datos"
+ * @return []string "Los nombres repetidos y cómo se van a renombrar"
+ * @return error "El error de la consulta"
+ */
+func findDuplicateCategoryNames(ctx context.Context, db *mongo.Database) ([]string, error) {
+	duplicates, err := duplicateCategoryNames(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, duplicate := range duplicates {
+		problems = append(problems, fmt.Sprintf("el nombre %q está repetido en %d categorías; la más antigua lo conservará y las demás se renombrarán con un sufijo numérico", duplicate.Name, len(duplicate.IDs)))
+	}
+
+	return problems, nil
+}
+
+/** Renombra las categorías repetidas, completa los slugs faltantes y crea el índice único de nombres
+ *
+ * De cada grupo de categorías con el mismo nombre, la más antigua lo conserva y las
+ * demás reciben un sufijo numérico; luego se pueden fusionar con
+ * POST /admin/categories/{id}/merge-into/{target}. Las categorías creadas antes de
+ * los slugs reciben uno generado a partir del nombre.
+ *
+ * @param ctx context.Context "El contexto de la base de datos"
+ * @param db *mongo.Database "La base de datos"
+ * @return error "El error de la migración"
+ */
+func createCategoryNameIndex(ctx context.Context, db *mongo.Database) error {
+	categories := db.Collection("categories")
+
+	duplicates, err := duplicateCategoryNames(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, duplicate := range duplicates {
+		suffix := 2
+		for _, id := range duplicate.IDs[1:] {
+			var name string
+			if name, suffix, err = availableCategoryName(ctx, categories, duplicate.Name, suffix); err != nil {
+				return err
+			}
+
+			if _, err = categories.UpdateByID(ctx, id, bson.M{"$set": bson.M{"name": name}}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = fillCategorySlugs(ctx, categories); err != nil {
+		return err
+	}
+
+	_, err = categories.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().
+			SetName("name_unique_ci").
+			SetUnique(true).
+			SetCollation(CaseInsensitiveCollation),
+	})
+
+	return err
+}
+
+func duplicateCategoryNames(ctx context.Context, db *mongo.Database) ([]duplicateCategoryName, error) {
+	// La intercalación hace que $group compare los nombres igual que el índice único
+	cursor, err := db.Collection("categories").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$group", Value: bson.M{"_id": "$name", "ids": bson.M{"$push": "$_id"}}}},
+		{{Key: "$match", Value: bson.M{"ids.1": bson.M{"$exists": true}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}, options.Aggregate().SetCollation(CaseInsensitiveCollation))
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicates []duplicateCategoryName
+	err = cursor.All(ctx, &duplicates)
+	return duplicates, err
+}
+
+// Busca el primer nombre "<name> (<n>)" libre desde el sufijo indicado y devuelve el sufijo siguiente
+func availableCategoryName(ctx context.Context, categories *mongo.Collection, name string, suffix int) (string, int, error) {
+	base := strings.TrimSpace(name)
+	if base == "" {
+		base = "Categoría"
+	}
+
+	for ; ; suffix++ {
+		candidate := fmt.Sprintf("%s (%d)", base, suffix)
+
+		count, err := categories.CountDocuments(ctx, bson.M{"name": candidate}, options.Count().SetCollation(CaseInsensitiveCollation).SetLimit(1))
+		if err != nil {
+			return "", suffix, err
+		} else if count == 0 {
+			return candidate, suffix + 1, nil
+		}
+	}
+}
+
+// Asigna un slug único a las categorías que no tienen, como CategoryService.uniqueSlug
+func fillCategorySlugs(ctx context.Context, categories *mongo.Collection) error {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"slug": bson.M{"$exists": false}},
+		bson.M{"slug": ""},
+		bson.M{"slug": nil},
+	}}
+	cursor, err := categories.Find(ctx, filter, options.Find().SetProjection(bson.M{"name": 1}).SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+
+	var pending []struct {
+		ID   primitive.ObjectID `bson:"_id"`
+		Name string             `bson:"name"`
+	}
+	if err = cursor.All(ctx, &pending); err != nil {
+		return err
+	}
+
+	for _, category := range pending {
+		base := utils.Slugify(category.Name)
+		if base == "" {
+			base = "categoria"
+		}
+
+		slug := base
+		for i := 2; ; i++ {
+			count, err := categories.CountDocuments(ctx, bson.M{"$or": bson.A{
+				bson.M{"slug": slug},
+				bson.M{"previous_slugs": slug},
+			}}, options.Count().SetLimit(1))
+			if err != nil {
+				return err
+			} else if count == 0 {
+				break
+			}
+
+			slug = fmt.Sprintf("%s-%d", base, i)
+		}
+
+		if _, err = categories.UpdateByID(ctx, category.ID, bson.M{"$set": bson.M{"slug": slug}}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -9,10 +9,10 @@ import (
 
 /** Establece conexión con la base de datos
  *
- * @param ctx context.Context El contexto de la base de datos
- * @param mongodbUri string La URI de conexión con la base de datos
- * @return *mongo.Client El cliente de base de datos
- * @return error El error al conectarse
+ * @param ctx context.Context "El contexto de la base de datos"
+ * @param mongodbUri string "La URI de conexión con la base de datos"
+ * @return *mongo.Client "El cliente de base de datos"
+ * @return error "El error al conectarse"
  */
 func Open(ctx context.Context, mongoDBUri string) (*mongo.Client, error) {
 	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoDBUri))
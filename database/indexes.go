@@ -0,0 +1,295 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Intercalación usada para comparar textos sin distinguir mayúsculas de minúsculas
+var CaseInsensitiveCollation = &options.Collation{Locale: "es", Strength: 2}
+
+/** Crea los índices iniciales de las colecciones de la base de datos
+ *
+ * El índice único de nombres de categorías lo crea la migración 7, después de
+ * renombrar las categorías repetidas.
+ *
+ * @param ctx context.Context "El contexto de la base de datos"
+ * @param db *mongo.Database "La base de datos"
+ * @return error "El error al crear los índices"
+ */
+func createInitialIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("categories").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().
+				SetName("slug_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"slug": bson.M{"$type": "string"}}),
+		},
+		{
+			Keys:    bson.D{{Key: "previous_slugs", Value: 1}},
+			Options: options.Index().SetName("previous_slugs"),
+		},
+		{
+			Keys:    bson.D{{Key: "merged_ids", Value: 1}},
+			Options: options.Index().SetName("merged_ids"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("appointments").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "category", Value: 1}},
+		Options: options.Index().SetName("category"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("appointments").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "helper", Value: 1}, {Key: "date", Value: 1}},
+		Options: options.Index().SetName("helper_date"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "skills", Value: 1}},
+		Options: options.Index().SetName("skills"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("reviews").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "appointment_id", Value: 1}, {Key: "author_role", Value: 1}},
+			Options: options.Index().SetName("appointment_author_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "subject_id", Value: 1}, {Key: "status", Value: 1}},
+			Options: options.Index().SetName("subject_status"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("jobs").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "run_at", Value: 1}},
+			Options: options.Index().SetName("status_run_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "appointment_id", Value: 1}},
+			Options: options.Index().SetName("appointment_id"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("appointment_notes").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "appointment_id", Value: 1}, {Key: "created_at", Value: 1}},
+		Options: options.Index().SetName("appointment_created_at"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("appointment_attachments").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "appointment_id", Value: 1}, {Key: "created_at", Value: 1}},
+		Options: options.Index().SetName("appointment_created_at"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("user_imports").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: -1}},
+		Options: options.Index().SetName("created_at"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("exports").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: -1}},
+		Options: options.Index().SetName("created_at"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("email_outbox").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+			Options: options.Index().SetName("status_next_attempt_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("created_at"),
+		},
+	})
+
+	return err
+}
+
+/** Crea el índice único de correos electrónicos de los usuarios
+ *
+ * @param ctx context.Context "El contexto de la base de datos"
+ * @param db *mongo.Database "La base de datos"
+ * @return error "El error al crear el índice"
+ */
+func createUserEmailIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetName("email_unique").SetUnique(true),
+	})
+
+	return err
+}
+
+/** Busca correos electrónicos repetidos, que impiden crear el índice único
+ *
+ * @param ctx context.Context "El contexto de la base de datos"
+ * @param db *mongo.Database "La base de datos"
+ * @return []string "Los correos repetidos"
+ * @return error "El error de la consulta"
+ */
+func findDuplicateEmails(ctx context.Context, db *mongo.Database) ([]string, error) {
+	return duplicateEmails(ctx, db, "$email")
+}
+
+/** Busca correos electrónicos repetidos sin distinguir mayúsculas ni espacios
+ *
+ * @param ctx context.Context "El contexto de la base de datos"
+ * @param db *mongo.Database "La base de datos"
+ * @return []string "Los correos repetidos"
+ * @return error "El error de la consulta"
+ */
+func findDuplicateNormalizedEmails(ctx context.Context, db *mongo.Database) ([]string, error) {
+	return duplicateEmails(ctx, db, normalizedEmail)
+}
+
+// Expresión de agregación que normaliza el correo como utils.NormalizeEmail
+var normalizedEmail = bson.M{"$toLower": bson.M{"$trim": bson.M{"input": "$email"}}}
+
+func duplicateEmails(ctx context.Context, db *mongo.Database, key interface{}) ([]string, error) {
+	cursor, err := db.Collection("users").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"email": bson.M{"$type": "string"}}}},
+		{{Key: "$group", Value: bson.M{"_id": key, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicates []struct {
+		Email string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	if err = cursor.All(ctx, &duplicates); err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, duplicate := range duplicates {
+		problems = append(problems, fmt.Sprintf("el correo %s está repetido en %d usuarios", duplicate.Email, duplicate.Count))
+	}
+
+	return problems, nil
+}
+
+/** Crea el índice TTL que elimina las sesiones vencidas
+ *
+ * El modelo de sesión no define etiquetas bson, por lo que la fecha de vencimiento
+ * se guarda en el campo "expiresat".
+ *
+ * @param ctx context.Context "El contexto de la base de datos"
+ * @param db *mongo.Database "La base de datos"
+ * @return error "El error al crear el índice"
+ */
+func createSessionExpirationIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("sessions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresat", Value: 1}},
+		Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0),
+	})
+
+	return err
+}
+
+/** Crea el índice TTL que elimina las claves de idempotencia vencidas
+ *
+ * @param ctx context.Context "El contexto de la base de datos"
+ * @param db *mongo.Database "La base de datos"
+ * @return error "El error al crear el índice"
+ */
+func createIdempotencyExpirationIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("idempotency_keys").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0),
+	})
+
+	return err
+}
+
+/** Normaliza los correos de los usuarios y hace que el índice único no distinga mayúsculas
+ *
+ * Si hay correos que solo difieren en mayúsculas o espacios, el índice nuevo no se
+ * podría crear, así que la migración falla sin modificar los usuarios ni el índice
+ * anterior; los usuarios repetidos se deben resolver antes de volver a aplicarla.
+ *
+ * @param ctx context.Context "El contexto de la base de datos"
+ * @param db *mongo.Database "La base de datos"
+ * @return error "El error de la migración"
+ */
+func normalizeUserEmails(ctx context.Context, db *mongo.Database) error {
+	users := db.Collection("users")
+
+	problems, err := findDuplicateNormalizedEmails(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("hay correos repetidos sin distinguir mayúsculas: %s", strings.Join(problems, "; "))
+	}
+
+	// El índice anterior distingue mayúsculas, por lo que se elimina antes de normalizar
+	if _, err := users.Indexes().DropOne(ctx, "email_unique"); err != nil && !isIndexNotFound(err) {
+		return err
+	}
+
+	_, err = users.UpdateMany(ctx, bson.M{"email": bson.M{"$type": "string"}}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"email": normalizedEmail}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = users.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().
+			SetName("email_unique_ci").
+			SetUnique(true).
+			SetCollation(CaseInsensitiveCollation),
+	})
+
+	return err
+}
+
+// Indica si el error es de un índice inexistente, por ejemplo al repetir una migración que falló
+func isIndexNotFound(err error) bool {
+	var commandError mongo.CommandError
+	return errors.As(err, &commandError) && commandError.Code == 27
+}
@@ -0,0 +1,422 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migración de la base de datos, identificada por una versión creciente
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	// Detecta datos que harían fallar la migración; si hay problemas no se aplica
+	Check func(ctx context.Context, db *mongo.Database) ([]string, error)
+	// Describe los cambios que hará la migración en los datos, para mostrarlos en la simulación
+	Preview func(ctx context.Context, db *mongo.Database) ([]string, error)
+}
+
+// Estado de una migración en la base de datos
+type MigrationStatus struct {
+	Version     int        `json:"version"`
+	Description string     `json:"description"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+	Problems    []string   `json:"problems,omitempty"`
+	Changes     []string   `json:"changes,omitempty"`
+}
+
+// Registro de una migración aplicada en la colección schema_migrations
+type migrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+	DurationMs  int64     `bson:"duration_ms"`
+}
+
+// Bloqueo que impide que varias instancias migren la base de datos a la vez
+type migrationLock struct {
+	ID        string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	LockedAt  time.Time `bson:"locked_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+const (
+	migrationsCollection     = "schema_migrations"
+	migrationLocksCollection = "schema_migrations_lock"
+	migrationLockID          = "schema_migrations"
+	migrationLockDuration    = 10 * time.Minute
+	migrationLockWait        = time.Minute
+	migrationLockPoll        = time.Second
+	// Frecuencia con la que se extiende el bloqueo mientras se aplican las migraciones
+	migrationLockRenewal = 2 * time.Minute
+)
+
+// Migraciones de la base de datos, en orden de aplicación. Las migraciones aplicadas
+// no se deben modificar; los cambios se agregan como migraciones nuevas al final.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "Crea los índices iniciales de las colecciones",
+		Up:          createInitialIndexes,
+	},
+	{
+		Version:     2,
+		Description: "Crea el índice único de correos electrónicos de usuarios",
+		Up:          createUserEmailIndex,
+		Check:       findDuplicateEmails,
+	},
+	{
+		Version:     3,
+		Description: "Crea el índice TTL de vencimiento de sesiones",
+		Up:          createSessionExpirationIndex,
+	},
+	{
+		Version:     4,
+		Description: "Normaliza los correos de usuarios y hace el índice único insensible a mayúsculas",
+		Up:          normalizeUserEmails,
+		Check:       findDuplicateNormalizedEmails,
+	},
+	{
+		Version:     5,
+		Description: "Asigna la versión inicial a los usuarios y las citas",
+		Up:          addDocumentVersions,
+	},
+	{
+		Version:     6,
+		Description: "Crea el índice TTL de vencimiento de claves de idempotencia",
+		Up:          createIdempotencyExpirationIndex,
+	},
+	{
+		Version:     7,
+		Description: "Renombra las categorías repetidas, completa sus slugs y crea el índice único de nombres",
+		Up:          createCategoryNameIndex,
+		Preview:     findDuplicateCategoryNames,
+	},
+}
+
+/** Obtiene el estado de todas las migraciones
+ *
+ * @param ctx context.Context "El contexto de la base de datos"
+ * @param db *mongo.Database "La base de datos"
+ * @return []MigrationStatus "El estado de cada migración, en orden"
+ * @return error "El error de la consulta"
+ */
+func GetMigrationStatus(ctx context.Context, db *mongo.Database) ([]MigrationStatus, error) {
+	if err := validateMigrations(); err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{
+			Version:     migration.Version,
+			Description: migration.Description,
+		}
+
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+/** Obtiene las migraciones pendientes, los problemas que impedirían aplicarlas y los cambios que harían
+ *
+ * No modifica la base de datos.
+ *
+ * @param ctx context.Context "El contexto de la base de datos"
+ * @param db *mongo.Database "La base de datos"
+ * @return []MigrationStatus "Las migraciones pendientes"
+ * @return error "El error de la consulta"
+ */
+func PendingMigrations(ctx context.Context, db *mongo.Database) ([]MigrationStatus, error) {
+	statuses, err := GetMigrationStatus(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []MigrationStatus
+	for i, status := range statuses {
+		if status.AppliedAt != nil {
+			continue
+		}
+
+		if check := migrations[i].Check; check != nil {
+			if status.Problems, err = check(ctx, db); err != nil {
+				return nil, err
+			}
+		}
+
+		if preview := migrations[i].Preview; preview != nil {
+			if status.Changes, err = preview(ctx, db); err != nil {
+				return nil, err
+			}
+		}
+
+		pending = append(pending, status)
+	}
+
+	return pending, nil
+}
+
+/** Aplica las migraciones pendientes en orden
+ *
+ * Toma el bloqueo de migraciones, por lo que si otra instancia está migrando espera a
+ * que termine. El bloqueo se renueva mientras se migra, así que una migración puede
+ * tardar más que migrationLockDuration; si se pierde, se cancela la migración en curso.
+ * Antes de aplicar cada migración se ejecuta su Check; si informa problemas, la
+ * migración no se aplica. Cada migración aplicada se registra en schema_migrations;
+ * si una falla, las siguientes no se aplican.
+ *
+ * @param ctx context.Context "El contexto de la base de datos"
+ * @param db *mongo.Database "La base de datos"
+ * @return []MigrationStatus "Las migraciones aplicadas"
+ * @return error "El error de la migración que falló"
+ */
+func Migrate(ctx context.Context, db *mongo.Database) ([]MigrationStatus, error) {
+	if err := validateMigrations(); err != nil {
+		return nil, err
+	}
+
+	owner, err := acquireMigrationLock(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	lost := renewMigrationLock(ctx, cancel, db, owner)
+	defer func() {
+		cancel()
+		<-lost
+		releaseMigrationLock(db, owner)
+	}()
+
+	// Se consulta después de tomar el bloqueo, por si otra instancia acaba de migrar
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []MigrationStatus
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		if migration.Check != nil {
+			var problems []string
+			if problems, err = migration.Check(ctx, db); err != nil {
+				return done, lockError(ctx, lost, err)
+			}
+
+			if len(problems) > 0 {
+				return done, fmt.Errorf("la migración %d (%s) no se puede aplicar: %s", migration.Version, migration.Description, strings.Join(problems, "; "))
+			}
+		}
+
+		start := time.Now()
+		if err = migration.Up(ctx, db); err != nil {
+			return done, fmt.Errorf("la migración %d (%s) falló: %w", migration.Version, migration.Description, lockError(ctx, lost, err))
+		}
+
+		record := migrationRecord{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+			DurationMs:  time.Since(start).Milliseconds(),
+		}
+		if _, err = db.Collection(migrationsCollection).InsertOne(ctx, record); err != nil {
+			return done, lockError(ctx, lost, err)
+		}
+
+		done = append(done, MigrationStatus{
+			Version:     record.Version,
+			Description: record.Description,
+			AppliedAt:   &record.AppliedAt,
+		})
+	}
+
+	return done, nil
+}
+
+func appliedMigrations(ctx context.Context, db *mongo.Database) (map[int]migrationRecord, error) {
+	cursor, err := db.Collection(migrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	var records []migrationRecord
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]migrationRecord, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+
+	return applied, nil
+}
+
+// Verifica que las versiones de las migraciones sean crecientes y no se repitan
+func validateMigrations() error {
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version <= migrations[i-1].Version {
+			return fmt.Errorf("la migración %d debe tener una versión mayor que %d", migrations[i].Version, migrations[i-1].Version)
+		}
+	}
+
+	return nil
+}
+
+/** Toma el bloqueo de migraciones, esperando si otra instancia lo tiene
+ *
+ * Un bloqueo vencido, por ejemplo de una instancia que se detuvo durante la migración,
+ * se puede tomar.
+ *
+ * @param ctx context.Context "El contexto de la base de datos"
+ * @param db *mongo.Database "La base de datos"
+ * @return string "El identificador del dueño del bloqueo"
+ * @return error "El error si no se pudo tomar el bloqueo a tiempo"
+ */
+func acquireMigrationLock(ctx context.Context, db *mongo.Database) (string, error) {
+	hostname, _ := os.Hostname()
+	owner := fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), primitive.NewObjectID().Hex())
+	collection := db.Collection(migrationLocksCollection)
+	deadline := time.Now().Add(migrationLockWait)
+
+	for {
+		now := time.Now()
+		lock := migrationLock{
+			ID:        migrationLockID,
+			Owner:     owner,
+			LockedAt:  now,
+			ExpiresAt: now.Add(migrationLockDuration),
+		}
+
+		_, err := collection.InsertOne(ctx, lock)
+		if err == nil {
+			return owner, nil
+		} else if !mongo.IsDuplicateKeyError(err) {
+			return "", err
+		}
+
+		// Toma el bloqueo si venció
+		err = collection.FindOneAndReplace(ctx, bson.M{
+			"_id":        migrationLockID,
+			"expires_at": bson.M{"$lt": now},
+		}, lock, options.FindOneAndReplace()).Err()
+		if err == nil {
+			return owner, nil
+		} else if err != mongo.ErrNoDocuments {
+			return "", err
+		}
+
+		if now.After(deadline) {
+			return "", errors.New("otra instancia está aplicando las migraciones")
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(migrationLockPoll):
+		}
+	}
+}
+
+/** Extiende periódicamente el vencimiento del bloqueo de migraciones hasta que se cancele el contexto
+ *
+ * Si otra instancia tomó el bloqueo, por ejemplo porque no se pudo renovar a tiempo,
+ * envía el error por el canal y cancela el contexto para detener la migración. Los
+ * errores al renovar se reintentan en la siguiente renovación.
+ *
+ * @param ctx context.Context "El contexto de la migración"
+ * @param cancel context.CancelFunc "Cancela el contexto de la migración"
+ * @param db *mongo.Database "La base de datos"
+ * @param owner string "El identificador del dueño del bloqueo"
+ * @return <-chan error "Recibe el error si se perdió el bloqueo; se cierra al terminar la renovación"
+ */
+func renewMigrationLock(ctx context.Context, cancel context.CancelFunc, db *mongo.Database, owner string) <-chan error {
+	lost := make(chan error, 1)
+
+	go func() {
+		defer close(lost)
+
+		ticker := time.NewTicker(migrationLockRenewal)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			result, err := db.Collection(migrationLocksCollection).UpdateOne(ctx, bson.M{
+				"_id":   migrationLockID,
+				"owner": owner,
+			}, bson.M{"$set": bson.M{"expires_at": time.Now().Add(migrationLockDuration)}})
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Error al renovar el bloqueo de migraciones: %v", err)
+				}
+				continue
+			}
+
+			if result.MatchedCount == 0 {
+				lost <- errors.New("se perdió el bloqueo de migraciones, otra instancia lo tomó")
+				cancel()
+				return
+			}
+		}
+	}()
+
+	return lost
+}
+
+/** Reemplaza el error de una migración cancelada por la pérdida del bloqueo
+ *
+ * @param ctx context.Context "El contexto de la migración"
+ * @param lost <-chan error "El canal de renovación del bloqueo"
+ * @param err error "El error de la migración"
+ * @return error "El error de la pérdida del bloqueo, o err si el bloqueo se mantiene"
+ */
+func lockError(ctx context.Context, lost <-chan error, err error) error {
+	if ctx.Err() == nil {
+		return err
+	}
+
+	// El contexto ya se canceló, así que la renovación termina y el canal se cierra
+	if lockErr, ok := <-lost; ok {
+		return lockErr
+	}
+
+	return err
+}
+
+func releaseMigrationLock(db *mongo.Database, owner string) {
+	db.Collection(migrationLocksCollection).DeleteOne(context.Background(), bson.M{
+		"_id":   migrationLockID,
+		"owner": owner,
+	})
+}
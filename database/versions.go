@@ -0,0 +1,26 @@
+package database
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Colecciones cuyos documentos tienen versión para detectar ediciones simultáneas
+var versionedCollections = []string{"users", "appointments"}
+
+// Asigna la versión inicial a los documentos creados antes de que existiera el campo
+func addDocumentVersions(ctx context.Context, db *mongo.Database) error {
+	for _, name := range versionedCollections {
+		_, err := db.Collection(name).UpdateMany(ctx,
+			bson.M{"version": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"version": 1}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -32,6 +32,44 @@ const docTemplate_swagger = `{
                 ],
                 "summary": "Obtiene todos las citas",
                 "operationId": "get-appointments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Estado de la cita",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID del ayudante",
+                        "name": "helper",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID del solicitante",
+                        "name": "created_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID de la categoría",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fecha inicial (AAAA-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fecha final, incluida (AAAA-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -42,7 +80,7 @@ const docTemplate_swagger = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/services.GetAppointmentsResponse"
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
@@ -70,6 +108,12 @@ const docTemplate_swagger = `{
                         "schema": {
                             "$ref": "#/definitions/services.CreateAppointmentRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Clave para repetir la respuesta en los reintentos",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -82,7 +126,107 @@ const docTemplate_swagger = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/services.CreateAppointmentResponse"
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "La solicitud con la misma clave se está procesando",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "La clave de idempotencia se usó con otra solicitud",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/appointments/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/x-ndjson",
+                    "application/json"
+                ],
+                "summary": "Exporta las citas a CSV, XLSX o NDJSON",
+                "operationId": "export-appointments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Formato (csv, xlsx, ndjson)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Generar la exportación en segundo plano",
+                        "name": "async",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Estado de la cita",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID del ayudante",
+                        "name": "helper",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID del solicitante",
+                        "name": "created_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID de la categoría",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fecha inicial (AAAA-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fecha final, incluida (AAAA-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/services.StartExportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
@@ -107,6 +251,12 @@ const docTemplate_swagger = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag de la versión que tiene el cliente",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -114,12 +264,21 @@ const docTemplate_swagger = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/services.GetAppointmentResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Versión de la cita"
+                            }
                         }
                     },
+                    "304": {
+                        "description": "La versión del cliente es la actual"
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/services.GetAppointmentResponse"
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
@@ -154,6 +313,13 @@ const docTemplate_swagger = `{
                         "schema": {
                             "$ref": "#/definitions/services.UpdateAppointmentRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag de la versión de la cita que se modifica, una lista de ETag o *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -166,7 +332,19 @@ const docTemplate_swagger = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/services.UpdateAppointmentResponse"
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "El registro fue modificado por otra persona",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Falta la cabecera If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
@@ -189,6 +367,13 @@ const docTemplate_swagger = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag de la versión de la cita que se elimina, una lista de ETag o *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -201,40 +386,105 @@ const docTemplate_swagger = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "El registro fue modificado por otra persona",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Falta la cabecera If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/admin/categories": {
-            "get": {
+            },
+            "patch": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
+                "description": "Aplica un parche JSON Merge Patch (RFC 7396): sólo se modifican los campos indicados y los campos con valor null se vacían",
+                "consumes": [
+                    "application/merge-patch+json"
+                ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Obtiene las categorías",
-                "operationId": "get-categories",
+                "summary": "Actualiza parcialmente una cita",
+                "operationId": "patch-appointment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la cita",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Campos a modificar",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateAppointmentRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag de la versión de la cita que se modifica, una lista de ETag o *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/services.GetCategoriesResponse"
+                            "$ref": "#/definitions/services.UpdateAppointmentResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/services.GetCategoriesResponse"
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "El registro fue modificado por otra persona",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Falta la cabecera If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/admin/appointments/{id}/attachments": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
@@ -243,73 +493,129 @@ const docTemplate_swagger = `{
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Crea una categoría",
-                "operationId": "create-category",
+                "summary": "Obtiene los archivos adjuntos de una cita",
+                "operationId": "get-appointment-attachments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la cita",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/services.CreateCategoryResponse"
+                            "$ref": "#/definitions/services.GetAppointmentAttachmentsResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/services.CreateCategoryResponse"
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/admin/categories/{id}": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
+                "consumes": [
+                    "multipart/form-data"
+                ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Obtiene una categoría por su ID",
-                "operationId": "get-category",
+                "summary": "Sube un archivo adjunto a una cita",
+                "operationId": "upload-appointment-attachment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la cita",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Imagen JPEG, PNG, GIF o WebP, o documento PDF",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/services.GetCategoryResponse"
+                            "$ref": "#/definitions/services.UploadAppointmentAttachmentResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/services.GetCategoryResponse"
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "La cita no existe",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/admin/appointments/{id}/attachments/{attachmentId}": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
+                ],
+                "summary": "Descarga un archivo adjunto de una cita",
+                "operationId": "download-appointment-attachment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la cita",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID del archivo adjunto",
+                        "name": "attachmentId",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Actualiza una categoría",
-                "operationId": "update-category",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/services.UpdateCategoryResponse"
+                            "type": "file"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/services.UpdateCategoryResponse"
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
@@ -323,8 +629,24 @@ const docTemplate_swagger = `{
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Elimina una categoría",
-                "operationId": "delete-category",
+                "summary": "Elimina un archivo adjunto de una cita",
+                "operationId": "delete-appointment-attachment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la cita",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID del archivo adjunto",
+                        "name": "attachmentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -335,13 +657,19 @@ const docTemplate_swagger = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "El archivo adjunto no existe",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
             }
         },
-        "/admin/users": {
+        "/admin/appointments/{id}/notes": {
             "get": {
                 "security": [
                     {
@@ -351,19 +679,34 @@ const docTemplate_swagger = `{
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Obtiene todos los usuarios",
-                "operationId": "get-users",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/services.GetUsersResponse"
-                        }
-                    },
+                "summary": "Obtiene las notas de una cita en orden cronológico",
+                "operationId": "get-appointment-notes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la cita",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Visibilidad (internal, shared)",
+                        "name": "visibility",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.GetAppointmentNotesResponse"
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/services.GetUsersResponse"
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
@@ -380,16 +723,23 @@ const docTemplate_swagger = `{
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Crea un usuario",
-                "operationId": "create-user",
+                "summary": "Agrega una nota a una cita",
+                "operationId": "create-appointment-note",
                 "parameters": [
                     {
-                        "description": "Datos del usuario",
-                        "name": "CreateUserRequest",
+                        "type": "string",
+                        "description": "ID de la cita",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Texto y visibilidad de la nota",
+                        "name": "CreateAppointmentNoteRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/services.CreateUserRequest"
+                            "$ref": "#/definitions/services.CreateAppointmentNoteRequest"
                         }
                     }
                 ],
@@ -397,20 +747,26 @@ const docTemplate_swagger = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/services.CreateUserResponse"
+                            "$ref": "#/definitions/services.CreateAppointmentNoteResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/services.CreateUserResponse"
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "La cita no existe",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
             }
         },
-        "/admin/users/email/{email}": {
-            "get": {
+        "/admin/appointments/{id}/notes/{noteId}": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
@@ -419,13 +775,20 @@ const docTemplate_swagger = `{
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Obtiene un usuario por su correo electrónico",
-                "operationId": "get-user-by-email",
+                "summary": "Elimina una nota de una cita",
+                "operationId": "delete-appointment-note",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Correo electrónico del usuario",
-                        "name": "email",
+                        "description": "ID de la cita",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID de la nota",
+                        "name": "noteId",
                         "in": "path",
                         "required": true
                     }
@@ -434,19 +797,25 @@ const docTemplate_swagger = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/services.GetUserResponse"
+                            "type": "string"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/services.GetUserResponse"
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "La nota no existe",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
             }
         },
-        "/admin/users/{id}": {
+        "/admin/appointments/{id}/reviews": {
             "get": {
                 "security": [
                     {
@@ -456,12 +825,12 @@ const docTemplate_swagger = `{
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Obtiene un usuario",
-                "operationId": "get-user",
+                "summary": "Obtiene las reseñas de una cita",
+                "operationId": "get-appointment-reviews",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID del usuario",
+                        "type": "string",
+                        "description": "ID de la cita",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -471,18 +840,18 @@ const docTemplate_swagger = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/services.GetUserResponse"
+                            "$ref": "#/definitions/services.GetReviewsResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/services.GetUserResponse"
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
             },
-            "put": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
@@ -494,23 +863,23 @@ const docTemplate_swagger = `{
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Actualiza un usuario",
-                "operationId": "update-user",
+                "summary": "Crea la reseña de una cita completada",
+                "operationId": "create-review",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID del usuario",
+                        "type": "string",
+                        "description": "ID de la cita",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Datos del usuario",
-                        "name": "UpdateUserRequest",
+                        "description": "Datos de la reseña",
+                        "name": "CreateReviewRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/services.UpdateUserRequest"
+                            "$ref": "#/definitions/services.CreateReviewRequest"
                         }
                     }
                 ],
@@ -518,18 +887,32 @@ const docTemplate_swagger = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/services.UpdateUserResponse"
+                            "$ref": "#/definitions/services.CreateReviewResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/services.UpdateUserResponse"
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "La cita no existe",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "La cita ya tiene una reseña de este autor",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/categories": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
@@ -538,101 +921,170 @@ const docTemplate_swagger = `{
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Elimina un usuario",
-                "operationId": "delete-user",
+                "summary": "Obtiene las categorías",
+                "operationId": "get-categories",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID del usuario",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "Idioma de los nombres y descripciones",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idiomas preferidos",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID de la categoría padre, o root para las de primer nivel",
+                        "name": "parent_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Texto a buscar en el nombre o el slug",
+                        "name": "q",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/services.GetCategoriesResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/admin/users/{id}/password": {
-            "put": {
+            },
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "consumes": [
+                "produces": [
                     "application/json"
                 ],
+                "summary": "Crea una categoría",
+                "operationId": "create-category",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.CreateCategoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Ya existe una categoría con ese nombre",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/categories/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
                 "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/x-ndjson",
                     "application/json"
                 ],
-                "summary": "Cambia la contraseña de un usuario",
-                "operationId": "change-password",
+                "summary": "Exporta las categorías a CSV, XLSX o NDJSON",
+                "operationId": "export-categories",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID del usuario",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Formato (csv, xlsx, ndjson)",
+                        "name": "format",
+                        "in": "query"
                     },
                     {
-                        "description": "Datos del usuario",
-                        "name": "ChangePasswordRequest",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/services.ChangePasswordRequest"
-                        }
+                        "type": "boolean",
+                        "description": "Generar la exportación en segundo plano",
+                        "name": "async",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID de la categoría padre, o root para las de primer nivel",
+                        "name": "parent_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Texto a buscar en el nombre o el slug",
+                        "name": "q",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "file"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/services.StartExportResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
             }
         },
-        "/admin/users/{id}/set-super-admin": {
+        "/admin/categories/reorder": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Configura un usuario como super administrador",
-                "operationId": "set-super-admin",
+                "summary": "Reordena las subcategorías de una categoría",
+                "operationId": "reorder-categories",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID del usuario",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Categoría padre y orden de las subcategorías",
+                        "name": "ReorderCategoriesRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.ReorderCategoriesRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -645,14 +1097,14 @@ const docTemplate_swagger = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
             }
         },
-        "/admin/users/{id}/unset-super-admin": {
-            "post": {
+        "/admin/categories/translations/missing": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
@@ -661,233 +1113,3366 @@ const docTemplate_swagger = `{
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Configura un super administrador como usuario",
-                "operationId": "unset-super-admin",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "ID del usuario",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
+                "summary": "Obtiene las categorías con traducciones faltantes",
+                "operationId": "get-missing-category-translations",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/services.GetMissingTranslationsResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
             }
         },
-        "/login": {
-            "post": {
-                "consumes": [
-                    "application/json"
+        "/admin/categories/tree": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Ingresa un usuario",
-                "operationId": "login-user",
+                "summary": "Obtiene el árbol de categorías",
+                "operationId": "get-category-tree",
                 "parameters": [
                     {
-                        "description": "Datos del usuario",
-                        "name": "loginUserRequest",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.loginUserRequest"
-                        }
+                        "type": "string",
+                        "description": "Idioma de los nombres y descripciones",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idiomas preferidos",
+                        "name": "Accept-Language",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Respuesta del login",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.loginUserResponse"
+                            "$ref": "#/definitions/services.GetCategoryTreeResponse"
                         }
                     },
                     "400": {
-                        "description": "Error en la solicitud",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.loginUserResponse"
+                            "$ref": "#/definitions/middlewares.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.loginUserRequest": {
-            "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
-            "properties": {
-                "email": {
-                    "type": "string"
-                },
-                "password": {
-                    "type": "string",
-                    "minLength": 6
-                }
-            }
         },
-        "handlers.loginUserResponse": {
-            "type": "object",
-            "properties": {
-                "access_token": {
-                    "type": "string"
-                },
+        "/admin/categories/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Obtiene una categoría por su ID o slug",
+                "operationId": "get-category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID o slug de la categoría",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idioma del nombre y la descripción",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idiomas preferidos",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.GetCategoryResponse"
+                        }
+                    },
+                    "301": {
+                        "description": "Redirección al slug actual de la categoría",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Actualiza una categoría",
+                "operationId": "update-category",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateCategoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Ya existe una categoría con ese nombre",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Elimina una categoría",
+                "operationId": "delete-category",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Aplica un parche JSON Merge Patch (RFC 7396): sólo se modifican los campos indicados y los campos con valor null se vacían",
+                "consumes": [
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Actualiza parcialmente una categoría",
+                "operationId": "patch-category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID o slug de la categoría",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Campos a modificar",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateCategoryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateCategoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Ya existe una categoría con ese nombre",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/categories/{id}/merge-into/{target}": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Fusiona una categoría en otra",
+                "operationId": "merge-category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la categoría a fusionar",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID de la categoría destino",
+                        "name": "target",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.MergeCategoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/categories/{id}/move": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Mueve una categoría a otra categoría padre",
+                "operationId": "move-category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la categoría",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Nueva categoría padre y posición",
+                        "name": "MoveCategoryRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.MoveCategoryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.MoveCategoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/categories/{id}/translations/{lang}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Agrega o reemplaza la traducción de una categoría",
+                "operationId": "set-category-translation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la categoría",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Código del idioma",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Nombre y descripción traducidos",
+                        "name": "SetCategoryTranslationRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.SetCategoryTranslationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateCategoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Elimina la traducción de una categoría",
+                "operationId": "delete-category-translation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la categoría",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Código del idioma",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/email-templates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Obtiene las plantillas de correo electrónico disponibles",
+                "operationId": "get-email-templates",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.emailTemplatesResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/email-templates/{name}/preview": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Si no se envían datos se usan datos de ejemplo. Con format=html o format=text se devuelve sólo ese cuerpo.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/html",
+                    "text/plain"
+                ],
+                "summary": "Previsualiza una plantilla de correo electrónico",
+                "operationId": "preview-email-template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nombre de la plantilla",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idioma de la plantilla",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Formato de la respuesta (json, html, text)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Datos de la plantilla",
+                        "name": "EmailTemplateData",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/utils.EmailTemplateData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.RenderedEmail"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "La plantilla no existe",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/emails": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Obtiene los correos electrónicos de la cola de envío",
+                "operationId": "get-emails",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Estado de entrega (queued, sending, sent, dead)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.GetEmailsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/emails/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Obtiene un correo electrónico con sus intentos de entrega",
+                "operationId": "get-email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del correo",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.GetEmailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "El correo no existe",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/emails/{id}/resend": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Vuelve a encolar un correo electrónico descartado o enviado",
+                "operationId": "resend-email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del correo",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.ResendEmailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "El correo no existe",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "El correo todavía está en cola",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/exports/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Obtiene el estado de una exportación en segundo plano",
+                "operationId": "get-export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la exportación",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.GetExportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "La exportación no existe",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/exports/{id}/download": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "summary": "Descarga el archivo de una exportación terminada",
+                "operationId": "download-export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la exportación",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "La exportación no está lista",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports/helpers": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "summary": "Obtiene la carga de trabajo y las horas de los ayudantes en un período",
+                "operationId": "get-helper-report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Fecha inicial (AAAA-MM-DD), por defecto el primer día del mes",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fecha final, incluida (AAAA-MM-DD), por defecto hoy",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID del ayudante",
+                        "name": "helper",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID de la categoría",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "json",
+                        "description": "Formato de la respuesta (json, csv)",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.GetHelperReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reviews": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Obtiene las reseñas",
+                "operationId": "get-reviews",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Estado de moderación (pending, approved, rejected)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.GetReviewsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reviews/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Obtiene una reseña",
+                "operationId": "get-review",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la reseña",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.GetReviewResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "La reseña no existe",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Elimina una reseña",
+                "operationId": "delete-review",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la reseña",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "La reseña no existe",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reviews/{id}/moderate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Modera una reseña",
+                "operationId": "moderate-review",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la reseña",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Estado de moderación",
+                        "name": "ModerateReviewRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.ModerateReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.ModerateReviewResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "La reseña no existe",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Obtiene las estadísticas del panel de administración",
+                "operationId": "get-stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Fecha inicial (AAAA-MM-DD), por defecto 30 días antes de la final",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fecha final, incluida (AAAA-MM-DD), por defecto hoy",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.GetStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Obtiene todos los usuarios",
+                "operationId": "get-users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tipo de usuario",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Estado del usuario",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID de una categoría de habilidad",
+                        "name": "skill",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Texto a buscar en el nombre o el correo electrónico",
+                        "name": "q",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.GetUsersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Crea un usuario",
+                "operationId": "create-user",
+                "parameters": [
+                    {
+                        "description": "Datos del usuario",
+                        "name": "CreateUserRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.CreateUserRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Clave para repetir la respuesta en los reintentos",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.CreateUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "El correo electrónico ya está registrado o la solicitud con la misma clave se está procesando",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "La clave de idempotencia se usó con otra solicitud",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/email/{email}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Obtiene un usuario por su correo electrónico",
+                "operationId": "get-user-by-email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Correo electrónico del usuario",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.GetUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/x-ndjson",
+                    "application/json"
+                ],
+                "summary": "Exporta los usuarios a CSV, XLSX o NDJSON",
+                "operationId": "export-users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Formato (csv, xlsx, ndjson)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Generar la exportación en segundo plano",
+                        "name": "async",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tipo de usuario",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Estado del usuario",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID de una categoría de habilidad",
+                        "name": "skill",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Texto a buscar en el nombre o el correo electrónico",
+                        "name": "q",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/services.StartExportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Con dry_run=true sólo valida el archivo y devuelve un informe por fila. Si no, inicia la importación y devuelve su id para consultar el progreso.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Importa usuarios desde un archivo CSV o XLSX",
+                "operationId": "import-users",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Archivo CSV o XLSX con una fila de encabezados; first_name, email y password son obligatorios",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Encabezado del archivo para cada campo, en JSON, por ejemplo {\\",
+                        "name": "mapping",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Sólo validar el archivo",
+                        "name": "dry_run",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.UserImportReport"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/services.StartUserImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/import/{importId}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Obtiene el progreso y los errores de una importación de usuarios",
+                "operationId": "get-user-import",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la importación",
+                        "name": "importId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.GetUserImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "La importación no existe",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Obtiene un usuario",
+                "operationId": "get-user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag de la versión que tiene el cliente",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.GetUserResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Versión del usuario"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "La versión del cliente es la actual"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Actualiza un usuario",
+                "operationId": "update-user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Datos del usuario",
+                        "name": "UpdateUserRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateUserRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag de la versión del usuario que se modifica, una lista de ETag o *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "El correo electrónico ya está registrado",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "El registro fue modificado por otra persona",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Falta la cabecera If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Elimina un usuario",
+                "operationId": "delete-user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag de la versión del usuario que se elimina, una lista de ETag o *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "El registro fue modificado por otra persona",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Falta la cabecera If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Aplica un parche JSON Merge Patch (RFC 7396): sólo se modifican los campos indicados y los campos con valor null se vacían",
+                "consumes": [
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Actualiza parcialmente un usuario",
+                "operationId": "patch-user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Campos a modificar",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateUserRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag de la versión del usuario que se modifica, una lista de ETag o *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "El registro fue modificado por otra persona",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Falta la cabecera If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/notification-preferences": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Obtiene los canales de aviso y el horario de silencio de un usuario",
+                "operationId": "get-notification-preferences",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.GetNotificationPreferencesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Actualiza los canales de aviso y el horario de silencio de un usuario",
+                "operationId": "update-notification-preferences",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Preferencias de aviso",
+                        "name": "UpdateNotificationPreferencesRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdateNotificationPreferencesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.GetNotificationPreferencesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/password": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Cambia la contraseña de un usuario",
+                "operationId": "change-password",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Datos del usuario",
+                        "name": "ChangePasswordRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/profile-image": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Sube la imagen de perfil de un usuario",
+                "operationId": "upload-profile-image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Imagen JPEG, PNG o GIF",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.UploadProfileImageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Elimina la imagen de perfil de un usuario",
+                "operationId": "delete-profile-image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/push-subscriptions": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Registra la suscripción web push de un navegador del usuario",
+                "operationId": "add-push-subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Suscripción push del navegador",
+                        "name": "AddPushSubscriptionRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.AddPushSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Elimina una suscripción web push del usuario",
+                "operationId": "remove-push-subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Endpoint de la suscripción",
+                        "name": "RemovePushSubscriptionRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.RemovePushSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/set-super-admin": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Configura un usuario como super administrador",
+                "operationId": "set-super-admin",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/unset-super-admin": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Configura un super administrador como usuario",
+                "operationId": "unset-super-admin",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Ingresa un usuario",
+                "operationId": "login-user",
+                "parameters": [
+                    {
+                        "description": "Datos del usuario",
+                        "name": "loginUserRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.loginUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Respuesta del login",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.loginUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error en la solicitud",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Credenciales incorrectas",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.emailTemplatesResponse": {
+            "type": "object",
+            "properties": {
+                "languages": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "templates": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handlers.loginUserRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6
+                }
+            }
+        },
+        "handlers.loginUserResponse": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
                 "access_token_expires_at": {
                     "type": "string"
                 },
-                "refresh_token": {
+                "refresh_token": {
+                    "type": "string"
+                },
+                "refresh_token_expires_at": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/handlers.userResponse"
+                }
+            }
+        },
+        "handlers.userResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "first_name": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "password_changed_at": {
+                    "type": "string"
+                },
+                "profile_image": {
+                    "type": "string"
+                }
+            }
+        },
+        "middlewares.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "errors": {
+                    "description": "Errores por campo, cuando la validación de la solicitud falla",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/utils.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Appointment": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "address": {
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "duration": {
+                    "type": "integer"
+                },
+                "helper": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Aumenta con cada modificación; se usa como ETag para detectar ediciones simultáneas",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AppointmentAttachment": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "appointment_id": {
+                    "type": "string"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "uploaded_by": {
+                    "$ref": "#/definitions/models.NoteAuthor"
+                }
+            }
+        },
+        "models.AppointmentNote": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "appointment_id": {
+                    "type": "string"
+                },
+                "author": {
+                    "$ref": "#/definitions/models.NoteAuthor"
+                },
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Category": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "ancestors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "merged_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "string"
+                },
+                "previous_slugs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "sort_order": {
+                    "type": "integer"
+                },
+                "translations": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.CategoryTranslation"
+                    }
+                }
+            }
+        },
+        "models.CategoryTranslation": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EmailDeliveryAttempt": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "permanent": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.Export": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "params": {
+                    "type": "string"
+                },
+                "resource": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.NoteAuthor": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.NotificationPreferences": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "quiet_hours": {
+                    "$ref": "#/definitions/models.QuietHours"
+                }
+            }
+        },
+        "models.OutboundEmail": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deliveries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.EmailDeliveryAttempt"
+                    }
+                },
+                "html_message": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "sender": {
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PushSubscription": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "keys": {
+                    "$ref": "#/definitions/models.PushSubscriptionKeys"
+                }
+            }
+        },
+        "models.PushSubscriptionKeys": {
+            "type": "object",
+            "required": [
+                "auth",
+                "p256dh"
+            ],
+            "properties": {
+                "auth": {
+                    "type": "string"
+                },
+                "p256dh": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.QuietHours": {
+            "type": "object",
+            "required": [
+                "end",
+                "start"
+            ],
+            "properties": {
+                "end": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "time_zone": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Review": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "appointment_id": {
+                    "type": "string"
+                },
+                "author_id": {
+                    "type": "string"
+                },
+                "author_role": {
+                    "type": "string"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "moderated_at": {
+                    "type": "string"
+                },
+                "moderated_by": {
+                    "type": "string"
+                },
+                "moderation_note": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "subject_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "first_name": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "notification_preferences": {
+                    "$ref": "#/definitions/models.NotificationPreferences"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "password_changed_at": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "profile_image": {
+                    "type": "string"
+                },
+                "profile_thumbnails": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "push_subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PushSubscription"
+                    }
+                },
+                "rating": {
+                    "$ref": "#/definitions/models.UserRating"
+                },
+                "skills": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Aumenta con cada modificación; se usa como ETag para detectar ediciones simultáneas",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UserImport": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImportRowError"
+                    }
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UserRating": {
+            "type": "object",
+            "properties": {
+                "average": {
+                    "type": "number"
+                },
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.AddPushSubscriptionRequest": {
+            "type": "object",
+            "required": [
+                "endpoint"
+            ],
+            "properties": {
+                "endpoint": {
+                    "type": "string"
+                },
+                "keys": {
+                    "$ref": "#/definitions/models.PushSubscriptionKeys"
+                }
+            }
+        },
+        "services.AppointmentStats": {
+            "type": "object",
+            "properties": {
+                "average_duration_minutes": {
+                    "type": "number"
+                },
+                "by_status": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.StatsCount"
+                    }
+                },
+                "cancellation_rate": {
+                    "type": "number"
+                },
+                "completion_rate": {
+                    "type": "number"
+                },
+                "per_day": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.DailyAppointments"
+                    }
+                },
+                "top_categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.CategoryStats"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.CategoryStats": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.CategoryTreeNode": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "$ref": "#/definitions/models.Category"
+                },
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.CategoryTreeNode"
+                    }
+                }
+            }
+        },
+        "services.ChangePasswordRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "password_confirmation"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 6
+                },
+                "password_confirmation": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.CreateAppointmentNoteRequest": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.CreateAppointmentNoteResponse": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "$ref": "#/definitions/models.AppointmentNote"
+                }
+            }
+        },
+        "services.CreateAppointmentRequest": {
+            "type": "object",
+            "required": [
+                "address",
+                "created_by",
+                "date",
+                "duration",
+                "helper",
+                "status"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "maxLength": 300
+                },
+                "category": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "duration": {
+                    "type": "integer"
+                },
+                "helper": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.CreateAppointmentResponse": {
+            "type": "object",
+            "properties": {
+                "appointment_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.CreateCategoryResponse": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.CreateReviewRequest": {
+            "type": "object",
+            "properties": {
+                "author_role": {
+                    "type": "string"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.CreateReviewResponse": {
+            "type": "object",
+            "properties": {
+                "review_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "first_name",
+                "last_name",
+                "password",
+                "status",
+                "type"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 254
+                },
+                "first_name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "language": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 6
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 30
+                },
+                "profile_image": {
+                    "type": "string"
+                },
+                "skills": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.CreateUserResponse": {
+            "type": "object",
+            "properties": {
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.DailyAppointments": {
+            "type": "object",
+            "properties": {
+                "by_status": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "date": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.GetAppointmentAttachmentsResponse": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AppointmentAttachment"
+                    }
+                }
+            }
+        },
+        "services.GetAppointmentNotesResponse": {
+            "type": "object",
+            "properties": {
+                "notes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AppointmentNote"
+                    }
+                }
+            }
+        },
+        "services.GetAppointmentResponse": {
+            "type": "object",
+            "properties": {
+                "appointment": {
+                    "$ref": "#/definitions/models.Appointment"
+                }
+            }
+        },
+        "services.GetAppointmentsResponse": {
+            "type": "object",
+            "properties": {
+                "appointments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Appointment"
+                    }
+                }
+            }
+        },
+        "services.GetCategoriesResponse": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Category"
+                    }
+                }
+            }
+        },
+        "services.GetCategoryResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "$ref": "#/definitions/models.Category"
+                }
+            }
+        },
+        "services.GetCategoryTreeResponse": {
+            "type": "object",
+            "properties": {
+                "tree": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.CategoryTreeNode"
+                    }
+                }
+            }
+        },
+        "services.GetEmailResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "$ref": "#/definitions/models.OutboundEmail"
+                }
+            }
+        },
+        "services.GetEmailsResponse": {
+            "type": "object",
+            "properties": {
+                "emails": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OutboundEmail"
+                    }
+                }
+            }
+        },
+        "services.GetExportResponse": {
+            "type": "object",
+            "properties": {
+                "download_url": {
+                    "type": "string"
+                },
+                "export": {
+                    "$ref": "#/definitions/models.Export"
+                }
+            }
+        },
+        "services.GetHelperReportResponse": {
+            "type": "object",
+            "properties": {
+                "from": {
                     "type": "string"
                 },
-                "refresh_token_expires_at": {
+                "helpers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.HelperReport"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.GetMissingTranslationsResponse": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.MissingCategoryTranslation"
+                    }
+                }
+            }
+        },
+        "services.GetNotificationPreferencesResponse": {
+            "type": "object",
+            "properties": {
+                "preferences": {
+                    "$ref": "#/definitions/models.NotificationPreferences"
+                }
+            }
+        },
+        "services.GetReviewResponse": {
+            "type": "object",
+            "properties": {
+                "review": {
+                    "$ref": "#/definitions/models.Review"
+                }
+            }
+        },
+        "services.GetReviewsResponse": {
+            "type": "object",
+            "properties": {
+                "reviews": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Review"
+                    }
+                }
+            }
+        },
+        "services.GetStatsResponse": {
+            "type": "object",
+            "properties": {
+                "appointments": {
+                    "$ref": "#/definitions/services.AppointmentStats"
+                },
+                "from": {
                     "type": "string"
                 },
-                "session_id": {
+                "generated_at": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "users": {
+                    "$ref": "#/definitions/services.UserStats"
+                }
+            }
+        },
+        "services.GetUserImportResponse": {
+            "type": "object",
+            "properties": {
+                "import": {
+                    "$ref": "#/definitions/models.UserImport"
+                }
+            }
+        },
+        "services.GetUserResponse": {
+            "type": "object",
+            "properties": {
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
+        "services.GetUsersResponse": {
+            "type": "object",
+            "properties": {
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.User"
+                    }
+                }
+            }
+        },
+        "services.HelperCategoryReport": {
+            "type": "object",
+            "properties": {
+                "appointments": {
+                    "type": "integer"
+                },
+                "booked_hours": {
+                    "type": "number"
+                },
+                "cancellations": {
+                    "type": "integer"
+                },
+                "category_id": {
+                    "type": "string"
+                },
+                "completed_hours": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "no_shows": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.HelperReport": {
+            "type": "object",
+            "properties": {
+                "appointments": {
+                    "type": "integer"
+                },
+                "booked_hours": {
+                    "type": "number"
+                },
+                "cancellations": {
+                    "type": "integer"
+                },
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.HelperCategoryReport"
+                    }
+                },
+                "completed_hours": {
+                    "type": "number"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "first_name": {
+                    "type": "string"
+                },
+                "helper_id": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "no_shows": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.MergeCategoryResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "$ref": "#/definitions/models.Category"
+                },
+                "reassigned_appointments": {
+                    "type": "integer"
+                },
+                "reassigned_children": {
+                    "type": "integer"
+                },
+                "reassigned_users": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.MissingCategoryTranslation": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "type": "string"
+                },
+                "languages": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.ModerateReviewRequest": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.ModerateReviewResponse": {
+            "type": "object",
+            "properties": {
+                "review": {
+                    "$ref": "#/definitions/models.Review"
+                }
+            }
+        },
+        "services.MoveCategoryRequest": {
+            "type": "object",
+            "properties": {
+                "parent_id": {
+                    "type": "string"
+                },
+                "sort_order": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "services.MoveCategoryResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "$ref": "#/definitions/models.Category"
+                }
+            }
+        },
+        "services.RemovePushSubscriptionRequest": {
+            "type": "object",
+            "required": [
+                "endpoint"
+            ],
+            "properties": {
+                "endpoint": {
                     "type": "string"
+                }
+            }
+        },
+        "services.ReorderCategoriesRequest": {
+            "type": "object",
+            "required": [
+                "category_ids"
+            ],
+            "properties": {
+                "category_ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "user": {
-                    "$ref": "#/definitions/handlers.userResponse"
+                "parent_id": {
+                    "type": "string"
                 }
             }
         },
-        "handlers.userResponse": {
+        "services.ResendEmailResponse": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
                 "email": {
-                    "type": "string"
-                },
-                "first_name": {
-                    "type": "string"
-                },
-                "last_name": {
-                    "type": "string"
-                },
-                "password_changed_at": {
-                    "type": "string"
-                },
-                "profile_image": {
-                    "type": "string"
+                    "$ref": "#/definitions/models.OutboundEmail"
                 }
             }
         },
-        "models.Appointment": {
+        "services.SetCategoryTranslationRequest": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
-                "address": {
-                    "type": "string"
-                },
-                "createdAt": {
-                    "type": "string"
-                },
-                "createdBy": {
-                    "type": "string"
-                },
-                "date": {
-                    "type": "string"
-                },
-                "duration": {
-                    "type": "integer"
-                },
-                "helper": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "status": {
-                    "type": "string"
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
                 },
-                "updatedAt": {
-                    "type": "string"
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
                 }
             }
         },
-        "models.Category": {
+        "services.StartExportResponse": {
             "type": "object",
             "properties": {
-                "_id": {
-                    "type": "string"
-                },
-                "description": {
+                "export_id": {
                     "type": "string"
                 },
-                "name": {
+                "status_url": {
                     "type": "string"
                 }
             }
         },
-        "models.User": {
+        "services.StartUserImportResponse": {
             "type": "object",
             "properties": {
-                "_id": {
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "email": {
-                    "type": "string"
-                },
-                "first_name": {
-                    "type": "string"
-                },
-                "last_name": {
-                    "type": "string"
-                },
-                "password": {
-                    "type": "string"
-                },
-                "password_changed_at": {
-                    "type": "string"
-                },
-                "profile_image": {
-                    "type": "string"
-                },
-                "status": {
-                    "type": "string"
-                },
-                "type": {
-                    "type": "string"
-                },
-                "updated_at": {
+                "import_id": {
                     "type": "string"
                 }
             }
         },
-        "services.ChangePasswordRequest": {
+        "services.StatsCount": {
             "type": "object",
             "properties": {
-                "password": {
-                    "type": "string"
+                "count": {
+                    "type": "integer"
                 },
-                "password_confirmation": {
+                "key": {
                     "type": "string"
                 }
             }
         },
-        "services.CreateAppointmentRequest": {
+        "services.UpdateAppointmentRequest": {
             "type": "object",
             "properties": {
                 "address": {
+                    "type": "string",
+                    "maxLength": 300
+                },
+                "category": {
                     "type": "string"
                 },
                 "created_by": {
@@ -907,39 +4492,80 @@ const docTemplate_swagger = `{
                 }
             }
         },
-        "services.CreateAppointmentResponse": {
+        "services.UpdateAppointmentResponse": {
             "type": "object",
             "properties": {
-                "appointment_id": {
-                    "type": "string"
+                "appointment": {
+                    "$ref": "#/definitions/models.Appointment"
                 }
             }
         },
-        "services.CreateCategoryResponse": {
+        "services.UpdateCategoryRequest": {
             "type": "object",
             "properties": {
-                "category_id": {
-                    "type": "string"
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
                 }
             }
         },
-        "services.CreateUserRequest": {
+        "services.UpdateCategoryResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "$ref": "#/definitions/models.Category"
+                }
+            }
+        },
+        "services.UpdateNotificationPreferencesRequest": {
+            "type": "object",
+            "required": [
+                "channels"
+            ],
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "quiet_hours": {
+                    "$ref": "#/definitions/models.QuietHours"
+                }
+            }
+        },
+        "services.UpdateUserRequest": {
             "type": "object",
             "properties": {
                 "email": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 254
                 },
                 "first_name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "language": {
                     "type": "string"
                 },
                 "last_name": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 100
                 },
-                "password": {
-                    "type": "string"
+                "phone": {
+                    "type": "string",
+                    "maxLength": 30
                 },
-                "profile_image": {
-                    "type": "string"
+                "skills": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
                 "status": {
                     "type": "string"
@@ -949,138 +4575,164 @@ const docTemplate_swagger = `{
                 }
             }
         },
-        "services.CreateUserResponse": {
+        "services.UpdateUserResponse": {
             "type": "object",
             "properties": {
-                "user_id": {
-                    "type": "string"
+                "user": {
+                    "$ref": "#/definitions/models.User"
                 }
             }
         },
-        "services.GetAppointmentResponse": {
+        "services.UploadAppointmentAttachmentResponse": {
             "type": "object",
             "properties": {
-                "appointment": {
-                    "$ref": "#/definitions/models.Appointment"
+                "attachment": {
+                    "$ref": "#/definitions/models.AppointmentAttachment"
                 }
             }
         },
-        "services.GetAppointmentsResponse": {
+        "services.UploadProfileImageResponse": {
             "type": "object",
             "properties": {
-                "appointments": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Appointment"
+                "profile_image": {
+                    "type": "string"
+                },
+                "profile_thumbnails": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
                     }
                 }
             }
         },
-        "services.GetCategoriesResponse": {
+        "services.UserImportReport": {
             "type": "object",
             "properties": {
-                "categories": {
+                "columns": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "rows": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Category"
+                        "$ref": "#/definitions/services.UserImportRowReport"
                     }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "integer"
                 }
             }
         },
-        "services.GetCategoryResponse": {
+        "services.UserImportRowReport": {
             "type": "object",
             "properties": {
-                "category": {
-                    "$ref": "#/definitions/models.Category"
+                "email": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImportRowError"
+                    }
+                },
+                "row": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "boolean"
                 }
             }
         },
-        "services.GetUserResponse": {
+        "services.UserStats": {
             "type": "object",
             "properties": {
-                "user": {
-                    "$ref": "#/definitions/models.User"
+                "by_status": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.StatsCount"
+                    }
+                },
+                "by_type": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.StatsCount"
+                    }
+                },
+                "signups_per_week": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.WeeklySignups"
+                    }
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "services.GetUsersResponse": {
+        "services.WeeklySignups": {
             "type": "object",
             "properties": {
-                "users": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.User"
-                    }
+                "count": {
+                    "type": "integer"
+                },
+                "week": {
+                    "type": "string"
                 }
             }
         },
-        "services.UpdateAppointmentRequest": {
+        "utils.EmailTemplateData": {
             "type": "object",
             "properties": {
                 "address": {
                     "type": "string"
                 },
-                "created_by": {
-                    "type": "string"
-                },
                 "date": {
                     "type": "string"
                 },
-                "duration": {
-                    "type": "integer"
+                "previous_helper": {
+                    "type": "boolean"
                 },
-                "helper": {
+                "recipient_name": {
                     "type": "string"
                 },
-                "status": {
+                "time": {
                     "type": "string"
                 }
             }
         },
-        "services.UpdateAppointmentResponse": {
-            "type": "object",
-            "properties": {
-                "appointment": {
-                    "$ref": "#/definitions/models.Appointment"
-                }
-            }
-        },
-        "services.UpdateCategoryResponse": {
-            "type": "object",
-            "properties": {
-                "category": {
-                    "$ref": "#/definitions/models.Category"
-                }
-            }
-        },
-        "services.UpdateUserRequest": {
+        "utils.FieldError": {
             "type": "object",
             "properties": {
-                "email": {
-                    "type": "string"
-                },
-                "first_name": {
+                "code": {
                     "type": "string"
                 },
-                "last_name": {
-                    "type": "string"
-                },
-                "profile_image": {
+                "field": {
                     "type": "string"
                 },
-                "status": {
-                    "type": "string"
-                },
-                "type": {
+                "message": {
                     "type": "string"
                 }
             }
         },
-        "services.UpdateUserResponse": {
+        "utils.RenderedEmail": {
             "type": "object",
             "properties": {
-                "user": {
-                    "$ref": "#/definitions/models.User"
+                "html": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
                 }
             }
         }
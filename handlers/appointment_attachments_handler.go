@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/services"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+)
+
+// @Summary Obtiene los archivos adjuntos de una cita
+// @ID 		get-appointment-attachments
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id path string true "ID de la cita"
+// @Success 200 {object} services.GetAppointmentAttachmentsResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/appointments/{id}/attachments [get]
+func handleGetAppointmentAttachments(service services.IAppointmentAttachmentService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		attachments, err := service.GetAttachments(id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(attachments))
+	}
+}
+
+// @Summary Sube un archivo adjunto a una cita
+// @ID 		upload-appointment-attachment
+// @Accept 	multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id 		path 		string 	true "ID de la cita"
+// @Param 	file 	formData 	file 	true "Imagen JPEG, PNG, GIF o WebP, o documento PDF"
+// @Success 200 {object} services.UploadAppointmentAttachmentResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 413 {object} middlewares.Problem
+// @Failure 404 {object} middlewares.Problem "La cita no existe"
+// @Router 	/admin/appointments/{id}/attachments [post]
+func handleUploadAppointmentAttachment(service services.IAppointmentAttachmentService, maxSize int64) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		// Margen para las cabeceras del formulario multipart
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSize+64<<10)
+
+		fileHeader, err := ctx.FormFile("file")
+		if err != nil {
+			ctx.Error(services.NewValidationError("file_required", "se requiere un archivo de hasta %s", formatBytes(maxSize)))
+			return
+		}
+
+		if fileHeader.Size > maxSize {
+			ctx.Error(services.NewTooLargeError("file_too_large", "el archivo no puede superar %s", formatBytes(maxSize)))
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			ctx.Error(services.NewValidationError("invalid_file", "%v", err))
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(io.LimitReader(file, maxSize))
+		if err != nil {
+			ctx.Error(services.NewValidationError("invalid_file", "%v", err))
+			return
+		}
+
+		attachment, err := service.UploadAttachment(id, fileHeader.Filename, data, authorFromToken(ctx))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(attachment))
+	}
+}
+
+// @Summary Descarga un archivo adjunto de una cita
+// @ID 		download-appointment-attachment
+// @Produce octet-stream
+// @Security ApiKeyAuth
+// @Param 	id 				path string true "ID de la cita"
+// @Param 	attachmentId 	path string true "ID del archivo adjunto"
+// @Success 200 {file} file
+// @Failure 404 {object} middlewares.Problem
+// @Router 	/admin/appointments/{id}/attachments/{attachmentId} [get]
+func handleDownloadAppointmentAttachment(service services.IAppointmentAttachmentService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		attachmentID := ctx.Param("attachmentId")
+		if id == "" || attachmentID == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		attachment, body, err := service.OpenAttachment(id, attachmentID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		defer body.Close()
+
+		disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName})
+		ctx.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, body, map[string]string{
+			"Content-Disposition":    disposition,
+			"X-Content-Type-Options": "nosniff",
+		})
+	}
+}
+
+// @Summary Elimina un archivo adjunto de una cita
+// @ID 		delete-appointment-attachment
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id 				path string true "ID de la cita"
+// @Param 	attachmentId 	path string true "ID del archivo adjunto"
+// @Success 200 {object} string
+// @Failure 400 {object} middlewares.Problem
+// @Failure 404 {object} middlewares.Problem "El archivo adjunto no existe"
+// @Router 	/admin/appointments/{id}/attachments/{attachmentId} [delete]
+func handleDeleteAppointmentAttachment(service services.IAppointmentAttachmentService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		attachmentID := ctx.Param("attachmentId")
+		if id == "" || attachmentID == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		if err := service.DeleteAttachment(id, attachmentID); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(nil))
+	}
+}
+
+/** Crea los endpoints de archivos adjuntos de una cita
+ *
+ * @param group gin.IRoutes "El grupo de endpoints de citas"
+ * @param service services.IAppointmentAttachmentService "El servicio de archivos adjuntos"
+ * @param maxSize int64 "El tamaño máximo de un archivo en bytes"
+ * @return *gin.IRoutes "El grupo de endpoints creado"
+ */
+func newAppointmentAttachmentHandler(group gin.IRoutes, service services.IAppointmentAttachmentService, maxSize int64) *gin.IRoutes {
+	group.GET("/:id/attachments", handleGetAppointmentAttachments(service))
+	group.POST("/:id/attachments", handleUploadAppointmentAttachment(service, maxSize))
+	group.GET("/:id/attachments/:attachmentId", handleDownloadAppointmentAttachment(service))
+	group.DELETE("/:id/attachments/:attachmentId", handleDeleteAppointmentAttachment(service))
+
+	return &group
+}
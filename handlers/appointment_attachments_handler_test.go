@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+)
+
+func TestAppointmentAttachmentRoutes(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+	appointmentID := s.insertAppointment(models.Appointment{
+		Date:     time.Now().Add(24 * time.Hour),
+		Duration: time.Hour,
+		Address:  "Av. 18 de Julio 1234",
+		Status:   models.AppointmentStatusPending,
+	})
+	path := "/api/admin/appointments/" + appointmentID + "/attachments"
+	content := testPNG(t)
+
+	var uploaded struct {
+		Attachment models.AppointmentAttachment `json:"attachment"`
+	}
+	s.decode(s.expectUpload(path, "file", "foto.png", content, nil, http.StatusOK), &uploaded)
+	if uploaded.Attachment.ContentType != "image/png" || uploaded.Attachment.Size != int64(len(content)) {
+		t.Errorf("POST %s: adjunto inesperado %+v", path, uploaded.Attachment)
+	}
+	s.expectUpload(path, "file", "notas.txt", []byte("texto plano"), nil, http.StatusBadRequest)
+	s.expectUpload("/api/admin/appointments/000000000000000000000000/attachments", "file", "foto.png", content, nil, http.StatusNotFound)
+	s.expectUpload(path, "file", "grande.png", append(content, make([]byte, 1<<20)...), nil, http.StatusRequestEntityTooLarge)
+
+	// El archivo se guarda fuera de lo que se publica y no se descarga sin pasar por la API
+	attachment, err := s.store.AppointmentAttachments().FindByID(context.Background(), s.objectID(appointmentID), uploaded.Attachment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(attachment.Key, "private/") || strings.Contains(attachment.Key, "foto") {
+		t.Errorf("la clave del adjunto %q no es privada y aleatoria", attachment.Key)
+	}
+	file := filepath.Join(s.storagePath, filepath.FromSlash(attachment.Key))
+	if _, err = os.Stat(file); err != nil {
+		t.Errorf("no se guardó el archivo: %v", err)
+	}
+	s.expectStatus(http.MethodGet, "/uploads/"+attachment.Key, nil, http.StatusNotFound)
+
+	var attachments struct {
+		Attachments []models.AppointmentAttachment `json:"attachments"`
+	}
+	s.decode(s.expect(http.MethodGet, path, nil, http.StatusOK), &attachments)
+	if len(attachments.Attachments) != 1 {
+		t.Errorf("GET %s: se esperaba 1 adjunto, se obtuvieron %d", path, len(attachments.Attachments))
+	}
+
+	download := path + "/" + uploaded.Attachment.ID.Hex()
+	recorder := s.expectStatus(http.MethodGet, download, nil, http.StatusOK)
+	if !bytes.Equal(recorder.Body.Bytes(), content) || !strings.Contains(recorder.Header().Get("Content-Disposition"), "foto.png") {
+		t.Errorf("GET %s: descarga inesperada (%s)", download, recorder.Header().Get("Content-Disposition"))
+	}
+
+	s.expect(http.MethodDelete, download, nil, http.StatusOK)
+	if _, err = os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("no se eliminó el archivo: %v", err)
+	}
+	s.expectError(http.MethodGet, download, nil, http.StatusNotFound, "attachment_not_found")
+	s.expectError(http.MethodDelete, download, nil, http.StatusNotFound, "attachment_not_found")
+}
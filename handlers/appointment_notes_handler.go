@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/middlewares"
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/services"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+)
+
+// @Summary Obtiene las notas de una cita en orden cronológico
+// @ID 		get-appointment-notes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id 			path 	string true 	"ID de la cita"
+// @Param 	visibility 	query 	string false 	"Visibilidad (internal, shared)"
+// @Success 200 {object} services.GetAppointmentNotesResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/appointments/{id}/notes [get]
+func handleGetAppointmentNotes(service services.IAppointmentNoteService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		notes, err := service.GetNotes(id, ctx.Query("visibility"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(notes))
+	}
+}
+
+// @Summary Agrega una nota a una cita
+// @ID 		create-appointment-note
+// @Accept 	json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id 								path string 									true "ID de la cita"
+// @Param 	CreateAppointmentNoteRequest 	body services.CreateAppointmentNoteRequest 	true "Texto y visibilidad de la nota"
+// @Success 200 {object} services.CreateAppointmentNoteResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 404 {object} middlewares.Problem "La cita no existe"
+// @Router 	/admin/appointments/{id}/notes [post]
+func handleCreateAppointmentNote(service services.IAppointmentNoteService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req services.CreateAppointmentNoteRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		note, err := service.CreateNote(id, req, authorFromToken(ctx))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(note))
+	}
+}
+
+// @Summary Elimina una nota de una cita
+// @ID 		delete-appointment-note
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id 		path string true "ID de la cita"
+// @Param 	noteId 	path string true "ID de la nota"
+// @Success 200 {object} string
+// @Failure 400 {object} middlewares.Problem
+// @Failure 404 {object} middlewares.Problem "La nota no existe"
+// @Router 	/admin/appointments/{id}/notes/{noteId} [delete]
+func handleDeleteAppointmentNote(service services.IAppointmentNoteService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		noteID := ctx.Param("noteId")
+		if id == "" || noteID == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		if err := service.DeleteNote(id, noteID); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(nil))
+	}
+}
+
+/** Devuelve el administrador autenticado como autor de una nota o un archivo
+ *
+ * @param ctx *gin.Context "El contexto de la petición"
+ * @return models.NoteAuthor "El autor"
+ */
+func authorFromToken(ctx *gin.Context) models.NoteAuthor {
+	var author models.NoteAuthor
+	if payload, ok := middlewares.GetAuthorizationPayload(ctx); ok {
+		author.Email = payload.Email
+		author.Name = strings.TrimSpace(payload.FirstName + " " + payload.LastName)
+	}
+
+	return author
+}
+
+/** Crea los endpoints de notas de una cita
+ *
+ * @param group gin.IRoutes "El grupo de endpoints de citas"
+ * @param service services.IAppointmentNoteService "El servicio de notas"
+ * @return *gin.IRoutes "El grupo de endpoints creado"
+ */
+func newAppointmentNoteHandler(group gin.IRoutes, service services.IAppointmentNoteService) *gin.IRoutes {
+	group.GET("/:id/notes", handleGetAppointmentNotes(service))
+	group.POST("/:id/notes", handleCreateAppointmentNote(service))
+	group.DELETE("/:id/notes/:noteId", handleDeleteAppointmentNote(service))
+
+	return &group
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+)
+
+func TestAppointmentNoteRoutes(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+	appointmentID := s.insertAppointment(models.Appointment{
+		Date:     time.Now().Add(24 * time.Hour),
+		Duration: time.Hour,
+		Address:  "Av. 18 de Julio 1234",
+		Status:   models.AppointmentStatusPending,
+	})
+	path := "/api/admin/appointments/" + appointmentID + "/notes"
+
+	var created struct {
+		Note models.AppointmentNote `json:"note"`
+	}
+	s.decode(s.expect(http.MethodPost, path, map[string]string{"body": "  Llamar antes de ir  "}, http.StatusOK), &created)
+	if created.Note.Body != "Llamar antes de ir" || created.Note.Visibility != models.NoteVisibilityInternal || created.Note.Author.Email != testAdminEmail {
+		t.Errorf("POST %s: nota inesperada %+v", path, created.Note)
+	}
+	s.expect(http.MethodPost, path, map[string]string{"body": "Tocar timbre", "visibility": models.NoteVisibilityShared}, http.StatusOK)
+	s.expectError(http.MethodPost, path, map[string]string{"body": "   "}, http.StatusBadRequest, "note_body_required")
+	s.expectError(http.MethodPost, path, map[string]string{"body": "Nota", "visibility": "publica"}, http.StatusBadRequest, "invalid_note_visibility")
+	s.expectError(http.MethodPost, "/api/admin/appointments/000000000000000000000000/notes", map[string]string{"body": "Nota"}, http.StatusNotFound, "appointment_not_found")
+
+	var notes struct {
+		Notes []models.AppointmentNote `json:"notes"`
+	}
+	s.decode(s.expect(http.MethodGet, path, nil, http.StatusOK), &notes)
+	if len(notes.Notes) != 2 {
+		t.Errorf("GET %s: se esperaban 2 notas, se obtuvieron %d", path, len(notes.Notes))
+	}
+	s.decode(s.expect(http.MethodGet, path+"?visibility=shared", nil, http.StatusOK), &notes)
+	if len(notes.Notes) != 1 || notes.Notes[0].Body != "Tocar timbre" {
+		t.Errorf("GET %s?visibility=shared: notas inesperadas %+v", path, notes.Notes)
+	}
+
+	s.expect(http.MethodDelete, path+"/"+created.Note.ID.Hex(), nil, http.StatusOK)
+	s.expectError(http.MethodDelete, path+"/"+created.Note.ID.Hex(), nil, http.StatusNotFound, "note_not_found")
+	s.decode(s.expect(http.MethodGet, path, nil, http.StatusOK), &notes)
+	if len(notes.Notes) != 1 {
+		t.Errorf("GET %s: se esperaba 1 nota después de eliminar, se obtuvieron %d", path, len(notes.Notes))
+	}
+}
@@ -1,10 +1,10 @@
 package handlers
 
 import (
-	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
 	"github.com/maferuy/ayudapp-admin-backend-core/services"
 	"github.com/maferuy/ayudapp-admin-backend-core/utils"
 )
@@ -13,14 +13,26 @@ import (
 // @ID 		get-appointments
 // @Produce json
 // @Security ApiKeyAuth
+// @Param 	status 		query string false "Estado de la cita"
+// @Param 	helper 		query string false "ID del ayudante"
+// @Param 	created_by 	query string false "ID del solicitante"
+// @Param 	category 	query string false "ID de la categoría"
+// @Param 	from 		query string false "Fecha inicial (AAAA-MM-DD)"
+// @Param 	to 			query string false "Fecha final, incluida (AAAA-MM-DD)"
 // @Success 200 {object} services.GetAppointmentsResponse
-// @Failure 400 {object} services.GetAppointmentsResponse
+// @Failure 400 {object} middlewares.Problem
 // @Router 	/admin/appointments [get]
 func handleGetAppointments(service services.IAppointmentService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		appointments, err := service.GetAppointments()
+		var filter repository.AppointmentFilter
+		if err := ctx.ShouldBindQuery(&filter); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		appointments, err := service.GetAppointments(ctx.Request.Context(), filter)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
@@ -34,20 +46,23 @@ func handleGetAppointments(service services.IAppointmentService) gin.HandlerFunc
 // @Produce json
 // @Security ApiKeyAuth
 // @Param   CreateAppointmentRequest body services.CreateAppointmentRequest true "Datos de la cita"
+// @Param 	Idempotency-Key header string false "Clave para repetir la respuesta en los reintentos"
 // @Success 200 {object} services.CreateAppointmentResponse
-// @Failure 400 {object} services.CreateAppointmentResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 409 {object} middlewares.Problem "La solicitud con la misma clave se está procesando"
+// @Failure 422 {object} middlewares.Problem "La clave de idempotencia se usó con otra solicitud"
 // @Router 	/admin/appointments [post]
 func handleCreateAppointment(service services.IAppointmentService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req services.CreateAppointmentRequest
 		if err := ctx.ShouldBindJSON(&req); err != nil {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+			ctx.Error(bindingError(err))
 			return
 		}
 
-		appointmentID, err := service.CreateAppointment(req)
+		appointmentID, err := service.CreateAppointment(ctx.Request.Context(), req)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
@@ -60,23 +75,31 @@ func handleCreateAppointment(service services.IAppointmentService) gin.HandlerFu
 // @Produce json
 // @Security ApiKeyAuth
 // @Param 	id path int true "ID de la cita"
+// @Param 	If-None-Match header string false "ETag de la versión que tiene el cliente"
 // @Success 200 {object} services.GetAppointmentResponse
-// @Failure 400 {object} services.GetAppointmentResponse
+// @Header 	200 {string} ETag "Versión de la cita"
+// @Success 304 "La versión del cliente es la actual"
+// @Failure 400 {object} middlewares.Problem
 // @Router 	/admin/appointments/{id} [get]
 func handleGetAppointment(service services.IAppointmentService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
 		if id == "" {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(errors.New("el id es requerido")))
+			ctx.Error(errIDRequired)
 			return
 		}
 
-		appointment, err := service.GetAppointment(id)
+		appointment, err := service.GetAppointment(ctx.Request.Context(), id)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
+		if notModified(ctx, appointment.Appointment.Version) {
+			return
+		}
+
+		setETag(ctx, appointment.Appointment.Version)
 		ctx.JSON(http.StatusOK, utils.SuccessResponse(appointment))
 	}
 }
@@ -88,29 +111,83 @@ func handleGetAppointment(service services.IAppointmentService) gin.HandlerFunc
 // @Security ApiKeyAuth
 // @Param   id 					path int 						true "ID de la cita"
 // @Param 	UpdateAppointmentRequest 	body services.UpdateAppointmentRequest true "Datos de la cita"
+// @Param 	If-Match header string true "ETag de la versión de la cita que se modifica, una lista de ETag o *"
 // @Success 200 {object} services.UpdateAppointmentResponse
-// @Failure 400 {object} services.UpdateAppointmentResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 412 {object} middlewares.Problem "El registro fue modificado por otra persona"
+// @Failure 428 {object} middlewares.Problem "Falta la cabecera If-Match"
 // @Router 	/admin/appointments/{id} [put]
 func handleUpdateAppointment(service services.IAppointmentService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req services.UpdateAppointmentRequest
 		if err := ctx.ShouldBindJSON(&req); err != nil {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+			ctx.Error(bindingError(err))
 			return
 		}
 
 		id := ctx.Param("id")
 		if id == "" {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(errors.New("el id es requerido")))
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		match, ok := ifMatch(ctx)
+		if !ok {
 			return
 		}
 
-		appointment, err := service.UpdateAppointment(id, req)
+		appointment, err := service.UpdateAppointment(ctx.Request.Context(), id, match, req)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
+		setETag(ctx, appointment.Appointment.Version)
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(appointment))
+	}
+}
+
+// @Summary Actualiza parcialmente una cita
+// @Description Aplica un parche JSON Merge Patch (RFC 7396): sólo se modifican los campos indicados y los campos con valor null se vacían
+// @ID 		patch-appointment
+// @Accept 	application/merge-patch+json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param   id 		path string 	true "ID de la cita"
+// @Param 	patch 	body services.UpdateAppointmentRequest true "Campos a modificar"
+// @Param 	If-Match header string true "ETag de la versión de la cita que se modifica, una lista de ETag o *"
+// @Success 200 {object} services.UpdateAppointmentResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 404 {object} middlewares.Problem
+// @Failure 415 {object} middlewares.Problem
+// @Failure 412 {object} middlewares.Problem "El registro fue modificado por otra persona"
+// @Failure 428 {object} middlewares.Problem "Falta la cabecera If-Match"
+// @Router 	/admin/appointments/{id} [patch]
+func handlePatchAppointment(service services.IAppointmentService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		match, ok := ifMatch(ctx)
+		if !ok {
+			return
+		}
+
+		patch, ok := readMergePatch(ctx)
+		if !ok {
+			return
+		}
+
+		appointment, err := service.PatchAppointment(ctx.Request.Context(), id, match, patch)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		setETag(ctx, appointment.Appointment.Version)
 		ctx.JSON(http.StatusOK, utils.SuccessResponse(appointment))
 	}
 }
@@ -120,20 +197,28 @@ func handleUpdateAppointment(service services.IAppointmentService) gin.HandlerFu
 // @Produce json
 // @Security ApiKeyAuth
 // @Param 	id path int true "ID de la cita"
+// @Param 	If-Match header string true "ETag de la versión de la cita que se elimina, una lista de ETag o *"
 // @Success 200 {object} string
-// @Failure 400 {object} string
+// @Failure 400 {object} middlewares.Problem
+// @Failure 412 {object} middlewares.Problem "El registro fue modificado por otra persona"
+// @Failure 428 {object} middlewares.Problem "Falta la cabecera If-Match"
 // @Router 	/admin/appointments/{id} [delete]
 func handleDeleteAppointment(service services.IAppointmentService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
 		if id == "" {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(errors.New("el id es requerido")))
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		match, ok := ifMatch(ctx)
+		if !ok {
 			return
 		}
 
-		err := service.DeleteAppointment(id)
+		err := service.DeleteAppointment(ctx.Request.Context(), id, match)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
@@ -145,14 +230,16 @@ func handleDeleteAppointment(service services.IAppointmentService) gin.HandlerFu
  *
  * @param group *gin.IRoutes "El grupo de endpoints padre"
  * @param service services.IAppointmentService "El servicio de citas"
+ * @param idempotency gin.HandlerFunc "El middleware de claves de idempotencia de la creación"
  * @return *gin.IRoutes "El grupo de endpoints creado"
  */
-func newAppointmentHandler(group gin.IRoutes, service services.IAppointmentService) *gin.IRoutes {
+func newAppointmentHandler(group gin.IRoutes, service services.IAppointmentService, idempotency gin.HandlerFunc) *gin.IRoutes {
 	group.GET("/", handleGetAppointments(service))
-	group.POST("/", handleCreateAppointment(service))
+	group.POST("/", idempotency, handleCreateAppointment(service))
 
 	group.GET("/:id", handleGetAppointment(service))
 	group.PUT("/:id", handleUpdateAppointment(service))
+	group.PATCH("/:id", handlePatchAppointment(service))
 	group.DELETE("/:id", handleDeleteAppointment(service))
 
 	return &group
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+)
+
+func TestAppointmentRoutes(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+	categoryID := s.createCategory("Limpieza")
+	helperID := s.insertUser(models.User{FirstName: "Ana", LastName: "Pérez", Email: "ana@ayudapp.test", Type: models.UserTypeHelper})
+
+	appointment := map[string]interface{}{
+		"date":       time.Now().Add(48 * time.Hour).Format(time.RFC3339),
+		"duration":   int64(2 * time.Hour),
+		"address":    "Av. 18 de Julio 1234",
+		"status":     "pending",
+		"helper":     helperID,
+		"created_by": helperID,
+		"category":   categoryID,
+	}
+
+	var created struct {
+		AppointmentID string `json:"appointment_id"`
+	}
+	s.decode(s.expect(http.MethodPost, "/api/admin/appointments/", appointment, http.StatusOK), &created)
+	path := "/api/admin/appointments/" + created.AppointmentID
+	s.expectJobs(created.AppointmentID, "appointment_reminder_24h pending", "appointment_reminder_1h pending")
+
+	first := s.expectIdempotent("/api/admin/appointments/", "cita-1", appointment, http.StatusOK, false)
+	retry := s.expectIdempotent("/api/admin/appointments/", "cita-1", appointment, http.StatusOK, true)
+	if first == nil || !bytes.Equal(first, retry) {
+		t.Errorf("el reintento no repitió la respuesta: %s != %s", first, retry)
+	}
+	other := map[string]interface{}{}
+	for key, value := range appointment {
+		other[key] = value
+	}
+	other["address"] = "Bulevar Artigas 1000"
+	s.expectIdempotent("/api/admin/appointments/", "cita-1", other, http.StatusUnprocessableEntity, false)
+	s.expectIdempotent("/api/admin/users/", "cita-1", map[string]string{"email": "no-es-un-correo"}, http.StatusBadRequest, false)
+
+	s.expect(http.MethodGet, "/api/admin/appointments/?status=pending", nil, http.StatusOK)
+	s.expect(http.MethodGet, path, nil, http.StatusOK)
+	s.expect(http.MethodGet, "/api/admin/appointments/000000000000000000000000", nil, http.StatusNotFound)
+
+	s.useETag(path)
+	s.expect(http.MethodPut, path, appointment, http.StatusOK)
+	s.expect(http.MethodPut, path, appointment, http.StatusPreconditionFailed)
+
+	s.useETag(path)
+	var patched struct {
+		Appointment struct {
+			Status  string `json:"status"`
+			Address string `json:"address"`
+		} `json:"appointment"`
+	}
+	s.decode(s.expectPatch(path, `{"status": "confirmed"}`, http.StatusOK), &patched)
+	if patched.Appointment.Status != "confirmed" || patched.Appointment.Address != "Av. 18 de Julio 1234" {
+		t.Errorf("PATCH %s no conservó los demás campos: %+v", path, patched.Appointment)
+	}
+	s.expectJobs(created.AppointmentID, "appointment_confirmed pending", "appointment_reminder_24h pending", "appointment_reminder_1h pending")
+	s.useETag(path)
+	s.expectPatch(path, `{"date": "2000-01-01T00:00:00Z"}`, http.StatusBadRequest)
+	stale := s.ifMatch
+	s.ifMatch = `"0", ` + stale
+	s.expectPatch(path, `{"status": "pending"}`, http.StatusOK)
+	s.expectPatch(path, `{"status": "confirmed"}`, http.StatusPreconditionFailed)
+	s.ifMatch = "*"
+	s.expectPatch(path, `{"status": "confirmed"}`, http.StatusOK)
+
+	s.ifMatch = ""
+	s.expect(http.MethodDelete, path, nil, http.StatusPreconditionRequired)
+	s.expectProblem(http.MethodPost, "/api/admin/appointments/", map[string]interface{}{
+		"date":       time.Now().Add(-time.Hour).Format(time.RFC3339),
+		"duration":   -1,
+		"status":     "desconocido",
+		"helper":     "no-es-un-id",
+		"created_by": helperID,
+	}, http.StatusBadRequest, "date", "duration", "address", "status", "helper")
+	s.useETag(path)
+	s.expect(http.MethodDelete, path, nil, http.StatusOK)
+	s.ifMatch = ""
+	s.expect(http.MethodGet, path, nil, http.StatusNotFound)
+	s.expectJobs(created.AppointmentID, "appointment_confirmed cancelled", "appointment_confirmed cancelled", "appointment_reminder_24h cancelled", "appointment_reminder_1h cancelled")
+}
+
+// Verifica los tipos y estados de las tareas programadas de una cita, en orden de ejecución
+func (s *testServer) expectJobs(appointmentID string, want ...string) {
+	s.t.Helper()
+
+	jobs, err := s.store.Jobs().Find(context.Background(), s.objectID(appointmentID))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	var got []string
+	for _, job := range jobs {
+		got = append(got, job.Type+" "+job.Status)
+	}
+	if !reflect.DeepEqual(got, want) {
+		s.t.Errorf("tareas de la cita %s = %q, want %q", appointmentID, got, want)
+	}
+}
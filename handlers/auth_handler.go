@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -50,20 +51,25 @@ func newUserResponse(user models.User) userResponse {
 // @Produce	json
 // @Param   loginUserRequest body loginUserRequest true 	"Datos del usuario"
 // @Success 200 {object} loginUserResponse "Respuesta del login"
-// @Failure 400 {object} loginUserResponse "Error en la solicitud"
+// @Failure 400 {object} middlewares.Problem "Error en la solicitud"
+// @Failure 401 {object} middlewares.Problem "Credenciales incorrectas"
 // @Router 	/login [post]
 func (server *Server) handleLoginUser(userService services.IUserService, AuthService services.IAuthService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req loginUserRequest
 		if err := ctx.ShouldBindJSON(&req); err != nil {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+			ctx.Error(bindingError(err))
 			return
 		}
 
-		resp, err := userService.GetUserByEmail(req.Email)
-
-		if err != nil {
-			ctx.JSON(http.StatusNotFound, utils.ErrorResponse(err))
+		// No se distingue entre un correo inexistente y una contraseña incorrecta
+		resp, err := userService.GetUserByEmail(ctx.Request.Context(), req.Email)
+		var notFound *services.NotFoundError
+		if errors.As(err, &notFound) {
+			ctx.Error(services.ErrInvalidCredentials)
+			return
+		} else if err != nil {
+			ctx.Error(err)
 			return
 		}
 
@@ -71,7 +77,7 @@ func (server *Server) handleLoginUser(userService services.IUserService, AuthSer
 
 		err = utils.CheckPassword(req.Password, user.Password)
 		if err != nil {
-			ctx.JSON(http.StatusUnauthorized, utils.ErrorResponse(err))
+			ctx.Error(services.ErrInvalidCredentials)
 			return
 		}
 
@@ -84,7 +90,7 @@ func (server *Server) handleLoginUser(userService services.IUserService, AuthSer
 			server.Config.AccessTokenDuration,
 		)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
@@ -97,11 +103,11 @@ func (server *Server) handleLoginUser(userService services.IUserService, AuthSer
 			server.Config.RefreshTokenDuration,
 		)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
-		session, err := AuthService.CreateSession(services.CreateSessionParams{
+		session, err := AuthService.CreateSession(ctx.Request.Context(), services.CreateSessionParams{
 			Email:        user.Email,
 			RefreshToken: refreshToken,
 			UserAgent:    ctx.Request.UserAgent(),
@@ -110,7 +116,7 @@ func (server *Server) handleLoginUser(userService services.IUserService, AuthSer
 			ExpiresAt:    refreshPayload.ExpiredAt,
 		})
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
@@ -1,10 +1,13 @@
 package handlers
 
 import (
-	"errors"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
 	"github.com/maferuy/ayudapp-admin-backend-core/services"
 	"github.com/maferuy/ayudapp-admin-backend-core/utils"
 )
@@ -14,19 +17,20 @@ import (
 // @Produce json
 // @Security ApiKeyAuth
 // @Success 200 {object} services.CreateCategoryResponse
-// @Failure 400 {object} services.CreateCategoryResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 409 {object} middlewares.Problem "Ya existe una categoría con ese nombre"
 // @Router 	/admin/categories [post]
 func handleCreateCategory(service services.ICategoryService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req services.CreateCategoryRequest
 		if err := ctx.ShouldBindJSON(&req); err != nil {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+			ctx.Error(bindingError(err))
 			return
 		}
 
-		categoryId, err := service.CreateCategory(req)
+		categoryId, err := service.CreateCategory(ctx.Request.Context(), req)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
@@ -38,43 +42,65 @@ func handleCreateCategory(service services.ICategoryService) gin.HandlerFunc {
 // @ID 		get-categories
 // @Produce json
 // @Security ApiKeyAuth
+// @Param 	lang 			query 	string false "Idioma de los nombres y descripciones"
+// @Param 	Accept-Language header 	string false "Idiomas preferidos"
+// @Param 	parent_id 		query 	string false "ID de la categoría padre, o root para las de primer nivel"
+// @Param 	q 				query 	string false "Texto a buscar en el nombre o el slug"
 // @Success 200 {object} services.GetCategoriesResponse
-// @Failure 400 {object} services.GetCategoriesResponse
+// @Failure 400 {object} middlewares.Problem
 // @Router 	/admin/categories [get]
 func handleGetCategories(service services.ICategoryService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		categories, err := service.GetCategories()
+		var filter repository.CategoryFilter
+		if err := ctx.ShouldBindQuery(&filter); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		categories, err := service.GetCategories(ctx.Request.Context(), filter, utils.ResolveLanguage(ctx))
 		if err != nil {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
+		setContentLanguage(ctx, categories.Languages)
 		ctx.JSON(http.StatusOK, utils.SuccessResponse(categories))
 	}
 }
 
-// @Summary	Obtiene una categoría por su ID
+// @Summary	Obtiene una categoría por su ID o slug
 // @ID 		get-category
 // @Produce json
 // @Security ApiKeyAuth
+// @Param 	id 				path 	string true "ID o slug de la categoría"
+// @Param 	lang 			query 	string false "Idioma del nombre y la descripción"
+// @Param 	Accept-Language header 	string false "Idiomas preferidos"
 // @Success 200 {object} services.GetCategoryResponse
-// @Failure 400 {object} services.GetCategoryResponse
+// @Success 301 {string} string "Redirección al slug actual de la categoría"
+// @Failure 400 {object} middlewares.Problem
 // @Router 	/admin/categories/{id} [get]
 func handleGetCategory(service services.ICategoryService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
 		if id == "" {
-			err := errors.New("el id es requerido")
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+			ctx.Error(errIDRequired)
 			return
 		}
 
-		category, err := service.GetCategory(id)
+		category, err := service.GetCategory(ctx.Request.Context(), id, utils.ResolveLanguage(ctx))
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
+		// Los slugs anteriores redirigen al slug actual de la categoría
+		if slug := category.Category.Slug; slug != "" && id != slug && id != category.Category.ID.Hex() {
+			location := url.URL{Path: path.Join(path.Dir(ctx.Request.URL.Path), slug), RawQuery: ctx.Request.URL.RawQuery}
+			ctx.Redirect(http.StatusMovedPermanently, location.String())
+			return
+		}
+
+		setContentLanguage(ctx, category.Languages)
 		ctx.JSON(http.StatusOK, utils.SuccessResponse(category))
 	}
 }
@@ -84,26 +110,63 @@ func handleGetCategory(service services.ICategoryService) gin.HandlerFunc {
 // @Produce json
 // @Security ApiKeyAuth
 // @Success 200 {object} services.UpdateCategoryResponse
-// @Failure 400 {object} services.UpdateCategoryResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 409 {object} middlewares.Problem "Ya existe una categoría con ese nombre"
 // @Router 	/admin/categories/{id} [put]
 func handleUpdateCategory(service services.ICategoryService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req services.UpdateCategoryRequest
 		if err := ctx.ShouldBindJSON(&req); err != nil {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+			ctx.Error(bindingError(err))
 			return
 		}
 
 		id := ctx.Param("id")
 		if id == "" {
-			err := errors.New("el id es requerido")
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+			ctx.Error(errIDRequired)
 			return
 		}
 
-		category, err := service.UpdateCategory(id, req)
+		category, err := service.UpdateCategory(ctx.Request.Context(), id, req)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(category))
+	}
+}
+
+// @Summary	Actualiza parcialmente una categoría
+// @Description Aplica un parche JSON Merge Patch (RFC 7396): sólo se modifican los campos indicados y los campos con valor null se vacían
+// @ID 		patch-category
+// @Accept 	application/merge-patch+json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param   id 		path string 	true "ID o slug de la categoría"
+// @Param 	patch 	body services.UpdateCategoryRequest true "Campos a modificar"
+// @Success 200 {object} services.UpdateCategoryResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 404 {object} middlewares.Problem
+// @Failure 409 {object} middlewares.Problem "Ya existe una categoría con ese nombre"
+// @Failure 415 {object} middlewares.Problem
+// @Router 	/admin/categories/{id} [patch]
+func handlePatchCategory(service services.ICategoryService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		patch, ok := readMergePatch(ctx)
+		if !ok {
+			return
+		}
+
+		category, err := service.PatchCategory(ctx.Request.Context(), id, patch)
+		if err != nil {
+			ctx.Error(err)
 			return
 		}
 
@@ -116,20 +179,163 @@ func handleUpdateCategory(service services.ICategoryService) gin.HandlerFunc {
 // @Produce json
 // @Security ApiKeyAuth
 // @Success 200 {object} string
-// @Failure 400 {object} string
+// @Failure 400 {object} middlewares.Problem
 // @Router 	/admin/categories/{id} [delete]
 func handleDeleteCategory(service services.ICategoryService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
 		if id == "" {
-			err := errors.New("el id es requerido")
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		err := service.DeleteCategory(ctx.Request.Context(), id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(nil))
+	}
+}
+
+// @Summary	Obtiene el árbol de categorías
+// @ID 		get-category-tree
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	lang 			query 	string false "Idioma de los nombres y descripciones"
+// @Param 	Accept-Language header 	string false "Idiomas preferidos"
+// @Success 200 {object} services.GetCategoryTreeResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/categories/tree [get]
+func handleGetCategoryTree(service services.ICategoryService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tree, err := service.GetCategoryTree(ctx.Request.Context(), utils.ResolveLanguage(ctx))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		setContentLanguage(ctx, tree.Languages)
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(tree))
+	}
+}
+
+// @Summary	Mueve una categoría a otra categoría padre
+// @ID 		move-category
+// @Accept 	json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id 					path string 						true "ID de la categoría"
+// @Param 	MoveCategoryRequest	body services.MoveCategoryRequest 	true "Nueva categoría padre y posición"
+// @Success 200 {object} services.MoveCategoryResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/categories/{id}/move [post]
+func handleMoveCategory(service services.ICategoryService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req services.MoveCategoryRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		category, err := service.MoveCategory(ctx.Request.Context(), id, req)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(category))
+	}
+}
+
+// @Summary	Reordena las subcategorías de una categoría
+// @ID 		reorder-categories
+// @Accept 	json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	ReorderCategoriesRequest body services.ReorderCategoriesRequest true "Categoría padre y orden de las subcategorías"
+// @Success 200 {object} string
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/categories/reorder [post]
+func handleReorderCategories(service services.ICategoryService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req services.ReorderCategoriesRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		if err := service.ReorderCategories(ctx.Request.Context(), req); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(nil))
+	}
+}
+
+// @Summary	Agrega o reemplaza la traducción de una categoría
+// @ID 		set-category-translation
+// @Accept 	json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id 								path string 								true "ID de la categoría"
+// @Param 	lang 							path string 								true "Código del idioma"
+// @Param 	SetCategoryTranslationRequest	body services.SetCategoryTranslationRequest true "Nombre y descripción traducidos"
+// @Success 200 {object} services.UpdateCategoryResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/categories/{id}/translations/{lang} [put]
+func handleSetCategoryTranslation(service services.ICategoryService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req services.SetCategoryTranslationRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
 			return
 		}
 
-		err := service.DeleteCategory(id)
+		category, err := service.SetCategoryTranslation(ctx.Request.Context(), id, ctx.Param("lang"), req)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(category))
+	}
+}
+
+// @Summary	Elimina la traducción de una categoría
+// @ID 		delete-category-translation
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id 		path string true "ID de la categoría"
+// @Param 	lang 	path string true "Código del idioma"
+// @Success 200 {object} string
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/categories/{id}/translations/{lang} [delete]
+func handleDeleteCategoryTranslation(service services.ICategoryService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		err := service.DeleteCategoryTranslation(ctx.Request.Context(), id, ctx.Param("lang"))
+		if err != nil {
+			ctx.Error(err)
 			return
 		}
 
@@ -137,6 +343,60 @@ func handleDeleteCategory(service services.ICategoryService) gin.HandlerFunc {
 	}
 }
 
+// @Summary	Obtiene las categorías con traducciones faltantes
+// @ID 		get-missing-category-translations
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} services.GetMissingTranslationsResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/categories/translations/missing [get]
+func handleGetMissingTranslations(service services.ICategoryService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		missing, err := service.GetMissingTranslations(ctx.Request.Context())
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(missing))
+	}
+}
+
+// @Summary	Fusiona una categoría en otra
+// @ID 		merge-category
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id 		path string true "ID de la categoría a fusionar"
+// @Param 	target 	path string true "ID de la categoría destino"
+// @Success 200 {object} services.MergeCategoryResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/categories/{id}/merge-into/{target} [post]
+func handleMergeCategory(service services.ICategoryService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		target := ctx.Param("target")
+		if id == "" || target == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		merged, err := service.MergeCategory(ctx.Request.Context(), id, target)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(merged))
+	}
+}
+
+// Indica en Content-Language los idiomas en que se devolvieron los contenidos
+func setContentLanguage(ctx *gin.Context, languages []string) {
+	if len(languages) > 0 {
+		ctx.Header("Content-Language", strings.Join(languages, ", "))
+	}
+}
+
 /**
  * @param group *gin.IRoutes "El grupo de endpoints padre"
  * @param service services.ICategoryService "El servicio de categorias"
@@ -147,7 +407,17 @@ func newCategoryHandler(group gin.IRoutes, service services.ICategoryService) *g
 	group.GET("/", handleGetCategories(service))
 	group.GET("/:id", handleGetCategory(service))
 	group.PUT("/:id", handleUpdateCategory(service))
+	group.PATCH("/:id", handlePatchCategory(service))
 	group.DELETE("/:id", handleDeleteCategory(service))
 
+	group.GET("/tree", handleGetCategoryTree(service))
+	group.POST("/reorder", handleReorderCategories(service))
+	group.POST("/:id/move", handleMoveCategory(service))
+	group.POST("/:id/merge-into/:target", handleMergeCategory(service))
+
+	group.GET("/translations/missing", handleGetMissingTranslations(service))
+	group.PUT("/:id/translations/:lang", handleSetCategoryTranslation(service))
+	group.DELETE("/:id/translations/:lang", handleDeleteCategoryTranslation(service))
+
 	return &group
 }
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCategoryRoutes(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+
+	var ids []string
+	for _, name := range []string{"Limpieza", "Jardinería", "Plomería"} {
+		ids = append(ids, s.createCategory(name))
+	}
+
+	s.expect(http.MethodPost, "/api/admin/categories/", map[string]string{"name": "limpieza"}, http.StatusConflict)
+
+	var created struct {
+		CategoryID string `json:"category_id"`
+	}
+	s.decode(s.expect(http.MethodPost, "/api/admin/categories/", map[string]string{
+		"name":      "Cortar el pasto",
+		"parent_id": ids[1],
+	}, http.StatusOK), &created)
+	childID := created.CategoryID
+
+	s.expect(http.MethodGet, "/api/admin/categories/", nil, http.StatusOK)
+	s.expect(http.MethodGet, "/api/admin/categories/"+ids[0], nil, http.StatusOK)
+	s.expect(http.MethodGet, "/api/admin/categories/jardineria", nil, http.StatusOK)
+	s.expect(http.MethodGet, "/api/admin/categories/no-existe", nil, http.StatusNotFound)
+	s.expect(http.MethodPut, "/api/admin/categories/"+ids[0], map[string]string{
+		"name":        "Limpieza del hogar",
+		"description": "Limpieza general",
+	}, http.StatusOK)
+	s.expectPatch("/api/admin/categories/"+ids[0], `{"description": null}`, http.StatusOK)
+	s.expect(http.MethodGet, "/api/admin/categories/limpieza", nil, http.StatusMovedPermanently)
+	s.expect(http.MethodGet, "/api/admin/categories/tree", nil, http.StatusOK)
+	s.expect(http.MethodPost, "/api/admin/categories/reorder", map[string]interface{}{
+		"category_ids": []string{ids[2], ids[1], ids[0]},
+	}, http.StatusOK)
+	s.expect(http.MethodPost, "/api/admin/categories/"+childID+"/move", map[string]string{"parent_id": ids[2]}, http.StatusOK)
+	s.expect(http.MethodPost, "/api/admin/categories/"+ids[2]+"/move", map[string]string{"parent_id": childID}, http.StatusBadRequest)
+	s.expect(http.MethodPut, "/api/admin/categories/"+ids[0]+"/translations/pt", map[string]string{"name": "Limpeza da casa"}, http.StatusOK)
+	s.expect(http.MethodGet, "/api/admin/categories/translations/missing", nil, http.StatusOK)
+	s.expect(http.MethodDelete, "/api/admin/categories/"+ids[0]+"/translations/pt", nil, http.StatusOK)
+	s.expect(http.MethodDelete, "/api/admin/categories/"+ids[2], nil, http.StatusConflict)
+	s.expect(http.MethodPost, "/api/admin/categories/"+ids[2]+"/merge-into/"+ids[1], nil, http.StatusOK)
+	s.expect(http.MethodGet, "/api/admin/categories/plomeria", nil, http.StatusMovedPermanently)
+	s.expect(http.MethodDelete, "/api/admin/categories/"+childID, nil, http.StatusOK)
+}
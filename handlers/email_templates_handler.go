@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/services"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+)
+
+type emailTemplatesResponse struct {
+	Templates []string `json:"templates"`
+	Languages []string `json:"languages"`
+}
+
+// @Summary Obtiene las plantillas de correo electrónico disponibles
+// @ID 		get-email-templates
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} emailTemplatesResponse
+// @Router 	/admin/email-templates [get]
+func handleGetEmailTemplates() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(emailTemplatesResponse{
+			Templates: utils.EmailTemplateNames(),
+			Languages: utils.SupportedLanguages,
+		}))
+	}
+}
+
+// @Summary Previsualiza una plantilla de correo electrónico
+// @Description Si no se envían datos se usan datos de ejemplo. Con format=html o format=text se devuelve sólo ese cuerpo.
+// @ID 		preview-email-template
+// @Accept 	json
+// @Produce json,html,plain
+// @Security ApiKeyAuth
+// @Param 	name 				path 	string 					true 	"Nombre de la plantilla"
+// @Param 	lang 				query 	string 					false 	"Idioma de la plantilla"
+// @Param 	format 				query 	string 					false 	"Formato de la respuesta (json, html, text)"
+// @Param 	EmailTemplateData 	body 	utils.EmailTemplateData false 	"Datos de la plantilla"
+// @Success 200 {object} utils.RenderedEmail
+// @Failure 400 {object} middlewares.Problem
+// @Failure 404 {object} middlewares.Problem "La plantilla no existe"
+// @Router 	/admin/email-templates/{name}/preview [post]
+func handlePreviewEmailTemplate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		name := ctx.Param("name")
+		if !utils.EmailTemplateExists(name) {
+			ctx.Error(services.ErrEmailTemplateNotFound)
+			return
+		}
+
+		data := utils.SampleEmailTemplateData()
+		if ctx.Request.ContentLength > 0 {
+			if err := ctx.ShouldBindJSON(&data); err != nil {
+				ctx.Error(bindingError(err))
+				return
+			}
+		}
+
+		email, err := utils.RenderEmail(name, utils.ResolveLanguage(ctx), data)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		switch ctx.Query("format") {
+		case "html":
+			ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(email.HTML))
+		case "text":
+			ctx.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(email.Text))
+		default:
+			ctx.JSON(http.StatusOK, utils.SuccessResponse(email))
+		}
+	}
+}
+
+/** Crea los endpoints de plantillas de correo electrónico
+ *
+ * @param group gin.IRoutes "El grupo de endpoints padre"
+ * @return *gin.IRoutes "El grupo de endpoints creado"
+ */
+func newEmailTemplateHandler(group gin.IRoutes) *gin.IRoutes {
+	group.GET("/", handleGetEmailTemplates())
+	group.POST("/:name/preview", handlePreviewEmailTemplate())
+
+	return &group
+}
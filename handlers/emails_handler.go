@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/services"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+)
+
+// @Summary Obtiene los correos electrónicos de la cola de envío
+// @ID 		get-emails
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	status query string false "Estado de entrega (queued, sending, sent, dead)"
+// @Success 200 {object} services.GetEmailsResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/emails [get]
+func handleGetEmails(outbox services.IEmailOutbox) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		emails, err := outbox.GetEmails(ctx.Query("status"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(emails))
+	}
+}
+
+// @Summary Obtiene un correo electrónico con sus intentos de entrega
+// @ID 		get-email
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id path string true "ID del correo"
+// @Success 200 {object} services.GetEmailResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 404 {object} middlewares.Problem "El correo no existe"
+// @Router 	/admin/emails/{id} [get]
+func handleGetEmail(outbox services.IEmailOutbox) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		email, err := outbox.GetEmail(id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(email))
+	}
+}
+
+// @Summary Vuelve a encolar un correo electrónico descartado o enviado
+// @ID 		resend-email
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id path string true "ID del correo"
+// @Success 200 {object} services.ResendEmailResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 404 {object} middlewares.Problem "El correo no existe"
+// @Failure 409 {object} middlewares.Problem "El correo todavía está en cola"
+// @Router 	/admin/emails/{id}/resend [post]
+func handleResendEmail(outbox services.IEmailOutbox) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		email, err := outbox.ResendEmail(id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(email))
+	}
+}
+
+/** Crea los endpoints de la cola de correos electrónicos
+ *
+ * @param group gin.IRoutes "El grupo de endpoints padre"
+ * @param outbox services.IEmailOutbox "La cola de correos"
+ * @return *gin.IRoutes "El grupo de endpoints creado"
+ */
+func newEmailHandler(group gin.IRoutes, outbox services.IEmailOutbox) *gin.IRoutes {
+	group.GET("/", handleGetEmails(outbox))
+	group.GET("/:id", handleGetEmail(outbox))
+	group.POST("/:id/resend", handleResendEmail(outbox))
+
+	return &group
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+)
+
+func TestEmailRoutes(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+
+	var ids []string
+	for _, status := range []string{models.EmailStatusQueued, models.EmailStatusDead} {
+		email := models.OutboundEmail{
+			Sender:    "no-reply@ayudapp.com",
+			Recipient: "ana@example.com",
+			Subject:   "Recordatorio",
+			Status:    status,
+			Attempts:  3,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		if err := s.store.Emails().Insert(context.Background(), &email); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, email.ID.Hex())
+	}
+	queuedID, deadID := ids[0], ids[1]
+
+	var emails struct {
+		Emails []models.OutboundEmail `json:"emails"`
+	}
+	s.decode(s.expect(http.MethodGet, "/api/admin/emails/", nil, http.StatusOK), &emails)
+	if len(emails.Emails) != 2 {
+		t.Errorf("se esperaban 2 correos, se obtuvieron %d", len(emails.Emails))
+	}
+	s.decode(s.expect(http.MethodGet, "/api/admin/emails/?status=dead", nil, http.StatusOK), &emails)
+	if len(emails.Emails) != 1 || emails.Emails[0].ID.Hex() != deadID {
+		t.Errorf("correos descartados inesperados: %+v", emails.Emails)
+	}
+	s.expect(http.MethodGet, "/api/admin/emails/"+queuedID, nil, http.StatusOK)
+
+	var resent struct {
+		Email models.OutboundEmail `json:"email"`
+	}
+	s.decode(s.expect(http.MethodPost, "/api/admin/emails/"+deadID+"/resend", nil, http.StatusOK), &resent)
+	if resent.Email.Status != models.EmailStatusQueued || resent.Email.Attempts != 0 {
+		t.Errorf("el correo no volvió a la cola: %+v", resent.Email)
+	}
+	s.expectError(http.MethodPost, "/api/admin/emails/"+queuedID+"/resend", nil, http.StatusConflict, "email_queued")
+	s.expectError(http.MethodGet, "/api/admin/emails/000000000000000000000000", nil, http.StatusNotFound, "email_not_found")
+	s.expectError(http.MethodPost, "/api/admin/emails/000000000000000000000000/resend", nil, http.StatusNotFound, "email_not_found")
+	s.expectError(http.MethodGet, "/api/admin/emails/no-es-un-id", nil, http.StatusBadRequest, "invalid_id")
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/maferuy/ayudapp-admin-backend-core/services"
+)
+
+// Error de las rutas que reciben un id vacío
+var errIDRequired = services.NewValidationError("id_required", "el id es requerido")
+
+/** Obtiene el error de una solicitud que no se pudo leer o validar
+ *
+ * Los errores de validación se mantienen para responder los errores de cada campo.
+ *
+ * @param err error "El error de ShouldBindJSON o ShouldBindQuery"
+ * @return error "El error a registrar en el contexto"
+ */
+func bindingError(err error) error {
+	var fields validator.ValidationErrors
+	if errors.As(err, &fields) {
+		return fields
+	}
+
+	return services.NewValidationError("invalid_request", "la solicitud es inválida: %v", err)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/services"
+)
+
+// Devuelve el ETag de un registro a partir de su versión
+func versionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// Agrega a la respuesta la cabecera ETag con la versión del registro
+func setETag(ctx *gin.Context, version int64) {
+	ctx.Header("ETag", versionETag(version))
+}
+
+/** Obtiene las versiones del registro que acepta el cliente a partir de la cabecera If-Match
+ *
+ * La cabecera puede ser * o una lista de ETag separados por comas. Los ETag débiles y los
+ * que no son de versión se ignoran, porque If-Match requiere una comparación fuerte. Si la
+ * cabecera falta, o ningún ETag puede coincidir, registra el error y devuelve false.
+ *
+ * @param ctx *gin.Context "El contexto de la solicitud"
+ * @return services.VersionMatch "Las versiones aceptadas"
+ * @return bool "Si la cabecera es válida"
+ */
+func ifMatch(ctx *gin.Context) (match services.VersionMatch, ok bool) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" {
+		ctx.Error(services.ErrIfMatchRequired)
+		return
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			match.Any = true
+			continue
+		}
+
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+
+		if version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64); err == nil {
+			match.Versions = append(match.Versions, version)
+		}
+	}
+
+	if !match.Any && len(match.Versions) == 0 {
+		ctx.Error(services.ErrVersionMismatch)
+		return
+	}
+
+	ok = true
+	return
+}
+
+/** Responde 304 Not Modified si el cliente ya tiene la versión actual del registro
+ *
+ * Compara la cabecera If-None-Match, que puede tener varios ETag, con la versión del
+ * registro sin distinguir los ETag débiles.
+ *
+ * @param ctx *gin.Context "El contexto de la solicitud"
+ * @param version int64 "La versión actual del registro"
+ * @return bool "Si se respondió 304"
+ */
+func notModified(ctx *gin.Context, version int64) bool {
+	header := ctx.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	etag := versionETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			setETag(ctx, version)
+			ctx.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
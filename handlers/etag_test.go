@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/services"
+)
+
+// Crea el contexto de una solicitud con las cabeceras indicadas
+func newTestContext(headers map[string]string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	for key, value := range headers {
+		ctx.Request.Header.Set(key, value)
+	}
+
+	return ctx, recorder
+}
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    services.VersionMatch
+		wantErr error
+	}{
+		{name: "versión", header: `"3"`, want: services.VersionMatch{Versions: []int64{3}}},
+		{name: "con espacios", header: ` "12" `, want: services.VersionMatch{Versions: []int64{12}}},
+		{name: "cualquiera", header: "*", want: services.VersionMatch{Any: true}},
+		{name: "lista", header: `"2", "5"`, want: services.VersionMatch{Versions: []int64{2, 5}}},
+		{name: "lista con ETag débil", header: `W/"2", "5"`, want: services.VersionMatch{Versions: []int64{5}}},
+		{name: "sin cabecera", header: "", wantErr: services.ErrIfMatchRequired},
+		{name: "sin comillas", header: "3", wantErr: services.ErrVersionMismatch},
+		{name: "ETag débil", header: `W/"3"`, wantErr: services.ErrVersionMismatch},
+		{name: "no es una versión", header: `"abc"`, wantErr: services.ErrVersionMismatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, _ := newTestContext(map[string]string{"If-Match": test.header})
+
+			match, ok := ifMatch(ctx)
+			if test.wantErr != nil {
+				if ok || len(ctx.Errors) == 0 || !errors.Is(ctx.Errors.Last().Err, test.wantErr) {
+					t.Errorf("ifMatch() = %+v, %t, errors %v; want error %v", match, ok, ctx.Errors, test.wantErr)
+				}
+				return
+			}
+
+			if !ok || !reflect.DeepEqual(match, test.want) {
+				t.Errorf("ifMatch() = %+v, %t, errors %v; want %+v", match, ok, ctx.Errors, test.want)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "sin cabecera", header: "", want: false},
+		{name: "misma versión", header: `"4"`, want: true},
+		{name: "otra versión", header: `"3"`, want: false},
+		{name: "lista", header: `"2", "4"`, want: true},
+		{name: "ETag débil", header: `W/"4"`, want: true},
+		{name: "cualquiera", header: "*", want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, recorder := newTestContext(map[string]string{"If-None-Match": test.header})
+
+			if got := notModified(ctx, 4); got != test.want {
+				t.Fatalf("notModified() = %t, want %t", got, test.want)
+			}
+
+			if test.want {
+				if recorder.Code != http.StatusNotModified || recorder.Header().Get("ETag") != `"4"` {
+					t.Errorf("respuesta = %d con ETag %q, want 304 con ETag \"4\"", recorder.Code, recorder.Header().Get("ETag"))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/middlewares"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"github.com/maferuy/ayudapp-admin-backend-core/services"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+)
+
+// Opciones comunes de los endpoints de exportación
+type exportOptions struct {
+	Format string `form:"format"`
+	Async  bool   `form:"async"`
+}
+
+// @Summary Exporta los usuarios a CSV, XLSX o NDJSON
+// @ID 		export-users
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/x-ndjson,json
+// @Security ApiKeyAuth
+// @Param 	format 	query string false "Formato (csv, xlsx, ndjson)" default(csv)
+// @Param 	async 	query bool 	 false "Generar la exportación en segundo plano"
+// @Param 	type 	query string false "Tipo de usuario"
+// @Param 	status 	query string false "Estado del usuario"
+// @Param 	skill 	query string false "ID de una categoría de habilidad"
+// @Param 	q 		query string false "Texto a buscar en el nombre o el correo electrónico"
+// @Success 200 {file} file
+// @Success 202 {object} services.StartExportResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/users/export [get]
+func handleExportUsers(service services.IExportService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var filter repository.UserFilter
+		if err := ctx.ShouldBindQuery(&filter); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		export(ctx, service, services.ExportResourceUsers, filter)
+	}
+}
+
+// @Summary Exporta las citas a CSV, XLSX o NDJSON
+// @ID 		export-appointments
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/x-ndjson,json
+// @Security ApiKeyAuth
+// @Param 	format 		query string false "Formato (csv, xlsx, ndjson)" default(csv)
+// @Param 	async 		query bool 	 false "Generar la exportación en segundo plano"
+// @Param 	status 		query string false "Estado de la cita"
+// @Param 	helper 		query string false "ID del ayudante"
+// @Param 	created_by 	query string false "ID del solicitante"
+// @Param 	category 	query string false "ID de la categoría"
+// @Param 	from 		query string false "Fecha inicial (AAAA-MM-DD)"
+// @Param 	to 			query string false "Fecha final, incluida (AAAA-MM-DD)"
+// @Success 200 {file} file
+// @Success 202 {object} services.StartExportResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/appointments/export [get]
+func handleExportAppointments(service services.IExportService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var filter repository.AppointmentFilter
+		if err := ctx.ShouldBindQuery(&filter); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		export(ctx, service, services.ExportResourceAppointments, filter)
+	}
+}
+
+// @Summary Exporta las categorías a CSV, XLSX o NDJSON
+// @ID 		export-categories
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/x-ndjson,json
+// @Security ApiKeyAuth
+// @Param 	format 		query string false "Formato (csv, xlsx, ndjson)" default(csv)
+// @Param 	async 		query bool 	 false "Generar la exportación en segundo plano"
+// @Param 	parent_id 	query string false "ID de la categoría padre, o root para las de primer nivel"
+// @Param 	q 			query string false "Texto a buscar en el nombre o el slug"
+// @Success 200 {file} file
+// @Success 202 {object} services.StartExportResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/categories/export [get]
+func handleExportCategories(service services.IExportService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var filter repository.CategoryFilter
+		if err := ctx.ShouldBindQuery(&filter); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		export(ctx, service, services.ExportResourceCategories, filter)
+	}
+}
+
+/** Responde con el archivo exportado o inicia la exportación en segundo plano
+ *
+ * @param ctx *gin.Context "El contexto de la petición"
+ * @param service services.IExportService "El servicio de exportaciones"
+ * @param resource string "El recurso a exportar"
+ * @param filter services.ListFilter "Los filtros del listado"
+ */
+func export(ctx *gin.Context, service services.IExportService, resource string, filter services.ListFilter) {
+	var opts exportOptions
+	if err := ctx.ShouldBindQuery(&opts); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+	if opts.Format == "" {
+		opts.Format = services.ExportFormatCSV
+	}
+
+	req := services.ExportRequest{
+		Resource: resource,
+		Format:   opts.Format,
+		Filter:   filter,
+		Params:   ctx.Request.URL.RawQuery,
+		Async:    opts.Async,
+	}
+	if payload, ok := middlewares.GetAuthorizationPayload(ctx); ok {
+		req.CreatedBy = payload.Email
+	}
+
+	async, err := service.RunsInBackground(req)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if async {
+		job, err := service.StartExport(req)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusAccepted, utils.SuccessResponse(job))
+		return
+	}
+
+	ctx.Header("Content-Type", services.ExportContentType(req.Format))
+	ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": services.ExportFileName(req, time.Now())}))
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.Status(http.StatusOK)
+
+	// Los encabezados ya se enviaron, así que un error sólo puede registrarse
+	if _, err := service.WriteExport(ctx.Writer, req); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+// @Summary Obtiene el estado de una exportación en segundo plano
+// @ID 		get-export
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id path string true "ID de la exportación"
+// @Success 200 {object} services.GetExportResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 404 {object} middlewares.Problem "La exportación no existe"
+// @Router 	/admin/exports/{id} [get]
+func handleGetExport(service services.IExportService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		job, err := service.GetExport(id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(job))
+	}
+}
+
+// @Summary Descarga el archivo de una exportación terminada
+// @ID 		download-export
+// @Produce octet-stream
+// @Security ApiKeyAuth
+// @Param 	id path string true "ID de la exportación"
+// @Success 200 {file} file
+// @Failure 404 {object} middlewares.Problem
+// @Failure 409 {object} middlewares.Problem "La exportación no está lista"
+// @Router 	/admin/exports/{id}/download [get]
+func handleDownloadExport(service services.IExportService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		job, body, err := service.OpenExport(id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		defer body.Close()
+
+		disposition := mime.FormatMediaType("attachment", map[string]string{"filename": job.FileName})
+		ctx.DataFromReader(http.StatusOK, job.Size, services.ExportContentType(job.Format), body, map[string]string{
+			"Content-Disposition":    disposition,
+			"X-Content-Type-Options": "nosniff",
+		})
+	}
+}
+
+/** Crea los endpoints de exportación
+ *
+ * @param group gin.IRoutes "El grupo de endpoints de exportaciones"
+ * @param userGroup gin.IRoutes "El grupo de endpoints de usuarios"
+ * @param appointmentGroup gin.IRoutes "El grupo de endpoints de citas"
+ * @param categoryGroup gin.IRoutes "El grupo de endpoints de categorías"
+ * @param service services.IExportService "El servicio de exportaciones"
+ * @return *gin.IRoutes "El grupo de endpoints creado"
+ */
+func newExportHandler(group gin.IRoutes, userGroup gin.IRoutes, appointmentGroup gin.IRoutes, categoryGroup gin.IRoutes, service services.IExportService) *gin.IRoutes {
+	group.GET("/:id", handleGetExport(service))
+	group.GET("/:id/download", handleDownloadExport(service))
+
+	userGroup.GET("/export", handleExportUsers(service))
+	appointmentGroup.GET("/export", handleExportAppointments(service))
+	categoryGroup.GET("/export", handleExportCategories(service))
+
+	return &group
+}
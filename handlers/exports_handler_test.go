@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+)
+
+func TestExportRoutes(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+	s.insertUser(models.User{FirstName: "Ana", LastName: "Pérez", Email: "ana@ayudapp.test", Password: "hash-secreto", Type: models.UserTypeHelper})
+	s.createCategory("Limpieza")
+
+	recorder := s.expectStatus(http.MethodGet, "/api/admin/users/export?type=helper", nil, http.StatusOK)
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/csv") || !strings.Contains(recorder.Header().Get("Content-Disposition"), "attachment") {
+		t.Errorf("cabeceras de la exportación inesperadas: %v", recorder.Header())
+	}
+	records, err := csv.NewReader(recorder.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0][3] != "email" || records[1][3] != "ana@ayudapp.test" {
+		t.Errorf("exportación de usuarios inesperada: %v", records)
+	}
+	for _, record := range records {
+		if strings.Contains(strings.Join(record, ","), "hash-secreto") {
+			t.Errorf("la exportación incluye la contraseña: %v", record)
+		}
+	}
+
+	recorder = s.expectStatus(http.MethodGet, "/api/admin/categories/export?format=ndjson", nil, http.StatusOK)
+	if lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n"); len(lines) != 1 || !strings.Contains(lines[0], `"Limpieza"`) {
+		t.Errorf("exportación de categorías inesperada: %s", recorder.Body.Bytes())
+	}
+	s.expectStatus(http.MethodGet, "/api/admin/appointments/export", nil, http.StatusOK)
+	s.expectError(http.MethodGet, "/api/admin/users/export?format=pdf", nil, http.StatusBadRequest, "invalid_export_format")
+	s.expectError(http.MethodGet, "/api/admin/exports/000000000000000000000000", nil, http.StatusNotFound, "export_not_found")
+	s.expectError(http.MethodGet, "/api/admin/exports/000000000000000000000000/download", nil, http.StatusNotFound, "export_not_found")
+
+	var started struct {
+		ExportID  string `json:"export_id"`
+		StatusURL string `json:"status_url"`
+	}
+	s.decode(s.expect(http.MethodGet, "/api/admin/users/export?async=true", nil, http.StatusAccepted), &started)
+	path := "/api/admin/exports/" + started.ExportID
+
+	var job struct {
+		Export      models.Export `json:"export"`
+		DownloadURL string        `json:"download_url"`
+	}
+	s.eventually("la exportación termina", func() bool {
+		s.decode(s.expect(http.MethodGet, path, nil, http.StatusOK), &job)
+		return job.Export.Status != models.ExportStatusRunning
+	})
+	if job.Export.Status != models.ExportStatusDone || job.Export.Rows != 2 || job.Export.CreatedBy != testAdminEmail || job.DownloadURL == "" {
+		t.Errorf("resultado de la exportación inesperado: %+v", job)
+	}
+
+	recorder = s.expectStatus(http.MethodGet, path+"/download", nil, http.StatusOK)
+	if records, err = csv.NewReader(recorder.Body).ReadAll(); err != nil || len(records) != 3 {
+		t.Errorf("descarga de la exportación inesperada: %v (%v)", records, err)
+	}
+}
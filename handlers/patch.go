@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/middlewares"
+	"github.com/maferuy/ayudapp-admin-backend-core/services"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+)
+
+/** Lee el parche JSON Merge Patch del cuerpo de la solicitud
+ *
+ * Acepta los tipos de contenido application/merge-patch+json y application/json. Si el
+ * parche no se puede leer responde el error y devuelve false.
+ *
+ * @param ctx *gin.Context "El contexto de la solicitud"
+ * @return []byte "El parche"
+ * @return bool "Si el parche se leyó correctamente"
+ */
+func readMergePatch(ctx *gin.Context) ([]byte, bool) {
+	mediaType, _, err := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+	if err != nil || (mediaType != utils.MergePatchContentType && mediaType != gin.MIMEJSON) {
+		middlewares.WriteProblem(ctx, http.StatusUnsupportedMediaType, "unsupported_media_type",
+			"el parche debe tener el tipo de contenido "+utils.MergePatchContentType)
+		return nil, false
+	}
+
+	patch, err := ctx.GetRawData()
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_patch", "no se pudo leer el parche: %v", err))
+		return nil, false
+	} else if len(patch) == 0 {
+		ctx.Error(services.NewValidationError("invalid_patch", "el parche está vacío"))
+		return nil, false
+	}
+
+	return patch, true
+}
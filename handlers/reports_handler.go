@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/services"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+)
+
+// @Summary Obtiene la carga de trabajo y las horas de los ayudantes en un período
+// @ID 		get-helper-report
+// @Produce json,text/csv
+// @Security ApiKeyAuth
+// @Param 	from 		query string false "Fecha inicial (AAAA-MM-DD), por defecto el primer día del mes"
+// @Param 	to 			query string false "Fecha final, incluida (AAAA-MM-DD), por defecto hoy"
+// @Param 	helper 		query string false "ID del ayudante"
+// @Param 	category 	query string false "ID de la categoría"
+// @Param 	format 		query string false "Formato de la respuesta (json, csv)" default(json)
+// @Success 200 {object} services.GetHelperReportResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/reports/helpers [get]
+func handleGetHelperReport(service services.IReportService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req services.HelperReportRequest
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		format := ctx.DefaultQuery("format", "json")
+		if format != "json" && format != services.ExportFormatCSV {
+			ctx.Error(services.NewValidationError("invalid_report_format", "el formato debe ser json o csv"))
+			return
+		}
+
+		report, err := service.GetHelperReport(req)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		if format == "json" {
+			ctx.JSON(http.StatusOK, utils.SuccessResponse(report))
+			return
+		}
+
+		fileName := "helpers-" + report.From + "-" + report.To + ".csv"
+		ctx.Header("Content-Type", services.ExportContentType(services.ExportFormatCSV))
+		ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+		ctx.Header("X-Content-Type-Options", "nosniff")
+		ctx.Status(http.StatusOK)
+
+		if err = services.WriteHelperReportCSV(ctx.Writer, report); err != nil {
+			_ = ctx.Error(err)
+		}
+	}
+}
+
+/** Crea los endpoints de informes
+ *
+ * @param group gin.IRoutes "El grupo de endpoints de informes"
+ * @param service services.IReportService "El servicio de informes"
+ * @return *gin.IRoutes "El grupo de endpoints creado"
+ */
+func newReportHandler(group gin.IRoutes, service services.IReportService) *gin.IRoutes {
+	group.GET("/helpers", handleGetHelperReport(service))
+
+	return &group
+}
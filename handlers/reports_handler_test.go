@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestReportRoutes(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+	anaID, juanID, cleaningID, _ := insertWorkload(s)
+
+	var report struct {
+		From    string `json:"from"`
+		To      string `json:"to"`
+		Helpers []struct {
+			HelperID       string  `json:"helper_id"`
+			Appointments   int     `json:"appointments"`
+			BookedHours    float64 `json:"booked_hours"`
+			CompletedHours float64 `json:"completed_hours"`
+			NoShows        int     `json:"no_shows"`
+			Cancellations  int     `json:"cancellations"`
+			Categories     []struct {
+				Name string `json:"name"`
+			} `json:"categories"`
+		} `json:"helpers"`
+	}
+	s.decode(s.expect(http.MethodGet, "/api/admin/reports/helpers?from=2026-03-01&to=2026-03-31", nil, http.StatusOK), &report)
+
+	// Los ayudantes se ordenan por apellido
+	if len(report.Helpers) != 2 || report.Helpers[0].HelperID != juanID || report.Helpers[1].HelperID != anaID {
+		t.Fatalf("ayudantes inesperados: %+v", report.Helpers)
+	}
+	juan, ana := report.Helpers[0], report.Helpers[1]
+	if juan.Appointments != 1 || juan.BookedHours != 1 || juan.CompletedHours != 0 || juan.NoShows != 1 {
+		t.Errorf("informe de Juan inesperado: %+v", juan)
+	}
+	if ana.Appointments != 3 || ana.BookedHours != 3.5 || ana.CompletedHours != 3.5 || ana.Cancellations != 1 {
+		t.Errorf("informe de Ana inesperado: %+v", ana)
+	}
+	if len(ana.Categories) != 1 || ana.Categories[0].Name != "Limpieza" {
+		t.Errorf("categorías de Ana inesperadas: %+v", ana.Categories)
+	}
+
+	s.decode(s.expect(http.MethodGet, "/api/admin/reports/helpers?from=2026-03-01&to=2026-03-31&category="+cleaningID, nil, http.StatusOK), &report)
+	if len(report.Helpers) != 1 || report.Helpers[0].HelperID != anaID {
+		t.Errorf("el filtro por categoría no se aplicó: %+v", report.Helpers)
+	}
+
+	recorder := s.expectStatus(http.MethodGet, "/api/admin/reports/helpers?from=2026-03-01&to=2026-03-31&format=csv", nil, http.StatusOK)
+	if !strings.Contains(recorder.Header().Get("Content-Disposition"), "helpers-2026-03-01-2026-03-31.csv") {
+		t.Errorf("nombre del archivo inesperado: %s", recorder.Header().Get("Content-Disposition"))
+	}
+	if records, err := csv.NewReader(recorder.Body).ReadAll(); err != nil || len(records) < 3 {
+		t.Errorf("informe CSV inesperado: %v (%v)", records, err)
+	}
+
+	s.expectError(http.MethodGet, "/api/admin/reports/helpers?format=pdf", nil, http.StatusBadRequest, "invalid_report_format")
+	s.expectError(http.MethodGet, "/api/admin/reports/helpers?helper=no-es-un-id", nil, http.StatusBadRequest, "invalid_helper")
+	s.expectError(http.MethodGet, "/api/admin/reports/helpers?from=2026-03-31&to=2026-03-01", nil, http.StatusBadRequest, "invalid_date_range")
+}
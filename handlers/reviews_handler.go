@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/middlewares"
+	"github.com/maferuy/ayudapp-admin-backend-core/services"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+)
+
+// @Summary Crea la reseña de una cita completada
+// @ID 		create-review
+// @Accept 	json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id 					path string 						true "ID de la cita"
+// @Param 	CreateReviewRequest body services.CreateReviewRequest 	true "Datos de la reseña"
+// @Success 200 {object} services.CreateReviewResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 409 {object} middlewares.Problem "La cita ya tiene una reseña de este autor"
+// @Failure 404 {object} middlewares.Problem "La cita no existe"
+// @Router 	/admin/appointments/{id}/reviews [post]
+func handleCreateReview(service services.IReviewService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req services.CreateReviewRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		reviewID, err := service.CreateReview(id, req)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(reviewID))
+	}
+}
+
+// @Summary Obtiene las reseñas de una cita
+// @ID 		get-appointment-reviews
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id path string true "ID de la cita"
+// @Success 200 {object} services.GetReviewsResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/appointments/{id}/reviews [get]
+func handleGetAppointmentReviews(service services.IReviewService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		reviews, err := service.GetAppointmentReviews(id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(reviews))
+	}
+}
+
+// @Summary Obtiene las reseñas
+// @ID 		get-reviews
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	status query string false "Estado de moderación (pending, approved, rejected)"
+// @Success 200 {object} services.GetReviewsResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/reviews [get]
+func handleGetReviews(service services.IReviewService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		reviews, err := service.GetReviews(ctx.Query("status"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(reviews))
+	}
+}
+
+// @Summary Obtiene una reseña
+// @ID 		get-review
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id path string true "ID de la reseña"
+// @Success 200 {object} services.GetReviewResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 404 {object} middlewares.Problem "La reseña no existe"
+// @Router 	/admin/reviews/{id} [get]
+func handleGetReview(service services.IReviewService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		review, err := service.GetReview(id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(review))
+	}
+}
+
+// @Summary Modera una reseña
+// @ID 		moderate-review
+// @Accept 	json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id 						path string 							true "ID de la reseña"
+// @Param 	ModerateReviewRequest 	body services.ModerateReviewRequest 	true "Estado de moderación"
+// @Success 200 {object} services.ModerateReviewResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 404 {object} middlewares.Problem "La reseña no existe"
+// @Router 	/admin/reviews/{id}/moderate [post]
+func handleModerateReview(service services.IReviewService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req services.ModerateReviewRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		var moderator string
+		if payload, ok := middlewares.GetAuthorizationPayload(ctx); ok {
+			moderator = payload.Email
+		}
+
+		review, err := service.ModerateReview(id, req, moderator)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(review))
+	}
+}
+
+// @Summary Elimina una reseña
+// @ID 		delete-review
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id path string true "ID de la reseña"
+// @Success 200 {object} string
+// @Failure 400 {object} middlewares.Problem
+// @Failure 404 {object} middlewares.Problem "La reseña no existe"
+// @Router 	/admin/reviews/{id} [delete]
+func handleDeleteReview(service services.IReviewService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		err := service.DeleteReview(id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(nil))
+	}
+}
+
+/** Crea los endpoints de reseñas y los de reseñas de una cita
+ *
+ * @param group gin.IRoutes "El grupo de endpoints de reseñas"
+ * @param appointmentGroup gin.IRoutes "El grupo de endpoints de citas"
+ * @param service services.IReviewService "El servicio de reseñas"
+ * @return *gin.IRoutes "El grupo de endpoints creado"
+ */
+func newReviewHandler(group gin.IRoutes, appointmentGroup gin.IRoutes, service services.IReviewService) *gin.IRoutes {
+	group.GET("/", handleGetReviews(service))
+	group.GET("/:id", handleGetReview(service))
+	group.DELETE("/:id", handleDeleteReview(service))
+	group.POST("/:id/moderate", handleModerateReview(service))
+
+	appointmentGroup.GET("/:id/reviews", handleGetAppointmentReviews(service))
+	appointmentGroup.POST("/:id/reviews", handleCreateReview(service))
+
+	return &group
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReviewRoutes(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+	helperID := s.insertUser(models.User{FirstName: "Ana", LastName: "Pérez", Email: "ana@ayudapp.test", Type: models.UserTypeHelper})
+	requesterID := s.insertUser(models.User{FirstName: "Juan", LastName: "Gómez", Email: "juan@ayudapp.test", Type: models.UserTypeUser})
+
+	completed := models.Appointment{
+		Date:      time.Now().Add(-48 * time.Hour),
+		Duration:  time.Hour,
+		Address:   "Av. 18 de Julio 1234",
+		Status:    models.AppointmentStatusCompleted,
+		CreatedBy: s.objectID(requesterID),
+		Helper:    s.objectID(helperID),
+	}
+	appointmentID := s.insertAppointment(completed)
+	path := "/api/admin/appointments/" + appointmentID + "/reviews"
+
+	review := map[string]interface{}{
+		"author_role": models.ReviewRoleRequester,
+		"score":       4,
+		"comment":     "Muy puntual",
+	}
+	var created struct {
+		ReviewID string `json:"review_id"`
+	}
+	s.decode(s.expect(http.MethodPost, path, review, http.StatusOK), &created)
+	s.expectError(http.MethodPost, path, review, http.StatusConflict, "review_exists")
+	s.expectError(http.MethodPost, path, map[string]interface{}{
+		"author_role": models.ReviewRoleHelper,
+		"score":       6,
+	}, http.StatusBadRequest, "invalid_score")
+	s.expectError(http.MethodPost, "/api/admin/appointments/000000000000000000000000/reviews", review, http.StatusNotFound, "appointment_not_found")
+
+	pending := completed
+	pending.Status = models.AppointmentStatusPending
+	s.expectError(http.MethodPost, "/api/admin/appointments/"+s.insertAppointment(pending)+"/reviews", review, http.StatusBadRequest, "appointment_not_completed")
+
+	withoutHelper := completed
+	withoutHelper.Helper = primitive.NilObjectID
+	s.expectError(http.MethodPost, "/api/admin/appointments/"+s.insertAppointment(withoutHelper)+"/reviews", review, http.StatusBadRequest, "appointment_without_helper")
+
+	var reviews struct {
+		Reviews []models.Review `json:"reviews"`
+	}
+	s.decode(s.expect(http.MethodGet, path, nil, http.StatusOK), &reviews)
+	if len(reviews.Reviews) != 1 || reviews.Reviews[0].SubjectID.Hex() != helperID {
+		t.Errorf("GET %s: se esperaba la reseña del ayudante, se obtuvo %+v", path, reviews.Reviews)
+	}
+	s.decode(s.expect(http.MethodGet, "/api/admin/reviews/?status=pending", nil, http.StatusOK), &reviews)
+	if len(reviews.Reviews) != 1 {
+		t.Errorf("se esperaba una reseña pendiente, se obtuvieron %d", len(reviews.Reviews))
+	}
+	s.expect(http.MethodGet, "/api/admin/reviews/"+created.ReviewID, nil, http.StatusOK)
+
+	var moderated struct {
+		Review models.Review `json:"review"`
+	}
+	s.decode(s.expect(http.MethodPost, "/api/admin/reviews/"+created.ReviewID+"/moderate", map[string]string{
+		"status": models.ReviewStatusApproved,
+		"note":   "Revisada",
+	}, http.StatusOK), &moderated)
+	if moderated.Review.Status != models.ReviewStatusApproved || moderated.Review.ModeratedBy != testAdminEmail {
+		t.Errorf("la reseña no quedó aprobada por el administrador: %+v", moderated.Review)
+	}
+	s.expectError(http.MethodPost, "/api/admin/reviews/"+created.ReviewID+"/moderate", map[string]string{"status": "oculta"}, http.StatusBadRequest, "invalid_review_status")
+
+	helper, err := s.store.Users().FindByID(context.Background(), s.objectID(helperID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if helper.Rating == nil || helper.Rating.Average != 4 || helper.Rating.Count != 1 {
+		t.Errorf("la calificación del ayudante no incluye la reseña aprobada: %+v", helper.Rating)
+	}
+
+	s.expect(http.MethodDelete, "/api/admin/reviews/"+created.ReviewID, nil, http.StatusOK)
+	s.expectError(http.MethodGet, "/api/admin/reviews/"+created.ReviewID, nil, http.StatusNotFound, "review_not_found")
+	s.expectError(http.MethodDelete, "/api/admin/reviews/"+created.ReviewID, nil, http.StatusNotFound, "review_not_found")
+	s.expectError(http.MethodPost, "/api/admin/reviews/"+created.ReviewID+"/moderate", map[string]string{"status": models.ReviewStatusApproved}, http.StatusNotFound, "review_not_found")
+	s.expectError(http.MethodGet, "/api/admin/reviews/no-es-un-id", nil, http.StatusBadRequest, "invalid_id")
+	helper, err = s.store.Users().FindByID(context.Background(), s.objectID(helperID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if helper.Rating != nil && helper.Rating.Count != 0 {
+		t.Errorf("la calificación del ayudante conserva la reseña eliminada: %+v", helper.Rating)
+	}
+}
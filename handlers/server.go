@@ -3,12 +3,20 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"log"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/maferuy/ayudapp-admin-backend-core/database"
 	_ "github.com/maferuy/ayudapp-admin-backend-core/docs"
 	"github.com/maferuy/ayudapp-admin-backend-core/middlewares"
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/notifications"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
 	"github.com/maferuy/ayudapp-admin-backend-core/services"
+	"github.com/maferuy/ayudapp-admin-backend-core/storage"
 	"github.com/maferuy/ayudapp-admin-backend-core/token"
 	"github.com/maferuy/ayudapp-admin-backend-core/utils"
 	"github.com/newrelic/go-agent/v3/integrations/nrgin"
@@ -16,40 +24,44 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	gindump "github.com/tpkeeper/gin-dump"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Server struct {
 	Config     utils.Config
 	TokenMaker token.IMaker
-	Client     *mongo.Client
-	Database   *mongo.Database
+	Store      repository.IStore
 	Router     *gin.Engine
 	APMApp     *newrelic.Application
+	Scheduler  services.IScheduler
+	Outbox     services.IEmailOutbox
+	Imports    services.IUserImportService
+	Storage    storage.IStorage
 }
 
 /** Crea un nuevo servidor HTTP y configura el router de la API
+ *
+ * Todas las rutas, la cola de correos y las tareas programadas usan el almacenamiento
+ * recibido, que puede ser un repository.MemoryStore para ejecutar la API sin MongoDB.
  *
  * @param config utils.Config "Configuración de la aplicación"
+ * @param store repository.IStore "El almacenamiento de los datos"
  * @return *Server "Instancia del servidor HTTP"
  * @return error "Error al crear el servidor HTTP"
  */
-func NewServer(config utils.Config) (*Server, error) {
+func NewServer(config utils.Config, store repository.IStore) (*Server, error) {
 	tokenMaker, err := token.NewJWTMaker(config.SecretKey)
 	if err != nil {
 		return nil, fmt.Errorf("error al crear el token maker: %s", utils.ErrorResponse(err))
 	}
 
-	client, err := database.Open(context.TODO(), config.MongoURI)
-	if err != nil {
-		return nil, fmt.Errorf("Error al conectar con la base de datos: %s", utils.ErrorResponse(err))
+	if err = utils.RegisterValidators(); err != nil {
+		return nil, fmt.Errorf("error al registrar las validaciones: %w", err)
 	}
 
 	server := &Server{
 		Config:     config,
 		TokenMaker: tokenMaker,
-		Client:     client,
-		Database:   client.Database("users-dev"),
+		Store:      store,
 	}
 
 	if config.APMAppName != "" && config.APMLicense != "" {
@@ -61,6 +73,22 @@ func NewServer(config utils.Config) (*Server, error) {
 		server.APMApp = app
 	}
 
+	server.Storage, err = storage.New(config)
+	if err != nil {
+		return nil, fmt.Errorf("Error al configurar el almacenamiento de archivos: %s", utils.ErrorResponse(err))
+	}
+
+	userService := services.NewUserService(store.Users(), server.Storage)
+	server.Outbox = services.NewEmailOutbox(store, utils.NewEmailService(config), config)
+	server.Imports = services.NewUserImportService(store, userService, config)
+
+	notifier, err := configNotifications(config, server.Outbox, userService)
+	if err != nil {
+		return nil, fmt.Errorf("Error al configurar los canales de aviso: %s", utils.ErrorResponse(err))
+	}
+
+	server.Scheduler = services.NewScheduler(store, notifier, config)
+
 	server.setupRouter()
 
 	return server, nil
@@ -77,18 +105,37 @@ func (server *Server) setupRouter() {
 	router.Use(
 		gin.Recovery(),
 		middlewares.Logger(),
-		gindump.Dump(),
-		middlewares.CorsConfig(),
+		middlewares.ErrorHandler(),
+		middlewares.CorsConfig(server.Config.CorsAllowedOrigins),
 	)
 
+	// El volcado de solicitudes incluye contraseñas y tokens, sólo se usa fuera de producción
+	if !server.Config.IsProduction() {
+		router.Use(gindump.Dump())
+	}
+
 	// Documentación
 	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Imágenes de perfil, si se guardan en el sistema de archivos local. El resto del
+	// almacenamiento no se publica: adjuntos y exportaciones tienen endpoints autenticados
+	if localStorage, ok := server.Storage.(*storage.LocalStorage); ok {
+		if publicURL, err := url.Parse(server.Config.StoragePublicURL); err == nil && strings.HasPrefix(publicURL.Path, "/") && publicURL.Path != "/" {
+			router.Static(path.Join(publicURL.Path, storage.ProfileImagePrefix), filepath.Join(localStorage.Root(), storage.ProfileImagePrefix))
+		}
+	}
+
 	// Instanciación de servicios
-	categoryService := services.NewCategoryService(server.Database)
-	appointmentService := services.NewAppointmentService(server.Database)
-	userService := services.NewUserService(server.Database)
-	authService := services.NewAuthService(server.Database, server.Config, &gin.Context{})
+	categoryService := services.NewCategoryService(server.Store)
+	appointmentService := services.NewAppointmentService(server.Store, server.Scheduler)
+	userService := services.NewUserService(server.Store.Users(), server.Storage)
+	authService := services.NewAuthService(server.Store.Sessions(), server.Config)
+	reviewService := services.NewReviewService(server.Store)
+	appointmentNoteService := services.NewAppointmentNoteService(server.Store)
+	appointmentAttachmentService := services.NewAppointmentAttachmentService(server.Store, server.Storage)
+	exportService := services.NewExportService(server.Store, server.Storage)
+	statsService := services.NewStatsService(server.Store, server.Config.StatsCacheTTL)
+	reportService := services.NewReportService(server.Store)
 
 	// Rutas API
 	apiRouter := router.Group("/api")
@@ -99,10 +146,27 @@ func (server *Server) setupRouter() {
 	categoryRoutes := adminRouter.Group("/categories")
 	appointmentRoutes := adminRouter.Group("/appointments")
 	userRoutes := adminRouter.Group("/users")
+	reviewRoutes := adminRouter.Group("/reviews")
+	emailTemplateRoutes := adminRouter.Group("/email-templates")
+	emailRoutes := adminRouter.Group("/emails")
+	exportRoutes := adminRouter.Group("/exports")
+	reportRoutes := adminRouter.Group("/reports")
+
+	// Los reintentos de las creaciones con la misma Idempotency-Key no crean duplicados
+	idempotency := middlewares.Idempotency(server.Store.IdempotencyKeys(), server.Config.IdempotencyTTL)
 
 	newCategoryHandler(categoryRoutes, categoryService)
-	newAppointmentHandler(appointmentRoutes, appointmentService)
-	newUserHandler(userRoutes, userService)
+	newAppointmentHandler(appointmentRoutes, appointmentService, idempotency)
+	newUserHandler(userRoutes, userService, server.Config.ProfileImageMaxSize, idempotency)
+	newEmailTemplateHandler(emailTemplateRoutes)
+	newUserImportHandler(userRoutes, server.Imports)
+	newReviewHandler(reviewRoutes, appointmentRoutes, reviewService)
+	newAppointmentNoteHandler(appointmentRoutes, appointmentNoteService)
+	newAppointmentAttachmentHandler(appointmentRoutes, appointmentAttachmentService, server.Config.AttachmentMaxSize)
+	newEmailHandler(emailRoutes, server.Outbox)
+	newExportHandler(exportRoutes, userRoutes, appointmentRoutes, categoryRoutes, exportService)
+	newStatsHandler(adminRouter, statsService)
+	newReportHandler(reportRoutes, reportService)
 
 	// Autenticación
 	newAuthHandler(
@@ -115,6 +179,50 @@ func (server *Server) setupRouter() {
 	server.Router = router
 }
 
+/** Configura los canales de aviso disponibles
+ *
+ * El correo electrónico siempre está disponible; SMS y web push se habilitan si
+ * están configurados. Con NOTIFICATIONS_DRIVER=log todos los canales sólo registran
+ * los avisos, para desarrollo local.
+ *
+ * @param config utils.Config "Configuración de la aplicación"
+ * @param emailService utils.IEmailService "El servicio de envío de correos"
+ * @param userService services.IUserService "El servicio de usuarios, para eliminar suscripciones expiradas"
+ * @return notifications.IDispatcher "El despachador de avisos"
+ * @return error "Error si la configuración de algún canal es inválida"
+ */
+func configNotifications(config utils.Config, emailService utils.IEmailService, userService services.IUserService) (notifications.IDispatcher, error) {
+	if config.NotificationsDriver == "log" {
+		return notifications.NewDispatcher(
+			notifications.NewLogChannel(models.NotificationChannelEmail),
+			notifications.NewLogChannel(models.NotificationChannelSMS),
+			notifications.NewLogChannel(models.NotificationChannelPush),
+		), nil
+	}
+
+	channels := []notifications.IChannel{notifications.NewEmailChannel(emailService, config.SMTPSender)}
+
+	if config.SMSProviderURL != "" {
+		provider := notifications.NewHTTPSMSProvider(config.SMSProviderURL, config.SMSProviderToken, config.SMSSender)
+		channels = append(channels, notifications.NewSMSChannel(provider))
+	}
+
+	if config.VAPIDPrivateKey != "" {
+		push, err := notifications.NewWebPushChannel(config.VAPIDPrivateKey, config.VAPIDSubject, func(user models.User, endpoint string) {
+			if err := userService.RemovePushSubscription(context.Background(), user.ID.Hex(), endpoint); err != nil {
+				log.Printf("Error al eliminar la suscripción push expirada: %v", err)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		channels = append(channels, push)
+	}
+
+	return notifications.NewDispatcher(channels...), nil
+}
+
 func configAPM(config utils.Config) (*newrelic.Application, error) {
 	return newrelic.NewApplication(
 		newrelic.ConfigAppName(config.APMAppName),
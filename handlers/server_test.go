@@ -0,0 +1,493 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	testAdminEmail    = "admin@ayudapp.test"
+	testAdminPassword = "secreto123"
+)
+
+// Servidor con el almacenamiento en memoria y la sesión de un administrador
+type testServer struct {
+	t           *testing.T
+	router      http.Handler
+	store       repository.IStore
+	storagePath string
+	token       string
+	// ETag que se envía en la cabecera If-Match, obtenido con useETag
+	ifMatch string
+}
+
+/** Crea un servidor con el almacenamiento en memoria y la sesión de un administrador
+ *
+ * El token se crea directamente porque el login tarda lo mismo que calcular el hash
+ * de la contraseña; TestAuthRoutes prueba el login.
+ *
+ * @param t *testing.T "La prueba"
+ * @return *testServer "El servidor"
+ */
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	// En producción no se vuelcan las solicitudes en la salida de las pruebas
+	config := utils.Config{
+		Environment:          utils.EnvProduction,
+		DatabaseName:         "test",
+		CorsAllowedOrigins:   []string{"*"},
+		SecretKey:            "12345678901234567890123456789012",
+		AccessTokenDuration:  time.Hour,
+		RefreshTokenDuration: 24 * time.Hour,
+		StorageLocalPath:     t.TempDir(),
+		StoragePublicURL:     "/uploads",
+		ProfileImageMaxSize:  1 << 20,
+		AttachmentMaxSize:    1 << 20,
+		NotificationsDriver:  "log",
+		IdempotencyTTL:       time.Hour,
+	}
+
+	store := repository.NewMemoryStore()
+	server, err := NewServer(config, store)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	s := &testServer{t: t, router: server.Router, store: store, storagePath: config.StorageLocalPath}
+	s.insertUser(models.User{FirstName: "Super", LastName: "Admin", Email: testAdminEmail, Type: models.UserTypeSuperadmin})
+
+	s.token, _, err = server.TokenMaker.CreateToken("Super", "Admin", testAdminEmail, models.UserTypeSuperadmin, "", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+/** Ejecuta una solicitud con la sesión y el If-Match del servidor
+ *
+ * @param method string "El método HTTP"
+ * @param path string "La ruta"
+ * @param body io.Reader "El cuerpo de la solicitud"
+ * @param headers map[string]string "Las cabeceras de la solicitud"
+ * @return *httptest.ResponseRecorder "La respuesta"
+ */
+func (s *testServer) send(method, path string, body io.Reader, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, body)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if s.token != "" && req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	if s.ifMatch != "" && req.Header.Get("If-Match") == "" {
+		req.Header.Set("If-Match", s.ifMatch)
+	}
+
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+/** Ejecuta una solicitud con un cuerpo JSON
+ *
+ * @param method string "El método HTTP"
+ * @param path string "La ruta"
+ * @param body interface{} "El cuerpo de la solicitud, nil para enviarla sin cuerpo"
+ * @return *httptest.ResponseRecorder "La respuesta"
+ */
+func (s *testServer) request(method, path string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	if body == nil {
+		return s.send(method, path, nil, nil)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	return s.send(method, path, bytes.NewReader(data), map[string]string{"Content-Type": "application/json"})
+}
+
+/** Ejecuta una solicitud JSON y verifica el código de estado de la respuesta
+ *
+ * @param method string "El método HTTP"
+ * @param path string "La ruta"
+ * @param body interface{} "El cuerpo de la solicitud"
+ * @param status int "El código de estado esperado"
+ * @return json.RawMessage "Los datos de la respuesta, o nil si el código no es el esperado"
+ */
+func (s *testServer) expect(method, path string, body interface{}, status int) json.RawMessage {
+	s.t.Helper()
+	return s.check(s.request(method, path, body), method+" "+path, status)
+}
+
+/** Ejecuta una solicitud PATCH con un parche JSON Merge Patch y verifica el código de estado
+ *
+ * @param path string "La ruta"
+ * @param patch string "El parche JSON"
+ * @param status int "El código de estado esperado"
+ * @return json.RawMessage "Los datos de la respuesta"
+ */
+func (s *testServer) expectPatch(path, patch string, status int) json.RawMessage {
+	s.t.Helper()
+
+	recorder := s.send(http.MethodPatch, path, strings.NewReader(patch), map[string]string{"Content-Type": "application/merge-patch+json"})
+	return s.check(recorder, "PATCH "+path, status)
+}
+
+/** Ejecuta una solicitud que debe fallar y verifica los campos inválidos de la respuesta problem+json
+ *
+ * @param method string "El método HTTP"
+ * @param path string "La ruta"
+ * @param body interface{} "El cuerpo de la solicitud"
+ * @param status int "El código de estado esperado"
+ * @param fields ...string "Los campos que deben tener errores"
+ */
+func (s *testServer) expectProblem(method, path string, body interface{}, status int, fields ...string) {
+	s.t.Helper()
+
+	data := s.expect(method, path, body, status)
+	if data == nil {
+		return
+	}
+
+	var problem struct {
+		Errors []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	s.decode(data, &problem)
+
+	invalid := map[string]bool{}
+	for _, fieldError := range problem.Errors {
+		invalid[fieldError.Field] = fieldError.Message != ""
+	}
+	for _, field := range fields {
+		if !invalid[field] {
+			s.t.Errorf("%s %s: se esperaba un error en el campo %s: %s", method, path, field, data)
+		}
+	}
+}
+
+/** Ejecuta una solicitud que debe fallar y verifica el código estable del error
+ *
+ * @param method string "El método HTTP"
+ * @param path string "La ruta"
+ * @param body interface{} "El cuerpo de la solicitud"
+ * @param status int "El código de estado esperado"
+ * @param code string "El código del error de la respuesta problem+json"
+ */
+func (s *testServer) expectError(method, path string, body interface{}, status int, code string) {
+	s.t.Helper()
+
+	data := s.expect(method, path, body, status)
+	if data == nil {
+		return
+	}
+
+	var problem struct {
+		Code string `json:"code"`
+	}
+	s.decode(data, &problem)
+	if problem.Code != code {
+		s.t.Errorf("%s %s: se esperaba el código %s, se obtuvo %s", method, path, code, data)
+	}
+}
+
+/** Ejecuta una solicitud POST con la cabecera Idempotency-Key y verifica la respuesta
+ *
+ * @param path string "La ruta"
+ * @param key string "La clave de idempotencia"
+ * @param body interface{} "El cuerpo de la solicitud"
+ * @param status int "El código de estado esperado"
+ * @param replayed bool "Si la respuesta debe ser la guardada de una solicitud anterior"
+ * @return []byte "El cuerpo de la respuesta"
+ */
+func (s *testServer) expectIdempotent(path, key string, body interface{}, status int, replayed bool) []byte {
+	s.t.Helper()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	recorder := s.send(http.MethodPost, path, bytes.NewReader(data), map[string]string{
+		"Content-Type":    "application/json",
+		"Idempotency-Key": key,
+	})
+	if got := recorder.Header().Get("Idempotent-Replayed") == "true"; recorder.Code != status || got != replayed {
+		s.t.Errorf("POST %s con la clave %s: se esperaba %d (repetida: %t), se obtuvo %d (repetida: %t): %s", path, key, status, replayed, recorder.Code, got, recorder.Body.Bytes())
+		return nil
+	}
+
+	return recorder.Body.Bytes()
+}
+
+/** Ejecuta una solicitud sin cuerpo con las cabeceras indicadas y verifica el código de estado
+ *
+ * @param method string "El método HTTP"
+ * @param path string "La ruta"
+ * @param headers map[string]string "Las cabeceras de la solicitud"
+ * @param status int "El código de estado esperado"
+ * @return *httptest.ResponseRecorder "La respuesta"
+ */
+func (s *testServer) expectStatus(method, path string, headers map[string]string, status int) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	recorder := s.send(method, path, nil, headers)
+	if recorder.Code != status {
+		s.t.Errorf("%s %s: se esperaba %d, se obtuvo %d: %s", method, path, status, recorder.Code, recorder.Body.Bytes())
+	}
+
+	return recorder
+}
+
+/** Sube un archivo en un formulario multipart y verifica el código de estado
+ *
+ * @param path string "La ruta"
+ * @param field string "El campo del archivo"
+ * @param fileName string "El nombre del archivo"
+ * @param content []byte "El contenido del archivo"
+ * @param values map[string]string "Los demás campos del formulario"
+ * @param status int "El código de estado esperado"
+ * @return json.RawMessage "Los datos de la respuesta"
+ */
+func (s *testServer) expectUpload(path, field, fileName string, content []byte, values map[string]string, status int) json.RawMessage {
+	s.t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile(field, fileName)
+	if err == nil {
+		_, err = part.Write(content)
+	}
+	for key, value := range values {
+		if err == nil {
+			err = form.WriteField(key, value)
+		}
+	}
+	if err == nil {
+		err = form.Close()
+	}
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	recorder := s.send(http.MethodPost, path, &body, map[string]string{"Content-Type": form.FormDataContentType()})
+	return s.check(recorder, "POST "+path, status)
+}
+
+/** Obtiene el ETag de un registro y lo usa en la cabecera If-Match de las siguientes solicitudes
+ *
+ * @param path string "La ruta del registro"
+ * @return string "El ETag"
+ */
+func (s *testServer) useETag(path string) string {
+	s.t.Helper()
+
+	s.ifMatch = ""
+	recorder := s.send(http.MethodGet, path, nil, nil)
+	etag := recorder.Header().Get("ETag")
+	if recorder.Code != http.StatusOK || etag == "" {
+		s.t.Fatalf("GET %s: se esperaba un ETag, se obtuvo %d: %s", path, recorder.Code, recorder.Body.Bytes())
+	}
+
+	s.ifMatch = etag
+	return etag
+}
+
+/** Verifica el código de estado de una respuesta y devuelve sus datos
+ *
+ * @param recorder *httptest.ResponseRecorder "La respuesta"
+ * @param request string "El método y la ruta, para el mensaje de error"
+ * @param status int "El código de estado esperado"
+ * @return json.RawMessage "El campo data de la respuesta, o el cuerpo completo si no lo tiene"
+ */
+func (s *testServer) check(recorder *httptest.ResponseRecorder, request string, status int) json.RawMessage {
+	s.t.Helper()
+
+	if recorder.Code != status {
+		s.t.Errorf("%s: se esperaba %d, se obtuvo %d: %s", request, status, recorder.Code, recorder.Body.Bytes())
+		return nil
+	}
+
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if json.Unmarshal(recorder.Body.Bytes(), &response) == nil && response.Data != nil {
+		return response.Data
+	}
+
+	return recorder.Body.Bytes()
+}
+
+// Decodifica los datos de una respuesta; no hace nada si la solicitud falló
+func (s *testServer) decode(data json.RawMessage, v interface{}) {
+	s.t.Helper()
+
+	if data == nil {
+		return
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		s.t.Errorf("error al decodificar %s: %v", data, err)
+	}
+}
+
+/** Espera hasta que una condición se cumpla, para los procesos en segundo plano
+ *
+ * @param what string "Lo que se espera, para el mensaje de error"
+ * @param done func() bool "La condición"
+ */
+func (s *testServer) eventually(what string, done func() bool) {
+	s.t.Helper()
+
+	// Las importaciones calculan el hash de cada contraseña, que es lento con -race
+	for deadline := time.Now().Add(30 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if done() {
+			return
+		}
+	}
+
+	s.t.Fatalf("se agotó el tiempo de espera: %s", what)
+}
+
+// Crea una categoría y devuelve su id
+func (s *testServer) createCategory(name string) string {
+	s.t.Helper()
+
+	var created struct {
+		CategoryID string `json:"category_id"`
+	}
+	s.decode(s.expect(http.MethodPost, "/api/admin/categories/", map[string]string{"name": name}, http.StatusOK), &created)
+	return created.CategoryID
+}
+
+// Guarda un usuario activo directamente en el almacenamiento, sin calcular el hash de la contraseña
+func (s *testServer) insertUser(user models.User) string {
+	s.t.Helper()
+
+	user.Status = models.UserStatusActive
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+	if err := s.store.Users().Insert(context.Background(), &user); err != nil {
+		s.t.Fatal(err)
+	}
+
+	return user.ID.Hex()
+}
+
+// Guarda una cita directamente en el almacenamiento, para usar fechas pasadas y cualquier estado
+func (s *testServer) insertAppointment(appointment models.Appointment) string {
+	s.t.Helper()
+
+	appointment.CreatedAt = time.Now()
+	appointment.UpdatedAt = appointment.CreatedAt
+	if err := s.store.Appointments().Insert(context.Background(), &appointment); err != nil {
+		s.t.Fatal(err)
+	}
+
+	return appointment.ID.Hex()
+}
+
+// Convierte un id de una respuesta en ObjectID
+func (s *testServer) objectID(id string) primitive.ObjectID {
+	s.t.Helper()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		s.t.Fatalf("id inválido %q: %v", id, err)
+	}
+
+	return objectID
+}
+
+func TestAuthRoutes(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+	s.token = ""
+
+	password, err := utils.HashPassword(testAdminPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.insertUser(models.User{FirstName: "Otro", LastName: "Admin", Email: "otro@ayudapp.test", Password: password, Type: models.UserTypeSuperadmin})
+
+	s.expect(http.MethodGet, "/api/admin/users/", nil, http.StatusUnauthorized)
+	s.expect(http.MethodPost, "/api/login", map[string]string{
+		"email":    "otro@ayudapp.test",
+		"password": "incorrecta",
+	}, http.StatusUnauthorized)
+	s.expect(http.MethodPost, "/api/login", map[string]string{
+		"email":    "nadie@ayudapp.test",
+		"password": "incorrecta",
+	}, http.StatusUnauthorized)
+
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	s.decode(s.expect(http.MethodPost, "/api/login", map[string]string{
+		"email":    "otro@ayudapp.test",
+		"password": testAdminPassword,
+	}, http.StatusOK), &login)
+	if login.AccessToken == "" {
+		t.Fatal("el login no devolvió un token de acceso")
+	}
+
+	s.token = login.AccessToken
+	s.expect(http.MethodGet, "/api/admin/users/", nil, http.StatusOK)
+}
+
+// Verifica que sólo se publiquen las imágenes de perfil del almacenamiento local
+func TestStorageRoutes(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+
+	files := map[string]int{
+		"users/perfil/small.jpg":                http.StatusOK,
+		"exports/reporte/usuarios.csv":          http.StatusNotFound,
+		"appointments/cita/adjunto.pdf":         http.StatusNotFound,
+		"private/appointments/cita/adjunto.pdf": http.StatusNotFound,
+	}
+
+	for key, status := range files {
+		path := filepath.Join(s.storagePath, filepath.FromSlash(key))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("contenido"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		s.expectStatus(http.MethodGet, "/uploads/"+key, nil, status)
+	}
+}
+
+func TestEmailTemplateRoutes(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+
+	s.expect(http.MethodGet, "/api/admin/email-templates/", nil, http.StatusOK)
+	s.expectError(http.MethodPost, "/api/admin/email-templates/no-existe/preview", nil, http.StatusNotFound, "email_template_not_found")
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/services"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+)
+
+// @Summary Obtiene las estadísticas del panel de administración
+// @ID 		get-stats
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	from 	query string false "Fecha inicial (AAAA-MM-DD), por defecto 30 días antes de la final"
+// @Param 	to 		query string false "Fecha final, incluida (AAAA-MM-DD), por defecto hoy"
+// @Success 200 {object} services.GetStatsResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/stats [get]
+func handleGetStats(service services.IStatsService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req services.StatsRequest
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		stats, err := service.GetStats(req)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(stats))
+	}
+}
+
+/** Crea el endpoint de estadísticas
+ *
+ * @param group gin.IRoutes "El grupo de endpoints de administración"
+ * @param service services.IStatsService "El servicio de estadísticas"
+ * @return *gin.IRoutes "El grupo de endpoints creado"
+ */
+func newStatsHandler(group gin.IRoutes, service services.IStatsService) *gin.IRoutes {
+	group.GET("/stats", handleGetStats(service))
+
+	return &group
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+)
+
+// Guarda citas de marzo de 2026 de dos ayudantes en dos categorías y devuelve sus ids
+func insertWorkload(s *testServer) (anaID, juanID, cleaningID, gardeningID string) {
+	s.t.Helper()
+
+	anaID = s.insertUser(models.User{FirstName: "Ana", LastName: "Pérez", Email: "ana@ayudapp.test", Type: models.UserTypeHelper})
+	juanID = s.insertUser(models.User{FirstName: "Juan", LastName: "Gómez", Email: "juan@ayudapp.test", Type: models.UserTypeHelper})
+	cleaningID = s.createCategory("Limpieza")
+	gardeningID = s.createCategory("Jardinería")
+
+	day := func(d int) time.Time { return time.Date(2026, time.March, d, 10, 0, 0, 0, time.UTC) }
+	for _, appointment := range []models.Appointment{
+		{Date: day(2), Duration: 2 * time.Hour, Status: models.AppointmentStatusCompleted, Helper: s.objectID(anaID), Category: s.objectID(cleaningID)},
+		{Date: day(2), Duration: 90 * time.Minute, Status: models.AppointmentStatusCompleted, Helper: s.objectID(anaID), Category: s.objectID(cleaningID)},
+		{Date: day(3), Duration: time.Hour, Status: models.AppointmentStatusCancelled, Helper: s.objectID(anaID), Category: s.objectID(cleaningID)},
+		{Date: day(4), Duration: time.Hour, Status: models.AppointmentStatusNoShow, Helper: s.objectID(juanID), Category: s.objectID(gardeningID)},
+		{Date: day(5), Duration: time.Hour, Status: models.AppointmentStatusPending, Category: s.objectID(gardeningID)},
+		{Date: day(20).AddDate(0, 1, 0), Duration: time.Hour, Status: models.AppointmentStatusCompleted, Helper: s.objectID(anaID)},
+	} {
+		appointment.Address = "Av. 18 de Julio 1234"
+		s.insertAppointment(appointment)
+	}
+
+	return
+}
+
+func TestStatsRoutes(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+	_, _, cleaningID, _ := insertWorkload(s)
+
+	var stats struct {
+		From         string `json:"from"`
+		To           string `json:"to"`
+		Appointments struct {
+			Total            int     `json:"total"`
+			CompletionRate   float64 `json:"completion_rate"`
+			CancellationRate float64 `json:"cancellation_rate"`
+			PerDay           []struct {
+				Date  string `json:"date"`
+				Total int    `json:"total"`
+			} `json:"per_day"`
+			TopCategories []struct {
+				ID    string `json:"id"`
+				Name  string `json:"name"`
+				Count int    `json:"count"`
+			} `json:"top_categories"`
+		} `json:"appointments"`
+		Users struct {
+			Total int `json:"total"`
+		} `json:"users"`
+	}
+	s.decode(s.expect(http.MethodGet, "/api/admin/stats?from=2026-03-01&to=2026-03-31", nil, http.StatusOK), &stats)
+
+	appointments := stats.Appointments
+	if stats.From != "2026-03-01" || stats.To != "2026-03-31" || appointments.Total != 5 {
+		t.Errorf("rango o total de citas inesperado: %+v", stats)
+	}
+	if appointments.CompletionRate != 0.4 || appointments.CancellationRate != 0.2 {
+		t.Errorf("tasas inesperadas: %v completadas, %v canceladas", appointments.CompletionRate, appointments.CancellationRate)
+	}
+	if len(appointments.PerDay) != 4 || appointments.PerDay[0].Date != "2026-03-02" || appointments.PerDay[0].Total != 2 {
+		t.Errorf("citas por día inesperadas: %+v", appointments.PerDay)
+	}
+	if len(appointments.TopCategories) != 2 || appointments.TopCategories[0].ID != cleaningID || appointments.TopCategories[0].Name != "Limpieza" || appointments.TopCategories[0].Count != 3 {
+		t.Errorf("categorías más usadas inesperadas: %+v", appointments.TopCategories)
+	}
+	if stats.Users.Total != 3 {
+		t.Errorf("se esperaban 3 usuarios, se obtuvieron %d", stats.Users.Total)
+	}
+
+	s.expectError(http.MethodGet, "/api/admin/stats?from=2026-03-31&to=2026-03-01", nil, http.StatusBadRequest, "invalid_date_range")
+	s.expectError(http.MethodGet, "/api/admin/stats?from=2020-01-01&to=2026-03-01", nil, http.StatusBadRequest, "date_range_too_long")
+	s.expect(http.MethodGet, "/api/admin/stats?from=ayer", nil, http.StatusBadRequest)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/middlewares"
+	"github.com/maferuy/ayudapp-admin-backend-core/services"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+)
+
+// Tamaño máximo de un archivo de importación
+const userImportMaxSize = 10 << 20
+
+// @Summary Importa usuarios desde un archivo CSV o XLSX
+// @Description Con dry_run=true sólo valida el archivo y devuelve un informe por fila. Si no, inicia la importación y devuelve su id para consultar el progreso.
+// @ID 		import-users
+// @Accept 	multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	file 	formData 	file 	true 	"Archivo CSV o XLSX con una fila de encabezados; first_name, email y password son obligatorios"
+// @Param 	mapping formData 	string 	false 	"Encabezado del archivo para cada campo, en JSON, por ejemplo {\"first_name\": \"Nombre\"}"
+// @Param 	dry_run formData 	bool 	false 	"Sólo validar el archivo"
+// @Success 200 {object} services.UserImportReport
+// @Success 202 {object} services.StartUserImportResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 413 {object} middlewares.Problem
+// @Router 	/admin/users/import [post]
+func handleImportUsers(service services.IUserImportService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, userImportMaxSize+64<<10)
+
+		fileHeader, err := ctx.FormFile("file")
+		if err != nil {
+			ctx.Error(services.NewValidationError("file_required", "se requiere un archivo de hasta %s", formatBytes(userImportMaxSize)))
+			return
+		}
+
+		if fileHeader.Size > userImportMaxSize {
+			ctx.Error(services.NewTooLargeError("file_too_large", "el archivo no puede superar %s", formatBytes(userImportMaxSize)))
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			ctx.Error(services.NewValidationError("invalid_file", "%v", err))
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(io.LimitReader(file, userImportMaxSize))
+		if err != nil {
+			ctx.Error(services.NewValidationError("invalid_file", "%v", err))
+			return
+		}
+
+		req := services.UserImportRequest{FileName: fileHeader.Filename, Data: data}
+		if mapping := ctx.PostForm("mapping"); mapping != "" {
+			if err = json.Unmarshal([]byte(mapping), &req.Mapping); err != nil {
+				ctx.Error(services.NewValidationError("invalid_mapping", "el mapeo de columnas debe ser un objeto JSON"))
+				return
+			}
+		}
+
+		if payload, ok := middlewares.GetAuthorizationPayload(ctx); ok {
+			req.CreatedBy = payload.Email
+		}
+
+		if dryRun, _ := strconv.ParseBool(ctx.PostForm("dry_run")); dryRun {
+			report, err := service.PreviewImport(req)
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+
+			ctx.JSON(http.StatusOK, utils.SuccessResponse(report))
+			return
+		}
+
+		job, err := service.StartImport(req)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusAccepted, utils.SuccessResponse(job))
+	}
+}
+
+// @Summary Obtiene el progreso y los errores de una importación de usuarios
+// @ID 		get-user-import
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	importId path string true "ID de la importación"
+// @Success 200 {object} services.GetUserImportResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 404 {object} middlewares.Problem "La importación no existe"
+// @Router 	/admin/users/import/{importId} [get]
+func handleGetUserImport(service services.IUserImportService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("importId")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		job, err := service.GetImport(id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(job))
+	}
+}
+
+/** Crea los endpoints de importación de usuarios
+ *
+ * @param group gin.IRoutes "El grupo de endpoints de usuarios"
+ * @param service services.IUserImportService "El servicio de importación"
+ * @return *gin.IRoutes "El grupo de endpoints creado"
+ */
+func newUserImportHandler(group gin.IRoutes, service services.IUserImportService) *gin.IRoutes {
+	group.POST("/import", handleImportUsers(service))
+	group.GET("/import/:importId", handleGetUserImport(service))
+
+	return &group
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+)
+
+func TestUserImportRoutes(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+	s.createCategory("Limpieza")
+
+	csv := []byte("Nombre,last_name,email,password,type,skills\n" +
+		"Ana,Pérez,ana@ayudapp.test,secreto123,helper,limpieza\n" +
+		"Juan,Gómez,no-es-un-correo,secreto123,user,\n" +
+		"Eva,Ruiz,eva@ayudapp.test,secreto123,superadmin,\n")
+	mapping := map[string]string{"mapping": `{"first_name": "Nombre"}`}
+
+	var report struct {
+		Total   int `json:"total"`
+		Valid   int `json:"valid"`
+		Invalid int `json:"invalid"`
+		Rows    []struct {
+			Row   int  `json:"row"`
+			Valid bool `json:"valid"`
+		} `json:"rows"`
+	}
+	dryRun := map[string]string{"mapping": mapping["mapping"], "dry_run": "true"}
+	s.decode(s.expectUpload("/api/admin/users/import", "file", "usuarios.csv", csv, dryRun, http.StatusOK), &report)
+	if report.Total != 3 || report.Valid != 1 || report.Invalid != 2 || len(report.Rows) != 3 || !report.Rows[0].Valid {
+		t.Errorf("informe de la validación inesperado: %+v", report)
+	}
+	if count, err := s.store.Users().Count(context.Background(), repository.UserFilter{}, 0); err != nil || count != 1 {
+		t.Errorf("la validación creó usuarios: %d (%v)", count, err)
+	}
+
+	s.expectUpload("/api/admin/users/import", "file", "usuarios.csv", []byte("last_name\nPérez\n"), nil, http.StatusBadRequest)
+	s.expectUpload("/api/admin/users/import", "file", "usuarios.csv", csv, map[string]string{"mapping": "no-es-json"}, http.StatusBadRequest)
+
+	var started struct {
+		ImportID string `json:"import_id"`
+	}
+	s.decode(s.expectUpload("/api/admin/users/import", "file", "usuarios.csv", csv, mapping, http.StatusAccepted), &started)
+	path := "/api/admin/users/import/" + started.ImportID
+
+	var job struct {
+		Import models.UserImport `json:"import"`
+	}
+	s.eventually("la importación termina", func() bool {
+		s.decode(s.expect(http.MethodGet, path, nil, http.StatusOK), &job)
+		return job.Import.Status != models.UserImportStatusRunning
+	})
+	if job.Import.Status != models.UserImportStatusDone || job.Import.Processed != 3 || job.Import.Created != 1 || job.Import.Failed != 2 {
+		t.Errorf("resultado de la importación inesperado: %+v", job.Import)
+	}
+	if job.Import.CreatedBy != testAdminEmail {
+		t.Errorf("la importación no registra al administrador: %q", job.Import.CreatedBy)
+	}
+
+	var user struct {
+		User models.User `json:"user"`
+	}
+	s.decode(s.expect(http.MethodGet, "/api/admin/users/email/ana@ayudapp.test", nil, http.StatusOK), &user)
+	if user.User.Type != models.UserTypeHelper || len(user.User.Skills) != 1 {
+		t.Errorf("usuario importado inesperado: %+v", user.User)
+	}
+	s.expect(http.MethodGet, "/api/admin/users/email/eva@ayudapp.test", nil, http.StatusNotFound)
+	s.expectError(http.MethodGet, "/api/admin/users/import/000000000000000000000000", nil, http.StatusNotFound, "import_not_found")
+}
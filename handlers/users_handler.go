@@ -1,10 +1,12 @@
 package handlers
 
 import (
-	"errors"
+	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
 	"github.com/maferuy/ayudapp-admin-backend-core/services"
 	"github.com/maferuy/ayudapp-admin-backend-core/utils"
 )
@@ -13,14 +15,24 @@ import (
 // @ID 		get-users
 // @Produce json
 // @Security ApiKeyAuth
+// @Param 	type 	query string false "Tipo de usuario"
+// @Param 	status 	query string false "Estado del usuario"
+// @Param 	skill 	query string false "ID de una categoría de habilidad"
+// @Param 	q 		query string false "Texto a buscar en el nombre o el correo electrónico"
 // @Success 200 {object} services.GetUsersResponse
-// @Failure 400 {object} services.GetUsersResponse
+// @Failure 400 {object} middlewares.Problem
 // @Router 	/admin/users [get]
 func handleGetUsers(service services.IUserService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		users, err := service.GetUsers()
+		var filter repository.UserFilter
+		if err := ctx.ShouldBindQuery(&filter); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		users, err := service.GetUsers(ctx.Request.Context(), filter)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
@@ -34,20 +46,23 @@ func handleGetUsers(service services.IUserService) gin.HandlerFunc {
 // @Produce json
 // @Security ApiKeyAuth
 // @Param   CreateUserRequest body services.CreateUserRequest true "Datos del usuario"
+// @Param 	Idempotency-Key header string false "Clave para repetir la respuesta en los reintentos"
 // @Success 200 {object} services.CreateUserResponse
-// @Failure 400 {object} services.CreateUserResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 409 {object} middlewares.Problem "El correo electrónico ya está registrado o la solicitud con la misma clave se está procesando"
+// @Failure 422 {object} middlewares.Problem "La clave de idempotencia se usó con otra solicitud"
 // @Router 	/admin/users [post]
 func handleCreateUser(service services.IUserService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req services.CreateUserRequest
 		if err := ctx.ShouldBindJSON(&req); err != nil {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+			ctx.Error(bindingError(err))
 			return
 		}
 
-		userID, err := service.CreateUser(req)
+		userID, err := service.CreateUser(ctx.Request.Context(), req)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
@@ -60,23 +75,31 @@ func handleCreateUser(service services.IUserService) gin.HandlerFunc {
 // @Produce json
 // @Security ApiKeyAuth
 // @Param 	id path int true "ID del usuario"
+// @Param 	If-None-Match header string false "ETag de la versión que tiene el cliente"
 // @Success 200 {object} services.GetUserResponse
-// @Failure 400 {object} services.GetUserResponse
+// @Header 	200 {string} ETag "Versión del usuario"
+// @Success 304 "La versión del cliente es la actual"
+// @Failure 400 {object} middlewares.Problem
 // @Router 	/admin/users/{id} [get]
 func handleGetUser(service services.IUserService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
 		if id == "" {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(errors.New("el id es requerido")))
+			ctx.Error(errIDRequired)
 			return
 		}
 
-		user, err := service.GetUser(id)
+		user, err := service.GetUser(ctx.Request.Context(), id)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
+			return
+		}
+
+		if notModified(ctx, user.User.Version) {
 			return
 		}
 
+		setETag(ctx, user.User.Version)
 		ctx.JSON(http.StatusOK, utils.SuccessResponse(user))
 	}
 }
@@ -88,29 +111,85 @@ func handleGetUser(service services.IUserService) gin.HandlerFunc {
 // @Security ApiKeyAuth
 // @Param   id 					path int 						true "ID del usuario"
 // @Param 	UpdateUserRequest 	body services.UpdateUserRequest true "Datos del usuario"
+// @Param 	If-Match header string true "ETag de la versión del usuario que se modifica, una lista de ETag o *"
 // @Success 200 {object} services.UpdateUserResponse
-// @Failure 400 {object} services.UpdateUserResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 409 {object} middlewares.Problem "El correo electrónico ya está registrado"
+// @Failure 412 {object} middlewares.Problem "El registro fue modificado por otra persona"
+// @Failure 428 {object} middlewares.Problem "Falta la cabecera If-Match"
 // @Router 	/admin/users/{id} [put]
 func handleUpdateUser(service services.IUserService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req services.UpdateUserRequest
 		if err := ctx.ShouldBindJSON(&req); err != nil {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
 			return
 		}
 
+		match, ok := ifMatch(ctx)
+		if !ok {
+			return
+		}
+
+		user, err := service.UpdateUser(ctx.Request.Context(), id, match, req)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		setETag(ctx, user.User.Version)
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(user))
+	}
+}
+
+// @Summary Actualiza parcialmente un usuario
+// @Description Aplica un parche JSON Merge Patch (RFC 7396): sólo se modifican los campos indicados y los campos con valor null se vacían
+// @ID 		patch-user
+// @Accept 	application/merge-patch+json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param   id 		path string 	true "ID del usuario"
+// @Param 	patch 	body services.UpdateUserRequest true "Campos a modificar"
+// @Param 	If-Match header string true "ETag de la versión del usuario que se modifica, una lista de ETag o *"
+// @Success 200 {object} services.UpdateUserResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 404 {object} middlewares.Problem
+// @Failure 409 {object} middlewares.Problem
+// @Failure 415 {object} middlewares.Problem
+// @Failure 412 {object} middlewares.Problem "El registro fue modificado por otra persona"
+// @Failure 428 {object} middlewares.Problem "Falta la cabecera If-Match"
+// @Router 	/admin/users/{id} [patch]
+func handlePatchUser(service services.IUserService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
 		if id == "" {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(errors.New("el id es requerido")))
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		match, ok := ifMatch(ctx)
+		if !ok {
+			return
+		}
+
+		patch, ok := readMergePatch(ctx)
+		if !ok {
 			return
 		}
 
-		user, err := service.UpdateUser(id, req)
+		user, err := service.PatchUser(ctx.Request.Context(), id, match, patch)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
+		setETag(ctx, user.User.Version)
 		ctx.JSON(http.StatusOK, utils.SuccessResponse(user))
 	}
 }
@@ -120,20 +199,28 @@ func handleUpdateUser(service services.IUserService) gin.HandlerFunc {
 // @Produce json
 // @Security ApiKeyAuth
 // @Param 	id path int true "ID del usuario"
+// @Param 	If-Match header string true "ETag de la versión del usuario que se elimina, una lista de ETag o *"
 // @Success 200 {object} string
-// @Failure 400 {object} string
+// @Failure 400 {object} middlewares.Problem
+// @Failure 412 {object} middlewares.Problem "El registro fue modificado por otra persona"
+// @Failure 428 {object} middlewares.Problem "Falta la cabecera If-Match"
 // @Router 	/admin/users/{id} [delete]
 func handleDeleteUser(service services.IUserService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
 		if id == "" {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(errors.New("el id es requerido")))
+			ctx.Error(errIDRequired)
 			return
 		}
 
-		err := service.DeleteUser(id)
+		match, ok := ifMatch(ctx)
+		if !ok {
+			return
+		}
+
+		err := service.DeleteUser(ctx.Request.Context(), id, match)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
@@ -149,25 +236,25 @@ func handleDeleteUser(service services.IUserService) gin.HandlerFunc {
 // @Param 	id						path int 							true "ID del usuario"
 // @Param 	ChangePasswordRequest	body services.ChangePasswordRequest true "Datos del usuario"
 // @Success 200 {object} string
-// @Failure 400 {object} string
+// @Failure 400 {object} middlewares.Problem
 // @Router 	/admin/users/{id}/password [put]
 func handleChangePassword(service services.IUserService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req services.ChangePasswordRequest
 		if err := ctx.ShouldBindJSON(&req); err != nil {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+			ctx.Error(bindingError(err))
 			return
 		}
 
 		id := ctx.Param("id")
 		if id == "" {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(errors.New("el id es requerido")))
+			ctx.Error(errIDRequired)
 			return
 		}
 
-		err := service.ChangePassword(id, req)
+		err := service.ChangePassword(ctx.Request.Context(), id, req)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
@@ -181,19 +268,19 @@ func handleChangePassword(service services.IUserService) gin.HandlerFunc {
 // @Security ApiKeyAuth
 // @Param 	id path int true "ID del usuario"
 // @Success 200 {object} string
-// @Failure 400 {object} string
+// @Failure 400 {object} middlewares.Problem
 // @Router 	/admin/users/{id}/set-super-admin [post]
 func handleSetSuperadmin(service services.IUserService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
 		if id == "" {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(errors.New("el id es requerido")))
+			ctx.Error(errIDRequired)
 			return
 		}
 
-		err := service.SetSuperadmin(id, true)
+		err := service.SetSuperadmin(ctx.Request.Context(), id, true)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
@@ -207,19 +294,19 @@ func handleSetSuperadmin(service services.IUserService) gin.HandlerFunc {
 // @Security ApiKeyAuth
 // @Param 	id path int true "ID del usuario"
 // @Success 200 {object} string
-// @Failure 400 {object} string
+// @Failure 400 {object} middlewares.Problem
 // @Router 	/admin/users/{id}/unset-super-admin [post]
 func handleUnsetSuperadmin(service services.IUserService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
 		if id == "" {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(errors.New("el id es requerido")))
+			ctx.Error(errIDRequired)
 			return
 		}
 
-		err := service.SetSuperadmin(id, false)
+		err := service.SetSuperadmin(ctx.Request.Context(), id, false)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
@@ -233,19 +320,19 @@ func handleUnsetSuperadmin(service services.IUserService) gin.HandlerFunc {
 // @Security ApiKeyAuth
 // @Param 	email path string true "Correo electrónico del usuario"
 // @Success 200 {object} services.GetUserResponse
-// @Failure 400 {object} services.GetUserResponse
+// @Failure 400 {object} middlewares.Problem
 // @Router 	/admin/users/email/{email} [get]
 func handleGetUserByEmail(service services.IUserService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		email := ctx.Param("email")
 		if email == "" {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(errors.New("el email es requerido")))
+			ctx.Error(services.NewValidationError("email_required", "el email es requerido"))
 			return
 		}
 
-		user, err := service.GetUserByEmail(email)
+		user, err := service.GetUserByEmail(ctx.Request.Context(), email)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+			ctx.Error(err)
 			return
 		}
 
@@ -253,18 +340,240 @@ func handleGetUserByEmail(service services.IUserService) gin.HandlerFunc {
 	}
 }
 
+// @Summary Obtiene los canales de aviso y el horario de silencio de un usuario
+// @ID 		get-notification-preferences
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id path string true "ID del usuario"
+// @Success 200 {object} services.GetNotificationPreferencesResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/users/{id}/notification-preferences [get]
+func handleGetNotificationPreferences(service services.IUserService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		preferences, err := service.GetNotificationPreferences(ctx.Request.Context(), id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(preferences))
+	}
+}
+
+// @Summary Actualiza los canales de aviso y el horario de silencio de un usuario
+// @ID 		update-notification-preferences
+// @Accept 	json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id 										path string 											true "ID del usuario"
+// @Param 	UpdateNotificationPreferencesRequest 	body services.UpdateNotificationPreferencesRequest 	true "Preferencias de aviso"
+// @Success 200 {object} services.GetNotificationPreferencesResponse
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/users/{id}/notification-preferences [put]
+func handleUpdateNotificationPreferences(service services.IUserService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req services.UpdateNotificationPreferencesRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		preferences, err := service.UpdateNotificationPreferences(ctx.Request.Context(), id, req)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(preferences))
+	}
+}
+
+// @Summary Registra la suscripción web push de un navegador del usuario
+// @ID 		add-push-subscription
+// @Accept 	json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id 							path string 								true "ID del usuario"
+// @Param 	AddPushSubscriptionRequest 	body services.AddPushSubscriptionRequest 	true "Suscripción push del navegador"
+// @Success 200 {object} string
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/users/{id}/push-subscriptions [post]
+func handleAddPushSubscription(service services.IUserService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req services.AddPushSubscriptionRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		if err := service.AddPushSubscription(ctx.Request.Context(), id, req); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(nil))
+	}
+}
+
+// @Summary Elimina una suscripción web push del usuario
+// @ID 		remove-push-subscription
+// @Accept 	json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id 								path string 									true "ID del usuario"
+// @Param 	RemovePushSubscriptionRequest 	body services.RemovePushSubscriptionRequest 	true "Endpoint de la suscripción"
+// @Success 200 {object} string
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/users/{id}/push-subscriptions [delete]
+func handleRemovePushSubscription(service services.IUserService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req services.RemovePushSubscriptionRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.Error(bindingError(err))
+			return
+		}
+
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		if err := service.RemovePushSubscription(ctx.Request.Context(), id, req.Endpoint); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(nil))
+	}
+}
+
+// @Summary Sube la imagen de perfil de un usuario
+// @ID 		upload-profile-image
+// @Accept 	multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id 		path 		string 	true "ID del usuario"
+// @Param 	image 	formData 	file 	true "Imagen JPEG, PNG o GIF"
+// @Success 200 {object} services.UploadProfileImageResponse
+// @Failure 400 {object} middlewares.Problem
+// @Failure 413 {object} middlewares.Problem
+// @Router 	/admin/users/{id}/profile-image [post]
+func handleUploadProfileImage(service services.IUserService, maxSize int64) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		// Margen para las cabeceras del formulario multipart
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSize+64<<10)
+
+		fileHeader, err := ctx.FormFile("image")
+		if err != nil {
+			ctx.Error(services.NewValidationError("image_required", "se requiere una imagen de hasta %s", formatBytes(maxSize)))
+			return
+		}
+
+		if fileHeader.Size > maxSize {
+			ctx.Error(services.NewTooLargeError("image_too_large", "la imagen no puede superar %s", formatBytes(maxSize)))
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			ctx.Error(services.NewValidationError("invalid_image", err.Error()))
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(io.LimitReader(file, maxSize))
+		if err != nil {
+			ctx.Error(services.NewValidationError("invalid_image", err.Error()))
+			return
+		}
+
+		image, err := service.UploadProfileImage(ctx.Request.Context(), id, data)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(image))
+	}
+}
+
+// @Summary Elimina la imagen de perfil de un usuario
+// @ID 		delete-profile-image
+// @Produce json
+// @Security ApiKeyAuth
+// @Param 	id path string true "ID del usuario"
+// @Success 200 {object} string
+// @Failure 400 {object} middlewares.Problem
+// @Router 	/admin/users/{id}/profile-image [delete]
+func handleDeleteProfileImage(service services.IUserService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if id == "" {
+			ctx.Error(errIDRequired)
+			return
+		}
+
+		if err := service.DeleteProfileImage(ctx.Request.Context(), id); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utils.SuccessResponse(nil))
+	}
+}
+
+// Devuelve un tamaño en bytes legible, por ejemplo "5 MB"
+func formatBytes(size int64) string {
+	if size >= 1<<20 && size%(1<<20) == 0 {
+		return fmt.Sprintf("%d MB", size>>20)
+	}
+	if size >= 1<<10 {
+		return fmt.Sprintf("%d KB", size>>10)
+	}
+
+	return fmt.Sprintf("%d bytes", size)
+}
+
 /** Crea un nuevo grupo de endpoints
  *
  * @param group *gin.RouterGroup "El grupo de endpoints padre"
  * @param service services.IUserService "El servicio de usuarios"
+ * @param maxImageSize int64 "El tamaño máximo de la imagen de perfil en bytes"
+ * @param idempotency gin.HandlerFunc "El middleware de claves de idempotencia de la creación"
  * @return *gin.RouterGroup "El grupo de endpoints creado"
  */
-func newUserHandler(group gin.IRoutes, userService services.IUserService) *gin.IRoutes {
+func newUserHandler(group gin.IRoutes, userService services.IUserService, maxImageSize int64, idempotency gin.HandlerFunc) *gin.IRoutes {
 	group.GET("/", handleGetUsers(userService))
-	group.POST("/", handleCreateUser(userService))
+	group.POST("/", idempotency, handleCreateUser(userService))
 
 	group.GET("/:id", handleGetUser(userService))
 	group.PUT("/:id", handleUpdateUser(userService))
+	group.PATCH("/:id", handlePatchUser(userService))
 	group.DELETE("/:id", handleDeleteUser(userService))
 
 	group.POST("/:id/password", handleChangePassword(userService))
@@ -273,5 +582,13 @@ func newUserHandler(group gin.IRoutes, userService services.IUserService) *gin.I
 
 	group.GET("/email/:email", handleGetUserByEmail(userService))
 
+	group.GET("/:id/notification-preferences", handleGetNotificationPreferences(userService))
+	group.PUT("/:id/notification-preferences", handleUpdateNotificationPreferences(userService))
+	group.POST("/:id/push-subscriptions", handleAddPushSubscription(userService))
+	group.DELETE("/:id/push-subscriptions", handleRemovePushSubscription(userService))
+
+	group.POST("/:id/profile-image", handleUploadProfileImage(userService, maxImageSize))
+	group.DELETE("/:id/profile-image", handleDeleteProfileImage(userService))
+
 	return &group
 }
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"testing"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+)
+
+func TestUserRoutes(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+	categoryID := s.createCategory("Limpieza")
+
+	var created struct {
+		UserID string `json:"user_id"`
+	}
+	s.decode(s.expect(http.MethodPost, "/api/admin/users/", map[string]interface{}{
+		"first_name": "Ana",
+		"last_name":  "Pérez",
+		"email":      "Ana@AyudApp.test",
+		"password":   "secreto123",
+		"type":       models.UserTypeHelper,
+		"status":     models.UserStatusActive,
+		"skills":     []string{categoryID},
+	}, http.StatusOK), &created)
+	path := "/api/admin/users/" + created.UserID
+
+	s.expect(http.MethodPost, "/api/admin/users/", map[string]string{
+		"first_name": "Ana",
+		"last_name":  "Pérez",
+		"email":      "ANA@ayudapp.test",
+		"password":   "secreto123",
+		"type":       models.UserTypeHelper,
+		"status":     models.UserStatusActive,
+	}, http.StatusConflict)
+	s.expectProblem(http.MethodPost, "/api/admin/users/", map[string]interface{}{
+		"email":  "no-es-un-correo",
+		"type":   "invitado",
+		"skills": []string{"no-es-un-id"},
+	}, http.StatusBadRequest, "first_name", "email", "type", "skills[0]")
+
+	s.expect(http.MethodGet, "/api/admin/users/?type=helper", nil, http.StatusOK)
+	s.expect(http.MethodGet, path, nil, http.StatusOK)
+	s.expect(http.MethodGet, "/api/admin/users/000000000000000000000000", nil, http.StatusNotFound)
+	s.expect(http.MethodGet, "/api/admin/users/no-es-un-id", nil, http.StatusBadRequest)
+	s.expect(http.MethodGet, "/api/admin/users/email/Ana@ayudapp.test", nil, http.StatusOK)
+
+	var updated struct {
+		User struct {
+			FirstName string `json:"first_name"`
+			LastName  string `json:"last_name"`
+			Phone     string `json:"phone"`
+		} `json:"user"`
+	}
+	s.expect(http.MethodPut, path, map[string]string{"first_name": "Ana María"}, http.StatusPreconditionRequired)
+	etag := s.useETag(path)
+	s.expectStatus(http.MethodGet, path, map[string]string{"If-None-Match": etag}, http.StatusNotModified)
+	s.decode(s.expect(http.MethodPut, path, map[string]string{"first_name": "Ana María"}, http.StatusOK), &updated)
+	if updated.User.FirstName != "Ana María" || updated.User.LastName != "Pérez" {
+		t.Errorf("PUT %s no aplicó los campos: %+v", path, updated.User)
+	}
+	s.expect(http.MethodPut, path, map[string]string{"first_name": "Ana"}, http.StatusPreconditionFailed)
+	s.expectStatus(http.MethodGet, path, map[string]string{"If-None-Match": etag}, http.StatusOK)
+
+	s.useETag(path)
+	s.decode(s.expectPatch(path, `{"phone": "099123456"}`, http.StatusOK), &updated)
+	if updated.User.FirstName != "Ana María" || updated.User.Phone != "099123456" {
+		t.Errorf("PATCH %s no conservó los demás campos: %+v", path, updated.User)
+	}
+	s.useETag(path)
+	// El teléfono vacío se omite de la respuesta
+	updated.User.Phone = ""
+	s.decode(s.expectPatch(path, `{"phone": null}`, http.StatusOK), &updated)
+	if updated.User.Phone != "" || updated.User.FirstName != "Ana María" {
+		t.Errorf("PATCH %s con null no vació el campo: %+v", path, updated.User)
+	}
+	s.useETag(path)
+	s.expectPatch(path, `{"first_name": null}`, http.StatusBadRequest)
+	s.expectPatch(path, `{"password": "otra"}`, http.StatusBadRequest)
+	s.expectPatch("/api/admin/users/000000000000000000000000", `{"phone": "1"}`, http.StatusNotFound)
+	s.ifMatch = ""
+
+	s.expect(http.MethodPost, path+"/password", map[string]string{
+		"password":              "nueva1234",
+		"password_confirmation": "nueva1234",
+	}, http.StatusOK)
+	s.expect(http.MethodPost, path+"/set-superadmin", nil, http.StatusOK)
+	s.expect(http.MethodPost, path+"/unset-superadmin", nil, http.StatusOK)
+
+	s.expect(http.MethodGet, path+"/notification-preferences", nil, http.StatusOK)
+	s.expect(http.MethodPut, path+"/notification-preferences", map[string]interface{}{
+		"channels": []string{"email", "sms"},
+	}, http.StatusOK)
+
+	keys := map[string]string{"p256dh": "clave", "auth": "secreto"}
+	s.expect(http.MethodPost, path+"/push-subscriptions", map[string]interface{}{
+		"endpoint": "https://push.example.com/1",
+		"keys":     keys,
+	}, http.StatusOK)
+	s.expect(http.MethodPost, path+"/push-subscriptions", map[string]interface{}{
+		"endpoint": "https://169.254.169.254/latest/meta-data",
+		"keys":     keys,
+	}, http.StatusBadRequest)
+	s.expect(http.MethodPost, path+"/push-subscriptions", map[string]interface{}{
+		"endpoint": "http://push.example.com/1",
+		"keys":     keys,
+	}, http.StatusBadRequest)
+	s.expect(http.MethodDelete, path+"/push-subscriptions", map[string]string{
+		"endpoint": "https://push.example.com/1",
+	}, http.StatusOK)
+
+	s.expectUpload(path+"/profile-image", "image", "perfil.png", testPNG(t), nil, http.StatusOK)
+	s.expect(http.MethodDelete, path+"/profile-image", nil, http.StatusOK)
+
+	deletedID := s.insertUser(models.User{FirstName: "Borrar", LastName: "Usuario", Email: "borrar@ayudapp.test", Type: models.UserTypeUser})
+	s.ifMatch = `"99"`
+	s.expect(http.MethodDelete, "/api/admin/users/"+deletedID, nil, http.StatusPreconditionFailed)
+	s.ifMatch = "*"
+	s.expect(http.MethodDelete, "/api/admin/users/"+deletedID, nil, http.StatusOK)
+	s.ifMatch = ""
+	s.expect(http.MethodGet, "/api/admin/users/"+deletedID, nil, http.StatusNotFound)
+}
+
+func TestUserIdempotency(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+
+	user := map[string]string{
+		"first_name": "Reintento",
+		"last_name":  "Usuario",
+		"email":      "reintento@ayudapp.test",
+		"password":   "secreto123",
+		"type":       models.UserTypeUser,
+		"status":     models.UserStatusActive,
+	}
+	first := s.expectIdempotent("/api/admin/users/", "usuario-1", user, http.StatusOK, false)
+	retry := s.expectIdempotent("/api/admin/users/", "usuario-1", user, http.StatusOK, true)
+	if first == nil || !bytes.Equal(first, retry) {
+		t.Errorf("el reintento no repitió la respuesta: %s != %s", first, retry)
+	}
+
+	invalid := map[string]string{"email": "no-es-un-correo"}
+	s.expectIdempotent("/api/admin/users/", "usuario-2", invalid, http.StatusBadRequest, false)
+	s.expectIdempotent("/api/admin/users/", "usuario-2", invalid, http.StatusBadRequest, true)
+}
+
+// Genera una imagen PNG de 8x8 para las pruebas de imágenes de perfil
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+
+	var data bytes.Buffer
+	if err := png.Encode(&data, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return data.Bytes()
+}
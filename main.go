@@ -3,12 +3,16 @@ package main
 import (
 	"context"
 	"log"
+	"net"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/database"
 	"github.com/maferuy/ayudapp-admin-backend-core/handlers"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
 	"github.com/maferuy/ayudapp-admin-backend-core/utils"
 
 	_ "github.com/maferuy/ayudapp-admin-backend-core/docs"
@@ -37,23 +41,64 @@ func main() {
 
 	utils.SetupLogOutput()
 
+	if config.IsProduction() {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	server, err := handlers.NewServer(config)
+	client, err := database.Open(context.TODO(), config.MongoURI)
+	if err != nil {
+		log.Fatal("Error al conectar con la base de datos: ", err)
+	}
+
+	db := client.Database(config.DatabaseName)
+
+	// Subcomando de migraciones: migrate [up|status] [-dry-run]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrateCommand(ctx, db, os.Args[2:])
+		client.Disconnect(context.TODO())
+		if err != nil {
+			log.Fatal("Error al migrar la base de datos: ", err)
+		}
+		return
+	}
+
+	if err = startupMigrations(ctx, config, db); err != nil {
+		log.Fatal("Error al migrar la base de datos: ", err)
+	}
+
+	server, err := handlers.NewServer(config, repository.NewMongoStore(db, config.DatabaseTimeout))
 	if err != nil {
 		log.Fatal("No se pudo crear el servidor: ", err)
 	}
 
 	defer func() {
-		if err := server.Client.Disconnect(context.TODO()); err != nil {
+		if err := client.Disconnect(context.TODO()); err != nil {
 			log.Fatal("No fue posible desconectar la base de datos: ", err)
 		}
 	}()
 
+	// Envío de recordatorios y avisos de citas
+	server.Scheduler.Start(ctx)
+
+	// Entrega de la cola de correos
+	server.Outbox.Start(ctx)
+
+	// Revisión de las importaciones de usuarios interrumpidas
+	server.Imports.Start(ctx)
+
+	// Las consultas de las solicitudes se cancelan si no terminan dentro del tiempo de apagado
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
 	httpServer := &http.Server{
 		Addr:    "localhost:" + config.Port,
 		Handler: server.Router,
+		BaseContext: func(net.Listener) context.Context {
+			return requestsCtx
+		},
 	}
 
 	go func() {
@@ -67,9 +112,10 @@ func main() {
 	stop()
 	log.Println("Deteniendo servidor. Presiona Ctrl+C para forzar.")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
 	defer cancel()
 	if err := httpServer.Shutdown(ctx); err != nil {
+		cancelRequests()
 		log.Fatalf("Servidor detenido forzosamente: %s", err)
 	}
 
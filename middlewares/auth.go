@@ -1,14 +1,12 @@
 package middlewares
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/maferuy/ayudapp-admin-backend-core/token"
-	"github.com/maferuy/ayudapp-admin-backend-core/utils"
 )
 
 const (
@@ -22,29 +20,26 @@ func AuthMiddleware(tokenMaker token.IMaker) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
-			err := errors.New("no se proveyó la cabecera de autorización")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.ErrorResponse(err))
+			WriteProblem(ctx, http.StatusUnauthorized, "missing_authorization", "no se proveyó la cabecera de autorización")
 			return
 		}
 
 		fields := strings.Fields(authorizationHeader)
 		if len(fields) < 2 {
-			err := errors.New("formato de cabecera de autorización inválido")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.ErrorResponse(err))
+			WriteProblem(ctx, http.StatusUnauthorized, "invalid_authorization", "formato de cabecera de autorización inválido")
 			return
 		}
 
 		authorizationType := strings.ToLower(fields[0])
 		if authorizationType != authorizationTypeBearer {
-			err := fmt.Errorf("tipo de autorización no soportado: %s", authorizationType)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.ErrorResponse(err))
+			WriteProblem(ctx, http.StatusUnauthorized, "invalid_authorization", fmt.Sprintf("tipo de autorización no soportado: %s", authorizationType))
 			return
 		}
 
 		accessToken := fields[1]
 		payload, err := tokenMaker.Valid(accessToken)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.ErrorResponse(err))
+			WriteProblem(ctx, http.StatusUnauthorized, "invalid_token", err.Error())
 			return
 		}
 
@@ -52,3 +47,14 @@ func AuthMiddleware(tokenMaker token.IMaker) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// Devuelve el payload del token del usuario autenticado en la petición
+func GetAuthorizationPayload(ctx *gin.Context) (*token.Payload, bool) {
+	payload, exists := ctx.Get(authorizationPayloadKey)
+	if !exists {
+		return nil, false
+	}
+
+	tokenPayload, ok := payload.(*token.Payload)
+	return tokenPayload, ok
+}
@@ -7,15 +7,21 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func CorsConfig() gin.HandlerFunc {
+// Permite las solicitudes de los orígenes indicados; "*" permite cualquier origen
+func CorsConfig(allowedOrigins []string) gin.HandlerFunc {
 	corsConfig := cors.Config{
-		AllowMethods:     []string{"POST", "GET", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"POST", "GET", "PUT", "PATCH", "DELETE"},
+		AllowHeaders:     []string{"Origin", "If-Match", "If-None-Match", IdempotencyKeyHeader},
+		ExposeHeaders:    []string{"Content-Length", "ETag", IdempotentReplayedHeader},
 		AllowCredentials: true,
 		AllowOriginFunc: func(origin string) bool {
-			return true
-			//return origin == "http://google.com"
+			for _, allowed := range allowedOrigins {
+				if allowed == "*" || allowed == origin {
+					return true
+				}
+			}
+
+			return false
 		},
 		MaxAge: 12 * time.Hour,
 	}
@@ -0,0 +1,152 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/maferuy/ayudapp-admin-backend-core/services"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+)
+
+// Respuesta de error con el formato RFC 7807
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	// Errores por campo, cuando la validación de la solicitud falla
+	Errors []utils.FieldError `json:"errors,omitempty"`
+}
+
+const (
+	problemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:ayudapp:error:"
+
+	// Código de estado para las solicitudes que el cliente canceló antes de recibir la respuesta
+	StatusClientClosedRequest = 499
+)
+
+// Este middleware convierte el último error registrado con ctx.Error en una respuesta problem+json
+func ErrorHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+
+		respondError(ctx)
+	}
+}
+
+// Escribe la respuesta problem+json del último error registrado con ctx.Error
+func respondError(ctx *gin.Context) {
+	err := ctx.Errors.Last().Err
+	status, code := ErrorStatus(err)
+
+	var fields validator.ValidationErrors
+	if errors.As(err, &fields) {
+		lang := utils.ValidationLanguage(ctx)
+		writeProblem(ctx, status, code, validationDetails[lang], utils.TranslateValidationErrors(fields, lang))
+		return
+	}
+
+	detail := err.Error()
+	if code == "internal_error" {
+		log.Printf("Error interno en %s %s: %v", ctx.Request.Method, ctx.Request.URL.Path, err)
+		detail = "ocurrió un error interno"
+	}
+
+	WriteProblem(ctx, status, code, detail)
+}
+
+// Descripción de los errores de validación, por idioma
+var validationDetails = map[string]string{
+	"es": "la solicitud tiene campos inválidos",
+	"en": "the request has invalid fields",
+}
+
+/** Escribe una respuesta problem+json y detiene la cadena de handlers
+ *
+ * @param ctx *gin.Context "El contexto de la solicitud"
+ * @param status int "El código de estado HTTP"
+ * @param code string "El código estable del error"
+ * @param detail string "La descripción del error"
+ */
+func WriteProblem(ctx *gin.Context, status int, code string, detail string) {
+	writeProblem(ctx, status, code, detail, nil)
+}
+
+func writeProblem(ctx *gin.Context, status int, code string, detail string, fields []utils.FieldError) {
+	title := http.StatusText(status)
+	if title == "" {
+		title = "Error"
+	}
+
+	ctx.Header("Content-Type", problemContentType)
+	ctx.AbortWithStatusJSON(status, Problem{
+		Type:     problemTypePrefix + code,
+		Title:    title,
+		Status:   status,
+		Detail:   detail,
+		Instance: ctx.Request.URL.Path,
+		Code:     code,
+		Errors:   fields,
+	})
+}
+
+/** Obtiene el código de estado HTTP y el código estable de un error
+ *
+ * @param err error "El error"
+ * @return status int "El código de estado HTTP"
+ * @return code string "El código del error; internal_error si el error no tiene tipo"
+ */
+func ErrorStatus(err error) (status int, code string) {
+	var (
+		notFound     *services.NotFoundError
+		conflict     *services.ConflictError
+		validation   *services.ValidationError
+		forbidden    *services.ForbiddenError
+		unauthorized *services.UnauthorizedError
+		invalidID    *services.InvalidIDError
+		tooLarge     *services.TooLargeError
+		precondition *services.PreconditionFailedError
+		required     *services.PreconditionRequiredError
+		fields       validator.ValidationErrors
+	)
+
+	switch {
+	case errors.As(err, &fields):
+		return http.StatusBadRequest, "validation_failed"
+	case errors.As(err, &notFound):
+		return http.StatusNotFound, notFound.Code
+	case errors.As(err, &conflict):
+		return http.StatusConflict, conflict.Code
+	case errors.As(err, &validation):
+		return http.StatusBadRequest, validation.Code
+	case errors.As(err, &forbidden):
+		return http.StatusForbidden, forbidden.Code
+	case errors.As(err, &unauthorized):
+		return http.StatusUnauthorized, unauthorized.Code
+	case errors.As(err, &invalidID):
+		return http.StatusBadRequest, invalidID.Code
+	case errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge, tooLarge.Code
+	case errors.As(err, &precondition):
+		return http.StatusPreconditionFailed, precondition.Code
+	case errors.As(err, &required):
+		return http.StatusPreconditionRequired, required.Code
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "timeout"
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest, "client_closed_request"
+	default:
+		return http.StatusInternalServerError, "internal_error"
+	}
+}
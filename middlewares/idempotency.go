@@ -0,0 +1,177 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+)
+
+const (
+	// Cabecera con la clave que identifica los reintentos de una misma solicitud
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// Cabecera que indica que la respuesta es la guardada de la primera solicitud
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// Tamaño máximo del cuerpo de las solicitudes con clave, que se lee completo para calcular su hash
+	maxIdempotentBodySize = 1 << 20
+)
+
+// Guarda una copia de la respuesta mientras se escribe
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	recorder.body.Write(data)
+	return recorder.ResponseWriter.Write(data)
+}
+
+func (recorder *responseRecorder) WriteString(data string) (int, error) {
+	recorder.body.WriteString(data)
+	return recorder.ResponseWriter.WriteString(data)
+}
+
+/** Este middleware repite la respuesta de las solicitudes reintentadas con la misma Idempotency-Key
+ *
+ * La primera solicitud con una clave se procesa normalmente y su respuesta se guarda
+ * durante ttl. Los reintentos con la misma clave y el mismo cuerpo reciben la respuesta
+ * guardada sin volver a procesarse; si el cuerpo es distinto se rechazan con 422 y, si
+ * la primera solicitud todavía se está procesando, con 409. Las claves son propias de
+ * cada usuario y de cada ruta. Las respuestas de errores internos no se guardan, para
+ * que el cliente pueda reintentar, y la clave también se libera si el handler entra en
+ * pánico. Los cuerpos de más de 1 MiB se rechazan con 413. Las solicitudes sin la
+ * cabecera no se modifican.
+ *
+ * @param idempotencyKeys repository.IIdempotencyRepository "El repositorio de claves de idempotencia"
+ * @param ttl time.Duration "El tiempo durante el que se guarda cada respuesta"
+ * @return gin.HandlerFunc "El middleware"
+ */
+func Idempotency(idempotencyKeys repository.IIdempotencyRepository, ttl time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			WriteProblem(ctx, http.StatusBadRequest, "invalid_idempotency_key", "la clave de idempotencia debe tener como máximo 255 caracteres")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxIdempotentBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			WriteProblem(ctx, http.StatusRequestEntityTooLarge, "request_too_large", "el cuerpo de la solicitud no puede superar 1 MiB")
+			return
+		} else if err != nil {
+			ctx.Error(err)
+			ctx.Abort()
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256(body)
+		now := time.Now()
+		record := models.IdempotencyRecord{
+			Key:         idempotencyScope(ctx, key),
+			RequestHash: hex.EncodeToString(hash[:]),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+
+		err = idempotencyKeys.Reserve(ctx.Request.Context(), record)
+		if errors.Is(err, repository.ErrDuplicate) {
+			replayResponse(ctx, idempotencyKeys, record)
+			return
+		} else if err != nil {
+			ctx.Error(err)
+			ctx.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+
+		// Si el handler entra en pánico se libera la clave antes de que Recovery responda 500
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				ctx.Writer = recorder.ResponseWriter
+				releaseIdempotencyKey(ctx, idempotencyKeys, record.Key)
+				panic(recovered)
+			}
+		}()
+
+		ctx.Next()
+
+		// Los errores se escriben aquí y no en ErrorHandler para guardar la respuesta
+		if len(ctx.Errors) > 0 && !ctx.Writer.Written() {
+			respondError(ctx)
+		}
+		ctx.Writer = recorder.ResponseWriter
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError || status == StatusClientClosedRequest {
+			releaseIdempotencyKey(ctx, idempotencyKeys, record.Key)
+			return
+		}
+
+		// La clave se guarda aunque el cliente haya cancelado la solicitud
+		record.Status = status
+		record.ContentType = recorder.Header().Get("Content-Type")
+		record.Body = recorder.body.Bytes()
+		if err := idempotencyKeys.Complete(context.Background(), record); err != nil {
+			log.Printf("Error al guardar la respuesta idempotente de %s %s: %v", ctx.Request.Method, ctx.Request.URL.Path, err)
+		}
+	}
+}
+
+// Libera la clave para que el cliente pueda reintentar, aunque haya cancelado la solicitud
+func releaseIdempotencyKey(ctx *gin.Context, idempotencyKeys repository.IIdempotencyRepository, key string) {
+	if err := idempotencyKeys.Delete(context.Background(), key); err != nil {
+		log.Printf("Error al liberar la clave de idempotencia de %s %s: %v", ctx.Request.Method, ctx.Request.URL.Path, err)
+	}
+}
+
+// Identifica la clave para el usuario de la sesión y la ruta de la solicitud
+func idempotencyScope(ctx *gin.Context, key string) string {
+	var email string
+	if payload, ok := GetAuthorizationPayload(ctx); ok {
+		email = payload.Email
+	}
+
+	return email + " " + ctx.Request.Method + " " + ctx.Request.URL.Path + " " + key
+}
+
+// Responde un reintento con la respuesta guardada de la clave
+func replayResponse(ctx *gin.Context, idempotencyKeys repository.IIdempotencyRepository, record models.IdempotencyRecord) {
+	stored, err := idempotencyKeys.FindByKey(ctx.Request.Context(), record.Key)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		// La primera solicitud falló y liberó la clave mientras tanto
+		WriteProblem(ctx, http.StatusConflict, "idempotency_request_in_progress", "la solicitud con esta clave de idempotencia todavía se está procesando")
+	case err != nil:
+		ctx.Error(err)
+		ctx.Abort()
+	case stored.RequestHash != record.RequestHash:
+		WriteProblem(ctx, http.StatusUnprocessableEntity, "idempotency_key_reused", "la clave de idempotencia ya se usó con otra solicitud")
+	case !stored.Completed:
+		WriteProblem(ctx, http.StatusConflict, "idempotency_request_in_progress", "la solicitud con esta clave de idempotencia todavía se está procesando")
+	default:
+		ctx.Header(IdempotentReplayedHeader, "true")
+		ctx.Data(stored.Status, stored.ContentType, stored.Body)
+		ctx.Abort()
+	}
+}
@@ -0,0 +1,218 @@
+package middlewares
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"github.com/maferuy/ayudapp-admin-backend-core/services"
+	"github.com/maferuy/ayudapp-admin-backend-core/token"
+)
+
+// Solicitud de una prueba de idempotencia y la respuesta esperada
+type idempotentStep struct {
+	user     string
+	key      string
+	body     string
+	status   int
+	replayed bool
+	// Cantidad de veces que se ejecutó el handler después de la solicitud
+	calls int
+}
+
+// Crea un router con el middleware sobre un handler que cuenta sus ejecuciones
+//
+// El handler responde según el cuerpo: "invalido" registra un error de validación,
+// "falla" un error interno, "panico" entra en pánico y cualquier otro cuerpo se
+// responde con 201.
+func newIdempotencyRouter(idempotencyKeys repository.IIdempotencyRepository, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(gin.RecoveryWithWriter(io.Discard))
+	router.Use(ErrorHandler())
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set(authorizationPayloadKey, &token.Payload{Email: ctx.GetHeader("X-User")})
+	})
+	router.POST("/items", Idempotency(idempotencyKeys, time.Hour), func(ctx *gin.Context) {
+		*calls++
+
+		body, _ := io.ReadAll(ctx.Request.Body)
+		switch string(body) {
+		case "invalido":
+			ctx.Error(services.NewValidationError("invalid_item", "el elemento es inválido"))
+		case "falla":
+			ctx.Error(errors.New("error de la base de datos"))
+		case "panico":
+			panic("error inesperado")
+		default:
+			ctx.JSON(http.StatusCreated, gin.H{"call": *calls, "body": string(body)})
+		}
+	})
+
+	return router
+}
+
+func TestIdempotency(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []idempotentStep
+	}{
+		{
+			name: "sin clave",
+			steps: []idempotentStep{
+				{body: "a", status: http.StatusCreated, calls: 1},
+				{body: "a", status: http.StatusCreated, calls: 2},
+			},
+		},
+		{
+			name: "reintento con la misma clave",
+			steps: []idempotentStep{
+				{key: "k1", body: "a", status: http.StatusCreated, calls: 1},
+				{key: "k1", body: "a", status: http.StatusCreated, replayed: true, calls: 1},
+				{key: "k1", body: "a", status: http.StatusCreated, replayed: true, calls: 1},
+			},
+		},
+		{
+			name: "clave reutilizada con otro cuerpo",
+			steps: []idempotentStep{
+				{key: "k1", body: "a", status: http.StatusCreated, calls: 1},
+				{key: "k1", body: "b", status: http.StatusUnprocessableEntity, calls: 1},
+			},
+		},
+		{
+			name: "claves distintas",
+			steps: []idempotentStep{
+				{key: "k1", body: "a", status: http.StatusCreated, calls: 1},
+				{key: "k2", body: "a", status: http.StatusCreated, calls: 2},
+			},
+		},
+		{
+			name: "la misma clave de otro usuario",
+			steps: []idempotentStep{
+				{user: "ana@ayudapp.test", key: "k1", body: "a", status: http.StatusCreated, calls: 1},
+				{user: "luis@ayudapp.test", key: "k1", body: "a", status: http.StatusCreated, calls: 2},
+			},
+		},
+		{
+			name: "se repiten los errores de la solicitud",
+			steps: []idempotentStep{
+				{key: "k1", body: "invalido", status: http.StatusBadRequest, calls: 1},
+				{key: "k1", body: "invalido", status: http.StatusBadRequest, replayed: true, calls: 1},
+			},
+		},
+		{
+			name: "no se guardan los errores internos",
+			steps: []idempotentStep{
+				{key: "k1", body: "falla", status: http.StatusInternalServerError, calls: 1},
+				{key: "k1", body: "falla", status: http.StatusInternalServerError, calls: 2},
+			},
+		},
+		{
+			name: "se libera la clave si el handler entra en pánico",
+			steps: []idempotentStep{
+				{key: "k1", body: "panico", status: http.StatusInternalServerError, calls: 1},
+				{key: "k1", body: "panico", status: http.StatusInternalServerError, calls: 2},
+			},
+		},
+		{
+			name: "cuerpo demasiado grande",
+			steps: []idempotentStep{
+				{key: "k1", body: strings.Repeat("a", maxIdempotentBodySize+1), status: http.StatusRequestEntityTooLarge, calls: 0},
+				{key: "k1", body: strings.Repeat("a", maxIdempotentBodySize), status: http.StatusCreated, calls: 1},
+			},
+		},
+		{
+			name: "clave demasiado larga",
+			steps: []idempotentStep{
+				{key: strings.Repeat("k", maxIdempotencyKeyLength+1), body: "a", status: http.StatusBadRequest, calls: 0},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var calls int
+			router := newIdempotencyRouter(repository.NewMemoryStore().IdempotencyKeys(), &calls)
+
+			var first string
+			for i, step := range test.steps {
+				recorder := sendIdempotent(router, step.user, step.key, step.body)
+
+				replayed := recorder.Header().Get(IdempotentReplayedHeader) == "true"
+				if recorder.Code != step.status || replayed != step.replayed || calls != step.calls {
+					t.Fatalf("solicitud %d: status %d, repetida %t, ejecuciones %d; want %d, %t, %d: %s",
+						i+1, recorder.Code, replayed, calls, step.status, step.replayed, step.calls, recorder.Body.String())
+				}
+
+				if step.replayed && recorder.Body.String() != first {
+					t.Errorf("solicitud %d: cuerpo %s, want la respuesta guardada %s", i+1, recorder.Body.String(), first)
+				}
+				if i == 0 {
+					first = recorder.Body.String()
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotencyReservedKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		status    int
+		calls     int
+	}{
+		{name: "solicitud en proceso", expiresAt: time.Now().Add(time.Hour), status: http.StatusConflict, calls: 0},
+		{name: "clave vencida", expiresAt: time.Now().Add(-time.Minute), status: http.StatusCreated, calls: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var calls int
+			idempotencyKeys := repository.NewMemoryStore().IdempotencyKeys()
+			router := newIdempotencyRouter(idempotencyKeys, &calls)
+
+			// Reserva la clave como lo hace una solicitud que todavía no terminó
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/items", nil)
+			ctx.Set(authorizationPayloadKey, &token.Payload{})
+			hash := sha256.Sum256([]byte("a"))
+			err := idempotencyKeys.Reserve(ctx, models.IdempotencyRecord{
+				Key:         idempotencyScope(ctx, "k1"),
+				RequestHash: hex.EncodeToString(hash[:]),
+				ExpiresAt:   test.expiresAt,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder = sendIdempotent(router, "", "k1", "a")
+			if recorder.Code != test.status || calls != test.calls {
+				t.Errorf("status %d, ejecuciones %d; want %d, %d: %s", recorder.Code, calls, test.status, test.calls, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func sendIdempotent(router http.Handler, user, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+	req.Header.Set("X-User", user)
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
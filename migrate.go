@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/database"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/** Ejecuta el subcomando de migraciones
+ *
+ * Uso: migrate [up|status] [-dry-run]. Sin acción se aplican las migraciones
+ * pendientes; con -dry-run sólo se muestran las que se aplicarían y los datos que
+ * impedirían aplicarlas.
+ *
+ * @param ctx context.Context "El contexto de la ejecución"
+ * @param db *mongo.Database "La base de datos"
+ * @param args []string "Los argumentos posteriores a migrate"
+ * @return err error "El error de la ejecución"
+ */
+func runMigrateCommand(ctx context.Context, db *mongo.Database, args []string) (err error) {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "Muestra las migraciones pendientes sin aplicarlas")
+
+	action := "up"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		action, args = args[0], args[1:]
+	}
+
+	if err = flags.Parse(args); err != nil {
+		return
+	}
+
+	switch action {
+	case "status":
+		return printMigrationStatus(ctx, db)
+	case "up":
+		if *dryRun {
+			return printPendingMigrations(ctx, db)
+		}
+
+		applied, err := database.Migrate(ctx, db)
+		for _, migration := range applied {
+			fmt.Printf("Aplicada %d: %s\n", migration.Version, migration.Description)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("La base de datos está al día")
+		}
+
+		return err
+	default:
+		return fmt.Errorf("acción desconocida: %s (use up o status)", action)
+	}
+}
+
+/** Aplica o verifica las migraciones al iniciar el servidor
+ *
+ * Con MIGRATE_ON_START se aplican las pendientes; si no, el servidor no inicia
+ * mientras haya migraciones pendientes.
+ *
+ * @param ctx context.Context "El contexto de la ejecución"
+ * @param config utils.Config "Configuración de la aplicación"
+ * @param db *mongo.Database "La base de datos"
+ * @return err error "El error de la migración"
+ */
+func startupMigrations(ctx context.Context, config utils.Config, db *mongo.Database) (err error) {
+	if config.MigrateOnStart {
+		applied, err := database.Migrate(ctx, db)
+		for _, migration := range applied {
+			log.Printf("Migración %d aplicada: %s", migration.Version, migration.Description)
+		}
+
+		return err
+	}
+
+	pending, err := database.PendingMigrations(ctx, db)
+	if err != nil {
+		return
+	}
+
+	if len(pending) > 0 {
+		err = fmt.Errorf("hay %d migraciones pendientes, aplíquelas con el comando migrate", len(pending))
+	}
+
+	return
+}
+
+func printMigrationStatus(ctx context.Context, db *mongo.Database) error {
+	statuses, err := database.GetMigrationStatus(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSIÓN\tESTADO\tAPLICADA\tDESCRIPCIÓN")
+	for _, status := range statuses {
+		state, appliedAt := "pendiente", "-"
+		if status.AppliedAt != nil {
+			state, appliedAt = "aplicada", status.AppliedAt.Local().Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", status.Version, state, appliedAt, status.Description)
+	}
+
+	return writer.Flush()
+}
+
+func printPendingMigrations(ctx context.Context, db *mongo.Database) error {
+	pending, err := database.PendingMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		fmt.Println("La base de datos está al día")
+		return nil
+	}
+
+	blocked := false
+	fmt.Printf("Se aplicarían %d migraciones:\n", len(pending))
+	for _, migration := range pending {
+		fmt.Printf("  %d: %s\n", migration.Version, migration.Description)
+		for _, problem := range migration.Problems {
+			fmt.Printf("     - %s\n", problem)
+			blocked = true
+		}
+		for _, change := range migration.Changes {
+			fmt.Printf("     * %s\n", change)
+		}
+	}
+
+	if blocked {
+		return errors.New("hay datos que impedirían aplicar las migraciones")
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// Nota visible sólo para los coordinadores
+	NoteVisibilityInternal = "internal"
+	// Nota visible también para el solicitante y el ayudante de la cita
+	NoteVisibilityShared = "shared"
+)
+
+type AppointmentNote struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	AppointmentID primitive.ObjectID `bson:"appointment_id" json:"appointment_id"`
+	Author        NoteAuthor         `bson:"author" json:"author"`
+	Body          string             `bson:"body" json:"body"`
+	Visibility    string             `bson:"visibility" json:"visibility"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Administrador que escribió una nota o subió un archivo, tomado del token de sesión
+type NoteAuthor struct {
+	Email string `bson:"email" json:"email"`
+	Name  string `bson:"name" json:"name"`
+}
+
+type AppointmentAttachment struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	AppointmentID primitive.ObjectID `bson:"appointment_id" json:"appointment_id"`
+	FileName      string             `bson:"file_name" json:"file_name"`
+	ContentType   string             `bson:"content_type" json:"content_type"`
+	Size          int64              `bson:"size" json:"size"`
+	Key           string             `bson:"key" json:"-"`
+	UploadedBy    NoteAuthor         `bson:"uploaded_by" json:"uploaded_by"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+}
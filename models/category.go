@@ -3,7 +3,51 @@ package models
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type Category struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	Name        string             `bson:"name" json:"name"`
-	Description string             `bson:"description" json:"description"`
+	ID            primitive.ObjectID             `bson:"_id,omitempty" json:"_id,omitempty"`
+	Name          string                         `bson:"name" json:"name"`
+	Slug          string                         `bson:"slug" json:"slug"`
+	PreviousSlugs []string                       `bson:"previous_slugs,omitempty" json:"previous_slugs,omitempty"`
+	MergedIDs     []primitive.ObjectID           `bson:"merged_ids,omitempty" json:"merged_ids,omitempty"`
+	Description   string                         `bson:"description" json:"description"`
+	Translations  map[string]CategoryTranslation `bson:"translations,omitempty" json:"translations,omitempty"`
+	ParentID      *primitive.ObjectID            `bson:"parent_id" json:"parent_id"`
+	Ancestors     []primitive.ObjectID           `bson:"ancestors" json:"ancestors"`
+	SortOrder     int                            `bson:"sort_order" json:"sort_order"`
+}
+
+type CategoryTranslation struct {
+	Name        string `bson:"name" json:"name"`
+	Description string `bson:"description" json:"description"`
+}
+
+/** Devuelve la categoría con el nombre y la descripción en el idioma indicado
+ *
+ * Si no existe traducción para el idioma se mantienen los valores originales.
+ *
+ * @param lang string "El código del idioma"
+ * @param defaultLang string "El idioma de los valores originales"
+ * @return localized Category "La categoría traducida"
+ * @return languages []string "Los idiomas en que quedaron el nombre y la descripción, sin repetir"
+ */
+func (category Category) Localize(lang string, defaultLang string) (localized Category, languages []string) {
+	localized = category
+	nameLang, descriptionLang := defaultLang, defaultLang
+
+	if translation, ok := category.Translations[lang]; ok {
+		if translation.Name != "" {
+			localized.Name = translation.Name
+			nameLang = lang
+		}
+		if translation.Description != "" {
+			localized.Description = translation.Description
+			descriptionLang = lang
+		}
+	}
+
+	languages = []string{nameLang}
+	if localized.Description != "" && descriptionLang != nameLang {
+		languages = append(languages, descriptionLang)
+	}
+
+	return
 }
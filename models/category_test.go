@@ -0,0 +1,44 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCategoryLocalize(t *testing.T) {
+	category := Category{
+		Name:        "Limpieza",
+		Description: "Limpieza del hogar",
+		Translations: map[string]CategoryTranslation{
+			"pt": {Name: "Limpeza", Description: "Limpeza da casa"},
+			"en": {Name: "Cleaning"},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		category        Category
+		lang            string
+		wantName        string
+		wantDescription string
+		wantLanguages   []string
+	}{
+		{"idioma predeterminado", category, "es", "Limpieza", "Limpieza del hogar", []string{"es"}},
+		{"traducción completa", category, "pt", "Limpeza", "Limpeza da casa", []string{"pt"}},
+		{"traducción sin descripción", category, "en", "Cleaning", "Limpieza del hogar", []string{"en", "es"}},
+		{"sin traducción", category, "fr", "Limpieza", "Limpieza del hogar", []string{"es"}},
+		{"sin descripción", Category{Name: "Plomería"}, "pt", "Plomería", "", []string{"es"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			localized, languages := test.category.Localize(test.lang, "es")
+			if localized.Name != test.wantName || localized.Description != test.wantDescription {
+				t.Errorf("Localize(%q) = %q, %q, want %q, %q", test.lang, localized.Name, localized.Description, test.wantName, test.wantDescription)
+			}
+			if !reflect.DeepEqual(languages, test.wantLanguages) {
+				t.Errorf("Localize(%q) languages = %q, want %q", test.lang, languages, test.wantLanguages)
+			}
+		})
+	}
+}
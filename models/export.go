@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ExportStatusRunning = "running"
+	ExportStatusDone    = "done"
+	ExportStatusFailed  = "failed"
+)
+
+// Exportación de un listado que se genera en segundo plano
+type Export struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	Resource   string             `bson:"resource" json:"resource"`
+	Format     string             `bson:"format" json:"format"`
+	Params     string             `bson:"params,omitempty" json:"params,omitempty"`
+	Status     string             `bson:"status" json:"status"`
+	Rows       int                `bson:"rows" json:"rows"`
+	Size       int64              `bson:"size" json:"size"`
+	FileName   string             `bson:"file_name" json:"file_name"`
+	Key        string             `bson:"key" json:"-"`
+	Error      string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedBy  string             `bson:"created_by" json:"created_by"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+	FinishedAt *time.Time         `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Solicitud recibida con la cabecera Idempotency-Key y la respuesta que se repite en los reintentos
+type IdempotencyRecord struct {
+	// Clave de idempotencia, propia de cada usuario y ruta
+	Key string `bson:"_id"`
+	// Hash SHA-256 del cuerpo de la solicitud
+	RequestHash string `bson:"request_hash"`
+	// Indica si la solicitud terminó y la respuesta está guardada
+	Completed   bool      `bson:"completed"`
+	Status      int       `bson:"status,omitempty"`
+	ContentType string    `bson:"content_type,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	CreatedAt   time.Time `bson:"created_at"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
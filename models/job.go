@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	JobTypeAppointmentReminder24h = "appointment_reminder_24h"
+	JobTypeAppointmentReminder1h  = "appointment_reminder_1h"
+	JobTypeAppointmentConfirmed   = "appointment_confirmed"
+	JobTypeAppointmentCancelled   = "appointment_cancelled"
+	JobTypeAppointmentReassigned  = "appointment_reassigned"
+
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusDone      = "done"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// Tarea programada de notificación, persistida en la colección "jobs"
+type Job struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	Type           string             `bson:"type" json:"type"`
+	AppointmentID  primitive.ObjectID `bson:"appointment_id" json:"appointment_id"`
+	PreviousHelper primitive.ObjectID `bson:"previous_helper,omitempty" json:"previous_helper,omitempty"`
+	Status         string             `bson:"status" json:"status"`
+	RunAt          time.Time          `bson:"run_at" json:"run_at"`
+	Attempts       int                `bson:"attempts" json:"attempts"`
+	SentTo         []string           `bson:"sent_to,omitempty" json:"sent_to,omitempty"`
+	LastError      string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	LockedBy       string             `bson:"locked_by,omitempty" json:"locked_by,omitempty"`
+	LockedUntil    *time.Time         `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+const (
+	NotificationChannelEmail = "email"
+	NotificationChannelSMS   = "sms"
+	NotificationChannelPush  = "push"
+)
+
+// Canales por los que un usuario desea recibir avisos y horario en el que no desea ser molestado
+type NotificationPreferences struct {
+	Channels   []string    `bson:"channels" json:"channels"`
+	QuietHours *QuietHours `bson:"quiet_hours,omitempty" json:"quiet_hours,omitempty"`
+}
+
+// Horario de silencio, con horas en formato HH:MM en la zona horaria indicada
+type QuietHours struct {
+	Start    string `bson:"start" json:"start" binding:"required"`
+	End      string `bson:"end" json:"end" binding:"required"`
+	TimeZone string `bson:"time_zone" json:"time_zone" binding:"omitempty,timezone"`
+}
+
+// Suscripción de un navegador a las notificaciones web push
+type PushSubscription struct {
+	Endpoint  string               `bson:"endpoint" json:"endpoint"`
+	Keys      PushSubscriptionKeys `bson:"keys" json:"keys"`
+	CreatedAt time.Time            `bson:"created_at" json:"created_at"`
+}
+
+// Claves de cifrado de una suscripción web push, en base64 url
+type PushSubscriptionKeys struct {
+	P256dh string `bson:"p256dh" json:"p256dh" binding:"required"`
+	Auth   string `bson:"auth" json:"auth" binding:"required"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	EmailStatusQueued  = "queued"
+	EmailStatusSending = "sending"
+	EmailStatusSent    = "sent"
+	EmailStatusDead    = "dead"
+)
+
+// Correo electrónico pendiente o enviado, persistido en la colección "email_outbox"
+type OutboundEmail struct {
+	ID            primitive.ObjectID     `bson:"_id,omitempty" json:"_id,omitempty"`
+	Sender        string                 `bson:"sender" json:"sender"`
+	Recipient     string                 `bson:"recipient" json:"recipient"`
+	Subject       string                 `bson:"subject" json:"subject"`
+	Message       string                 `bson:"message" json:"message"`
+	HTMLMessage   string                 `bson:"html_message,omitempty" json:"html_message,omitempty"`
+	Status        string                 `bson:"status" json:"status"`
+	Attempts      int                    `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time              `bson:"next_attempt_at" json:"next_attempt_at"`
+	Deliveries    []EmailDeliveryAttempt `bson:"deliveries,omitempty" json:"deliveries,omitempty"`
+	LockedBy      string                 `bson:"locked_by,omitempty" json:"-"`
+	LockedUntil   *time.Time             `bson:"locked_until,omitempty" json:"-"`
+	SentAt        *time.Time             `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+	CreatedAt     time.Time              `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time              `bson:"updated_at" json:"updated_at"`
+}
+
+// Resultado de un intento de entrega de un correo electrónico
+type EmailDeliveryAttempt struct {
+	At        time.Time `bson:"at" json:"at"`
+	Error     string    `bson:"error,omitempty" json:"error,omitempty"`
+	Permanent bool      `bson:"permanent,omitempty" json:"permanent,omitempty"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ReviewRoleRequester = "requester"
+	ReviewRoleHelper    = "helper"
+
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+type Review struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	AppointmentID  primitive.ObjectID `bson:"appointment_id" json:"appointment_id"`
+	AuthorID       primitive.ObjectID `bson:"author_id" json:"author_id"`
+	AuthorRole     string             `bson:"author_role" json:"author_role"`
+	SubjectID      primitive.ObjectID `bson:"subject_id" json:"subject_id"`
+	Score          int                `bson:"score" json:"score"`
+	Comment        string             `bson:"comment" json:"comment"`
+	Status         string             `bson:"status" json:"status"`
+	ModerationNote string             `bson:"moderation_note,omitempty" json:"moderation_note,omitempty"`
+	ModeratedBy    string             `bson:"moderated_by,omitempty" json:"moderated_by,omitempty"`
+	ModeratedAt    *time.Time         `bson:"moderated_at,omitempty" json:"moderated_at,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
@@ -6,16 +6,46 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	UserTypeUser       = "user"
+	UserTypeHelper     = "helper"
+	UserTypeAdmin      = "admin"
+	UserTypeSuperadmin = "superadmin"
+)
+
+const (
+	UserStatusActive   = "active"
+	UserStatusInactive = "inactive"
+	UserStatusPending  = "pending"
+)
+
 type User struct {
-	ID                primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	FirstName         string             `bson:"first_name" json:"first_name"`
-	LastName          string             `bson:"last_name" json:"last_name"`
-	Email             string             `bson:"email" json:"email"`
-	Password          string             `bson:"password" json:"password,omitempty"`
-	Type              string             `bson:"type" json:"type"`
-	Status            string             `bson:"status" json:"status"`
-	ProfileImage      string             `bson:"profile_image,omitempty" json:"profile_image,omitempty"`
-	PasswordChangedAt time.Time          `bson:"password_changed_at,omitempty" json:"password_changed_at,omitempty"`
-	CreatedAt         time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt         time.Time          `bson:"updated_at" json:"updated_at"`
+	ID                primitive.ObjectID   `bson:"_id,omitempty" json:"_id,omitempty"`
+	FirstName         string               `bson:"first_name" json:"first_name"`
+	LastName          string               `bson:"last_name" json:"last_name"`
+	Email             string               `bson:"email" json:"email"`
+	Password          string               `bson:"password" json:"password,omitempty"`
+	Type              string               `bson:"type" json:"type"`
+	Status            string               `bson:"status" json:"status"`
+	Phone             string               `bson:"phone,omitempty" json:"phone,omitempty"`
+	ProfileImage      string               `bson:"profile_image,omitempty" json:"profile_image,omitempty"`
+	ProfileThumbnails map[string]string    `bson:"profile_thumbnails,omitempty" json:"profile_thumbnails,omitempty"`
+	ProfileImageKeys  []string             `bson:"profile_image_keys,omitempty" json:"-"`
+	Language          string               `bson:"language,omitempty" json:"language,omitempty"`
+	Skills            []primitive.ObjectID `bson:"skills,omitempty" json:"skills,omitempty"`
+	Rating            *UserRating          `bson:"rating,omitempty" json:"rating,omitempty"`
+	PasswordChangedAt time.Time            `bson:"password_changed_at,omitempty" json:"password_changed_at,omitempty"`
+	CreatedAt         time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt         time.Time            `bson:"updated_at" json:"updated_at"`
+	// Aumenta con cada modificación; se usa como ETag para detectar ediciones simultáneas
+	Version int64 `bson:"version" json:"version"`
+
+	NotificationPreferences *NotificationPreferences `bson:"notification_preferences,omitempty" json:"notification_preferences,omitempty"`
+	PushSubscriptions       []PushSubscription       `bson:"push_subscriptions,omitempty" json:"push_subscriptions,omitempty"`
+}
+
+// Promedio de las reseñas aprobadas que recibió un ayudante
+type UserRating struct {
+	Average float64 `bson:"average" json:"average"`
+	Count   int     `bson:"count" json:"count"`
 }
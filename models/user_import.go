@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	UserImportStatusRunning = "running"
+	UserImportStatusDone    = "done"
+	UserImportStatusFailed  = "failed"
+)
+
+// Importación masiva de usuarios desde un archivo CSV o XLSX
+type UserImport struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	FileName   string             `bson:"file_name" json:"file_name"`
+	Status     string             `bson:"status" json:"status"`
+	Total      int                `bson:"total" json:"total"`
+	Processed  int                `bson:"processed" json:"processed"`
+	Created    int                `bson:"created" json:"created"`
+	Failed     int                `bson:"failed" json:"failed"`
+	Errors     []ImportRowError   `bson:"errors,omitempty" json:"errors,omitempty"`
+	CreatedBy  string             `bson:"created_by" json:"created_by"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+	FinishedAt *time.Time         `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
+
+// Error de validación o de creación de una fila importada
+type ImportRowError struct {
+	Row     int    `bson:"row" json:"row"`
+	Field   string `bson:"field,omitempty" json:"field,omitempty"`
+	Message string `bson:"message" json:"message"`
+}
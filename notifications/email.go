@@ -0,0 +1,45 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+)
+
+// Canal de avisos por correo electrónico
+type EmailChannel struct {
+	emailService utils.IEmailService
+	sender       string
+}
+
+func (channel *EmailChannel) Name() string {
+	return models.NotificationChannelEmail
+}
+
+/** Envía el aviso al correo electrónico del usuario
+ *
+ * @param ctx context.Context "El contexto del envío"
+ * @param user models.User "El destinatario"
+ * @param notification Notification "El aviso"
+ * @return err error "El error del envío"
+ */
+func (channel *EmailChannel) Send(ctx context.Context, user models.User, notification Notification) (err error) {
+	if user.Email == "" {
+		return ErrNoAddress
+	}
+
+	_, err = channel.emailService.SendEmail(utils.SendEmailRequest{
+		Sender:      channel.sender,
+		Recipient:   user.Email,
+		Subject:     notification.Subject,
+		Message:     notification.Text,
+		HTMLMessage: notification.HTML,
+	})
+
+	return
+}
+
+func NewEmailChannel(emailService utils.IEmailService, sender string) IChannel {
+	return &EmailChannel{emailService: emailService, sender: sender}
+}
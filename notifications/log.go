@@ -0,0 +1,58 @@
+package notifications
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+)
+
+// Aviso registrado por un canal de registro
+type SentNotification struct {
+	User         models.User
+	Notification Notification
+}
+
+// Canal que sólo registra los avisos, para desarrollo local y pruebas
+type LogChannel struct {
+	name string
+
+	mu   sync.Mutex
+	sent []SentNotification
+}
+
+func (channel *LogChannel) Name() string {
+	return channel.name
+}
+
+/** Registra el aviso en el log y en memoria
+ *
+ * @param ctx context.Context "El contexto del envío"
+ * @param user models.User "El destinatario"
+ * @param notification Notification "El aviso"
+ * @return err error "Siempre nil"
+ */
+func (channel *LogChannel) Send(ctx context.Context, user models.User, notification Notification) (err error) {
+	log.Printf("[%s] Aviso para %s (%s): %s", channel.name, user.Email, user.ID.Hex(), notification.Subject)
+
+	channel.mu.Lock()
+	defer channel.mu.Unlock()
+
+	channel.sent = append(channel.sent, SentNotification{User: user, Notification: notification})
+	return nil
+}
+
+// Devuelve una copia de los avisos registrados
+func (channel *LogChannel) Sent() []SentNotification {
+	channel.mu.Lock()
+	defer channel.mu.Unlock()
+
+	sent := make([]SentNotification, len(channel.sent))
+	copy(sent, channel.sent)
+	return sent
+}
+
+func NewLogChannel(name string) *LogChannel {
+	return &LogChannel{name: name}
+}
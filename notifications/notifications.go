@@ -0,0 +1,189 @@
+// Package notifications envía avisos a los usuarios por los canales que prefieren
+// (correo electrónico, SMS o web push), respetando su horario de silencio.
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+)
+
+// Indica que el usuario no tiene una dirección para el canal, por ejemplo un teléfono para SMS
+var ErrNoAddress = errors.New("el usuario no tiene dirección para este canal")
+
+// Canales intrusivos, que no se usan durante el horario de silencio salvo en avisos urgentes
+var intrusiveChannels = map[string]bool{
+	models.NotificationChannelSMS:  true,
+	models.NotificationChannelPush: true,
+}
+
+// Aviso a enviar; SMS usa sólo el asunto y push el asunto y el texto
+type Notification struct {
+	Subject string
+	Text    string
+	HTML    string
+	// Los avisos urgentes se envían aunque el usuario esté en horario de silencio
+	Urgent bool
+}
+
+// Canal de envío de avisos
+type IChannel interface {
+	Name() string
+	Send(ctx context.Context, user models.User, notification Notification) (err error)
+}
+
+// Resultado del envío de un aviso por cada canal
+type DeliveryResult struct {
+	Delivered []string          `json:"delivered"`
+	Skipped   []string          `json:"skipped"`
+	Failed    map[string]string `json:"failed,omitempty"`
+}
+
+type IDispatcher interface {
+	Notify(ctx context.Context, user models.User, notification Notification) (result DeliveryResult, err error)
+}
+
+type Dispatcher struct {
+	channels map[string]IChannel
+	now      func() time.Time
+}
+
+/** Envía un aviso a un usuario por los canales que prefiere
+ *
+ * Si el usuario no tiene preferencias se usa el correo electrónico. Durante el
+ * horario de silencio se omiten los canales intrusivos, salvo en avisos urgentes,
+ * y si no queda ninguno se envía por correo electrónico.
+ *
+ * @param ctx context.Context "El contexto del envío"
+ * @param user models.User "El destinatario"
+ * @param notification Notification "El aviso"
+ * @return result DeliveryResult "Los canales usados, omitidos y con error"
+ * @return err error "Error si el aviso no se pudo entregar por ningún canal"
+ */
+func (dispatcher *Dispatcher) Notify(ctx context.Context, user models.User, notification Notification) (result DeliveryResult, err error) {
+	channels := []string{models.NotificationChannelEmail}
+	if user.NotificationPreferences != nil && len(user.NotificationPreferences.Channels) > 0 {
+		channels = user.NotificationPreferences.Channels
+	}
+
+	if !notification.Urgent && user.NotificationPreferences != nil && InQuietHours(user.NotificationPreferences.QuietHours, dispatcher.now()) {
+		var allowed []string
+		for _, name := range channels {
+			if intrusiveChannels[name] {
+				result.Skipped = append(result.Skipped, name)
+				continue
+			}
+			allowed = append(allowed, name)
+		}
+
+		if len(allowed) == 0 {
+			allowed = []string{models.NotificationChannelEmail}
+		}
+		channels = allowed
+	}
+
+	for _, name := range channels {
+		channel, ok := dispatcher.channels[name]
+		if !ok {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+
+		sendErr := channel.Send(ctx, user, notification)
+		if sendErr == ErrNoAddress {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+		if sendErr != nil {
+			log.Printf("Error al enviar el aviso por %s al usuario %s: %v", name, user.ID.Hex(), sendErr)
+			if result.Failed == nil {
+				result.Failed = map[string]string{}
+			}
+			result.Failed[name] = sendErr.Error()
+			continue
+		}
+
+		result.Delivered = append(result.Delivered, name)
+	}
+
+	if len(result.Delivered) == 0 && len(result.Failed) > 0 {
+		err = fmt.Errorf("no se pudo enviar el aviso por ningún canal: %v", result.Failed)
+	}
+
+	return
+}
+
+/** Indica si un momento está dentro de un horario de silencio
+ *
+ * El horario puede cruzar la medianoche, por ejemplo de 22:00 a 08:00.
+ *
+ * @param quietHours *models.QuietHours "El horario de silencio"
+ * @param now time.Time "El momento a evaluar"
+ * @return bool "Si el momento está dentro del horario"
+ */
+func InQuietHours(quietHours *models.QuietHours, now time.Time) bool {
+	if quietHours == nil {
+		return false
+	}
+
+	start, err := ParseClock(quietHours.Start)
+	if err != nil {
+		return false
+	}
+
+	end, err := ParseClock(quietHours.End)
+	if err != nil || start == end {
+		return false
+	}
+
+	if location, err := time.LoadLocation(quietHours.TimeZone); err == nil {
+		now = now.In(location)
+	}
+
+	minutes := now.Hour()*60 + now.Minute()
+	if start < end {
+		return minutes >= start && minutes < end
+	}
+
+	return minutes >= start || minutes < end
+}
+
+/** Convierte una hora en formato HH:MM en minutos desde la medianoche
+ *
+ * @param clock string "La hora"
+ * @return int "Los minutos desde la medianoche"
+ * @return error "Error si la hora es inválida"
+ */
+func ParseClock(clock string) (int, error) {
+	parts := strings.Split(clock, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("la hora %q debe tener el formato HH:MM", clock)
+	}
+
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil || hours < 0 || hours > 23 {
+		return 0, fmt.Errorf("la hora %q debe tener el formato HH:MM", clock)
+	}
+
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil || minutes < 0 || minutes > 59 {
+		return 0, fmt.Errorf("la hora %q debe tener el formato HH:MM", clock)
+	}
+
+	return hours*60 + minutes, nil
+}
+
+func NewDispatcher(channels ...IChannel) IDispatcher {
+	dispatcher := &Dispatcher{channels: map[string]IChannel{}, now: time.Now}
+	for _, channel := range channels {
+		dispatcher.channels[channel.Name()] = channel
+	}
+
+	return dispatcher
+}
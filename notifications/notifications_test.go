@@ -0,0 +1,184 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+)
+
+// Canal de prueba que registra los envíos y devuelve el error indicado
+type fakeChannel struct {
+	name string
+	err  error
+	sent int
+}
+
+func (channel *fakeChannel) Name() string {
+	return channel.name
+}
+
+func (channel *fakeChannel) Send(ctx context.Context, user models.User, notification Notification) error {
+	if channel.err != nil {
+		return channel.err
+	}
+
+	channel.sent++
+	return nil
+}
+
+func TestInQuietHours(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 10, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		quietHours *models.QuietHours
+		now        time.Time
+		want       bool
+	}{
+		{"sin horario", nil, at(23, 0), false},
+		{"dentro del horario", &models.QuietHours{Start: "13:00", End: "15:00"}, at(14, 0), true},
+		{"al terminar el horario", &models.QuietHours{Start: "13:00", End: "15:00"}, at(15, 0), false},
+		{"antes de medianoche", &models.QuietHours{Start: "22:00", End: "08:00"}, at(23, 30), true},
+		{"después de medianoche", &models.QuietHours{Start: "22:00", End: "08:00"}, at(7, 59), true},
+		{"fuera del horario nocturno", &models.QuietHours{Start: "22:00", End: "08:00"}, at(12, 0), false},
+		// 02:00 UTC son las 23:00 en Montevideo (UTC-3)
+		{"en la zona horaria del usuario", &models.QuietHours{Start: "22:00", End: "08:00", TimeZone: "America/Montevideo"}, at(2, 0), true},
+		// 23:00 UTC son las 20:00 en Montevideo
+		{"fuera del horario en la zona del usuario", &models.QuietHours{Start: "22:00", End: "08:00", TimeZone: "America/Montevideo"}, at(23, 0), false},
+		{"inicio igual al fin", &models.QuietHours{Start: "08:00", End: "08:00"}, at(8, 0), false},
+		{"hora inválida", &models.QuietHours{Start: "25:00", End: "08:00"}, at(2, 0), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := InQuietHours(test.quietHours, test.now); got != test.want {
+				t.Errorf("InQuietHours(%+v, %v) = %t, want %t", test.quietHours, test.now, got, test.want)
+			}
+		})
+	}
+}
+
+func TestDispatcherNotify(t *testing.T) {
+	// 03:00 en Montevideo, dentro del horario de silencio
+	night := time.Date(2026, 3, 10, 6, 0, 0, 0, time.UTC)
+	// 15:00 en Montevideo
+	day := time.Date(2026, 3, 10, 18, 0, 0, 0, time.UTC)
+	quietHours := &models.QuietHours{Start: "22:00", End: "08:00", TimeZone: "America/Montevideo"}
+
+	tests := []struct {
+		name          string
+		preferences   *models.NotificationPreferences
+		now           time.Time
+		urgent        bool
+		failing       map[string]error
+		wantDelivered []string
+		wantSkipped   []string
+		wantFailed    []string
+		wantErr       bool
+	}{
+		{
+			name:          "sin preferencias usa el correo",
+			now:           day,
+			wantDelivered: []string{"email"},
+		},
+		{
+			name:          "canales preferidos",
+			preferences:   &models.NotificationPreferences{Channels: []string{"sms", "push"}, QuietHours: quietHours},
+			now:           day,
+			wantDelivered: []string{"sms", "push"},
+		},
+		{
+			name:          "horario de silencio omite los canales intrusivos",
+			preferences:   &models.NotificationPreferences{Channels: []string{"push", "email"}, QuietHours: quietHours},
+			now:           night,
+			wantDelivered: []string{"email"},
+			wantSkipped:   []string{"push"},
+		},
+		{
+			name:          "horario de silencio sin canales permitidos usa el correo",
+			preferences:   &models.NotificationPreferences{Channels: []string{"sms", "push"}, QuietHours: quietHours},
+			now:           night,
+			wantDelivered: []string{"email"},
+			wantSkipped:   []string{"sms", "push"},
+		},
+		{
+			name:          "los avisos urgentes ignoran el horario de silencio",
+			preferences:   &models.NotificationPreferences{Channels: []string{"sms", "push"}, QuietHours: quietHours},
+			now:           night,
+			urgent:        true,
+			wantDelivered: []string{"sms", "push"},
+		},
+		{
+			name:          "sin dirección se omite el canal",
+			preferences:   &models.NotificationPreferences{Channels: []string{"sms", "email"}},
+			now:           day,
+			failing:       map[string]error{"sms": ErrNoAddress},
+			wantDelivered: []string{"email"},
+			wantSkipped:   []string{"sms"},
+		},
+		{
+			name:          "un canal con error no impide los demás",
+			preferences:   &models.NotificationPreferences{Channels: []string{"push", "email"}},
+			now:           day,
+			failing:       map[string]error{"push": errors.New("suscripción vencida")},
+			wantDelivered: []string{"email"},
+			wantFailed:    []string{"push"},
+		},
+		{
+			name:        "error si ningún canal entregó el aviso",
+			preferences: &models.NotificationPreferences{Channels: []string{"sms", "push"}},
+			now:         day,
+			failing:     map[string]error{"sms": ErrNoAddress, "push": errors.New("suscripción vencida")},
+			wantSkipped: []string{"sms"},
+			wantFailed:  []string{"push"},
+			wantErr:     true,
+		},
+		{
+			name:        "sin dirección en ningún canal no es un error",
+			preferences: &models.NotificationPreferences{Channels: []string{"sms"}},
+			now:         day,
+			failing:     map[string]error{"sms": ErrNoAddress},
+			wantSkipped: []string{"sms"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			channels := map[string]*fakeChannel{}
+			var list []IChannel
+			for _, name := range []string{models.NotificationChannelEmail, models.NotificationChannelSMS, models.NotificationChannelPush} {
+				channels[name] = &fakeChannel{name: name, err: test.failing[name]}
+				list = append(list, channels[name])
+			}
+
+			dispatcher := NewDispatcher(list...).(*Dispatcher)
+			dispatcher.now = func() time.Time { return test.now }
+
+			user := models.User{Email: "ana@ayudapp.test", NotificationPreferences: test.preferences}
+			result, err := dispatcher.Notify(context.Background(), user, Notification{Subject: "Aviso", Urgent: test.urgent})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Notify() error = %v, want error %t", err, test.wantErr)
+			}
+
+			var failed []string
+			for name := range result.Failed {
+				failed = append(failed, name)
+			}
+			if !reflect.DeepEqual(result.Delivered, test.wantDelivered) || !reflect.DeepEqual(result.Skipped, test.wantSkipped) || !reflect.DeepEqual(failed, test.wantFailed) {
+				t.Errorf("Notify() = %+v, want entregado %q, omitido %q, con error %q", result, test.wantDelivered, test.wantSkipped, test.wantFailed)
+			}
+
+			for _, name := range test.wantDelivered {
+				if channels[name].sent != 1 {
+					t.Errorf("el canal %s envió %d avisos, want 1", name, channels[name].sent)
+				}
+			}
+		})
+	}
+}
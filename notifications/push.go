@@ -0,0 +1,440 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// Tiempo que el servicio de push conserva un aviso si el navegador no está conectado
+	pushTTL = 24 * time.Hour
+	// Tamaño de registro de aes128gcm, el contenido cifrado siempre ocupa un único registro
+	pushRecordSize = 4096
+	// Tamaño máximo del texto de un aviso push antes de cifrarlo
+	pushMaxBodyLength = 2048
+)
+
+// Indica que la suscripción ya no es válida y debe eliminarse
+var ErrPushSubscriptionExpired = errors.New("la suscripción push expiró")
+
+// Canal de avisos web push, con autenticación VAPID (RFC 8292) y cifrado aes128gcm (RFC 8291)
+type WebPushChannel struct {
+	privateKey *ecdsa.PrivateKey
+	publicKey  string
+	subject    string
+	client     *http.Client
+	onExpired  func(user models.User, endpoint string)
+}
+
+func (channel *WebPushChannel) Name() string {
+	return models.NotificationChannelPush
+}
+
+/** Envía el aviso a todas las suscripciones push del usuario
+ *
+ * Las suscripciones que el servicio de push informa como inexistentes se notifican
+ * para eliminarlas. El envío se considera exitoso si llega a alguna suscripción.
+ *
+ * @param ctx context.Context "El contexto del envío"
+ * @param user models.User "El destinatario"
+ * @param notification Notification "El aviso"
+ * @return err error "El error del envío"
+ */
+func (channel *WebPushChannel) Send(ctx context.Context, user models.User, notification Notification) (err error) {
+	if len(user.PushSubscriptions) == 0 {
+		return ErrNoAddress
+	}
+
+	body := truncateUTF8(notification.Text, pushMaxBodyLength)
+
+	payload, err := json.Marshal(map[string]string{
+		"title": notification.Subject,
+		"body":  body,
+	})
+	if err != nil {
+		return
+	}
+
+	delivered := false
+	for _, subscription := range user.PushSubscriptions {
+		sendErr := channel.push(ctx, subscription, payload, notification.Urgent)
+		if sendErr == ErrPushSubscriptionExpired {
+			if channel.onExpired != nil {
+				channel.onExpired(user, subscription.Endpoint)
+			}
+			continue
+		}
+		if sendErr != nil {
+			err = sendErr
+			continue
+		}
+
+		delivered = true
+	}
+
+	if delivered {
+		return nil
+	}
+	if err == nil {
+		return ErrNoAddress
+	}
+
+	return
+}
+
+// Devuelve la clave pública VAPID que los navegadores necesitan para suscribirse
+func (channel *WebPushChannel) PublicKey() string {
+	return channel.publicKey
+}
+
+/** Cifra y envía un aviso a una suscripción
+ *
+ * @param ctx context.Context "El contexto del envío"
+ * @param subscription models.PushSubscription "La suscripción"
+ * @param payload []byte "El contenido del aviso"
+ * @param urgent bool "Si el aviso es urgente"
+ * @return error "El error del envío"
+ */
+func (channel *WebPushChannel) push(ctx context.Context, subscription models.PushSubscription, payload []byte, urgent bool) error {
+	body, err := encryptPushPayload(subscription.Keys, payload)
+	if err != nil {
+		return err
+	}
+
+	authorization, err := channel.vapidAuthorization(subscription.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	urgency := "normal"
+	if urgent {
+		urgency = "high"
+	}
+
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(pushTTL.Seconds())))
+	req.Header.Set("Urgency", urgency)
+
+	resp, err := channel.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrPushSubscriptionExpired
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("el servicio de push respondió %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	return nil
+}
+
+/** Genera la cabecera Authorization VAPID para el origen de una suscripción
+ *
+ * @param endpoint string "La URL de la suscripción"
+ * @return string "La cabecera"
+ * @return error "El error al firmar el token"
+ */
+func (channel *WebPushChannel) vapidAuthorization(endpoint string) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{
+		"aud": parsed.Scheme + "://" + parsed.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": channel.subject,
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(channel.privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("vapid t=%s, k=%s", signed, channel.publicKey), nil
+}
+
+/** Cifra el contenido de un aviso para una suscripción según RFC 8291
+ *
+ * @param keys models.PushSubscriptionKeys "Las claves de la suscripción"
+ * @param payload []byte "El contenido del aviso"
+ * @return []byte "El contenido cifrado con su cabecera aes128gcm"
+ * @return error "El error de cifrado"
+ */
+func encryptPushPayload(keys models.PushSubscriptionKeys, payload []byte) ([]byte, error) {
+	serverPrivate, _, _, err := elliptic.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err = rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return encryptPushRecord(keys, payload, serverPrivate, salt)
+}
+
+/** Cifra el contenido de un aviso con la clave efímera y la sal indicadas
+ *
+ * @param keys models.PushSubscriptionKeys "Las claves de la suscripción"
+ * @param payload []byte "El contenido del aviso"
+ * @param serverPrivate []byte "La clave privada efímera P-256 del servidor"
+ * @param salt []byte "La sal aleatoria de 16 bytes"
+ * @return []byte "El contenido cifrado con su cabecera aes128gcm"
+ * @return error "El error de cifrado"
+ */
+func encryptPushRecord(keys models.PushSubscriptionKeys, payload []byte, serverPrivate []byte, salt []byte) ([]byte, error) {
+	curve := elliptic.P256()
+
+	userPublic, err := decodeBase64URL(keys.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("la clave p256dh es inválida: %v", err)
+	}
+
+	authSecret, err := decodeBase64URL(keys.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("la clave auth es inválida: %v", err)
+	}
+
+	userX, userY := elliptic.Unmarshal(curve, userPublic)
+	if userX == nil {
+		return nil, errors.New("la clave p256dh no es un punto P-256 válido")
+	}
+
+	serverX, serverY := curve.ScalarBaseMult(serverPrivate)
+	serverPublic := elliptic.Marshal(curve, serverX, serverY)
+
+	sharedX, _ := curve.ScalarMult(userX, userY, serverPrivate)
+	sharedSecret := make([]byte, 32)
+	sharedX.FillBytes(sharedSecret)
+
+	keyInfo := append([]byte("WebPush: info\x00"), userPublic...)
+	keyInfo = append(keyInfo, serverPublic...)
+	ikm, err := hkdfExpand(hkdf.Extract(sha256.New, sharedSecret, authSecret), keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	prk := hkdf.Extract(sha256.New, ikm, salt)
+	contentKey, err := hkdfExpand(prk, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := hkdfExpand(prk, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Un único registro, terminado con el delimitador de último registro
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	if len(plaintext)+gcm.Overhead() > pushRecordSize {
+		return nil, errors.New("el aviso push es demasiado grande")
+	}
+
+	header := make([]byte, 16+4+1, 16+4+1+len(serverPublic))
+	copy(header, salt)
+	binary.BigEndian.PutUint32(header[16:], pushRecordSize)
+	header[20] = byte(len(serverPublic))
+	header = append(header, serverPublic...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+/** Valida que el endpoint de una suscripción sea una URL https de un host público
+ *
+ * Los endpoints los indica el cliente y el servidor les envía solicitudes, por lo que
+ * se rechazan los que apuntan a la red interna. Los nombres que resuelven a
+ * direcciones internas se rechazan al conectar.
+ *
+ * @param endpoint string "La URL de la suscripción"
+ * @return error "El motivo por el que el endpoint es inválido"
+ */
+func ValidatePushEndpoint(endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Hostname() == "" {
+		return errors.New("el endpoint debe ser una URL absoluta")
+	}
+
+	if parsed.Scheme != "https" {
+		return errors.New("el endpoint debe usar https")
+	}
+
+	if parsed.User != nil {
+		return errors.New("el endpoint no puede incluir credenciales")
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("el endpoint no puede apuntar a una dirección interna")
+	}
+
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return errors.New("el endpoint no puede apuntar a una dirección interna")
+	}
+
+	return nil
+}
+
+// Rangos reservados que no cubren los métodos de net.IP: 0.0.0.0/8 y el espacio compartido de RFC 6598
+var reservedNetworks = []*net.IPNet{
+	{IP: net.IPv4(0, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+	{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)},
+}
+
+// Indica si una dirección IP es pública, es decir, no es interna, local ni de multicast
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+/** Crea el cliente HTTP de los envíos push, que sólo se conecta a direcciones públicas
+ *
+ * La dirección se verifica después de resolver el nombre, lo que también cubre los
+ * nombres que cambian de dirección entre la validación y el envío. Las redirecciones
+ * no se siguen.
+ *
+ * @return *http.Client "El cliente"
+ */
+func newPushClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("el endpoint push resuelve a una dirección interna: %s", host)
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Recorta un texto a un máximo de bytes sin partir un carácter UTF-8
+func truncateUTF8(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+
+	return text[:limit]
+}
+
+func hkdfExpand(prk []byte, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, info), out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func decodeBase64URL(value string) ([]byte, error) {
+	value = strings.TrimRight(value, "=")
+	return base64.RawURLEncoding.DecodeString(value)
+}
+
+/** Crea el canal web push a partir de la clave privada VAPID
+ *
+ * @param privateKey string "La clave privada VAPID P-256, en base64 url"
+ * @param subject string "El contacto del remitente, mailto: o https:"
+ * @param onExpired func(models.User, string) "Se llama con cada suscripción expirada"
+ * @return *WebPushChannel "El canal"
+ * @return error "Error si la clave es inválida"
+ */
+func NewWebPushChannel(privateKey string, subject string, onExpired func(user models.User, endpoint string)) (*WebPushChannel, error) {
+	curve := elliptic.P256()
+
+	d, err := decodeBase64URL(privateKey)
+	if err != nil || len(d) != 32 {
+		return nil, errors.New("la clave privada VAPID es inválida")
+	}
+
+	key := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(d)}
+	key.PublicKey.Curve = curve
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d)
+
+	return &WebPushChannel{
+		privateKey: key,
+		publicKey:  base64.RawURLEncoding.EncodeToString(elliptic.Marshal(curve, key.PublicKey.X, key.PublicKey.Y)),
+		subject:    subject,
+		client:     newPushClient(),
+		onExpired:  onExpired,
+	}, nil
+}
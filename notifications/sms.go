@@ -0,0 +1,106 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+)
+
+// Proveedor de envío de SMS
+type ISMSProvider interface {
+	SendSMS(ctx context.Context, phone string, message string) (err error)
+}
+
+// Proveedor de SMS genérico que recibe los mensajes por HTTP
+type HTTPSMSProvider struct {
+	url    string
+	token  string
+	sender string
+	client *http.Client
+}
+
+/** Envía un SMS con un POST JSON {"from", "to", "message"} a la URL del proveedor
+ *
+ * El token, si está configurado, se envía en la cabecera Authorization como Bearer.
+ *
+ * @param ctx context.Context "El contexto del envío"
+ * @param phone string "El teléfono del destinatario"
+ * @param message string "El mensaje"
+ * @return err error "Error si el proveedor no acepta el mensaje"
+ */
+func (provider *HTTPSMSProvider) SendSMS(ctx context.Context, phone string, message string) (err error) {
+	body, err := json.Marshal(map[string]string{
+		"from":    provider.sender,
+		"to":      phone,
+		"message": message,
+	})
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.url, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if provider.token != "" {
+		req.Header.Set("Authorization", "Bearer "+provider.token)
+	}
+
+	resp, err := provider.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("el proveedor de SMS respondió %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	return nil
+}
+
+func NewHTTPSMSProvider(url string, token string, sender string) ISMSProvider {
+	return &HTTPSMSProvider{
+		url:    url,
+		token:  token,
+		sender: sender,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Canal de avisos por SMS
+type SMSChannel struct {
+	provider ISMSProvider
+}
+
+func (channel *SMSChannel) Name() string {
+	return models.NotificationChannelSMS
+}
+
+/** Envía el asunto del aviso por SMS al teléfono del usuario
+ *
+ * @param ctx context.Context "El contexto del envío"
+ * @param user models.User "El destinatario"
+ * @param notification Notification "El aviso"
+ * @return err error "El error del envío"
+ */
+func (channel *SMSChannel) Send(ctx context.Context, user models.User, notification Notification) (err error) {
+	if user.Phone == "" {
+		return ErrNoAddress
+	}
+
+	return channel.provider.SendSMS(ctx, user.Phone, notification.Subject)
+}
+
+func NewSMSChannel(provider ISMSProvider) IChannel {
+	return &SMSChannel{provider: provider}
+}
@@ -0,0 +1,302 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Filtros del listado y la exportación de usuarios
+type UserFilter struct {
+	Type   string `form:"type" json:"type"`
+	Status string `form:"status" json:"status"`
+	Skill  string `form:"skill" json:"skill"`
+	Search string `form:"q" json:"q"`
+}
+
+// Filtros del listado y la exportación de citas
+type AppointmentFilter struct {
+	Status    string    `form:"status" json:"status"`
+	Helper    string    `form:"helper" json:"helper"`
+	CreatedBy string    `form:"created_by" json:"created_by"`
+	Category  string    `form:"category" json:"category"`
+	From      time.Time `form:"from" json:"from" time_format:"2006-01-02"`
+	To        time.Time `form:"to" json:"to" time_format:"2006-01-02"`
+}
+
+// Filtros del listado y la exportación de categorías
+type CategoryFilter struct {
+	ParentID string `form:"parent_id" json:"parent_id"`
+	Search   string `form:"q" json:"q"`
+}
+
+// Filtros del listado de reseñas
+type ReviewFilter struct {
+	AppointmentID primitive.ObjectID
+	Status        string
+}
+
+/** Convierte los filtros de usuarios en una consulta
+ *
+ * @return query bson.M "La consulta"
+ * @return err error "Error si algún id es inválido"
+ */
+func (filter UserFilter) Query() (query bson.M, err error) {
+	query = bson.M{}
+
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.Skill != "" {
+		var skill primitive.ObjectID
+		if skill, err = primitive.ObjectIDFromHex(filter.Skill); err != nil {
+			err = errors.New("la habilidad es inválida")
+			return
+		}
+		query["skills"] = skill
+	}
+	if filter.Search != "" {
+		search := primitive.Regex{Pattern: regexp.QuoteMeta(filter.Search), Options: "i"}
+		query["$or"] = bson.A{
+			bson.M{"first_name": search},
+			bson.M{"last_name": search},
+			bson.M{"email": search},
+		}
+	}
+
+	return
+}
+
+/** Convierte los filtros de citas en una consulta
+ *
+ * La fecha final incluye todo el día indicado.
+ *
+ * @return query bson.M "La consulta"
+ * @return err error "Error si algún id es inválido"
+ */
+func (filter AppointmentFilter) Query() (query bson.M, err error) {
+	query = bson.M{}
+
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	ids := []struct {
+		field string
+		value string
+	}{
+		{"helper", filter.Helper},
+		{"created_by", filter.CreatedBy},
+		{"category", filter.Category},
+	}
+	for _, id := range ids {
+		if id.value == "" {
+			continue
+		}
+
+		var objectID primitive.ObjectID
+		if objectID, err = primitive.ObjectIDFromHex(id.value); err != nil {
+			err = errors.New("el filtro " + id.field + " debe ser un id válido")
+			return
+		}
+		query[id.field] = objectID
+	}
+
+	date := bson.M{}
+	if !filter.From.IsZero() {
+		date["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		date["$lt"] = filter.To.AddDate(0, 0, 1)
+	}
+	if len(date) > 0 {
+		query["date"] = date
+	}
+
+	return
+}
+
+/** Convierte los filtros de categorías en una consulta
+ *
+ * El valor "root" de parent_id filtra las categorías de primer nivel.
+ *
+ * @return query bson.M "La consulta"
+ * @return err error "Error si el id de la categoría padre es inválido"
+ */
+func (filter CategoryFilter) Query() (query bson.M, err error) {
+	query = bson.M{}
+
+	switch filter.ParentID {
+	case "":
+	case "root":
+		query["parent_id"] = nil
+	default:
+		var parentID primitive.ObjectID
+		if parentID, err = primitive.ObjectIDFromHex(filter.ParentID); err != nil {
+			err = errors.New("la categoría padre es inválida")
+			return
+		}
+		query["parent_id"] = parentID
+	}
+
+	if filter.Search != "" {
+		search := primitive.Regex{Pattern: regexp.QuoteMeta(filter.Search), Options: "i"}
+		query["$or"] = bson.A{
+			bson.M{"name": search},
+			bson.M{"slug": search},
+		}
+	}
+
+	return
+}
+
+/** Indica si un usuario cumple los filtros, con el mismo criterio que Query
+ *
+ * @param user models.User "El usuario"
+ * @return bool "Si el usuario cumple los filtros"
+ */
+func (filter UserFilter) Matches(user models.User) bool {
+	if filter.Type != "" && user.Type != filter.Type {
+		return false
+	}
+	if filter.Status != "" && user.Status != filter.Status {
+		return false
+	}
+	if filter.Skill != "" {
+		skill, err := primitive.ObjectIDFromHex(filter.Skill)
+		if err != nil || !containsObjectID(user.Skills, skill) {
+			return false
+		}
+	}
+	if filter.Search != "" && !containsFold(filter.Search, user.FirstName, user.LastName, user.Email) {
+		return false
+	}
+
+	return true
+}
+
+/** Indica si una cita cumple los filtros, con el mismo criterio que Query
+ *
+ * @param appointment models.Appointment "La cita"
+ * @return bool "Si la cita cumple los filtros"
+ */
+func (filter AppointmentFilter) Matches(appointment models.Appointment) bool {
+	if filter.Status != "" && appointment.Status != filter.Status {
+		return false
+	}
+
+	ids := []struct {
+		value string
+		id    primitive.ObjectID
+	}{
+		{filter.Helper, appointment.Helper},
+		{filter.CreatedBy, appointment.CreatedBy},
+		{filter.Category, appointment.Category},
+	}
+	for _, id := range ids {
+		if id.value == "" {
+			continue
+		}
+		if objectID, err := primitive.ObjectIDFromHex(id.value); err != nil || objectID != id.id {
+			return false
+		}
+	}
+
+	if !filter.From.IsZero() && appointment.Date.Before(filter.From) {
+		return false
+	}
+	if !filter.To.IsZero() && !appointment.Date.Before(filter.To.AddDate(0, 0, 1)) {
+		return false
+	}
+
+	return true
+}
+
+/** Indica si una categoría cumple los filtros, con el mismo criterio que Query
+ *
+ * @param category models.Category "La categoría"
+ * @return bool "Si la categoría cumple los filtros"
+ */
+func (filter CategoryFilter) Matches(category models.Category) bool {
+	switch filter.ParentID {
+	case "":
+	case "root":
+		if category.ParentID != nil {
+			return false
+		}
+	default:
+		parentID, err := primitive.ObjectIDFromHex(filter.ParentID)
+		if err != nil || category.ParentID == nil || *category.ParentID != parentID {
+			return false
+		}
+	}
+
+	if filter.Search != "" && !containsFold(filter.Search, category.Name, category.Slug) {
+		return false
+	}
+
+	return true
+}
+
+// Indica si alguno de los textos contiene el buscado, sin distinguir mayúsculas de minúsculas
+func containsFold(search string, values ...string) bool {
+	search = strings.ToLower(search)
+	for _, value := range values {
+		if strings.Contains(strings.ToLower(value), search) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, item := range ids {
+		if item == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+/** Convierte los filtros de reseñas en una consulta
+ *
+ * @return query bson.M "La consulta"
+ */
+func (filter ReviewFilter) Query() (query bson.M) {
+	query = bson.M{}
+
+	if !filter.AppointmentID.IsZero() {
+		query["appointment_id"] = filter.AppointmentID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	return
+}
+
+/** Indica si una reseña cumple los filtros, con el mismo criterio que Query
+ *
+ * @param review models.Review "La reseña"
+ * @return bool "Si la reseña cumple los filtros"
+ */
+func (filter ReviewFilter) Matches(review models.Review) bool {
+	if !filter.AppointmentID.IsZero() && review.AppointmentID != filter.AppointmentID {
+		return false
+	}
+	if filter.Status != "" && review.Status != filter.Status {
+		return false
+	}
+
+	return true
+}
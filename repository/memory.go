@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Repositorios guardados en memoria, para pruebas y desarrollo local
+//
+// Los documentos se copian al guardarlos y al leerlos codificándolos en BSON, de
+// modo que se comportan como los guardados en MongoDB (por ejemplo, las fechas se
+// redondean a milisegundos y se devuelven en UTC).
+type MemoryStore struct {
+	mu           sync.Mutex
+	users        map[primitive.ObjectID]models.User
+	appointments map[primitive.ObjectID]models.Appointment
+	categories   map[primitive.ObjectID]models.Category
+	sessions     map[primitive.ObjectID]models.Session
+	reviews      map[primitive.ObjectID]models.Review
+	notes        map[primitive.ObjectID]models.AppointmentNote
+	attachments  map[primitive.ObjectID]models.AppointmentAttachment
+	userImports  map[primitive.ObjectID]models.UserImport
+	exports      map[primitive.ObjectID]models.Export
+	emails       map[primitive.ObjectID]models.OutboundEmail
+	jobs         map[primitive.ObjectID]models.Job
+	// Las claves de idempotencia no se descartan al fallar una transacción
+	idempotencyKeys map[string]models.IdempotencyRecord
+}
+
+func (store *MemoryStore) Users() IUserRepository {
+	return &memoryUserRepository{store: store}
+}
+
+func (store *MemoryStore) Appointments() IAppointmentRepository {
+	return &memoryAppointmentRepository{store: store}
+}
+
+func (store *MemoryStore) Categories() ICategoryRepository {
+	return &memoryCategoryRepository{store: store}
+}
+
+func (store *MemoryStore) Sessions() ISessionRepository {
+	return &memorySessionRepository{store: store}
+}
+
+func (store *MemoryStore) IdempotencyKeys() IIdempotencyRepository {
+	return &memoryIdempotencyRepository{store: store}
+}
+
+func (store *MemoryStore) Reviews() IReviewRepository {
+	return &memoryReviewRepository{store: store}
+}
+
+func (store *MemoryStore) AppointmentNotes() IAppointmentNoteRepository {
+	return &memoryAppointmentNoteRepository{store: store}
+}
+
+func (store *MemoryStore) AppointmentAttachments() IAppointmentAttachmentRepository {
+	return &memoryAppointmentAttachmentRepository{store: store}
+}
+
+func (store *MemoryStore) UserImports() IUserImportRepository {
+	return &memoryUserImportRepository{store: store}
+}
+
+func (store *MemoryStore) Exports() IExportRepository {
+	return &memoryExportRepository{store: store}
+}
+
+func (store *MemoryStore) Emails() IEmailRepository {
+	return &memoryEmailRepository{store: store}
+}
+
+func (store *MemoryStore) Jobs() IJobRepository {
+	return &memoryJobRepository{store: store}
+}
+
+/** Ejecuta fn y descarta todos sus cambios si devuelve un error
+ *
+ * A diferencia de MongoDB, las transacciones en memoria no aíslan los cambios de
+ * otras operaciones concurrentes.
+ *
+ * @param ctx context.Context "El contexto de la operación"
+ * @param fn func(ctx context.Context) error "Las operaciones de la transacción"
+ * @return error "El error de la transacción"
+ */
+func (store *MemoryStore) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	store.mu.Lock()
+	snapshot := store.copy()
+	store.mu.Unlock()
+
+	if err := fn(ctx); err != nil {
+		store.mu.Lock()
+		store.restore(snapshot)
+		store.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// Copia los mapas de documentos, salvo las claves de idempotencia; se debe llamar con el mutex tomado
+func (store *MemoryStore) copy() *MemoryStore {
+	return &MemoryStore{
+		users:        copyMap(store.users),
+		appointments: copyMap(store.appointments),
+		categories:   copyMap(store.categories),
+		sessions:     copyMap(store.sessions),
+		reviews:      copyMap(store.reviews),
+		notes:        copyMap(store.notes),
+		attachments:  copyMap(store.attachments),
+		userImports:  copyMap(store.userImports),
+		exports:      copyMap(store.exports),
+		emails:       copyMap(store.emails),
+		jobs:         copyMap(store.jobs),
+	}
+}
+
+// Vuelve a los documentos de una copia; se debe llamar con el mutex tomado
+func (store *MemoryStore) restore(snapshot *MemoryStore) {
+	store.users, store.appointments, store.categories, store.sessions = snapshot.users, snapshot.appointments, snapshot.categories, snapshot.sessions
+	store.reviews, store.notes, store.attachments = snapshot.reviews, snapshot.notes, snapshot.attachments
+	store.userImports, store.exports, store.emails, store.jobs = snapshot.userImports, snapshot.exports, snapshot.emails, snapshot.jobs
+}
+
+// Copia un documento codificándolo en BSON, para que no comparta slices ni mapas con el original
+func clone(in interface{}, out interface{}) error {
+	data, err := bson.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	return bson.Unmarshal(data, out)
+}
+
+// Copia el mapa de documentos; los documentos no se modifican una vez guardados
+func copyMap[T any](documents map[primitive.ObjectID]T) map[primitive.ObjectID]T {
+	result := make(map[primitive.ObjectID]T, len(documents))
+	for id, document := range documents {
+		result[id] = document
+	}
+
+	return result
+}
+
+// Convierte los conteos por valor en una lista de mayor a menor, como $group y $sort en MongoDB
+func sortedCounts(counts map[string]int) []Count {
+	result := make([]Count, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, Count{Key: key, Count: count})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Count > result[j].Count || (result[i].Count == result[j].Count && result[i].Key < result[j].Key)
+	})
+	return result
+}
+
+// Compara dos ids, que se ordenan por fecha de creación
+func lessObjectID(a, b primitive.ObjectID) bool {
+	return bytes.Compare(a[:], b[:]) < 0
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:           map[primitive.ObjectID]models.User{},
+		appointments:    map[primitive.ObjectID]models.Appointment{},
+		categories:      map[primitive.ObjectID]models.Category{},
+		sessions:        map[primitive.ObjectID]models.Session{},
+		reviews:         map[primitive.ObjectID]models.Review{},
+		notes:           map[primitive.ObjectID]models.AppointmentNote{},
+		attachments:     map[primitive.ObjectID]models.AppointmentAttachment{},
+		userImports:     map[primitive.ObjectID]models.UserImport{},
+		exports:         map[primitive.ObjectID]models.Export{},
+		emails:          map[primitive.ObjectID]models.OutboundEmail{},
+		jobs:            map[primitive.ObjectID]models.Job{},
+		idempotencyKeys: map[string]models.IdempotencyRecord{},
+	}
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryAppointmentAttachmentRepository struct {
+	store *MemoryStore
+}
+
+func (repository *memoryAppointmentAttachmentRepository) Find(ctx context.Context, appointmentID primitive.ObjectID) (attachments []models.AppointmentAttachment, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, stored := range repository.store.attachments {
+		if stored.AppointmentID != appointmentID {
+			continue
+		}
+
+		var attachment models.AppointmentAttachment
+		if err = clone(stored, &attachment); err != nil {
+			return
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	sort.Slice(attachments, func(i, j int) bool {
+		if !attachments[i].CreatedAt.Equal(attachments[j].CreatedAt) {
+			return attachments[i].CreatedAt.Before(attachments[j].CreatedAt)
+		}
+		return lessObjectID(attachments[i].ID, attachments[j].ID)
+	})
+	return
+}
+
+func (repository *memoryAppointmentAttachmentRepository) FindByID(ctx context.Context, appointmentID primitive.ObjectID, id primitive.ObjectID) (attachment models.AppointmentAttachment, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.attachments[id]
+	if !ok || stored.AppointmentID != appointmentID {
+		return attachment, ErrNotFound
+	}
+
+	err = clone(stored, &attachment)
+	return
+}
+
+func (repository *memoryAppointmentAttachmentRepository) Insert(ctx context.Context, attachment *models.AppointmentAttachment) (err error) {
+	if attachment.ID.IsZero() {
+		attachment.ID = primitive.NewObjectID()
+	}
+
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if _, exists := repository.store.attachments[attachment.ID]; exists {
+		return ErrDuplicate
+	}
+
+	var stored models.AppointmentAttachment
+	if err = clone(attachment, &stored); err != nil {
+		return
+	}
+
+	repository.store.attachments[stored.ID] = stored
+	return
+}
+
+func (repository *memoryAppointmentAttachmentRepository) Delete(ctx context.Context, appointmentID primitive.ObjectID, id primitive.ObjectID) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if stored, ok := repository.store.attachments[id]; !ok || stored.AppointmentID != appointmentID {
+		return ErrNotFound
+	}
+
+	delete(repository.store.attachments, id)
+	return
+}
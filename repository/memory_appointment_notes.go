@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryAppointmentNoteRepository struct {
+	store *MemoryStore
+}
+
+func (repository *memoryAppointmentNoteRepository) Find(ctx context.Context, appointmentID primitive.ObjectID, visibility string) (notes []models.AppointmentNote, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, stored := range repository.store.notes {
+		if stored.AppointmentID != appointmentID || (visibility != "" && stored.Visibility != visibility) {
+			continue
+		}
+
+		var note models.AppointmentNote
+		if err = clone(stored, &note); err != nil {
+			return
+		}
+		notes = append(notes, note)
+	}
+
+	sort.Slice(notes, func(i, j int) bool {
+		if !notes[i].CreatedAt.Equal(notes[j].CreatedAt) {
+			return notes[i].CreatedAt.Before(notes[j].CreatedAt)
+		}
+		return lessObjectID(notes[i].ID, notes[j].ID)
+	})
+	return
+}
+
+func (repository *memoryAppointmentNoteRepository) Insert(ctx context.Context, note *models.AppointmentNote) (err error) {
+	if note.ID.IsZero() {
+		note.ID = primitive.NewObjectID()
+	}
+
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if _, exists := repository.store.notes[note.ID]; exists {
+		return ErrDuplicate
+	}
+
+	var stored models.AppointmentNote
+	if err = clone(note, &stored); err != nil {
+		return
+	}
+
+	repository.store.notes[stored.ID] = stored
+	return
+}
+
+func (repository *memoryAppointmentNoteRepository) Delete(ctx context.Context, appointmentID primitive.ObjectID, id primitive.ObjectID) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if stored, ok := repository.store.notes[id]; !ok || stored.AppointmentID != appointmentID {
+		return ErrNotFound
+	}
+
+	delete(repository.store.notes, id)
+	return
+}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryAppointmentRepository struct {
+	store *MemoryStore
+}
+
+func (repository *memoryAppointmentRepository) Find(ctx context.Context, filter AppointmentFilter) (appointments []models.Appointment, err error) {
+	if _, err = filter.Query(); err != nil {
+		return
+	}
+
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, stored := range repository.store.appointments {
+		if !filter.Matches(stored) {
+			continue
+		}
+
+		var appointment models.Appointment
+		if err = clone(stored, &appointment); err != nil {
+			return
+		}
+		appointments = append(appointments, appointment)
+	}
+
+	sort.Slice(appointments, func(i, j int) bool { return lessObjectID(appointments[i].ID, appointments[j].ID) })
+	return
+}
+
+func (repository *memoryAppointmentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (appointment models.Appointment, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.appointments[id]
+	if !ok {
+		return appointment, ErrNotFound
+	}
+
+	err = clone(stored, &appointment)
+	return
+}
+
+func (repository *memoryAppointmentRepository) Insert(ctx context.Context, appointment *models.Appointment) (err error) {
+	if appointment.ID.IsZero() {
+		appointment.ID = primitive.NewObjectID()
+	}
+	appointment.Version = 1
+
+	return repository.save(*appointment, true)
+}
+
+func (repository *memoryAppointmentRepository) Update(ctx context.Context, appointment models.Appointment) (err error) {
+	return repository.save(appointment, false)
+}
+
+func (repository *memoryAppointmentRepository) Delete(ctx context.Context, id primitive.ObjectID, version int64) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.appointments[id]
+	if !ok {
+		return ErrNotFound
+	} else if stored.Version != version {
+		return ErrVersionConflict
+	}
+
+	delete(repository.store.appointments, id)
+	return
+}
+
+func (repository *memoryAppointmentRepository) ReassignCategory(ctx context.Context, from primitive.ObjectID, to primitive.ObjectID) (modified int64, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for id, appointment := range repository.store.appointments {
+		if appointment.Category != from || from == to {
+			continue
+		}
+
+		appointment.Category = to
+		appointment.Version++
+		repository.store.appointments[id] = appointment
+		modified++
+	}
+
+	return
+}
+
+// Guarda una copia de la cita; insert indica si la cita debe ser nueva
+func (repository *memoryAppointmentRepository) save(appointment models.Appointment, insert bool) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	previous, exists := repository.store.appointments[appointment.ID]
+	if exists == insert {
+		if insert {
+			return ErrDuplicate
+		}
+		return ErrNotFound
+	}
+
+	if !insert {
+		if previous.Version != appointment.Version {
+			return ErrVersionConflict
+		}
+		appointment.Version++
+	}
+
+	var stored models.Appointment
+	if err = clone(appointment, &stored); err != nil {
+		return
+	}
+
+	repository.store.appointments[stored.ID] = stored
+	return
+}
+
+func (repository *memoryAppointmentRepository) Count(ctx context.Context, filter AppointmentFilter, limit int64) (count int64, err error) {
+	appointments, err := repository.Find(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	count = int64(len(appointments))
+	if limit > 0 && count > limit {
+		count = limit
+	}
+
+	return
+}
+
+func (repository *memoryAppointmentRepository) Each(ctx context.Context, filter AppointmentFilter, fn func(appointment models.Appointment) error) (err error) {
+	appointments, err := repository.Find(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	sort.SliceStable(appointments, func(i, j int) bool { return appointments[i].Date.Before(appointments[j].Date) })
+	for _, appointment := range appointments {
+		if err = fn(appointment); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (repository *memoryAppointmentRepository) Summarize(ctx context.Context, from time.Time, end time.Time, top int) (summary AppointmentSummary, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	byStatus, categories := map[string]int{}, map[primitive.ObjectID]int{}
+	perDay := map[DayCount]int{}
+	var total time.Duration
+	var count int
+	for _, appointment := range repository.store.appointments {
+		if appointment.Date.Before(from) || !appointment.Date.Before(end) {
+			continue
+		}
+
+		byStatus[appointment.Status]++
+		perDay[DayCount{Date: appointment.Date.UTC().Format("2006-01-02"), Status: appointment.Status}]++
+		if !appointment.Category.IsZero() {
+			categories[appointment.Category]++
+		}
+		total += appointment.Duration
+		count++
+	}
+
+	summary.ByStatus = sortedCounts(byStatus)
+	if count > 0 {
+		summary.AverageDuration = total / time.Duration(count)
+	}
+
+	for day, count := range perDay {
+		day.Count = count
+		summary.PerDay = append(summary.PerDay, day)
+	}
+	sort.Slice(summary.PerDay, func(i, j int) bool {
+		a, b := summary.PerDay[i], summary.PerDay[j]
+		return a.Date < b.Date || (a.Date == b.Date && a.Status < b.Status)
+	})
+
+	for id, count := range categories {
+		summary.TopCategories = append(summary.TopCategories, CategoryCount{ID: id, Count: count})
+	}
+	sort.Slice(summary.TopCategories, func(i, j int) bool {
+		a, b := summary.TopCategories[i], summary.TopCategories[j]
+		return a.Count > b.Count || (a.Count == b.Count && lessObjectID(a.ID, b.ID))
+	})
+	if len(summary.TopCategories) > top {
+		summary.TopCategories = summary.TopCategories[:top]
+	}
+
+	return
+}
+
+func (repository *memoryAppointmentRepository) HelperWorkloads(ctx context.Context, filter AppointmentFilter) (workloads []HelperWorkload, err error) {
+	appointments, err := repository.Find(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	indexes := map[[2]primitive.ObjectID]int{}
+	for _, appointment := range appointments {
+		if appointment.Helper.IsZero() {
+			continue
+		}
+
+		key := [2]primitive.ObjectID{appointment.Helper, appointment.Category}
+		i, ok := indexes[key]
+		if !ok {
+			i = len(workloads)
+			indexes[key] = i
+			workloads = append(workloads, HelperWorkload{Helper: appointment.Helper, Category: appointment.Category})
+		}
+
+		workload := &workloads[i]
+		workload.Appointments++
+		switch appointment.Status {
+		case models.AppointmentStatusCancelled:
+			workload.Cancellations++
+		case models.AppointmentStatusCompleted:
+			workload.CompletedDuration += appointment.Duration
+		case models.AppointmentStatusNoShow:
+			workload.NoShows++
+		}
+		if appointment.Status != models.AppointmentStatusCancelled {
+			workload.BookedDuration += appointment.Duration
+		}
+	}
+
+	sort.Slice(workloads, func(i, j int) bool {
+		a, b := workloads[i], workloads[j]
+		return lessObjectID(a.Helper, b.Helper) || (a.Helper == b.Helper && lessObjectID(a.Category, b.Category))
+	})
+	return
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryCategoryRepository struct {
+	store *MemoryStore
+}
+
+func (repository *memoryCategoryRepository) Find(ctx context.Context, filter CategoryFilter) (categories []models.Category, err error) {
+	if _, err = filter.Query(); err != nil {
+		return
+	}
+
+	categories, err = repository.collect(filter.Matches)
+	if err != nil {
+		return
+	}
+
+	sort.SliceStable(categories, func(i, j int) bool {
+		if categories[i].SortOrder != categories[j].SortOrder {
+			return categories[i].SortOrder < categories[j].SortOrder
+		}
+		if categories[i].Name != categories[j].Name {
+			return categories[i].Name < categories[j].Name
+		}
+		return lessObjectID(categories[i].ID, categories[j].ID)
+	})
+	return
+}
+
+func (repository *memoryCategoryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (category models.Category, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.categories[id]
+	if !ok {
+		return category, ErrNotFound
+	}
+
+	err = clone(stored, &category)
+	return
+}
+
+func (repository *memoryCategoryRepository) Resolve(ctx context.Context, idOrSlug string) (category models.Category, err error) {
+	match := func(category models.Category) bool {
+		return category.Slug == idOrSlug || containsString(category.PreviousSlugs, idOrSlug)
+	}
+	if id, err := primitive.ObjectIDFromHex(idOrSlug); err == nil {
+		match = func(category models.Category) bool {
+			return category.ID == id || containsObjectID(category.MergedIDs, id)
+		}
+	}
+
+	categories, err := repository.collect(match)
+	if err != nil {
+		return
+	}
+	if len(categories) == 0 {
+		return category, ErrNotFound
+	}
+
+	sort.Slice(categories, func(i, j int) bool { return lessObjectID(categories[i].ID, categories[j].ID) })
+	return categories[0], nil
+}
+
+func (repository *memoryCategoryRepository) FindDescendants(ctx context.Context, id primitive.ObjectID) (categories []models.Category, err error) {
+	return repository.collect(func(category models.Category) bool {
+		return containsObjectID(category.Ancestors, id)
+	})
+}
+
+func (repository *memoryCategoryRepository) CountChildren(ctx context.Context, parentID *primitive.ObjectID) (count int, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, category := range repository.store.categories {
+		if sameObjectID(category.ParentID, parentID) {
+			count++
+		}
+	}
+
+	return
+}
+
+func (repository *memoryCategoryRepository) NameExists(ctx context.Context, name string, excludeID primitive.ObjectID) (exists bool, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for id, category := range repository.store.categories {
+		if id != excludeID && strings.EqualFold(category.Name, name) {
+			return true, nil
+		}
+	}
+
+	return
+}
+
+func (repository *memoryCategoryRepository) SlugExists(ctx context.Context, slug string, excludeID primitive.ObjectID) (exists bool, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for id, category := range repository.store.categories {
+		if id != excludeID && (category.Slug == slug || containsString(category.PreviousSlugs, slug)) {
+			return true, nil
+		}
+	}
+
+	return
+}
+
+func (repository *memoryCategoryRepository) Insert(ctx context.Context, category *models.Category) (err error) {
+	if category.ID.IsZero() {
+		category.ID = primitive.NewObjectID()
+	}
+
+	return repository.save(*category, true)
+}
+
+func (repository *memoryCategoryRepository) Update(ctx context.Context, category models.Category) (err error) {
+	return repository.save(category, false)
+}
+
+func (repository *memoryCategoryRepository) Delete(ctx context.Context, id primitive.ObjectID) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if _, ok := repository.store.categories[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(repository.store.categories, id)
+	return
+}
+
+func (repository *memoryCategoryRepository) SetSortOrders(ctx context.Context, parentID *primitive.ObjectID, ids []primitive.ObjectID) (matched int, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for i, id := range ids {
+		category, ok := repository.store.categories[id]
+		if !ok || !sameObjectID(category.ParentID, parentID) {
+			continue
+		}
+
+		category.SortOrder = i
+		repository.store.categories[id] = category
+		matched++
+	}
+
+	return
+}
+
+// Devuelve copias de las categorías que cumplen la condición
+func (repository *memoryCategoryRepository) collect(match func(category models.Category) bool) (categories []models.Category, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, stored := range repository.store.categories {
+		if !match(stored) {
+			continue
+		}
+
+		var category models.Category
+		if err = clone(stored, &category); err != nil {
+			return
+		}
+		categories = append(categories, category)
+	}
+
+	return
+}
+
+// Guarda una copia de la categoría respetando los mismos índices únicos que MongoDB
+func (repository *memoryCategoryRepository) save(category models.Category, insert bool) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if _, exists := repository.store.categories[category.ID]; exists == insert {
+		if insert {
+			return ErrDuplicate
+		}
+		return ErrNotFound
+	}
+
+	for id, other := range repository.store.categories {
+		if id == category.ID {
+			continue
+		}
+		if strings.EqualFold(other.Name, category.Name) || (category.Slug != "" && other.Slug == category.Slug) {
+			return ErrDuplicate
+		}
+	}
+
+	var stored models.Category
+	if err = clone(category, &stored); err != nil {
+		return
+	}
+
+	repository.store.categories[stored.ID] = stored
+	return
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sameObjectID(a, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryEmailRepository struct {
+	store *MemoryStore
+}
+
+func (repository *memoryEmailRepository) Find(ctx context.Context, status string) (emails []models.OutboundEmail, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, stored := range repository.store.emails {
+		if status != "" && stored.Status != status {
+			continue
+		}
+
+		var email models.OutboundEmail
+		if err = clone(stored, &email); err != nil {
+			return
+		}
+		emails = append(emails, email)
+	}
+
+	sort.Slice(emails, func(i, j int) bool {
+		if !emails[i].CreatedAt.Equal(emails[j].CreatedAt) {
+			return emails[i].CreatedAt.After(emails[j].CreatedAt)
+		}
+		return lessObjectID(emails[j].ID, emails[i].ID)
+	})
+	return
+}
+
+func (repository *memoryEmailRepository) FindByID(ctx context.Context, id primitive.ObjectID) (email models.OutboundEmail, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.emails[id]
+	if !ok {
+		return email, ErrNotFound
+	}
+
+	err = clone(stored, &email)
+	return
+}
+
+func (repository *memoryEmailRepository) Insert(ctx context.Context, email *models.OutboundEmail) (err error) {
+	if email.ID.IsZero() {
+		email.ID = primitive.NewObjectID()
+	}
+
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if _, exists := repository.store.emails[email.ID]; exists {
+		return ErrDuplicate
+	}
+
+	return repository.put(*email)
+}
+
+func (repository *memoryEmailRepository) Requeue(ctx context.Context, id primitive.ObjectID, now time.Time) (email models.OutboundEmail, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.emails[id]
+	if !ok || (stored.Status != models.EmailStatusDead && stored.Status != models.EmailStatusSent) {
+		return email, ErrNotFound
+	}
+
+	if err = clone(stored, &email); err != nil {
+		return
+	}
+
+	email.Status = models.EmailStatusQueued
+	email.Attempts = 0
+	email.NextAttemptAt = now
+	email.UpdatedAt = now
+	email.SentAt = nil
+
+	if err = repository.put(email); err != nil {
+		return
+	}
+
+	err = clone(repository.store.emails[id], &email)
+	return
+}
+
+func (repository *memoryEmailRepository) Claim(ctx context.Context, owner string, now time.Time, lockedUntil time.Time) (email models.OutboundEmail, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	var next *models.OutboundEmail
+	for _, stored := range repository.store.emails {
+		stored := stored
+		pending := stored.Status == models.EmailStatusQueued && !stored.NextAttemptAt.After(now)
+		expired := stored.Status == models.EmailStatusSending && stored.LockedUntil != nil && stored.LockedUntil.Before(now)
+		if !pending && !expired {
+			continue
+		}
+
+		if next == nil || stored.NextAttemptAt.Before(next.NextAttemptAt) ||
+			(stored.NextAttemptAt.Equal(next.NextAttemptAt) && lessObjectID(stored.ID, next.ID)) {
+			next = &stored
+		}
+	}
+	if next == nil {
+		return email, ErrNotFound
+	}
+
+	if err = clone(*next, &email); err != nil {
+		return
+	}
+
+	email.Status = models.EmailStatusSending
+	email.LockedBy = owner
+	email.LockedUntil = &lockedUntil
+	email.UpdatedAt = now
+	email.Attempts++
+
+	if err = repository.put(email); err != nil {
+		return
+	}
+
+	err = clone(repository.store.emails[email.ID], &email)
+	return
+}
+
+func (repository *memoryEmailRepository) Finish(ctx context.Context, email models.OutboundEmail, owner string, attempt models.EmailDeliveryAttempt) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.emails[email.ID]
+	if !ok || stored.LockedBy != owner {
+		return ErrNotFound
+	}
+
+	var updated models.OutboundEmail
+	if err = clone(stored, &updated); err != nil {
+		return
+	}
+
+	updated.Status = email.Status
+	updated.NextAttemptAt = email.NextAttemptAt
+	updated.UpdatedAt = email.UpdatedAt
+	if email.SentAt != nil {
+		updated.SentAt = email.SentAt
+	}
+	updated.Deliveries = append(updated.Deliveries, attempt)
+	updated.LockedBy = ""
+	updated.LockedUntil = nil
+
+	return repository.put(updated)
+}
+
+// Guarda una copia del correo; se debe llamar con el mutex tomado
+func (repository *memoryEmailRepository) put(email models.OutboundEmail) (err error) {
+	var stored models.OutboundEmail
+	if err = clone(email, &stored); err != nil {
+		return
+	}
+
+	repository.store.emails[stored.ID] = stored
+	return
+}
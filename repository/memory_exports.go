@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryExportRepository struct {
+	store *MemoryStore
+}
+
+func (repository *memoryExportRepository) FindByID(ctx context.Context, id primitive.ObjectID) (export models.Export, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.exports[id]
+	if !ok {
+		return export, ErrNotFound
+	}
+
+	err = clone(stored, &export)
+	return
+}
+
+func (repository *memoryExportRepository) Insert(ctx context.Context, export *models.Export) (err error) {
+	if export.ID.IsZero() {
+		export.ID = primitive.NewObjectID()
+	}
+
+	return repository.save(*export, true)
+}
+
+func (repository *memoryExportRepository) Update(ctx context.Context, export models.Export) (err error) {
+	return repository.save(export, false)
+}
+
+// Guarda una copia de la exportación; insert indica si la exportación debe ser nueva
+func (repository *memoryExportRepository) save(export models.Export, insert bool) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if _, exists := repository.store.exports[export.ID]; exists == insert {
+		if insert {
+			return ErrDuplicate
+		}
+		return ErrNotFound
+	}
+
+	var stored models.Export
+	if err = clone(export, &stored); err != nil {
+		return
+	}
+
+	repository.store.exports[stored.ID] = stored
+	return
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+)
+
+type memoryIdempotencyRepository struct {
+	store *MemoryStore
+}
+
+func (repository *memoryIdempotencyRepository) Reserve(ctx context.Context, record models.IdempotencyRecord) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if stored, exists := repository.store.idempotencyKeys[record.Key]; exists && stored.ExpiresAt.After(time.Now()) {
+		return ErrDuplicate
+	}
+
+	return repository.save(record)
+}
+
+func (repository *memoryIdempotencyRepository) FindByKey(ctx context.Context, key string) (record models.IdempotencyRecord, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.idempotencyKeys[key]
+	if !ok || !stored.ExpiresAt.After(time.Now()) {
+		return record, ErrNotFound
+	}
+
+	err = clone(stored, &record)
+	return
+}
+
+func (repository *memoryIdempotencyRepository) Complete(ctx context.Context, record models.IdempotencyRecord) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if _, exists := repository.store.idempotencyKeys[record.Key]; !exists {
+		return ErrNotFound
+	}
+
+	record.Completed = true
+	return repository.save(record)
+}
+
+func (repository *memoryIdempotencyRepository) Delete(ctx context.Context, key string) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	delete(repository.store.idempotencyKeys, key)
+	return
+}
+
+// Guarda una copia del registro; se debe llamar con el mutex tomado
+func (repository *memoryIdempotencyRepository) save(record models.IdempotencyRecord) (err error) {
+	var stored models.IdempotencyRecord
+	if err = clone(record, &stored); err != nil {
+		return
+	}
+
+	repository.store.idempotencyKeys[stored.Key] = stored
+	return
+}
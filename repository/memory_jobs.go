@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryJobRepository struct {
+	store *MemoryStore
+}
+
+func (repository *memoryJobRepository) Find(ctx context.Context, appointmentID primitive.ObjectID) (jobs []models.Job, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, stored := range repository.store.jobs {
+		if stored.AppointmentID != appointmentID {
+			continue
+		}
+
+		var job models.Job
+		if err = clone(stored, &job); err != nil {
+			return
+		}
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].RunAt.Equal(jobs[j].RunAt) {
+			return jobs[i].RunAt.Before(jobs[j].RunAt)
+		}
+		return lessObjectID(jobs[i].ID, jobs[j].ID)
+	})
+	return
+}
+
+func (repository *memoryJobRepository) Insert(ctx context.Context, job *models.Job) (err error) {
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if _, exists := repository.store.jobs[job.ID]; exists {
+		return ErrDuplicate
+	}
+
+	return repository.put(*job)
+}
+
+func (repository *memoryJobRepository) CancelPending(ctx context.Context, appointmentID primitive.ObjectID, types []string, now time.Time) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, stored := range repository.store.jobs {
+		if stored.AppointmentID != appointmentID || stored.Status != models.JobStatusPending {
+			continue
+		}
+		if len(types) > 0 && !containsString(types, stored.Type) {
+			continue
+		}
+
+		stored.Status = models.JobStatusCancelled
+		stored.UpdatedAt = now
+		if err = repository.put(stored); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (repository *memoryJobRepository) Claim(ctx context.Context, owner string, now time.Time, lockedUntil time.Time) (job models.Job, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	var next *models.Job
+	for _, stored := range repository.store.jobs {
+		stored := stored
+		pending := stored.Status == models.JobStatusPending
+		expired := stored.Status == models.JobStatusRunning && stored.LockedUntil != nil && stored.LockedUntil.Before(now)
+		if stored.RunAt.After(now) || (!pending && !expired) {
+			continue
+		}
+
+		if next == nil || stored.RunAt.Before(next.RunAt) ||
+			(stored.RunAt.Equal(next.RunAt) && lessObjectID(stored.ID, next.ID)) {
+			next = &stored
+		}
+	}
+	if next == nil {
+		return job, ErrNotFound
+	}
+
+	if err = clone(*next, &job); err != nil {
+		return
+	}
+
+	job.Status = models.JobStatusRunning
+	job.LockedBy = owner
+	job.LockedUntil = &lockedUntil
+	job.UpdatedAt = now
+	job.Attempts++
+
+	if err = repository.put(job); err != nil {
+		return
+	}
+
+	err = clone(repository.store.jobs[job.ID], &job)
+	return
+}
+
+func (repository *memoryJobRepository) AddSentTo(ctx context.Context, id primitive.ObjectID, recipient string) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if containsString(stored.SentTo, recipient) {
+		return
+	}
+
+	var updated models.Job
+	if err = clone(stored, &updated); err != nil {
+		return
+	}
+
+	updated.SentTo = append(updated.SentTo, recipient)
+	return repository.put(updated)
+}
+
+func (repository *memoryJobRepository) Finish(ctx context.Context, job models.Job, owner string) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.jobs[job.ID]
+	if !ok || stored.LockedBy != owner {
+		return ErrNotFound
+	}
+
+	var updated models.Job
+	if err = clone(stored, &updated); err != nil {
+		return
+	}
+
+	updated.Status = job.Status
+	updated.RunAt = job.RunAt
+	updated.UpdatedAt = job.UpdatedAt
+	if job.LastError != "" {
+		updated.LastError = job.LastError
+	}
+	updated.LockedBy = ""
+	updated.LockedUntil = nil
+
+	return repository.put(updated)
+}
+
+// Guarda una copia de la tarea; se debe llamar con el mutex tomado
+func (repository *memoryJobRepository) put(job models.Job) (err error) {
+	var stored models.Job
+	if err = clone(job, &stored); err != nil {
+		return
+	}
+
+	repository.store.jobs[stored.ID] = stored
+	return
+}
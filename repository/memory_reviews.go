@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryReviewRepository struct {
+	store *MemoryStore
+}
+
+func (repository *memoryReviewRepository) Find(ctx context.Context, filter ReviewFilter) (reviews []models.Review, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, stored := range repository.store.reviews {
+		if !filter.Matches(stored) {
+			continue
+		}
+
+		var review models.Review
+		if err = clone(stored, &review); err != nil {
+			return
+		}
+		reviews = append(reviews, review)
+	}
+
+	sort.Slice(reviews, func(i, j int) bool {
+		if !reviews[i].CreatedAt.Equal(reviews[j].CreatedAt) {
+			return reviews[i].CreatedAt.After(reviews[j].CreatedAt)
+		}
+		return lessObjectID(reviews[j].ID, reviews[i].ID)
+	})
+	return
+}
+
+func (repository *memoryReviewRepository) FindByID(ctx context.Context, id primitive.ObjectID) (review models.Review, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.reviews[id]
+	if !ok {
+		return review, ErrNotFound
+	}
+
+	err = clone(stored, &review)
+	return
+}
+
+func (repository *memoryReviewRepository) Insert(ctx context.Context, review *models.Review) (err error) {
+	if review.ID.IsZero() {
+		review.ID = primitive.NewObjectID()
+	}
+
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	// Como el índice único appointment_author_unique
+	for id, other := range repository.store.reviews {
+		if id == review.ID || (other.AppointmentID == review.AppointmentID && other.AuthorRole == review.AuthorRole) {
+			return ErrDuplicate
+		}
+	}
+
+	return repository.put(*review)
+}
+
+func (repository *memoryReviewRepository) Update(ctx context.Context, review models.Review) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if _, ok := repository.store.reviews[review.ID]; !ok {
+		return ErrNotFound
+	}
+
+	return repository.put(review)
+}
+
+func (repository *memoryReviewRepository) Delete(ctx context.Context, id primitive.ObjectID) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if _, ok := repository.store.reviews[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(repository.store.reviews, id)
+	return
+}
+
+func (repository *memoryReviewRepository) Rating(ctx context.Context, subjectID primitive.ObjectID) (rating *models.UserRating, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	var total int
+	for _, review := range repository.store.reviews {
+		if review.SubjectID == subjectID && review.AuthorRole == models.ReviewRoleRequester && review.Status == models.ReviewStatusApproved {
+			if rating == nil {
+				rating = &models.UserRating{}
+			}
+			rating.Count++
+			total += review.Score
+		}
+	}
+
+	if rating != nil {
+		rating.Average = float64(total) / float64(rating.Count)
+	}
+
+	return
+}
+
+// Guarda una copia de la reseña; se debe llamar con el mutex tomado
+func (repository *memoryReviewRepository) put(review models.Review) (err error) {
+	var stored models.Review
+	if err = clone(review, &stored); err != nil {
+		return
+	}
+
+	repository.store.reviews[stored.ID] = stored
+	return
+}
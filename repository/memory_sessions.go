@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memorySessionRepository struct {
+	store *MemoryStore
+}
+
+func (repository *memorySessionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (session models.Session, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.sessions[id]
+	if !ok {
+		return session, ErrNotFound
+	}
+
+	err = clone(stored, &session)
+	return
+}
+
+func (repository *memorySessionRepository) Insert(ctx context.Context, session *models.Session) (err error) {
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if _, exists := repository.store.sessions[session.ID]; exists {
+		return ErrDuplicate
+	}
+
+	var stored models.Session
+	if err = clone(session, &stored); err != nil {
+		return
+	}
+
+	repository.store.sessions[stored.ID] = stored
+	return
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryUserImportRepository struct {
+	store *MemoryStore
+}
+
+func (repository *memoryUserImportRepository) FindByID(ctx context.Context, id primitive.ObjectID) (job models.UserImport, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.userImports[id]
+	if !ok {
+		return job, ErrNotFound
+	}
+
+	err = clone(stored, &job)
+	return
+}
+
+func (repository *memoryUserImportRepository) Insert(ctx context.Context, job *models.UserImport) (err error) {
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if _, exists := repository.store.userImports[job.ID]; exists {
+		return ErrDuplicate
+	}
+
+	return repository.put(*job)
+}
+
+func (repository *memoryUserImportRepository) SaveProgress(ctx context.Context, job models.UserImport) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.userImports[job.ID]
+	if !ok || stored.Status != models.UserImportStatusRunning {
+		return ErrNotFound
+	}
+
+	stored.Status = job.Status
+	stored.Processed = job.Processed
+	stored.Created = job.Created
+	stored.Failed = job.Failed
+	stored.Errors = job.Errors
+	stored.UpdatedAt = job.UpdatedAt
+	stored.FinishedAt = job.FinishedAt
+	return repository.put(stored)
+}
+
+func (repository *memoryUserImportRepository) FailStale(ctx context.Context, before time.Time, failure models.ImportRowError) (failed int64, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	now := time.Now()
+	for _, stored := range repository.store.userImports {
+		if stored.Status != models.UserImportStatusRunning || !stored.UpdatedAt.Before(before) {
+			continue
+		}
+
+		var job models.UserImport
+		if err = clone(stored, &job); err != nil {
+			return
+		}
+
+		job.Status = models.UserImportStatusFailed
+		job.Errors = append(job.Errors, failure)
+		job.UpdatedAt = now
+		job.FinishedAt = &now
+		if err = repository.put(job); err != nil {
+			return
+		}
+		failed++
+	}
+
+	return
+}
+
+// Guarda una copia de la importación; se debe llamar con el mutex tomado
+func (repository *memoryUserImportRepository) put(job models.UserImport) (err error) {
+	var stored models.UserImport
+	if err = clone(job, &stored); err != nil {
+		return
+	}
+
+	repository.store.userImports[stored.ID] = stored
+	return
+}
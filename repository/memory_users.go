@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryUserRepository struct {
+	store *MemoryStore
+}
+
+func (repository *memoryUserRepository) Find(ctx context.Context, filter UserFilter) (users []models.User, err error) {
+	if _, err = filter.Query(); err != nil {
+		return
+	}
+
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, stored := range repository.store.users {
+		if !filter.Matches(stored) {
+			continue
+		}
+
+		var user models.User
+		if err = clone(stored, &user); err != nil {
+			return
+		}
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool { return lessObjectID(users[i].ID, users[j].ID) })
+	return
+}
+
+func (repository *memoryUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (user models.User, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.users[id]
+	if !ok {
+		return user, ErrNotFound
+	}
+
+	err = clone(stored, &user)
+	return
+}
+
+func (repository *memoryUserRepository) FindByEmail(ctx context.Context, email string) (user models.User, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, stored := range repository.store.users {
+		if strings.EqualFold(stored.Email, email) {
+			err = clone(stored, &user)
+			return
+		}
+	}
+
+	return user, ErrNotFound
+}
+
+func (repository *memoryUserRepository) Insert(ctx context.Context, user *models.User) (err error) {
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	user.Version = 1
+
+	return repository.save(*user, true)
+}
+
+func (repository *memoryUserRepository) Update(ctx context.Context, user models.User) (err error) {
+	return repository.save(user, false)
+}
+
+func (repository *memoryUserRepository) Delete(ctx context.Context, id primitive.ObjectID, version int64) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.users[id]
+	if !ok {
+		return ErrNotFound
+	} else if stored.Version != version {
+		return ErrVersionConflict
+	}
+
+	delete(repository.store.users, id)
+	return
+}
+
+func (repository *memoryUserRepository) SetPassword(ctx context.Context, id primitive.ObjectID, password string, changedAt time.Time) (err error) {
+	return repository.modify(id, func(user *models.User) {
+		user.Password = password
+		user.PasswordChangedAt = changedAt
+		user.UpdatedAt = changedAt
+	})
+}
+
+func (repository *memoryUserRepository) SetNotificationPreferences(ctx context.Context, id primitive.ObjectID, preferences models.NotificationPreferences) (err error) {
+	return repository.modify(id, func(user *models.User) {
+		user.NotificationPreferences = &preferences
+		user.UpdatedAt = time.Now()
+	})
+}
+
+func (repository *memoryUserRepository) AddPushSubscription(ctx context.Context, id primitive.ObjectID, subscription models.PushSubscription) (err error) {
+	return repository.modify(id, func(user *models.User) {
+		user.PushSubscriptions = append(user.PushSubscriptions, subscription)
+		user.UpdatedAt = time.Now()
+	})
+}
+
+func (repository *memoryUserRepository) RemovePushSubscription(ctx context.Context, id primitive.ObjectID, endpoint string) (err error) {
+	return repository.modify(id, func(user *models.User) {
+		subscriptions := user.PushSubscriptions[:0]
+		for _, subscription := range user.PushSubscriptions {
+			if subscription.Endpoint != endpoint {
+				subscriptions = append(subscriptions, subscription)
+			}
+		}
+		user.PushSubscriptions = subscriptions
+	})
+}
+
+func (repository *memoryUserRepository) SetProfileImage(ctx context.Context, id primitive.ObjectID, image string, thumbnails map[string]string, keys []string) (err error) {
+	return repository.modify(id, func(user *models.User) {
+		user.ProfileImage = image
+		user.ProfileThumbnails = thumbnails
+		user.ProfileImageKeys = keys
+		user.UpdatedAt = time.Now()
+	})
+}
+
+func (repository *memoryUserRepository) ClearProfileImage(ctx context.Context, id primitive.ObjectID) (previous models.User, err error) {
+	if previous, err = repository.FindByID(ctx, id); err != nil {
+		return
+	}
+
+	err = repository.modify(id, func(user *models.User) {
+		user.ProfileImage = ""
+		user.ProfileThumbnails = nil
+		user.ProfileImageKeys = nil
+		user.UpdatedAt = time.Now()
+	})
+	return
+}
+
+func (repository *memoryUserRepository) ReplaceSkill(ctx context.Context, from primitive.ObjectID, to primitive.ObjectID) (matched int64, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for id, stored := range repository.store.users {
+		if !containsObjectID(stored.Skills, from) {
+			continue
+		}
+
+		var user models.User
+		if err = clone(stored, &user); err != nil {
+			return
+		}
+
+		skills := []primitive.ObjectID{}
+		for _, skill := range user.Skills {
+			if skill != from {
+				skills = append(skills, skill)
+			}
+		}
+		if !containsObjectID(skills, to) {
+			skills = append(skills, to)
+		}
+		user.Skills = skills
+		user.Version++
+
+		repository.store.users[id] = user
+		matched++
+	}
+
+	return
+}
+
+// Aplica un cambio a una copia del usuario guardado y la guarda
+func (repository *memoryUserRepository) modify(id primitive.ObjectID, change func(user *models.User)) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, ok := repository.store.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	var user models.User
+	if err = clone(stored, &user); err != nil {
+		return
+	}
+	change(&user)
+	user.Version++
+
+	return repository.put(user)
+}
+
+// Guarda una copia del usuario; insert indica si el usuario debe ser nuevo
+func (repository *memoryUserRepository) save(user models.User, insert bool) (err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	stored, exists := repository.store.users[user.ID]
+	if exists == insert {
+		if insert {
+			return ErrDuplicate
+		}
+		return ErrNotFound
+	}
+
+	if !insert {
+		if stored.Version != user.Version {
+			return ErrVersionConflict
+		}
+		user.Version++
+	}
+
+	return repository.put(user)
+}
+
+// Guarda el usuario verificando, como el índice único de MongoDB, que el correo no se repita
+func (repository *memoryUserRepository) put(user models.User) (err error) {
+	for id, other := range repository.store.users {
+		if id != user.ID && user.Email != "" && strings.EqualFold(other.Email, user.Email) {
+			return ErrDuplicate
+		}
+	}
+
+	var stored models.User
+	if err = clone(user, &stored); err != nil {
+		return
+	}
+
+	repository.store.users[stored.ID] = stored
+	return
+}
+
+func (repository *memoryUserRepository) SetRating(ctx context.Context, id primitive.ObjectID, rating *models.UserRating) (err error) {
+	return repository.modify(id, func(user *models.User) {
+		user.Rating = rating
+	})
+}
+
+func (repository *memoryUserRepository) FindByEmails(ctx context.Context, emails []string) (users []models.User, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, stored := range repository.store.users {
+		for _, email := range emails {
+			if !strings.EqualFold(stored.Email, email) {
+				continue
+			}
+
+			var user models.User
+			if err = clone(stored, &user); err != nil {
+				return
+			}
+			users = append(users, user)
+			break
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool { return lessObjectID(users[i].ID, users[j].ID) })
+	return
+}
+
+func (repository *memoryUserRepository) Count(ctx context.Context, filter UserFilter, limit int64) (count int64, err error) {
+	users, err := repository.Find(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	count = int64(len(users))
+	if limit > 0 && count > limit {
+		count = limit
+	}
+
+	return
+}
+
+func (repository *memoryUserRepository) Each(ctx context.Context, filter UserFilter, fn func(user models.User) error) (err error) {
+	users, err := repository.Find(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	for _, user := range users {
+		if err = fn(user); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (repository *memoryUserRepository) Summarize(ctx context.Context, from time.Time, end time.Time) (summary UserSummary, err error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	byType, byStatus := map[string]int{}, map[string]int{}
+	signups := map[[2]int]int{}
+	for _, user := range repository.store.users {
+		byType[user.Type]++
+		byStatus[user.Status]++
+
+		if !user.CreatedAt.Before(from) && user.CreatedAt.Before(end) {
+			year, week := user.CreatedAt.UTC().ISOWeek()
+			signups[[2]int{year, week}]++
+		}
+	}
+
+	summary.ByType = sortedCounts(byType)
+	summary.ByStatus = sortedCounts(byStatus)
+	for week, count := range signups {
+		summary.SignupsPerWeek = append(summary.SignupsPerWeek, WeekCount{Year: week[0], Week: week[1], Count: count})
+	}
+	sort.Slice(summary.SignupsPerWeek, func(i, j int) bool {
+		a, b := summary.SignupsPerWeek[i], summary.SignupsPerWeek[j]
+		return a.Year < b.Year || (a.Year == b.Year && a.Week < b.Week)
+	})
+
+	return
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Operador $inc que aumenta la versión de los documentos modificados
+var incrementVersion = bson.M{"version": 1}
+
+// Repositorios guardados en una base de datos MongoDB
+type MongoStore struct {
+	db      *mongo.Database
+	timeout time.Duration
+}
+
+func (store *MongoStore) Users() IUserRepository {
+	return &mongoUserRepository{collection: store.db.Collection("users"), timeout: store.timeout}
+}
+
+func (store *MongoStore) Appointments() IAppointmentRepository {
+	return &mongoAppointmentRepository{collection: store.db.Collection("appointments"), timeout: store.timeout}
+}
+
+func (store *MongoStore) Categories() ICategoryRepository {
+	return &mongoCategoryRepository{collection: store.db.Collection("categories"), timeout: store.timeout}
+}
+
+func (store *MongoStore) Sessions() ISessionRepository {
+	return &mongoSessionRepository{collection: store.db.Collection("sessions"), timeout: store.timeout}
+}
+
+func (store *MongoStore) IdempotencyKeys() IIdempotencyRepository {
+	return &mongoIdempotencyRepository{collection: store.db.Collection("idempotency_keys"), timeout: store.timeout}
+}
+
+func (store *MongoStore) Reviews() IReviewRepository {
+	return &mongoReviewRepository{collection: store.db.Collection("reviews"), timeout: store.timeout}
+}
+
+func (store *MongoStore) AppointmentNotes() IAppointmentNoteRepository {
+	return &mongoAppointmentNoteRepository{collection: store.db.Collection("appointment_notes"), timeout: store.timeout}
+}
+
+func (store *MongoStore) AppointmentAttachments() IAppointmentAttachmentRepository {
+	return &mongoAppointmentAttachmentRepository{collection: store.db.Collection("appointment_attachments"), timeout: store.timeout}
+}
+
+func (store *MongoStore) UserImports() IUserImportRepository {
+	return &mongoUserImportRepository{collection: store.db.Collection("user_imports"), timeout: store.timeout}
+}
+
+func (store *MongoStore) Exports() IExportRepository {
+	return &mongoExportRepository{collection: store.db.Collection("exports"), timeout: store.timeout}
+}
+
+func (store *MongoStore) Emails() IEmailRepository {
+	return &mongoEmailRepository{collection: store.db.Collection("email_outbox"), timeout: store.timeout}
+}
+
+func (store *MongoStore) Jobs() IJobRepository {
+	return &mongoJobRepository{collection: store.db.Collection("jobs"), timeout: store.timeout}
+}
+
+/** Ejecuta fn en una transacción de MongoDB
+ *
+ * MongoDB debe estar configurado como replica set para usar transacciones.
+ *
+ * @param ctx context.Context "El contexto de la operación"
+ * @param fn func(ctx context.Context) error "Las operaciones de la transacción"
+ * @return error "El error de la transacción"
+ */
+func (store *MongoStore) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := store.db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// Convierte los errores de MongoDB en los errores del paquete
+func mongoError(err error) error {
+	switch {
+	case err == mongo.ErrNoDocuments:
+		return ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return ErrDuplicate
+	default:
+		return err
+	}
+}
+
+// Limita la duración de una operación; sin límite sólo se cancela junto con ctx
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Devuelve ErrNotFound si la operación no encontró el documento
+func matchedOne(result *mongo.UpdateResult, err error) error {
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+/** Verifica el resultado de una operación condicionada a la versión del documento
+ *
+ * @param ctx context.Context "El contexto de la operación"
+ * @param collection *mongo.Collection "La colección del documento"
+ * @param id primitive.ObjectID "El id del documento"
+ * @param matched int64 "La cantidad de documentos que coincidieron con el id y la versión"
+ * @return error "ErrNotFound si el documento no existe, ErrVersionConflict si tiene otra versión"
+ */
+func matchedVersion(ctx context.Context, collection *mongo.Collection, id primitive.ObjectID, matched int64) error {
+	if matched > 0 {
+		return nil
+	}
+
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": id}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	} else if count == 0 {
+		return ErrNotFound
+	}
+
+	return ErrVersionConflict
+}
+
+func NewMongoStore(db *mongo.Database, timeout time.Duration) *MongoStore {
+	return &MongoStore{db: db, timeout: timeout}
+}
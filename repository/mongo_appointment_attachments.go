@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoAppointmentAttachmentRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func (repository *mongoAppointmentAttachmentRepository) Find(ctx context.Context, appointmentID primitive.ObjectID) (attachments []models.AppointmentAttachment, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := repository.collection.Find(ctx, bson.M{"appointment_id": appointmentID}, opts)
+	if err != nil {
+		return
+	}
+
+	err = cursor.All(ctx, &attachments)
+	return
+}
+
+func (repository *mongoAppointmentAttachmentRepository) FindByID(ctx context.Context, appointmentID primitive.ObjectID, id primitive.ObjectID) (attachment models.AppointmentAttachment, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	err = mongoError(repository.collection.FindOne(ctx, bson.M{"_id": id, "appointment_id": appointmentID}).Decode(&attachment))
+	return
+}
+
+func (repository *mongoAppointmentAttachmentRepository) Insert(ctx context.Context, attachment *models.AppointmentAttachment) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	if attachment.ID.IsZero() {
+		attachment.ID = primitive.NewObjectID()
+	}
+
+	_, err = repository.collection.InsertOne(ctx, attachment)
+	return mongoError(err)
+}
+
+func (repository *mongoAppointmentAttachmentRepository) Delete(ctx context.Context, appointmentID primitive.ObjectID, id primitive.ObjectID) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	result, err := repository.collection.DeleteOne(ctx, bson.M{"_id": id, "appointment_id": appointmentID})
+	if err != nil {
+		return
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return
+}
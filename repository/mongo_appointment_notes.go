@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoAppointmentNoteRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func (repository *mongoAppointmentNoteRepository) Find(ctx context.Context, appointmentID primitive.ObjectID, visibility string) (notes []models.AppointmentNote, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	filter := bson.M{"appointment_id": appointmentID}
+	if visibility != "" {
+		filter["visibility"] = visibility
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := repository.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return
+	}
+
+	err = cursor.All(ctx, &notes)
+	return
+}
+
+func (repository *mongoAppointmentNoteRepository) Insert(ctx context.Context, note *models.AppointmentNote) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	if note.ID.IsZero() {
+		note.ID = primitive.NewObjectID()
+	}
+
+	_, err = repository.collection.InsertOne(ctx, note)
+	return mongoError(err)
+}
+
+func (repository *mongoAppointmentNoteRepository) Delete(ctx context.Context, appointmentID primitive.ObjectID, id primitive.ObjectID) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	result, err := repository.collection.DeleteOne(ctx, bson.M{"_id": id, "appointment_id": appointmentID})
+	if err != nil {
+		return
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return
+}
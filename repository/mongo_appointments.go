@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoAppointmentRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func (repository *mongoAppointmentRepository) Find(ctx context.Context, filter AppointmentFilter) (appointments []models.Appointment, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	query, err := filter.Query()
+	if err != nil {
+		return
+	}
+
+	cursor, err := repository.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return
+	}
+
+	err = cursor.All(ctx, &appointments)
+	return
+}
+
+func (repository *mongoAppointmentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (appointment models.Appointment, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	err = mongoError(repository.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&appointment))
+	return
+}
+
+func (repository *mongoAppointmentRepository) Insert(ctx context.Context, appointment *models.Appointment) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	if appointment.ID.IsZero() {
+		appointment.ID = primitive.NewObjectID()
+	}
+	appointment.Version = 1
+
+	_, err = repository.collection.InsertOne(ctx, appointment)
+	return mongoError(err)
+}
+
+func (repository *mongoAppointmentRepository) Update(ctx context.Context, appointment models.Appointment) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	version := appointment.Version
+	appointment.Version++
+
+	result, err := repository.collection.ReplaceOne(ctx, bson.M{"_id": appointment.ID, "version": version}, appointment)
+	if err != nil {
+		return mongoError(err)
+	}
+
+	return matchedVersion(ctx, repository.collection, appointment.ID, result.MatchedCount)
+}
+
+func (repository *mongoAppointmentRepository) Delete(ctx context.Context, id primitive.ObjectID, version int64) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	result, err := repository.collection.DeleteOne(ctx, bson.M{"_id": id, "version": version})
+	if err != nil {
+		return
+	}
+
+	return matchedVersion(ctx, repository.collection, id, result.DeletedCount)
+}
+
+func (repository *mongoAppointmentRepository) ReassignCategory(ctx context.Context, from primitive.ObjectID, to primitive.ObjectID) (modified int64, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	result, err := repository.collection.UpdateMany(ctx,
+		bson.M{"category": from},
+		bson.M{"$set": bson.M{"category": to}, "$inc": incrementVersion},
+	)
+	if err != nil {
+		return
+	}
+
+	modified = result.ModifiedCount
+	return
+}
+
+func (repository *mongoAppointmentRepository) Count(ctx context.Context, filter AppointmentFilter, limit int64) (count int64, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	query, err := filter.Query()
+	if err != nil {
+		return
+	}
+
+	opts := options.Count()
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	return repository.collection.CountDocuments(ctx, query, opts)
+}
+
+// El recorrido no usa el tiempo límite de las operaciones, porque puede abarcar toda la colección
+func (repository *mongoAppointmentRepository) Each(ctx context.Context, filter AppointmentFilter, fn func(appointment models.Appointment) error) (err error) {
+	query, err := filter.Query()
+	if err != nil {
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := repository.collection.Find(ctx, query, opts)
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var appointment models.Appointment
+		if err = cursor.Decode(&appointment); err != nil {
+			return
+		}
+		if err = fn(appointment); err != nil {
+			return
+		}
+	}
+
+	return cursor.Err()
+}
+
+func (repository *mongoAppointmentRepository) Summarize(ctx context.Context, from time.Time, end time.Time, top int) (summary AppointmentSummary, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"date": bson.M{"$gte": from, "$lt": end}}}},
+		{{Key: "$facet", Value: bson.M{
+			"by_status": bson.A{
+				bson.M{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+			},
+			"per_day": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":   bson.M{"date": bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$date"}}, "status": "$status"},
+					"count": bson.M{"$sum": 1},
+				}},
+				bson.M{"$sort": bson.D{{Key: "_id.date", Value: 1}, {Key: "_id.status", Value: 1}}},
+			},
+			"duration": bson.A{
+				bson.M{"$group": bson.M{"_id": nil, "average": bson.M{"$avg": "$duration"}}},
+			},
+			"top_categories": bson.A{
+				bson.M{"$match": bson.M{"category": bson.M{"$exists": true}}},
+				bson.M{"$group": bson.M{"_id": "$category", "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+				bson.M{"$limit": top},
+			},
+		}}},
+	}
+
+	cursor, err := repository.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return
+	}
+
+	var result []struct {
+		ByStatus []Count `bson:"by_status"`
+		PerDay   []struct {
+			ID struct {
+				Date   string `bson:"date"`
+				Status string `bson:"status"`
+			} `bson:"_id"`
+			Count int `bson:"count"`
+		} `bson:"per_day"`
+		Duration []struct {
+			Average float64 `bson:"average"`
+		} `bson:"duration"`
+		TopCategories []CategoryCount `bson:"top_categories"`
+	}
+	if err = cursor.All(ctx, &result); err != nil || len(result) == 0 {
+		return
+	}
+
+	summary.ByStatus = result[0].ByStatus
+	summary.TopCategories = result[0].TopCategories
+	for _, count := range result[0].PerDay {
+		summary.PerDay = append(summary.PerDay, DayCount{Date: count.ID.Date, Status: count.ID.Status, Count: count.Count})
+	}
+	if len(result[0].Duration) > 0 {
+		summary.AverageDuration = time.Duration(result[0].Duration[0].Average)
+	}
+
+	return
+}
+
+func (repository *mongoAppointmentRepository) HelperWorkloads(ctx context.Context, filter AppointmentFilter) (workloads []HelperWorkload, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	match, err := filter.Query()
+	if err != nil {
+		return
+	}
+	if _, ok := match["helper"]; !ok {
+		match["helper"] = bson.M{"$exists": true}
+	}
+
+	durationIf := func(status string) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", status}}, "$duration", 0}}}
+	}
+	countIf := func(status string) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", status}}, 1, 0}}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          bson.M{"helper": "$helper", "category": "$category"},
+			"appointments": bson.M{"$sum": 1},
+			"booked_duration": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$status", models.AppointmentStatusCancelled}}, 0, "$duration",
+			}}},
+			"completed_duration": durationIf(models.AppointmentStatusCompleted),
+			"no_shows":           countIf(models.AppointmentStatusNoShow),
+			"cancellations":      countIf(models.AppointmentStatusCancelled),
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id.helper", Value: 1}, {Key: "_id.category", Value: 1}}}},
+	}
+
+	cursor, err := repository.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return
+	}
+
+	var results []struct {
+		ID struct {
+			Helper   primitive.ObjectID `bson:"helper"`
+			Category primitive.ObjectID `bson:"category"`
+		} `bson:"_id"`
+		Appointments      int   `bson:"appointments"`
+		BookedDuration    int64 `bson:"booked_duration"`
+		CompletedDuration int64 `bson:"completed_duration"`
+		NoShows           int   `bson:"no_shows"`
+		Cancellations     int   `bson:"cancellations"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return
+	}
+
+	for _, result := range results {
+		workloads = append(workloads, HelperWorkload{
+			Helper:            result.ID.Helper,
+			Category:          result.ID.Category,
+			Appointments:      result.Appointments,
+			BookedDuration:    time.Duration(result.BookedDuration),
+			CompletedDuration: time.Duration(result.CompletedDuration),
+			NoShows:           result.NoShows,
+			Cancellations:     result.Cancellations,
+		})
+	}
+
+	return
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/database"
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoCategoryRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func (repository *mongoCategoryRepository) Find(ctx context.Context, filter CategoryFilter) (categories []models.Category, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	query, err := filter.Query()
+	if err != nil {
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "sort_order", Value: 1}, {Key: "name", Value: 1}})
+	cursor, err := repository.collection.Find(ctx, query, opts)
+	if err != nil {
+		return
+	}
+
+	err = cursor.All(ctx, &categories)
+	return
+}
+
+func (repository *mongoCategoryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (category models.Category, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	err = mongoError(repository.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&category))
+	return
+}
+
+func (repository *mongoCategoryRepository) Resolve(ctx context.Context, idOrSlug string) (category models.Category, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"slug": idOrSlug},
+		bson.M{"previous_slugs": idOrSlug},
+	}}
+	if id, err := primitive.ObjectIDFromHex(idOrSlug); err == nil {
+		filter = bson.M{"$or": bson.A{
+			bson.M{"_id": id},
+			bson.M{"merged_ids": id},
+		}}
+	}
+
+	err = mongoError(repository.collection.FindOne(ctx, filter).Decode(&category))
+	return
+}
+
+func (repository *mongoCategoryRepository) FindDescendants(ctx context.Context, id primitive.ObjectID) (categories []models.Category, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	cursor, err := repository.collection.Find(ctx, bson.M{"ancestors": id})
+	if err != nil {
+		return
+	}
+
+	err = cursor.All(ctx, &categories)
+	return
+}
+
+func (repository *mongoCategoryRepository) CountChildren(ctx context.Context, parentID *primitive.ObjectID) (count int, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	total, err := repository.collection.CountDocuments(ctx, bson.M{"parent_id": parentID})
+	return int(total), err
+}
+
+func (repository *mongoCategoryRepository) NameExists(ctx context.Context, name string, excludeID primitive.ObjectID) (exists bool, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	filter := bson.M{"name": name, "_id": bson.M{"$ne": excludeID}}
+	opts := options.Count().SetCollation(database.CaseInsensitiveCollation).SetLimit(1)
+
+	count, err := repository.collection.CountDocuments(ctx, filter, opts)
+	return count > 0, err
+}
+
+func (repository *mongoCategoryRepository) SlugExists(ctx context.Context, slug string, excludeID primitive.ObjectID) (exists bool, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	filter := bson.M{
+		"_id": bson.M{"$ne": excludeID},
+		"$or": bson.A{
+			bson.M{"slug": slug},
+			bson.M{"previous_slugs": slug},
+		},
+	}
+
+	count, err := repository.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+func (repository *mongoCategoryRepository) Insert(ctx context.Context, category *models.Category) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	if category.ID.IsZero() {
+		category.ID = primitive.NewObjectID()
+	}
+
+	_, err = repository.collection.InsertOne(ctx, category)
+	return mongoError(err)
+}
+
+func (repository *mongoCategoryRepository) Update(ctx context.Context, category models.Category) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	result, err := repository.collection.ReplaceOne(ctx, bson.M{"_id": category.ID}, category)
+	return matchedOne(result, err)
+}
+
+func (repository *mongoCategoryRepository) Delete(ctx context.Context, id primitive.ObjectID) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	result, err := repository.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return
+	}
+	if result.DeletedCount == 0 {
+		err = ErrNotFound
+	}
+
+	return
+}
+
+func (repository *mongoCategoryRepository) SetSortOrders(ctx context.Context, parentID *primitive.ObjectID, ids []primitive.ObjectID) (matched int, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	if len(ids) == 0 {
+		return
+	}
+
+	updates := make([]mongo.WriteModel, len(ids))
+	for i, id := range ids {
+		updates[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id, "parent_id": parentID}).
+			SetUpdate(bson.M{"$set": bson.M{"sort_order": i}})
+	}
+
+	result, err := repository.collection.BulkWrite(ctx, updates)
+	if err != nil {
+		return
+	}
+
+	matched = int(result.MatchedCount)
+	return
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoEmailRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func (repository *mongoEmailRepository) Find(ctx context.Context, status string) (emails []models.OutboundEmail, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	cursor, err := repository.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return
+	}
+
+	err = cursor.All(ctx, &emails)
+	return
+}
+
+func (repository *mongoEmailRepository) FindByID(ctx context.Context, id primitive.ObjectID) (email models.OutboundEmail, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	err = mongoError(repository.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&email))
+	return
+}
+
+func (repository *mongoEmailRepository) Insert(ctx context.Context, email *models.OutboundEmail) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	if email.ID.IsZero() {
+		email.ID = primitive.NewObjectID()
+	}
+
+	_, err = repository.collection.InsertOne(ctx, email)
+	return mongoError(err)
+}
+
+func (repository *mongoEmailRepository) Requeue(ctx context.Context, id primitive.ObjectID, now time.Time) (email models.OutboundEmail, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":    id,
+		"status": bson.M{"$in": bson.A{models.EmailStatusDead, models.EmailStatusSent}},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":          models.EmailStatusQueued,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"updated_at":      now,
+		},
+		"$unset": bson.M{"sent_at": ""},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = mongoError(repository.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&email))
+	return
+}
+
+func (repository *mongoEmailRepository) Claim(ctx context.Context, owner string, now time.Time, lockedUntil time.Time) (email models.OutboundEmail, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"status": models.EmailStatusQueued, "next_attempt_at": bson.M{"$lte": now}},
+			bson.M{"status": models.EmailStatusSending, "locked_until": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       models.EmailStatusSending,
+			"locked_by":    owner,
+			"locked_until": lockedUntil,
+			"updated_at":   now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	err = mongoError(repository.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&email))
+	return
+}
+
+func (repository *mongoEmailRepository) Finish(ctx context.Context, email models.OutboundEmail, owner string, attempt models.EmailDeliveryAttempt) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	set := bson.M{
+		"status":          email.Status,
+		"next_attempt_at": email.NextAttemptAt,
+		"updated_at":      email.UpdatedAt,
+	}
+	if email.SentAt != nil {
+		set["sent_at"] = email.SentAt
+	}
+
+	update := bson.M{
+		"$set":   set,
+		"$push":  bson.M{"deliveries": attempt},
+		"$unset": bson.M{"locked_by": "", "locked_until": ""},
+	}
+
+	return matchedOne(repository.collection.UpdateOne(ctx, bson.M{"_id": email.ID, "locked_by": owner}, update))
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoExportRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func (repository *mongoExportRepository) FindByID(ctx context.Context, id primitive.ObjectID) (export models.Export, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	err = mongoError(repository.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&export))
+	return
+}
+
+func (repository *mongoExportRepository) Insert(ctx context.Context, export *models.Export) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	if export.ID.IsZero() {
+		export.ID = primitive.NewObjectID()
+	}
+
+	_, err = repository.collection.InsertOne(ctx, export)
+	return mongoError(err)
+}
+
+func (repository *mongoExportRepository) Update(ctx context.Context, export models.Export) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	result, err := repository.collection.ReplaceOne(ctx, bson.M{"_id": export.ID}, export)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return
+}
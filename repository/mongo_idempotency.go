@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Los registros vencidos se eliminan con el índice TTL de expires_at, que MongoDB
+// aplica con algunos segundos de retraso; mientras tanto se tratan como inexistentes
+type mongoIdempotencyRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func (repository *mongoIdempotencyRepository) Reserve(ctx context.Context, record models.IdempotencyRecord) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	_, err = repository.collection.InsertOne(ctx, record)
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	// Reemplaza el registro si venció y todavía no se eliminó
+	err = repository.collection.FindOneAndReplace(ctx, bson.M{
+		"_id":        record.Key,
+		"expires_at": bson.M{"$lte": time.Now()},
+	}, record).Err()
+	if err == mongo.ErrNoDocuments {
+		return ErrDuplicate
+	}
+
+	return mongoError(err)
+}
+
+func (repository *mongoIdempotencyRepository) FindByKey(ctx context.Context, key string) (record models.IdempotencyRecord, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	err = mongoError(repository.collection.FindOne(ctx, bson.M{
+		"_id":        key,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}).Decode(&record))
+	return
+}
+
+func (repository *mongoIdempotencyRepository) Complete(ctx context.Context, record models.IdempotencyRecord) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	result, err := repository.collection.UpdateOne(ctx, bson.M{"_id": record.Key}, bson.M{"$set": bson.M{
+		"completed":    true,
+		"status":       record.Status,
+		"content_type": record.ContentType,
+		"body":         record.Body,
+	}})
+	return matchedOne(result, err)
+}
+
+func (repository *mongoIdempotencyRepository) Delete(ctx context.Context, key string) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	_, err = repository.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoJobRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func (repository *mongoJobRepository) Find(ctx context.Context, appointmentID primitive.ObjectID) (jobs []models.Job, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "run_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := repository.collection.Find(ctx, bson.M{"appointment_id": appointmentID}, opts)
+	if err != nil {
+		return
+	}
+
+	err = cursor.All(ctx, &jobs)
+	return
+}
+
+func (repository *mongoJobRepository) Insert(ctx context.Context, job *models.Job) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+
+	_, err = repository.collection.InsertOne(ctx, job)
+	return mongoError(err)
+}
+
+func (repository *mongoJobRepository) CancelPending(ctx context.Context, appointmentID primitive.ObjectID, types []string, now time.Time) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	filter := bson.M{"appointment_id": appointmentID, "status": models.JobStatusPending}
+	if len(types) > 0 {
+		filter["type"] = bson.M{"$in": types}
+	}
+	update := bson.M{"$set": bson.M{"status": models.JobStatusCancelled, "updated_at": now}}
+
+	_, err = repository.collection.UpdateMany(ctx, filter, update)
+	return
+}
+
+func (repository *mongoJobRepository) Claim(ctx context.Context, owner string, now time.Time, lockedUntil time.Time) (job models.Job, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	filter := bson.M{
+		"run_at": bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{"status": models.JobStatusPending},
+			bson.M{"status": models.JobStatusRunning, "locked_until": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       models.JobStatusRunning,
+			"locked_by":    owner,
+			"locked_until": lockedUntil,
+			"updated_at":   now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	err = mongoError(repository.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job))
+	return
+}
+
+func (repository *mongoJobRepository) AddSentTo(ctx context.Context, id primitive.ObjectID, recipient string) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	return matchedOne(repository.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$addToSet": bson.M{"sent_to": recipient}}))
+}
+
+func (repository *mongoJobRepository) Finish(ctx context.Context, job models.Job, owner string) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	set := bson.M{
+		"status":     job.Status,
+		"run_at":     job.RunAt,
+		"updated_at": job.UpdatedAt,
+	}
+	if job.LastError != "" {
+		set["last_error"] = job.LastError
+	}
+
+	update := bson.M{
+		"$set":   set,
+		"$unset": bson.M{"locked_by": "", "locked_until": ""},
+	}
+
+	return matchedOne(repository.collection.UpdateOne(ctx, bson.M{"_id": job.ID, "locked_by": owner}, update))
+}
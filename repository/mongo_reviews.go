@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoReviewRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func (repository *mongoReviewRepository) Find(ctx context.Context, filter ReviewFilter) (reviews []models.Review, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	cursor, err := repository.collection.Find(ctx, filter.Query(), opts)
+	if err != nil {
+		return
+	}
+
+	err = cursor.All(ctx, &reviews)
+	return
+}
+
+func (repository *mongoReviewRepository) FindByID(ctx context.Context, id primitive.ObjectID) (review models.Review, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	err = mongoError(repository.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&review))
+	return
+}
+
+func (repository *mongoReviewRepository) Insert(ctx context.Context, review *models.Review) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	if review.ID.IsZero() {
+		review.ID = primitive.NewObjectID()
+	}
+
+	_, err = repository.collection.InsertOne(ctx, review)
+	return mongoError(err)
+}
+
+func (repository *mongoReviewRepository) Update(ctx context.Context, review models.Review) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	result, err := repository.collection.ReplaceOne(ctx, bson.M{"_id": review.ID}, review)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return
+}
+
+func (repository *mongoReviewRepository) Delete(ctx context.Context, id primitive.ObjectID) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	result, err := repository.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return
+}
+
+func (repository *mongoReviewRepository) Rating(ctx context.Context, subjectID primitive.ObjectID) (rating *models.UserRating, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"subject_id":  subjectID,
+			"author_role": models.ReviewRoleRequester,
+			"status":      models.ReviewStatusApproved,
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     nil,
+			"average": bson.M{"$avg": "$score"},
+			"count":   bson.M{"$sum": 1},
+		}}},
+	}
+
+	cursor, err := repository.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return
+	}
+
+	var ratings []models.UserRating
+	if err = cursor.All(ctx, &ratings); err != nil || len(ratings) == 0 {
+		return
+	}
+
+	return &ratings[0], nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoSessionRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+// Las sesiones se guardan con el id también en _id, porque el modelo no define
+// etiquetas bson y su id se guarda en el campo "id"
+type sessionDocument struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	models.Session `bson:",inline"`
+}
+
+func (repository *mongoSessionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (session models.Session, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	var document sessionDocument
+	if err = mongoError(repository.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&document)); err != nil {
+		return
+	}
+
+	session = document.Session
+	session.ID = document.ID
+	return
+}
+
+func (repository *mongoSessionRepository) Insert(ctx context.Context, session *models.Session) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+
+	_, err = repository.collection.InsertOne(ctx, sessionDocument{ID: session.ID, Session: *session})
+	return mongoError(err)
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoUserImportRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func (repository *mongoUserImportRepository) FindByID(ctx context.Context, id primitive.ObjectID) (job models.UserImport, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	err = mongoError(repository.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&job))
+	return
+}
+
+func (repository *mongoUserImportRepository) Insert(ctx context.Context, job *models.UserImport) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+
+	_, err = repository.collection.InsertOne(ctx, job)
+	return mongoError(err)
+}
+
+func (repository *mongoUserImportRepository) SaveProgress(ctx context.Context, job models.UserImport) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	// Se guarda una lista vacía en lugar de null para que FailStale pueda agregar errores
+	errors := job.Errors
+	if errors == nil {
+		errors = []models.ImportRowError{}
+	}
+
+	update := bson.M{"$set": bson.M{
+		"status":      job.Status,
+		"processed":   job.Processed,
+		"created":     job.Created,
+		"failed":      job.Failed,
+		"errors":      errors,
+		"updated_at":  job.UpdatedAt,
+		"finished_at": job.FinishedAt,
+	}}
+
+	// Una importación marcada como interrumpida no vuelve a quedar en curso
+	filter := bson.M{"_id": job.ID, "status": models.UserImportStatusRunning}
+	return matchedOne(repository.collection.UpdateOne(ctx, filter, update))
+}
+
+func (repository *mongoUserImportRepository) FailStale(ctx context.Context, before time.Time, failure models.ImportRowError) (failed int64, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"status":     models.UserImportStatusRunning,
+		"updated_at": bson.M{"$lt": before},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      models.UserImportStatusFailed,
+			"updated_at":  now,
+			"finished_at": now,
+		},
+		"$push": bson.M{"errors": failure},
+	}
+
+	result, err := repository.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return
+	}
+
+	failed = result.ModifiedCount
+	return
+}
@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/database"
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoUserRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func (repository *mongoUserRepository) Find(ctx context.Context, filter UserFilter) (users []models.User, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	query, err := filter.Query()
+	if err != nil {
+		return
+	}
+
+	cursor, err := repository.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return
+	}
+
+	err = cursor.All(ctx, &users)
+	return
+}
+
+func (repository *mongoUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (user models.User, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	err = mongoError(repository.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user))
+	return
+}
+
+func (repository *mongoUserRepository) FindByEmail(ctx context.Context, email string) (user models.User, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	// La intercalación coincide con el índice único, que no distingue mayúsculas
+	opts := options.FindOne().SetCollation(database.CaseInsensitiveCollation)
+	err = mongoError(repository.collection.FindOne(ctx, bson.M{"email": email}, opts).Decode(&user))
+	return
+}
+
+func (repository *mongoUserRepository) Insert(ctx context.Context, user *models.User) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	user.Version = 1
+
+	_, err = repository.collection.InsertOne(ctx, user)
+	return mongoError(err)
+}
+
+func (repository *mongoUserRepository) Update(ctx context.Context, user models.User) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	version := user.Version
+	user.Version++
+
+	result, err := repository.collection.ReplaceOne(ctx, bson.M{"_id": user.ID, "version": version}, user)
+	if err != nil {
+		return mongoError(err)
+	}
+
+	return matchedVersion(ctx, repository.collection, user.ID, result.MatchedCount)
+}
+
+func (repository *mongoUserRepository) Delete(ctx context.Context, id primitive.ObjectID, version int64) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	result, err := repository.collection.DeleteOne(ctx, bson.M{"_id": id, "version": version})
+	if err != nil {
+		return
+	}
+
+	return matchedVersion(ctx, repository.collection, id, result.DeletedCount)
+}
+
+func (repository *mongoUserRepository) SetPassword(ctx context.Context, id primitive.ObjectID, password string, changedAt time.Time) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{"password": password, "password_changed_at": changedAt, "updated_at": changedAt},
+		"$inc": incrementVersion,
+	}
+	return matchedOne(repository.collection.UpdateOne(ctx, bson.M{"_id": id}, update))
+}
+
+func (repository *mongoUserRepository) SetNotificationPreferences(ctx context.Context, id primitive.ObjectID, preferences models.NotificationPreferences) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{"notification_preferences": preferences, "updated_at": time.Now()},
+		"$inc": incrementVersion,
+	}
+	return matchedOne(repository.collection.UpdateOne(ctx, bson.M{"_id": id}, update))
+}
+
+func (repository *mongoUserRepository) AddPushSubscription(ctx context.Context, id primitive.ObjectID, subscription models.PushSubscription) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	update := bson.M{
+		"$push": bson.M{"push_subscriptions": subscription},
+		"$set":  bson.M{"updated_at": time.Now()},
+		"$inc":  incrementVersion,
+	}
+	return matchedOne(repository.collection.UpdateOne(ctx, bson.M{"_id": id}, update))
+}
+
+func (repository *mongoUserRepository) RemovePushSubscription(ctx context.Context, id primitive.ObjectID, endpoint string) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	update := bson.M{
+		"$pull": bson.M{"push_subscriptions": bson.M{"endpoint": endpoint}},
+		"$inc":  incrementVersion,
+	}
+	return matchedOne(repository.collection.UpdateOne(ctx, bson.M{"_id": id}, update))
+}
+
+func (repository *mongoUserRepository) SetProfileImage(ctx context.Context, id primitive.ObjectID, image string, thumbnails map[string]string, keys []string) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"profile_image":      image,
+			"profile_thumbnails": thumbnails,
+			"profile_image_keys": keys,
+			"updated_at":         time.Now(),
+		},
+		"$inc": incrementVersion,
+	}
+	return matchedOne(repository.collection.UpdateOne(ctx, bson.M{"_id": id}, update))
+}
+
+func (repository *mongoUserRepository) ClearProfileImage(ctx context.Context, id primitive.ObjectID) (previous models.User, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	update := bson.M{
+		"$unset": bson.M{"profile_image": "", "profile_thumbnails": "", "profile_image_keys": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$inc":   incrementVersion,
+	}
+
+	err = mongoError(repository.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update).Decode(&previous))
+	return
+}
+
+func (repository *mongoUserRepository) ReplaceSkill(ctx context.Context, from primitive.ObjectID, to primitive.ObjectID) (matched int64, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	result, err := repository.collection.UpdateMany(ctx,
+		bson.M{"skills": from},
+		bson.M{"$addToSet": bson.M{"skills": to}, "$inc": incrementVersion},
+	)
+	if err != nil {
+		return
+	}
+
+	if _, err = repository.collection.UpdateMany(ctx,
+		bson.M{"skills": from},
+		bson.M{"$pull": bson.M{"skills": from}},
+	); err != nil {
+		return
+	}
+
+	matched = result.MatchedCount
+	return
+}
+
+func (repository *mongoUserRepository) SetRating(ctx context.Context, id primitive.ObjectID, rating *models.UserRating) (err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	update := bson.M{"$unset": bson.M{"rating": ""}, "$inc": incrementVersion}
+	if rating != nil {
+		update = bson.M{"$set": bson.M{"rating": rating}, "$inc": incrementVersion}
+	}
+	return matchedOne(repository.collection.UpdateOne(ctx, bson.M{"_id": id}, update))
+}
+
+func (repository *mongoUserRepository) FindByEmails(ctx context.Context, emails []string) (users []models.User, err error) {
+	if len(emails) == 0 {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	opts := options.Find().
+		SetCollation(database.CaseInsensitiveCollation).
+		SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := repository.collection.Find(ctx, bson.M{"email": bson.M{"$in": emails}}, opts)
+	if err != nil {
+		return
+	}
+
+	err = cursor.All(ctx, &users)
+	return
+}
+
+func (repository *mongoUserRepository) Count(ctx context.Context, filter UserFilter, limit int64) (count int64, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	query, err := filter.Query()
+	if err != nil {
+		return
+	}
+
+	opts := options.Count()
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	return repository.collection.CountDocuments(ctx, query, opts)
+}
+
+// El recorrido no usa el tiempo límite de las operaciones, porque puede abarcar toda la colección
+func (repository *mongoUserRepository) Each(ctx context.Context, filter UserFilter, fn func(user models.User) error) (err error) {
+	query, err := filter.Query()
+	if err != nil {
+		return
+	}
+
+	cursor, err := repository.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user models.User
+		if err = cursor.Decode(&user); err != nil {
+			return
+		}
+		if err = fn(user); err != nil {
+			return
+		}
+	}
+
+	return cursor.Err()
+}
+
+func (repository *mongoUserRepository) Summarize(ctx context.Context, from time.Time, end time.Time) (summary UserSummary, err error) {
+	ctx, cancel := withTimeout(ctx, repository.timeout)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$facet", Value: bson.M{
+			"by_type": bson.A{
+				bson.M{"$group": bson.M{"_id": "$type", "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+			},
+			"by_status": bson.A{
+				bson.M{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+			},
+			"signups": bson.A{
+				bson.M{"$match": bson.M{"created_at": bson.M{"$gte": from, "$lt": end}}},
+				bson.M{"$group": bson.M{
+					"_id":   bson.M{"year": bson.M{"$isoWeekYear": "$created_at"}, "week": bson.M{"$isoWeek": "$created_at"}},
+					"count": bson.M{"$sum": 1},
+				}},
+				bson.M{"$sort": bson.D{{Key: "_id.year", Value: 1}, {Key: "_id.week", Value: 1}}},
+			},
+		}}},
+	}
+
+	cursor, err := repository.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return
+	}
+
+	var result []struct {
+		ByType   []Count `bson:"by_type"`
+		ByStatus []Count `bson:"by_status"`
+		Signups  []struct {
+			ID struct {
+				Year int `bson:"year"`
+				Week int `bson:"week"`
+			} `bson:"_id"`
+			Count int `bson:"count"`
+		} `bson:"signups"`
+	}
+	if err = cursor.All(ctx, &result); err != nil || len(result) == 0 {
+		return
+	}
+
+	summary.ByType = result[0].ByType
+	summary.ByStatus = result[0].ByStatus
+	for _, signups := range result[0].Signups {
+		summary.SignupsPerWeek = append(summary.SignupsPerWeek, WeekCount{Year: signups.ID.Year, Week: signups.ID.Week, Count: signups.Count})
+	}
+
+	return
+}
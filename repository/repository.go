@@ -0,0 +1,212 @@
+// Package repository aísla el acceso a los datos de la aplicación: usuarios, citas,
+// categorías, sesiones, claves de idempotencia, reseñas, notas y adjuntos de citas,
+// importaciones, exportaciones, la cola de correos y las tareas programadas. Cada repositorio tiene una
+// implementación sobre MongoDB y otra en memoria, que permite probar los servicios y
+// los handlers sin una base de datos.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Indica que el documento buscado no existe
+var ErrNotFound = errors.New("el documento no existe")
+
+// Indica que el documento viola un índice único
+var ErrDuplicate = errors.New("el documento ya existe")
+
+// Indica que el documento fue modificado desde que se leyó y no coincide la versión esperada
+var ErrVersionConflict = errors.New("el documento fue modificado por otra operación")
+
+type IUserRepository interface {
+	Find(ctx context.Context, filter UserFilter) (users []models.User, err error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (user models.User, err error)
+	FindByEmail(ctx context.Context, email string) (user models.User, err error)
+	// Las modificaciones aumentan la versión del usuario. Update y Delete sólo se aplican
+	// si la versión guardada es la indicada; si no devuelven ErrVersionConflict
+	Insert(ctx context.Context, user *models.User) (err error)
+	Update(ctx context.Context, user models.User) (err error)
+	Delete(ctx context.Context, id primitive.ObjectID, version int64) (err error)
+
+	SetPassword(ctx context.Context, id primitive.ObjectID, password string, changedAt time.Time) (err error)
+	SetNotificationPreferences(ctx context.Context, id primitive.ObjectID, preferences models.NotificationPreferences) (err error)
+	AddPushSubscription(ctx context.Context, id primitive.ObjectID, subscription models.PushSubscription) (err error)
+	RemovePushSubscription(ctx context.Context, id primitive.ObjectID, endpoint string) (err error)
+	SetProfileImage(ctx context.Context, id primitive.ObjectID, image string, thumbnails map[string]string, keys []string) (err error)
+	ClearProfileImage(ctx context.Context, id primitive.ObjectID) (previous models.User, err error)
+
+	// Reemplaza una habilidad por otra en todos los usuarios que la tienen
+	ReplaceSkill(ctx context.Context, from primitive.ObjectID, to primitive.ObjectID) (matched int64, err error)
+	// Guarda la calificación del usuario, o la quita si es nil
+	SetRating(ctx context.Context, id primitive.ObjectID, rating *models.UserRating) (err error)
+
+	// Busca los usuarios con alguno de los correos, sin distinguir mayúsculas de minúsculas
+	FindByEmails(ctx context.Context, emails []string) (users []models.User, err error)
+	// Cuenta los usuarios que cumplen el filtro, hasta limit si es positivo
+	Count(ctx context.Context, filter UserFilter, limit int64) (count int64, err error)
+	// Recorre los usuarios que cumplen el filtro en el orden de Find, sin cargarlos todos en memoria
+	Each(ctx context.Context, filter UserFilter, fn func(user models.User) error) (err error)
+	// Cuenta los usuarios por tipo y estado, y las altas por semana entre from y end
+	Summarize(ctx context.Context, from time.Time, end time.Time) (summary UserSummary, err error)
+}
+
+type IAppointmentRepository interface {
+	Find(ctx context.Context, filter AppointmentFilter) (appointments []models.Appointment, err error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (appointment models.Appointment, err error)
+	// Las modificaciones aumentan la versión de la cita. Update y Delete sólo se aplican
+	// si la versión guardada es la indicada; si no devuelven ErrVersionConflict
+	Insert(ctx context.Context, appointment *models.Appointment) (err error)
+	Update(ctx context.Context, appointment models.Appointment) (err error)
+	Delete(ctx context.Context, id primitive.ObjectID, version int64) (err error)
+
+	// Reasigna todas las citas de una categoría a otra
+	ReassignCategory(ctx context.Context, from primitive.ObjectID, to primitive.ObjectID) (modified int64, err error)
+
+	// Cuenta las citas que cumplen el filtro, hasta limit si es positivo
+	Count(ctx context.Context, filter AppointmentFilter, limit int64) (count int64, err error)
+	// Recorre las citas que cumplen el filtro por fecha, sin cargarlas todas en memoria
+	Each(ctx context.Context, filter AppointmentFilter, fn func(appointment models.Appointment) error) (err error)
+	// Resume las citas con fecha entre from y end; top limita las categorías más pedidas
+	Summarize(ctx context.Context, from time.Time, end time.Time, top int) (summary AppointmentSummary, err error)
+	// Suma el trabajo de cada ayudante por categoría en las citas con ayudante que cumplen el filtro
+	HelperWorkloads(ctx context.Context, filter AppointmentFilter) (workloads []HelperWorkload, err error)
+}
+
+type ICategoryRepository interface {
+	// Las categorías se devuelven ordenadas por posición y nombre
+	Find(ctx context.Context, filter CategoryFilter) (categories []models.Category, err error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (category models.Category, err error)
+	// Busca por id, por id fusionado, por slug o por slug anterior
+	Resolve(ctx context.Context, idOrSlug string) (category models.Category, err error)
+	FindDescendants(ctx context.Context, id primitive.ObjectID) (categories []models.Category, err error)
+	CountChildren(ctx context.Context, parentID *primitive.ObjectID) (count int, err error)
+	// Compara el nombre sin distinguir mayúsculas de minúsculas
+	NameExists(ctx context.Context, name string, excludeID primitive.ObjectID) (exists bool, err error)
+	// Considera también los slugs anteriores
+	SlugExists(ctx context.Context, slug string, excludeID primitive.ObjectID) (exists bool, err error)
+	Insert(ctx context.Context, category *models.Category) (err error)
+	Update(ctx context.Context, category models.Category) (err error)
+	Delete(ctx context.Context, id primitive.ObjectID) (err error)
+
+	// Asigna la posición de cada categoría según el orden de ids; matched cuenta
+	// sólo las categorías que pertenecen a la categoría padre
+	SetSortOrders(ctx context.Context, parentID *primitive.ObjectID, ids []primitive.ObjectID) (matched int, err error)
+}
+
+type ISessionRepository interface {
+	FindByID(ctx context.Context, id primitive.ObjectID) (session models.Session, err error)
+	Insert(ctx context.Context, session *models.Session) (err error)
+}
+
+type IIdempotencyRepository interface {
+	// Guarda la clave mientras se procesa la solicitud; devuelve ErrDuplicate si la
+	// clave ya existe y no venció
+	Reserve(ctx context.Context, record models.IdempotencyRecord) (err error)
+	FindByKey(ctx context.Context, key string) (record models.IdempotencyRecord, err error)
+	// Guarda la respuesta de la solicitud y marca la clave como completada
+	Complete(ctx context.Context, record models.IdempotencyRecord) (err error)
+	Delete(ctx context.Context, key string) (err error)
+}
+
+type IReviewRepository interface {
+	// Las reseñas se devuelven de la más reciente a la más antigua
+	Find(ctx context.Context, filter ReviewFilter) (reviews []models.Review, err error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (review models.Review, err error)
+	// Devuelve ErrDuplicate si la cita ya tiene una reseña del mismo autor
+	Insert(ctx context.Context, review *models.Review) (err error)
+	Update(ctx context.Context, review models.Review) (err error)
+	Delete(ctx context.Context, id primitive.ObjectID) (err error)
+
+	// Calcula el promedio de las reseñas aprobadas de los solicitantes sobre un usuario;
+	// devuelve nil si no tiene
+	Rating(ctx context.Context, subjectID primitive.ObjectID) (rating *models.UserRating, err error)
+}
+
+type IAppointmentNoteRepository interface {
+	// Las notas se devuelven en orden cronológico; la visibilidad vacía incluye todas
+	Find(ctx context.Context, appointmentID primitive.ObjectID, visibility string) (notes []models.AppointmentNote, err error)
+	Insert(ctx context.Context, note *models.AppointmentNote) (err error)
+	// Devuelve ErrNotFound si la nota no existe o es de otra cita
+	Delete(ctx context.Context, appointmentID primitive.ObjectID, id primitive.ObjectID) (err error)
+}
+
+type IAppointmentAttachmentRepository interface {
+	// Los adjuntos se devuelven en orden cronológico
+	Find(ctx context.Context, appointmentID primitive.ObjectID) (attachments []models.AppointmentAttachment, err error)
+	// Devuelve ErrNotFound si el adjunto no existe o es de otra cita
+	FindByID(ctx context.Context, appointmentID primitive.ObjectID, id primitive.ObjectID) (attachment models.AppointmentAttachment, err error)
+	Insert(ctx context.Context, attachment *models.AppointmentAttachment) (err error)
+	Delete(ctx context.Context, appointmentID primitive.ObjectID, id primitive.ObjectID) (err error)
+}
+
+type IUserImportRepository interface {
+	FindByID(ctx context.Context, id primitive.ObjectID) (job models.UserImport, err error)
+	Insert(ctx context.Context, job *models.UserImport) (err error)
+	// Guarda el progreso y el estado de una importación; devuelve ErrNotFound si ya no está en curso
+	SaveProgress(ctx context.Context, job models.UserImport) (err error)
+	// Marca como fallidas las importaciones en curso que no se actualizan desde before
+	FailStale(ctx context.Context, before time.Time, failure models.ImportRowError) (failed int64, err error)
+}
+
+type IExportRepository interface {
+	FindByID(ctx context.Context, id primitive.ObjectID) (export models.Export, err error)
+	Insert(ctx context.Context, export *models.Export) (err error)
+	Update(ctx context.Context, export models.Export) (err error)
+}
+
+type IEmailRepository interface {
+	// Los correos se devuelven del más reciente al más antiguo; el estado vacío incluye todos
+	Find(ctx context.Context, status string) (emails []models.OutboundEmail, err error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (email models.OutboundEmail, err error)
+	Insert(ctx context.Context, email *models.OutboundEmail) (err error)
+	// Vuelve a encolar un correo descartado o enviado; devuelve ErrNotFound si no existe o sigue en cola
+	Requeue(ctx context.Context, id primitive.ObjectID, now time.Time) (email models.OutboundEmail, err error)
+
+	// Reserva hasta lockedUntil el próximo correo pendiente o con la reserva vencida y
+	// cuenta el intento; devuelve ErrNotFound si no hay correos pendientes
+	Claim(ctx context.Context, owner string, now time.Time, lockedUntil time.Time) (email models.OutboundEmail, err error)
+	// Registra el intento de entrega de un correo reservado por owner y guarda su
+	// estado, la fecha del próximo intento y la de envío; libera la reserva
+	Finish(ctx context.Context, email models.OutboundEmail, owner string, attempt models.EmailDeliveryAttempt) (err error)
+}
+
+type IJobRepository interface {
+	// Las tareas de una cita se devuelven por fecha de ejecución
+	Find(ctx context.Context, appointmentID primitive.ObjectID) (jobs []models.Job, err error)
+	Insert(ctx context.Context, job *models.Job) (err error)
+	// Cancela las tareas pendientes de una cita de los tipos indicados; sin tipos cancela todas
+	CancelPending(ctx context.Context, appointmentID primitive.ObjectID, types []string, now time.Time) (err error)
+
+	// Reserva hasta lockedUntil la próxima tarea vencida, pendiente o con la reserva
+	// vencida, y cuenta el intento; devuelve ErrNotFound si no hay tareas vencidas
+	Claim(ctx context.Context, owner string, now time.Time, lockedUntil time.Time) (job models.Job, err error)
+	// Registra que el aviso de la tarea ya se envió al destinatario
+	AddSentTo(ctx context.Context, id primitive.ObjectID, recipient string) (err error)
+	// Guarda el estado, la fecha de ejecución y el último error de una tarea reservada
+	// por owner y libera la reserva; devuelve ErrNotFound si la reserva expiró
+	Finish(ctx context.Context, job models.Job, owner string) (err error)
+}
+
+// Conjunto de repositorios que comparten una base de datos
+type IStore interface {
+	Users() IUserRepository
+	Appointments() IAppointmentRepository
+	Categories() ICategoryRepository
+	Sessions() ISessionRepository
+	IdempotencyKeys() IIdempotencyRepository
+	Reviews() IReviewRepository
+	AppointmentNotes() IAppointmentNoteRepository
+	AppointmentAttachments() IAppointmentAttachmentRepository
+	UserImports() IUserImportRepository
+	Exports() IExportRepository
+	Emails() IEmailRepository
+	Jobs() IJobRepository
+
+	// Ejecuta fn en una transacción; las operaciones deben usar el contexto recibido
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Cantidad de documentos con un mismo valor
+type Count struct {
+	Key   string `bson:"_id"`
+	Count int    `bson:"count"`
+}
+
+// Cantidad de documentos de una semana ISO
+type WeekCount struct {
+	Year  int
+	Week  int
+	Count int
+}
+
+// Cantidad de citas de un día con un estado
+type DayCount struct {
+	Date   string
+	Status string
+	Count  int
+}
+
+// Cantidad de citas de una categoría
+type CategoryCount struct {
+	ID    primitive.ObjectID `bson:"_id"`
+	Count int                `bson:"count"`
+}
+
+// Totales de los usuarios; los conteos van de mayor a menor
+type UserSummary struct {
+	ByType   []Count
+	ByStatus []Count
+	// Altas por semana, en orden cronológico
+	SignupsPerWeek []WeekCount
+}
+
+// Totales de las citas de un período; los conteos van de mayor a menor
+type AppointmentSummary struct {
+	ByStatus []Count
+	// Citas por día, en formato 2006-01-02 y UTC, y por estado, en orden cronológico
+	PerDay          []DayCount
+	AverageDuration time.Duration
+	TopCategories   []CategoryCount
+}
+
+// Trabajo de un ayudante en una categoría
+type HelperWorkload struct {
+	Helper       primitive.ObjectID
+	Category     primitive.ObjectID
+	Appointments int
+	// Suma de las duraciones de las citas no canceladas
+	BookedDuration    time.Duration
+	CompletedDuration time.Duration
+	NoShows           int
+	Cancellations     int
+}
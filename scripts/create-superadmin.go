@@ -9,15 +9,19 @@ import (
 	"github.com/maferuy/ayudapp-admin-backend-core/database"
 	"github.com/maferuy/ayudapp-admin-backend-core/models"
 	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 var ctx = context.Background()
 
+// Contraseña predeterminada del superadministrador, sólo aceptada en desarrollo
+const defaultAdminPassword = "123456"
+
 func main() {
 	adminFirstName := flag.String("firstname", "Super", "Nombre de administrador")
 	adminLastName := flag.String("lastname", "Admin", "Apellido del administrador")
 	adminEmail := flag.String("email", "admin@mafer.dev", "El correo electrónico del administrador")
-	adminPassword := flag.String("password", "123456", "Contraseña del administrador")
+	adminPassword := flag.String("password", defaultAdminPassword, "Contraseña del administrador")
 
 	// Obtiene los valores de los argumentos pasados por CLI
 	flag.Parse()
@@ -28,6 +32,10 @@ func main() {
 		log.Fatalf("Error al leer la configuración: %v", err)
 	}
 
+	if config.Environment != utils.EnvDevelopment && *adminPassword == defaultAdminPassword {
+		log.Fatalf("La contraseña predeterminada del administrador sólo se permite en desarrollo")
+	}
+
 	// Encripta el password
 	password, err := utils.HashPassword(*adminPassword)
 	if err != nil {
@@ -41,24 +49,27 @@ func main() {
 	}
 
 	// Asigna la colección de usuarios
-	collection := client.Database("users-dev").Collection("users")
+	collection := client.Database(config.DatabaseName).Collection("users")
 
 	// Crea el usuario
 	admin := models.User{
 		FirstName:         *adminFirstName,
 		LastName:          *adminLastName,
-		Email:             *adminEmail,
+		Email:             utils.NormalizeEmail(*adminEmail),
 		Password:          password,
 		Type:              "superadmin",
 		Status:            "active",
 		CreatedAt:         time.Now(),
 		UpdatedAt:         time.Now(),
 		PasswordChangedAt: time.Now(),
+		Version:           1,
 	}
 
 	// Inserta el registro en la base de dats
 	_, err = collection.InsertOne(ctx, admin)
-	if err != nil {
+	if mongo.IsDuplicateKeyError(err) {
+		log.Fatalf("Ya existe un usuario con el correo %s", admin.Email)
+	} else if err != nil {
 		log.Fatalf("Error al crear el superadministrador: %v", err)
 	}
 
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"github.com/maferuy/ayudapp-admin-backend-core/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tipos de archivo admitidos como adjuntos, detectados a partir del contenido, con su extensión
+var allowedAttachmentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+type UploadAppointmentAttachmentResponse struct {
+	Attachment models.AppointmentAttachment `json:"attachment"`
+}
+
+type GetAppointmentAttachmentsResponse struct {
+	Attachments []models.AppointmentAttachment `json:"attachments"`
+}
+
+type IAppointmentAttachmentService interface {
+	GetAttachments(appointmentId string) (response GetAppointmentAttachmentsResponse, err error)
+	UploadAttachment(appointmentId string, fileName string, data []byte, author models.NoteAuthor) (response UploadAppointmentAttachmentResponse, err error)
+	OpenAttachment(appointmentId string, attachmentId string) (attachment models.AppointmentAttachment, body io.ReadCloser, err error)
+	DeleteAttachment(appointmentId string, attachmentId string) (err error)
+}
+
+type AppointmentAttachmentService struct {
+	store   repository.IStore
+	storage storage.IStorage
+}
+
+/** Obtiene los archivos adjuntos de una cita
+ *
+ * @param appointmentId string "El id de la cita"
+ * @return response GetAppointmentAttachmentsResponse "Los archivos adjuntos"
+ * @return err error "El error de la operación"
+ */
+func (service *AppointmentAttachmentService) GetAttachments(appointmentId string) (response GetAppointmentAttachmentsResponse, err error) {
+	id, err := parseID(appointmentId)
+	if err != nil {
+		return
+	}
+
+	response.Attachments, err = service.store.AppointmentAttachments().Find(ctx, id)
+	return
+}
+
+/** Guarda un archivo adjunto de una cita, como una foto o un formulario firmado
+ *
+ * @param appointmentId string "El id de la cita"
+ * @param fileName string "El nombre original del archivo"
+ * @param data []byte "El contenido del archivo"
+ * @param author models.NoteAuthor "El administrador que sube el archivo"
+ * @return response UploadAppointmentAttachmentResponse "El archivo adjunto"
+ * @return err error "El error de la operación"
+ */
+func (service *AppointmentAttachmentService) UploadAttachment(appointmentId string, fileName string, data []byte, author models.NoteAuthor) (response UploadAppointmentAttachmentResponse, err error) {
+	contentType := http.DetectContentType(data)
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+
+	extension, ok := allowedAttachmentTypes[contentType]
+	if !ok {
+		err = NewValidationError("unsupported_attachment_type", "el archivo debe ser una imagen JPEG, PNG, GIF o WebP, o un documento PDF")
+		return
+	}
+
+	id, err := findAppointmentID(service.store, appointmentId)
+	if err != nil {
+		return
+	}
+
+	attachment := models.AppointmentAttachment{
+		ID:            primitive.NewObjectID(),
+		AppointmentID: id,
+		FileName:      sanitizeFileName(fileName, extension),
+		ContentType:   contentType,
+		Size:          int64(len(data)),
+		UploadedBy:    author,
+		CreatedAt:     time.Now(),
+	}
+
+	// La clave es privada y aleatoria: el contenido sólo se descarga con OpenAttachment
+	suffix := make([]byte, 16)
+	if _, err = rand.Read(suffix); err != nil {
+		return
+	}
+	attachment.Key = fmt.Sprintf("%s/appointments/%s/%s%s", storage.PrivatePrefix, id.Hex(), hex.EncodeToString(suffix), extension)
+
+	if err = service.storage.Put(ctx, attachment.Key, bytes.NewReader(data), contentType); err != nil {
+		return
+	}
+
+	if err = service.store.AppointmentAttachments().Insert(ctx, &attachment); err != nil {
+		if deleteErr := service.storage.Delete(ctx, attachment.Key); deleteErr != nil {
+			log.Printf("Error al eliminar el archivo %s: %v", attachment.Key, deleteErr)
+		}
+		return
+	}
+
+	response.Attachment = attachment
+	return
+}
+
+/** Abre el contenido de un archivo adjunto para descargarlo
+ *
+ * @param appointmentId string "El id de la cita"
+ * @param attachmentId string "El id del archivo adjunto"
+ * @return attachment models.AppointmentAttachment "Los datos del archivo"
+ * @return body io.ReadCloser "El contenido, que debe cerrarse"
+ * @return err error "El error de la operación"
+ */
+func (service *AppointmentAttachmentService) OpenAttachment(appointmentId string, attachmentId string) (attachment models.AppointmentAttachment, body io.ReadCloser, err error) {
+	if attachment, err = service.findAttachment(appointmentId, attachmentId); err != nil {
+		return
+	}
+
+	body, err = service.storage.Get(ctx, attachment.Key)
+	if err == storage.ErrNotFound {
+		err = ErrAttachmentContentNotFound
+	}
+
+	return
+}
+
+/** Elimina un archivo adjunto de una cita y su contenido
+ *
+ * @param appointmentId string "El id de la cita"
+ * @param attachmentId string "El id del archivo adjunto"
+ * @return err error "El error de la operación"
+ */
+func (service *AppointmentAttachmentService) DeleteAttachment(appointmentId string, attachmentId string) (err error) {
+	attachment, err := service.findAttachment(appointmentId, attachmentId)
+	if err != nil {
+		return
+	}
+
+	err = service.store.AppointmentAttachments().Delete(ctx, attachment.AppointmentID, attachment.ID)
+	if err == repository.ErrNotFound {
+		err = ErrAttachmentNotFound
+	}
+	if err != nil {
+		return
+	}
+
+	return service.storage.Delete(ctx, attachment.Key)
+}
+
+/** Obtiene un archivo adjunto de una cita
+ *
+ * @param appointmentId string "El id de la cita"
+ * @param attachmentId string "El id del archivo adjunto"
+ * @return attachment models.AppointmentAttachment "Los datos del archivo"
+ * @return err error "El error de la operación"
+ */
+func (service *AppointmentAttachmentService) findAttachment(appointmentId string, attachmentId string) (attachment models.AppointmentAttachment, err error) {
+	id, err := parseID(appointmentId)
+	if err != nil {
+		return
+	}
+
+	attachmentID, err := parseID(attachmentId)
+	if err != nil {
+		return
+	}
+
+	attachment, err = service.store.AppointmentAttachments().FindByID(ctx, id, attachmentID)
+	if err == repository.ErrNotFound {
+		err = ErrAttachmentNotFound
+	}
+
+	return
+}
+
+/** Limpia el nombre original de un archivo para mostrarlo y descargarlo con la extensión de su tipo real
+ *
+ * @param fileName string "El nombre original"
+ * @param extension string "La extensión del tipo detectado"
+ * @return string "El nombre limpio"
+ */
+func sanitizeFileName(fileName string, extension string) string {
+	name := strings.TrimSuffix(filepath.Base(strings.ReplaceAll(fileName, "\\", "/")), filepath.Ext(fileName))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`"/\:*?<>|`, r) {
+			return -1
+		}
+		return r
+	}, name)
+
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." {
+		name = "adjunto"
+	}
+
+	return name + extension
+}
+
+func NewAppointmentAttachmentService(store repository.IStore, storage storage.IStorage) IAppointmentAttachmentService {
+	return &AppointmentAttachmentService{store: store, storage: storage}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CreateAppointmentNoteRequest struct {
+	Body       string `json:"body"`
+	Visibility string `json:"visibility"`
+}
+
+type CreateAppointmentNoteResponse struct {
+	Note models.AppointmentNote `json:"note"`
+}
+
+type GetAppointmentNotesResponse struct {
+	Notes []models.AppointmentNote `json:"notes"`
+}
+
+type IAppointmentNoteService interface {
+	GetNotes(appointmentId string, visibility string) (response GetAppointmentNotesResponse, err error)
+	CreateNote(appointmentId string, req CreateAppointmentNoteRequest, author models.NoteAuthor) (response CreateAppointmentNoteResponse, err error)
+	DeleteNote(appointmentId string, noteId string) (err error)
+}
+
+type AppointmentNoteService struct {
+	store repository.IStore
+}
+
+/** Obtiene las notas de una cita en orden cronológico
+ *
+ * @param appointmentId string "El id de la cita"
+ * @param visibility string "La visibilidad de las notas, vacía para todas"
+ * @return response GetAppointmentNotesResponse "Las notas"
+ * @return err error "El error de la operación"
+ */
+func (service *AppointmentNoteService) GetNotes(appointmentId string, visibility string) (response GetAppointmentNotesResponse, err error) {
+	id, err := parseID(appointmentId)
+	if err != nil {
+		return
+	}
+
+	response.Notes, err = service.store.AppointmentNotes().Find(ctx, id, visibility)
+	return
+}
+
+/** Agrega una nota a una cita
+ *
+ * @param appointmentId string "El id de la cita"
+ * @param req CreateAppointmentNoteRequest "El texto y la visibilidad de la nota"
+ * @param author models.NoteAuthor "El administrador que escribe la nota"
+ * @return response CreateAppointmentNoteResponse "La nota creada"
+ * @return err error "El error de la operación"
+ */
+func (service *AppointmentNoteService) CreateNote(appointmentId string, req CreateAppointmentNoteRequest, author models.NoteAuthor) (response CreateAppointmentNoteResponse, err error) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		err = NewValidationError("note_body_required", "el texto de la nota es requerido")
+		return
+	}
+
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = models.NoteVisibilityInternal
+	}
+	if visibility != models.NoteVisibilityInternal && visibility != models.NoteVisibilityShared {
+		err = NewValidationError("invalid_note_visibility", "la visibilidad debe ser internal o shared")
+		return
+	}
+
+	id, err := findAppointmentID(service.store, appointmentId)
+	if err != nil {
+		return
+	}
+
+	note := models.AppointmentNote{
+		AppointmentID: id,
+		Author:        author,
+		Body:          body,
+		Visibility:    visibility,
+		CreatedAt:     time.Now(),
+	}
+
+	if err = service.store.AppointmentNotes().Insert(ctx, &note); err != nil {
+		return
+	}
+
+	response.Note = note
+	return
+}
+
+/** Elimina una nota de una cita
+ *
+ * @param appointmentId string "El id de la cita"
+ * @param noteId string "El id de la nota"
+ * @return err error "El error de la operación"
+ */
+func (service *AppointmentNoteService) DeleteNote(appointmentId string, noteId string) (err error) {
+	id, err := parseID(appointmentId)
+	if err != nil {
+		return
+	}
+
+	noteID, err := parseID(noteId)
+	if err != nil {
+		return
+	}
+
+	err = service.store.AppointmentNotes().Delete(ctx, id, noteID)
+	if err == repository.ErrNotFound {
+		err = ErrNoteNotFound
+	}
+
+	return
+}
+
+/** Comprueba que una cita exista y devuelve su id
+ *
+ * @param store repository.IStore "El almacenamiento de los datos"
+ * @param appointmentId string "El id de la cita"
+ * @return id primitive.ObjectID "El id de la cita"
+ * @return err error "Error si el id es inválido o la cita no existe"
+ */
+func findAppointmentID(store repository.IStore, appointmentId string) (id primitive.ObjectID, err error) {
+	if id, err = parseID(appointmentId); err != nil {
+		return
+	}
+
+	_, err = store.Appointments().FindByID(ctx, id)
+	if err == repository.ErrNotFound {
+		err = ErrAppointmentNotFound
+	}
+
+	return
+}
+
+func NewAppointmentNoteService(store repository.IStore) IAppointmentNoteService {
+	return &AppointmentNoteService{store: store}
+}
@@ -1,32 +1,44 @@
 package services
 
 import (
-	"errors"
+	"context"
 	"time"
 
 	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
 	"github.com/maferuy/ayudapp-admin-backend-core/utils"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CreateAppointmentRequest struct {
-	Date      time.Time     `json:"date"`
-	Duration  time.Duration `json:"duration"`
-	Address   string        `json:"address"`
-	Status    string        `json:"status"`
-	Helper    string        `json:"helper"`
-	CreatedBy string        `json:"created_by"`
+	Date      time.Time     `json:"date" binding:"required,future"`
+	Duration  time.Duration `json:"duration" binding:"required,gt=0"`
+	Address   string        `json:"address" binding:"required,max=300"`
+	Status    string        `json:"status" binding:"required,appointment_status"`
+	Helper    string        `json:"helper" binding:"required,objectid"`
+	CreatedBy string        `json:"created_by" binding:"required,objectid"`
+	Category  string        `json:"category" binding:"omitempty,objectid"`
 }
 
 type UpdateAppointmentRequest struct {
 	Date      time.Time     `json:"date"`
-	Duration  time.Duration `json:"duration"`
-	Address   string        `json:"address"`
-	Status    string        `json:"status"`
-	Helper    string        `json:"helper"`
-	CreatedBy string        `json:"created_by"`
+	Duration  time.Duration `json:"duration" binding:"omitempty,gt=0"`
+	Address   string        `json:"address" binding:"omitempty,max=300"`
+	Status    string        `json:"status" binding:"omitempty,appointment_status"`
+	Helper    string        `json:"helper" binding:"omitempty,objectid"`
+	CreatedBy string        `json:"created_by" binding:"omitempty,objectid"`
+	Category  string        `json:"category" binding:"omitempty,objectid"`
+}
+
+// Campos editables de una cita, a los que se aplican las actualizaciones parciales
+type appointmentFields struct {
+	Date      time.Time     `json:"date" binding:"required"`
+	Duration  time.Duration `json:"duration" binding:"required,gt=0"`
+	Address   string        `json:"address" binding:"required,max=300"`
+	Status    string        `json:"status" binding:"required,appointment_status"`
+	Helper    string        `json:"helper" binding:"required,objectid"`
+	CreatedBy string        `json:"created_by" binding:"required,objectid"`
+	Category  string        `json:"category" binding:"omitempty,objectid"`
 }
 
 type GetAppointmentsResponse struct {
@@ -46,61 +58,68 @@ type UpdateAppointmentResponse struct {
 }
 
 type IAppointmentService interface {
-	GetAppointments() (response GetAppointmentsResponse, err error)
-	CreateAppointment(req CreateAppointmentRequest) (response CreateAppointmentResponse, err error)
+	GetAppointments(ctx context.Context, filter repository.AppointmentFilter) (response GetAppointmentsResponse, err error)
+	CreateAppointment(ctx context.Context, req CreateAppointmentRequest) (response CreateAppointmentResponse, err error)
 
-	GetAppointment(id string) (response GetAppointmentResponse, err error)
-	UpdateAppointment(id string, req UpdateAppointmentRequest) (response UpdateAppointmentResponse, err error)
-	DeleteAppointment(id string) (err error)
+	GetAppointment(ctx context.Context, id string) (response GetAppointmentResponse, err error)
+	UpdateAppointment(ctx context.Context, id string, match VersionMatch, req UpdateAppointmentRequest) (response UpdateAppointmentResponse, err error)
+	PatchAppointment(ctx context.Context, id string, match VersionMatch, patch []byte) (response UpdateAppointmentResponse, err error)
+	DeleteAppointment(ctx context.Context, id string, match VersionMatch) (err error)
 }
 
 type AppointmentService struct {
-	db *mongo.Database
+	store     repository.IStore
+	scheduler IScheduler
 }
 
 /** Obtiene todos las citas
  *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param filter repository.AppointmentFilter "Los filtros del listado"
  * @return GetAppointmentsResponse "Las citas"
  * @return err error "El error de la operación"
  */
-func (service *AppointmentService) GetAppointments() (response GetAppointmentsResponse, err error) {
-	var appointments []models.Appointment
-	collection := service.db.Collection("appointments")
-
-	cursor, err := collection.Find(ctx, bson.M{})
+func (service *AppointmentService) GetAppointments(ctx context.Context, filter repository.AppointmentFilter) (response GetAppointmentsResponse, err error) {
+	appointments, err := service.store.Appointments().Find(ctx, filter)
 	if err != nil {
 		return
 	}
 
-	if err = cursor.All(ctx, &appointments); err != nil {
-		return
-	}
-
 	response.Appointments = appointments
 	return
 }
 
 /** Crea una cita
  *
+ * La cita y sus recordatorios se guardan en la misma transacción, para que un
+ * reintento no cree la cita dos veces si falla la programación.
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
  * @param req CreateAppointmentRequest "Los valores de la cita a crear"
  * @return CreateAppointmentResponse "El id de la cita creado"
  * @return err error "El error de la operación"
  */
-func (service *AppointmentService) CreateAppointment(req CreateAppointmentRequest) (response CreateAppointmentResponse, err error) {
-	collection := service.db.Collection("appointments")
-
+func (service *AppointmentService) CreateAppointment(ctx context.Context, req CreateAppointmentRequest) (response CreateAppointmentResponse, err error) {
 	createdBy, err := primitive.ObjectIDFromHex(req.CreatedBy)
 	if err != nil {
-		err = errors.New("el ayudante es inválido")
+		err = NewValidationError("invalid_created_by", "el creador es inválido")
 		return
 	}
 
 	helper, err := primitive.ObjectIDFromHex(req.Helper)
 	if err != nil {
-		err = errors.New("el ayudante es inválido")
+		err = NewValidationError("invalid_helper", "el ayudante es inválido")
 		return
 	}
 
+	var category primitive.ObjectID
+	if req.Category != "" {
+		if category, err = primitive.ObjectIDFromHex(req.Category); err != nil {
+			err = NewValidationError("invalid_category", "la categoría es inválida")
+			return
+		}
+	}
+
 	appointment := models.Appointment{
 		Date:      req.Date,
 		Duration:  req.Duration,
@@ -108,134 +127,237 @@ func (service *AppointmentService) CreateAppointment(req CreateAppointmentReques
 		Status:    req.Status,
 		CreatedBy: createdBy,
 		Helper:    helper,
+		Category:  category,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	result, err := collection.InsertOne(ctx, appointment)
+	err = service.store.WithTransaction(ctx, func(tx context.Context) error {
+		if err := service.store.Appointments().Insert(tx, &appointment); err != nil {
+			return err
+		}
+
+		return service.scheduler.ScheduleAppointmentReminders(tx, appointment)
+	})
 	if err != nil {
 		return
 	}
 
-	response.AppointmentID = result.InsertedID.(primitive.ObjectID).Hex()
+	response.AppointmentID = appointment.ID.Hex()
 	return
 }
 
 /** Obtiene una cita
  *
+ * @param ctx context.Context "El contexto de la solicitud"
  * @param id string "El ID de la cita"
  * @return GetAppointmentResponse "La cita"
  * @return err error "El error de la operación"
  */
-func (service *AppointmentService) GetAppointment(appointmentId string) (response GetAppointmentResponse, err error) {
-	collection := service.db.Collection("appointments")
-	var appointment models.Appointment
-
-	id, err := primitive.ObjectIDFromHex(appointmentId)
+func (service *AppointmentService) GetAppointment(ctx context.Context, appointmentId string) (response GetAppointmentResponse, err error) {
+	appointment, err := service.findAppointment(ctx, appointmentId)
 	if err != nil {
 		return
 	}
 
-	filter := bson.M{"_id": id}
+	response.Appointment = appointment
+	return
+}
 
-	count, err := collection.CountDocuments(ctx, filter)
+/** Actualiza una cita
+ *
+ * Sólo se modifican los campos que tienen valor en la solicitud.
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param id string "El id de la cita"
+ * @param match VersionMatch "Las versiones de la cita que acepta el cliente"
+ * @param req UpdateAppointmentRequest "Los valores de la cita a actualizar"
+ * @return UpdateAppointmentResponse "Los datos de la cita actualizado"
+ * @return err error "El error de la operación"
+ */
+func (service *AppointmentService) UpdateAppointment(ctx context.Context, appointmentId string, match VersionMatch, req UpdateAppointmentRequest) (response UpdateAppointmentResponse, err error) {
+	appointment, err := service.findAppointment(ctx, appointmentId)
 	if err != nil {
 		return
 	}
-	if count == 0 {
-		err = errors.New("no se encontró e la cita")
+
+	if err = checkVersion(appointment.Version, match); err != nil {
 		return
 	}
 
-	result := collection.FindOne(ctx, filter)
-	if err = result.Err(); err != nil {
-		return
+	fields := newAppointmentFields(appointment)
+	if !req.Date.IsZero() {
+		fields.Date = req.Date
+	}
+	if req.Duration != 0 {
+		fields.Duration = req.Duration
+	}
+	if req.Address != "" {
+		fields.Address = req.Address
+	}
+	if req.Status != "" {
+		fields.Status = req.Status
+	}
+	if req.Helper != "" {
+		fields.Helper = req.Helper
+	}
+	if req.CreatedBy != "" {
+		fields.CreatedBy = req.CreatedBy
+	}
+	if req.Category != "" {
+		fields.Category = req.Category
 	}
 
-	err = result.Decode(&appointment)
-	if err != nil {
+	if err = utils.ValidateStruct(fields); err != nil {
 		return
 	}
 
-	response.Appointment = appointment
-	return
-
+	return service.saveAppointment(ctx, appointment, fields)
 }
 
-/** Actualiza una cita
+/** Actualiza parcialmente una cita con un parche JSON Merge Patch (RFC 7396)
  *
- * @param req UpdateAppointmentRequest "Los valores de la cita a actualizar"
+ * @param ctx context.Context "El contexto de la solicitud"
  * @param id string "El id de la cita"
- * @return UpdateAppointmentResponse "Los datos de la cita actualizado"
+ * @param match VersionMatch "Las versiones de la cita que acepta el cliente"
+ * @param patch []byte "El parche con los campos a modificar; null vacía un campo"
+ * @return UpdateAppointmentResponse "La cita actualizada"
  * @return err error "El error de la operación"
  */
-func (service *AppointmentService) UpdateAppointment(appointmentId string, req UpdateAppointmentRequest) (response UpdateAppointmentResponse, err error) {
-	collection := service.db.Collection("appointments")
-	var appointment models.Appointment
-
-	id, err := primitive.ObjectIDFromHex(appointmentId)
+func (service *AppointmentService) PatchAppointment(ctx context.Context, appointmentId string, match VersionMatch, patch []byte) (response UpdateAppointmentResponse, err error) {
+	appointment, err := service.findAppointment(ctx, appointmentId)
 	if err != nil {
 		return
 	}
 
-	filter := bson.M{"_id": id}
-
-	count, err := collection.CountDocuments(ctx, filter)
-	if err != nil {
-		return
-	}
-	if count == 0 {
-		err = errors.New("no se encontró e la cita")
+	if err = checkVersion(appointment.Version, match); err != nil {
 		return
 	}
 
-	cursor, err := collection.Find(ctx, filter)
-	if err != nil {
+	var fields appointmentFields
+	if err = applyMergePatch(newAppointmentFields(appointment), patch, &fields); err != nil {
 		return
 	}
 
-	if err = cursor.Decode(&appointment); err != nil {
+	return service.saveAppointment(ctx, appointment, fields)
+}
+
+// Guarda los campos editables de la cita, avisa los cambios y la devuelve como quedó en la base de datos
+func (service *AppointmentService) saveAppointment(ctx context.Context, appointment models.Appointment, fields appointmentFields) (response UpdateAppointmentResponse, err error) {
+	// Las citas pasadas se pueden seguir editando, pero no se pueden mover al pasado
+	if !fields.Date.Equal(appointment.Date) && !fields.Date.After(time.Now()) {
+		err = NewValidationError("date_in_past", "la fecha de la cita debe ser futura")
 		return
 	}
 
+	previous := appointment
+	appointment.Date = fields.Date
+	appointment.Duration = fields.Duration
+	appointment.Address = fields.Address
+	appointment.Status = fields.Status
 	appointment.UpdatedAt = time.Now()
 
-	updatedValues, err := utils.ToDoc(appointment)
-	if err != nil {
-		return
+	// Los ids ya fueron validados con las reglas de appointmentFields
+	appointment.Helper, _ = primitive.ObjectIDFromHex(fields.Helper)
+	appointment.CreatedBy, _ = primitive.ObjectIDFromHex(fields.CreatedBy)
+	appointment.Category = primitive.NilObjectID
+	if fields.Category != "" {
+		appointment.Category, _ = primitive.ObjectIDFromHex(fields.Category)
 	}
 
-	update := bson.D{{"$set", updatedValues}}
-	if _, err = collection.UpdateOne(ctx, filter, update); err != nil {
-		return
-	}
+	// Los avisos del cambio se programan en la misma transacción que la modificación
+	err = service.store.WithTransaction(ctx, func(tx context.Context) (err error) {
+		if err = appointmentError(service.store.Appointments().Update(tx, appointment)); err != nil {
+			return
+		}
+
+		if response.Appointment, err = service.store.Appointments().FindByID(tx, appointment.ID); err != nil {
+			return appointmentError(err)
+		}
+
+		return service.scheduler.NotifyAppointmentChange(tx, previous, response.Appointment)
+	})
 
-	response.Appointment = appointment
 	return
 }
 
+func newAppointmentFields(appointment models.Appointment) appointmentFields {
+	fields := appointmentFields{
+		Date:     appointment.Date,
+		Duration: appointment.Duration,
+		Address:  appointment.Address,
+		Status:   appointment.Status,
+	}
+
+	if !appointment.Helper.IsZero() {
+		fields.Helper = appointment.Helper.Hex()
+	}
+	if !appointment.CreatedBy.IsZero() {
+		fields.CreatedBy = appointment.CreatedBy.Hex()
+	}
+	if !appointment.Category.IsZero() {
+		fields.Category = appointment.Category.Hex()
+	}
+
+	return fields
+}
+
 /** Elimina una cita
  *
+ * @param ctx context.Context "El contexto de la solicitud"
  * @param id string "El id de la cita"
+ * @param match VersionMatch "Las versiones de la cita que acepta el cliente"
  * @return err error "El error de la operación"
  */
-func (service *AppointmentService) DeleteAppointment(appointmentId string) (err error) {
-	collection := service.db.Collection("appointments")
-
-	id, err := primitive.ObjectIDFromHex(appointmentId)
+func (service *AppointmentService) DeleteAppointment(ctx context.Context, appointmentId string, match VersionMatch) (err error) {
+	appointment, err := service.findAppointment(ctx, appointmentId)
 	if err != nil {
 		return
 	}
 
-	filter := bson.M{"id": id}
-	result := collection.FindOneAndDelete(ctx, filter)
-	if err = result.Err(); err != nil {
+	if err = checkVersion(appointment.Version, match); err != nil {
 		return
 	}
 
+	return service.store.WithTransaction(ctx, func(tx context.Context) error {
+		if err := service.store.Appointments().Delete(tx, appointment.ID, appointment.Version); err != nil {
+			return appointmentError(err)
+		}
+
+		return service.scheduler.CancelAppointmentJobs(tx, appointment.ID)
+	})
+}
+
+/** Busca una cita por su id
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param appointmentId string "El id de la cita"
+ * @return appointment models.Appointment "La cita"
+ * @return err error "El error de la operación"
+ */
+func (service *AppointmentService) findAppointment(ctx context.Context, appointmentId string) (appointment models.Appointment, err error) {
+	id, err := parseID(appointmentId)
+	if err != nil {
+		return
+	}
+
+	appointment, err = service.store.Appointments().FindByID(ctx, id)
+	err = appointmentError(err)
 	return
 }
 
-func NewAppointmentService(db *mongo.Database) IAppointmentService {
-	return &AppointmentService{db: db}
+// Reemplaza los errores de cita inexistente y de versión del repositorio por unos descriptivos
+func appointmentError(err error) error {
+	switch err {
+	case repository.ErrNotFound:
+		return ErrAppointmentNotFound
+	case repository.ErrVersionConflict:
+		return ErrVersionMismatch
+	}
+
+	return err
+}
+
+func NewAppointmentService(store repository.IStore, scheduler IScheduler) IAppointmentService {
+	return &AppointmentService{store: store, scheduler: scheduler}
 }
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Programador que falla después de guardar sus tareas
+type failingScheduler struct {
+	Scheduler
+}
+
+func (scheduler *failingScheduler) ScheduleAppointmentReminders(ctx context.Context, appointment models.Appointment) (err error) {
+	if err = scheduler.Scheduler.ScheduleAppointmentReminders(ctx, appointment); err != nil {
+		return
+	}
+
+	return errors.New("error al programar los recordatorios")
+}
+
+func (scheduler *failingScheduler) CancelAppointmentJobs(ctx context.Context, appointmentID primitive.ObjectID) (err error) {
+	return errors.New("error al cancelar las tareas")
+}
+
+func TestAppointmentSchedulingIsTransactional(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	scheduler := &failingScheduler{Scheduler: Scheduler{store: store}}
+	service := NewAppointmentService(store, scheduler)
+
+	_, err := service.CreateAppointment(ctx, CreateAppointmentRequest{
+		Date:      time.Now().Add(48 * time.Hour),
+		Duration:  time.Hour,
+		Address:   "Av. 18 de Julio 1234",
+		Status:    models.AppointmentStatusPending,
+		Helper:    primitive.NewObjectID().Hex(),
+		CreatedBy: primitive.NewObjectID().Hex(),
+	})
+	if err == nil {
+		t.Fatal("CreateAppointment() no devolvió el error de la programación")
+	}
+
+	appointments, err := store.Appointments().Find(ctx, repository.AppointmentFilter{})
+	if err != nil || len(appointments) != 0 {
+		t.Fatalf("citas guardadas = %+v, %v; want ninguna", appointments, err)
+	}
+
+	// La cita sigue existiendo si falla la cancelación de sus tareas
+	appointment := models.Appointment{Date: time.Now().Add(48 * time.Hour), Status: models.AppointmentStatusPending}
+	if err = store.Appointments().Insert(ctx, &appointment); err != nil {
+		t.Fatal(err)
+	}
+	if err = service.DeleteAppointment(ctx, appointment.ID.Hex(), VersionMatch{Any: true}); err == nil {
+		t.Fatal("DeleteAppointment() no devolvió el error de la cancelación")
+	}
+	if _, err = store.Appointments().FindByID(ctx, appointment.ID); err != nil {
+		t.Errorf("FindByID() error = %v, want la cita sin eliminar", err)
+	}
+}
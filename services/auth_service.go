@@ -1,14 +1,12 @@
 package services
 
 import (
+	"context"
 	"time"
 
-	"github.com/gin-gonic/gin"
 	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
 	"github.com/maferuy/ayudapp-admin-backend-core/utils"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CreateSessionParams struct {
@@ -21,25 +19,23 @@ type CreateSessionParams struct {
 }
 
 type IAuthService interface {
-	CreateSession(params CreateSessionParams) (models.Session, error)
-	GetSession(sessionId string) (models.Session, error)
+	CreateSession(ctx context.Context, params CreateSessionParams) (models.Session, error)
+	GetSession(ctx context.Context, sessionId string) (models.Session, error)
 }
 
 type AuthService struct {
-	db     *mongo.Database
-	config utils.Config
-	ctx    *gin.Context
+	sessions repository.ISessionRepository
+	config   utils.Config
 }
 
 /** Crea una sesión para un usuario
  *
+ * @param ctx context.Context "El contexto de la solicitud"
  * @param params CreateSessionParams "Los parámetros de la sesión a crear"
  * @return models.Session "La sesión creada"
  * @return error "El error que ocurrió al crear la sesión"
  */
-func (service *AuthService) CreateSession(params CreateSessionParams) (models.Session, error) {
-	var collection = service.db.Collection("sessions")
-
+func (service *AuthService) CreateSession(ctx context.Context, params CreateSessionParams) (models.Session, error) {
 	session := models.Session{
 		Email:        params.Email,
 		RefreshToken: params.RefreshToken,
@@ -50,28 +46,30 @@ func (service *AuthService) CreateSession(params CreateSessionParams) (models.Se
 		ExpiresAt:    params.ExpiresAt,
 	}
 
-	result, err := collection.InsertOne(ctx, &session)
-	if err != nil {
+	if err := service.sessions.Insert(ctx, &session); err != nil {
 		return models.Session{}, err
 	}
 
-	session.ID = result.InsertedID.(primitive.ObjectID)
-
 	return session, nil
 }
 
 /** Obtiene una sesión
  *
+ * @param ctx context.Context "El contexto de la solicitud"
  * @param sessionId string "El id de la sesión a obtener"
  * @return models.Session "La sesión obtenida"
  * @return error "El error que ocurrió al obtener la sesión"
  */
-func (service *AuthService) GetSession(sessionId string) (models.Session, error) {
-	var collection = service.db.Collection("sessions")
-	var session models.Session
+func (service *AuthService) GetSession(ctx context.Context, sessionId string) (models.Session, error) {
+	id, err := parseID(sessionId)
+	if err != nil {
+		return models.Session{}, err
+	}
 
-	var filter = bson.M{"id": sessionId}
-	err := collection.FindOne(ctx, filter).Decode(&session)
+	session, err := service.sessions.FindByID(ctx, id)
+	if err == repository.ErrNotFound {
+		return models.Session{}, ErrSessionNotFound
+	}
 	if err != nil {
 		return models.Session{}, err
 	}
@@ -79,10 +77,9 @@ func (service *AuthService) GetSession(sessionId string) (models.Session, error)
 	return session, nil
 }
 
-func NewAuthService(db *mongo.Database, config utils.Config, ctx *gin.Context) IAuthService {
+func NewAuthService(sessions repository.ISessionRepository, config utils.Config) IAuthService {
 	return &AuthService{
-		db:     db,
-		config: config,
-		ctx:    ctx,
+		sessions: sessions,
+		config:   config,
 	}
 }
@@ -1,23 +1,47 @@
 package services
 
 import (
-	"errors"
+	"context"
+	"fmt"
+	"strings"
 
 	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
 	"github.com/maferuy/ayudapp-admin-backend-core/utils"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CreateCategoryRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name         string                                `json:"name" binding:"required,max=100"`
+	Description  string                                `json:"description" binding:"max=1000"`
+	ParentID     string                                `json:"parent_id" binding:"omitempty,objectid"`
+	Translations map[string]models.CategoryTranslation `json:"translations" binding:"omitempty,dive,keys,language,endkeys"`
 }
 
 type UpdateCategoryRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string `json:"name" binding:"omitempty,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+}
+
+// Campos editables de una categoría, a los que se aplican las actualizaciones parciales
+type categoryFields struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+}
+
+type SetCategoryTranslationRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+}
+
+type MoveCategoryRequest struct {
+	ParentID  string `json:"parent_id" binding:"omitempty,objectid"`
+	SortOrder *int   `json:"sort_order" binding:"omitempty,min=0"`
+}
+
+type ReorderCategoriesRequest struct {
+	ParentID    string   `json:"parent_id" binding:"omitempty,objectid"`
+	CategoryIDs []string `json:"category_ids" binding:"required,min=1,dive,objectid"`
 }
 
 type CreateCategoryResponse struct {
@@ -26,192 +50,928 @@ type CreateCategoryResponse struct {
 
 type GetCategoriesResponse struct {
 	Categories []models.Category `json:"categories"`
+	// Los idiomas en que se devolvieron los contenidos, para la cabecera Content-Language
+	Languages []string `json:"-"`
 }
 
 type GetCategoryResponse struct {
-	Category models.Category `json:"category"`
+	Category  models.Category `json:"category"`
+	Languages []string        `json:"-"`
 }
 
 type UpdateCategoryResponse struct {
 	Category models.Category `json:"category"`
 }
 
+type CategoryTreeNode struct {
+	Category models.Category    `json:"category"`
+	Children []CategoryTreeNode `json:"children"`
+}
+
+type GetCategoryTreeResponse struct {
+	Tree      []CategoryTreeNode `json:"tree"`
+	Languages []string           `json:"-"`
+}
+
+type MoveCategoryResponse struct {
+	Category models.Category `json:"category"`
+}
+
+type MergeCategoryResponse struct {
+	Category               models.Category `json:"category"`
+	ReassignedAppointments int64           `json:"reassigned_appointments"`
+	ReassignedUsers        int64           `json:"reassigned_users"`
+	ReassignedChildren     int64           `json:"reassigned_children"`
+}
+
+type MissingCategoryTranslation struct {
+	CategoryID string   `json:"category_id"`
+	Name       string   `json:"name"`
+	Languages  []string `json:"languages"`
+}
+
+type GetMissingTranslationsResponse struct {
+	Categories []MissingCategoryTranslation `json:"categories"`
+}
+
 type ICategoryService interface {
-	CreateCategory(req CreateCategoryRequest) (response CreateCategoryResponse, err error)
-	GetCategories() (response GetCategoriesResponse, err error)
-	GetCategory(id string) (response GetCategoryResponse, err error)
-	UpdateCategory(id string, req UpdateCategoryRequest) (response UpdateCategoryResponse, err error)
-	DeleteCategory(id string) (err error)
+	CreateCategory(ctx context.Context, req CreateCategoryRequest) (response CreateCategoryResponse, err error)
+	GetCategories(ctx context.Context, filter repository.CategoryFilter, lang string) (response GetCategoriesResponse, err error)
+	GetCategory(ctx context.Context, id string, lang string) (response GetCategoryResponse, err error)
+	UpdateCategory(ctx context.Context, id string, req UpdateCategoryRequest) (response UpdateCategoryResponse, err error)
+	PatchCategory(ctx context.Context, id string, patch []byte) (response UpdateCategoryResponse, err error)
+	DeleteCategory(ctx context.Context, id string) (err error)
+
+	GetCategoryTree(ctx context.Context, lang string) (response GetCategoryTreeResponse, err error)
+	MoveCategory(ctx context.Context, id string, req MoveCategoryRequest) (response MoveCategoryResponse, err error)
+	ReorderCategories(ctx context.Context, req ReorderCategoriesRequest) (err error)
+
+	SetCategoryTranslation(ctx context.Context, id string, lang string, req SetCategoryTranslationRequest) (response UpdateCategoryResponse, err error)
+	DeleteCategoryTranslation(ctx context.Context, id string, lang string) (err error)
+	GetMissingTranslations(ctx context.Context) (response GetMissingTranslationsResponse, err error)
+
+	MergeCategory(ctx context.Context, id string, targetId string) (response MergeCategoryResponse, err error)
 }
 
 type CategoryService struct {
-	db *mongo.Database
+	store repository.IStore
 }
 
 /** Crea una categoría
  *
+ * @param ctx context.Context "El contexto de la solicitud"
  * @param req CreateCategoryRequest "Los datos de la categoría"
  * @return response CreateCategoryResponse "El id de la categoría"
  * @return err error "El error de la operación"
  */
-func (service CategoryService) CreateCategory(req CreateCategoryRequest) (response CreateCategoryResponse, err error) {
-	collection := service.db.Collection("categories")
+func (service CategoryService) CreateCategory(ctx context.Context, req CreateCategoryRequest) (response CreateCategoryResponse, err error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		err = NewValidationError("name_required", "el nombre es requerido")
+		return
+	}
 
-	category := models.Category{
-		Name:        req.Name,
-		Description: req.Description,
+	if err = service.checkNameAvailable(ctx, name, primitive.NilObjectID); err != nil {
+		return
 	}
 
-	result, err := collection.InsertOne(ctx, category)
+	slug, err := service.uniqueSlug(ctx, name, primitive.NilObjectID)
 	if err != nil {
 		return
 	}
 
-	response.CategoryID = result.InsertedID.(primitive.ObjectID).Hex()
+	for lang := range req.Translations {
+		if !utils.IsSupportedLanguage(lang) || lang == utils.DefaultLanguage {
+			err = NewValidationError("unsupported_language", "idioma no soportado: %s", lang)
+			return
+		}
+	}
+
+	category := models.Category{
+		Name:         name,
+		Slug:         slug,
+		Description:  req.Description,
+		Translations: req.Translations,
+		Ancestors:    []primitive.ObjectID{},
+	}
+
+	if req.ParentID != "" {
+		var parent models.Category
+		if parent, err = service.findParent(ctx, req.ParentID); err != nil {
+			return
+		}
+
+		category.ParentID = &parent.ID
+		category.Ancestors = append(append(category.Ancestors, parent.Ancestors...), parent.ID)
+	}
+
+	if category.SortOrder, err = service.store.Categories().CountChildren(ctx, category.ParentID); err != nil {
+		return
+	}
+
+	if err = categoryError(service.store.Categories().Insert(ctx, &category)); err != nil {
+		return
+	}
+
+	response.CategoryID = category.ID.Hex()
 	return
 }
 
 /** Obtiene todas las categorías
  *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param filter repository.CategoryFilter "Los filtros del listado"
+ * @param lang string "El idioma en que se devuelven los nombres y descripciones"
  * @return response GetCategoriesResponse "Las categorías"
  * @return err error "El error de la operación"
  */
-func (service CategoryService) GetCategories() (response GetCategoriesResponse, err error) {
-	var categories []models.Category
-	collection := service.db.Collection("categories")
+func (service CategoryService) GetCategories(ctx context.Context, filter repository.CategoryFilter, lang string) (response GetCategoriesResponse, err error) {
+	categories, err := service.store.Categories().Find(ctx, filter)
+	if err != nil {
+		return
+	}
 
-	cursor, err := collection.Find(ctx, bson.M{})
+	for i := range categories {
+		var languages []string
+		categories[i], languages = categories[i].Localize(lang, utils.DefaultLanguage)
+		response.Languages = appendLanguages(response.Languages, languages...)
+	}
+
+	response.Categories = categories
+	return
+}
+
+/** Obtiene una categoría por su id o por su slug
+ *
+ * También resuelve los slugs anteriores de las categorías renombradas.
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param categoryId string "El id o el slug de la categoría"
+ * @param lang string "El idioma en que se devuelven el nombre y la descripción"
+ * @return response GetCategoryResponse "La categoría"
+ */
+func (service CategoryService) GetCategory(ctx context.Context, categoryId string, lang string) (response GetCategoryResponse, err error) {
+	category, err := service.store.Categories().Resolve(ctx, categoryId)
+	if err = categoryError(err); err != nil {
+		return
+	}
+
+	response.Category, response.Languages = category.Localize(lang, utils.DefaultLanguage)
+	return
+}
+
+/** Actualiza una categoría
+ *
+ * Sólo se modifican los campos que tienen valor en la solicitud.
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param categoryId string "El id de la categoría"
+ * @param req UpdateCategoryRequest "Los datos de la categoría"
+ * @return response UpdateCategoryResponse "La categoría actualizada"
+ * @return err error "El error de la operación"
+ */
+func (service CategoryService) UpdateCategory(ctx context.Context, categoryId string, req UpdateCategoryRequest) (response UpdateCategoryResponse, err error) {
+	category, err := service.findCategory(ctx, categoryId)
+	if err != nil {
+		return
+	}
+
+	fields := categoryFields{Name: category.Name, Description: category.Description}
+	if req.Name != "" {
+		fields.Name = req.Name
+	}
+	if req.Description != "" {
+		fields.Description = req.Description
+	}
+
+	return service.saveCategory(ctx, category, fields)
+}
+
+/** Actualiza parcialmente una categoría con un parche JSON Merge Patch (RFC 7396)
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param categoryId string "El id de la categoría"
+ * @param patch []byte "El parche con los campos a modificar; null vacía un campo"
+ * @return response UpdateCategoryResponse "La categoría actualizada"
+ * @return err error "El error de la operación"
+ */
+func (service CategoryService) PatchCategory(ctx context.Context, categoryId string, patch []byte) (response UpdateCategoryResponse, err error) {
+	category, err := service.findCategory(ctx, categoryId)
 	if err != nil {
 		return
 	}
 
-	if err = cursor.All(ctx, &categories); err != nil {
+	var fields categoryFields
+	current := categoryFields{Name: category.Name, Description: category.Description}
+	if err = applyMergePatch(current, patch, &fields); err != nil {
 		return
 	}
 
-	response.Categories = categories
+	return service.saveCategory(ctx, category, fields)
+}
+
+// Guarda los campos editables de la categoría y la devuelve como quedó en la base de datos
+func (service CategoryService) saveCategory(ctx context.Context, category models.Category, fields categoryFields) (response UpdateCategoryResponse, err error) {
+	fields.Name = strings.TrimSpace(fields.Name)
+	if fields.Name == "" {
+		err = NewValidationError("name_required", "el nombre es requerido")
+		return
+	}
+
+	if err = service.checkNameAvailable(ctx, fields.Name, category.ID); err != nil {
+		return
+	}
+
+	// Al renombrar se genera un nuevo slug y se conserva el anterior para redirigir
+	if slug := utils.Slugify(fields.Name); category.Slug == "" || slug != category.Slug {
+		if slug, err = service.uniqueSlug(ctx, fields.Name, category.ID); err != nil {
+			return
+		}
+
+		if category.Slug != "" && category.Slug != slug && !containsString(category.PreviousSlugs, category.Slug) {
+			category.PreviousSlugs = append(category.PreviousSlugs, category.Slug)
+		}
+		category.Slug = slug
+	}
+
+	category.Name = fields.Name
+	category.Description = fields.Description
+
+	if err = categoryError(service.store.Categories().Update(ctx, category)); err != nil {
+		return
+	}
+
+	if category, err = service.store.Categories().FindByID(ctx, category.ID); err != nil {
+		err = categoryError(err)
+		return
+	}
+
+	response.Category = category
 	return
 }
 
-/** Obtiene una categoría
+/** Elimina una categoría
  *
+ * @param ctx context.Context "El contexto de la solicitud"
  * @param categoryId string "El id de la categoría"
- * @return response GetCategoryResponse "La categoría"
+ * @return err error "El error de la operación"
  */
-func (service CategoryService) GetCategory(categoryId string) (response GetCategoryResponse, err error) {
-	var category models.Category
-	collection := service.db.Collection("categories")
+func (service CategoryService) DeleteCategory(ctx context.Context, categoryId string) (err error) {
+	category, err := service.findCategory(ctx, categoryId)
+	if err != nil {
+		return
+	}
 
-	id, err := primitive.ObjectIDFromHex(categoryId)
+	children, err := service.store.Categories().CountChildren(ctx, &category.ID)
 	if err != nil {
 		return
+	} else if children > 0 {
+		err = ErrCategoryHasChildren
+		return
 	}
 
-	filter := bson.M{"_id": id}
-	count, err := collection.CountDocuments(ctx, filter)
+	return categoryError(service.store.Categories().Delete(ctx, category.ID))
+}
+
+/** Obtiene el árbol de categorías
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param lang string "El idioma en que se devuelven los nombres y descripciones"
+ * @return response GetCategoryTreeResponse "Las categorías raíz con sus subcategorías"
+ * @return err error "El error de la operación"
+ */
+func (service CategoryService) GetCategoryTree(ctx context.Context, lang string) (response GetCategoryTreeResponse, err error) {
+	categories, err := service.store.Categories().Find(ctx, repository.CategoryFilter{})
 	if err != nil {
 		return
-	} else if count == 0 {
-		err = errors.New("categoría no encontrada")
+	}
+
+	children := make(map[primitive.ObjectID][]models.Category)
+	var roots []models.Category
+	for _, category := range categories {
+		var languages []string
+		category, languages = category.Localize(lang, utils.DefaultLanguage)
+		response.Languages = appendLanguages(response.Languages, languages...)
+		if category.ParentID == nil {
+			roots = append(roots, category)
+		} else {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+
+	response.Tree = buildCategoryTree(roots, children)
+	return
+}
+
+/** Mueve una categoría a otra categoría padre o a la raíz
+ *
+ * La categoría se ubica en la posición indicada entre sus nuevas hermanas, que se
+ * renumeran para dejarle lugar; sin posición queda al final o, si no cambia de padre,
+ * donde estaba. Las hermanas que deja se renumeran para no dejar huecos.
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param categoryId string "El id de la categoría"
+ * @param req MoveCategoryRequest "La nueva categoría padre y posición"
+ * @return response MoveCategoryResponse "La categoría movida"
+ * @return err error "El error de la operación"
+ */
+func (service CategoryService) MoveCategory(ctx context.Context, categoryId string, req MoveCategoryRequest) (response MoveCategoryResponse, err error) {
+	id, err := parseID(categoryId)
+	if err != nil {
 		return
 	}
 
-	result := collection.FindOne(ctx, filter)
-	if err = result.Err(); err != nil {
+	var newParentID *primitive.ObjectID
+	if req.ParentID != "" {
+		var parentID primitive.ObjectID
+		if parentID, err = parseID(req.ParentID); err != nil {
+			return
+		}
+		newParentID = &parentID
+	}
+
+	err = service.store.WithTransaction(ctx, func(tx context.Context) error {
+		response = MoveCategoryResponse{}
+		return service.moveCategory(tx, id, newParentID, req.SortOrder, &response)
+	})
+
+	return
+}
+
+/** Ejecuta el movimiento de una categoría dentro de una transacción
+ *
+ * @param tx context.Context "El contexto de la transacción"
+ * @param id primitive.ObjectID "El id de la categoría"
+ * @param parentID *primitive.ObjectID "El id de la nueva categoría padre, o nil para la raíz"
+ * @param sortOrder *int "La posición entre las nuevas hermanas, o nil para la posición por defecto"
+ * @param response *MoveCategoryResponse "La categoría movida"
+ * @return err error "El error de la operación"
+ */
+func (service CategoryService) moveCategory(tx context.Context, id primitive.ObjectID, parentID *primitive.ObjectID, sortOrder *int, response *MoveCategoryResponse) (err error) {
+	categories := service.store.Categories()
+
+	category, err := categories.FindByID(tx, id)
+	if err != nil {
+		return categoryError(err)
+	}
+
+	ancestors := []primitive.ObjectID{}
+	if parentID != nil {
+		var parent models.Category
+		if parent, err = service.findParent(tx, parentID.Hex()); err != nil {
+			return
+		}
+
+		// Evita ciclos: la categoría no puede colgar de sí misma ni de sus descendientes
+		if parent.ID == id || containsObjectID(parent.Ancestors, id) {
+			return NewValidationError("category_cycle", "no se puede mover una categoría dentro de sí misma o de sus subcategorías")
+		}
+
+		ancestors = append(append(ancestors, parent.Ancestors...), parent.ID)
+	}
+
+	siblings, err := categories.Find(tx, repository.CategoryFilter{ParentID: parentFilter(parentID)})
+	if err != nil {
 		return
 	}
 
-	err = result.Decode(&category)
+	// Las hermanas en su orden actual, sin la categoría movida
+	position := -1
+	ids := make([]primitive.ObjectID, 0, len(siblings)+1)
+	for _, sibling := range siblings {
+		if sibling.ID == id {
+			position = len(ids)
+			continue
+		}
+		ids = append(ids, sibling.ID)
+	}
+
+	if sortOrder != nil {
+		position = *sortOrder
+	}
+	if position < 0 || position > len(ids) {
+		position = len(ids)
+	}
+
+	ids = append(ids, primitive.NilObjectID)
+	copy(ids[position+1:], ids[position:])
+	ids[position] = id
+
+	previousParentID := category.ParentID
+	category.ParentID = parentID
+	category.Ancestors = ancestors
+	category.SortOrder = position
+
+	if err = categoryError(categories.Update(tx, category)); err != nil {
+		return
+	}
+
+	if _, err = categories.SetSortOrders(tx, parentID, ids); err != nil {
+		return
+	}
+	if !sameParent(previousParentID, parentID) {
+		if _, err = service.renumberChildren(tx, previousParentID, nil); err != nil {
+			return
+		}
+	}
+
+	// Actualiza los ancestros de todas las subcategorías
+	descendants, err := categories.FindDescendants(tx, id)
 	if err != nil {
 		return
 	}
 
+	for _, descendant := range descendants {
+		descendantAncestors := append([]primitive.ObjectID{}, ancestors...)
+		descendantAncestors = append(descendantAncestors, id)
+		for i, ancestor := range descendant.Ancestors {
+			if ancestor == id {
+				descendantAncestors = append(descendantAncestors, descendant.Ancestors[i+1:]...)
+				break
+			}
+		}
+
+		descendant.Ancestors = descendantAncestors
+		if err = categories.Update(tx, descendant); err != nil {
+			return
+		}
+	}
+
 	response.Category = category
 	return
 }
 
-/** Actualiza una categoryia
+/** Reordena las subcategorías de una categoría padre
+ *
+ * Las subcategorías que no se indican quedan a continuación, en su orden actual.
+ * Si alguna categoría no pertenece a la categoría padre no se modifica ninguna.
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param req ReorderCategoriesRequest "La categoría padre y los ids en el orden deseado"
+ * @return err error "El error de la operación"
+ */
+func (service CategoryService) ReorderCategories(ctx context.Context, req ReorderCategoriesRequest) (err error) {
+	var parentID *primitive.ObjectID
+	if req.ParentID != "" {
+		var id primitive.ObjectID
+		if id, err = parseID(req.ParentID); err != nil {
+			return
+		}
+		parentID = &id
+	}
+
+	ids := make([]primitive.ObjectID, len(req.CategoryIDs))
+	for i, categoryId := range req.CategoryIDs {
+		if ids[i], err = parseID(categoryId); err != nil {
+			return
+		}
+	}
+
+	return service.store.WithTransaction(ctx, func(tx context.Context) error {
+		matched, err := service.renumberChildren(tx, parentID, ids)
+		if err != nil {
+			return err
+		}
+
+		if matched != len(ids) {
+			return NewValidationError("category_parent_mismatch", "algunas categorías no pertenecen a la categoría padre indicada")
+		}
+
+		return nil
+	})
+}
+
+/** Numera las subcategorías de una categoría padre desde 0, primero las indicadas y luego el resto
+ *
+ * @param tx context.Context "El contexto de la transacción"
+ * @param parentID *primitive.ObjectID "El id de la categoría padre, o nil para la raíz"
+ * @param ordered []primitive.ObjectID "Los ids que van primero, en orden"
+ * @return matched int "Cuántas de las categorías indicadas pertenecen a la categoría padre"
+ * @return err error "El error de la operación"
+ */
+func (service CategoryService) renumberChildren(tx context.Context, parentID *primitive.ObjectID, ordered []primitive.ObjectID) (matched int, err error) {
+	categories := service.store.Categories()
+
+	children, err := categories.Find(tx, repository.CategoryFilter{ParentID: parentFilter(parentID)})
+	if err != nil {
+		return
+	}
+
+	ids := append([]primitive.ObjectID{}, ordered...)
+	for _, child := range children {
+		if !containsObjectID(ordered, child.ID) {
+			ids = append(ids, child.ID)
+		}
+	}
+
+	if matched, err = categories.SetSortOrders(tx, parentID, ids); err != nil {
+		return
+	}
+
+	matched -= len(ids) - len(ordered)
+	return
+}
+
+/** Agrega o reemplaza la traducción de una categoría
  *
+ * @param ctx context.Context "El contexto de la solicitud"
  * @param categoryId string "El id de la categoría"
- * @param req UpdateCategoryRequest "Los datos de la categoría"
+ * @param lang string "El código del idioma de la traducción"
+ * @param req SetCategoryTranslationRequest "El nombre y la descripción traducidos"
  * @return response UpdateCategoryResponse "La categoría actualizada"
  * @return err error "El error de la operación"
  */
-func (service CategoryService) UpdateCategory(categoryId string, req UpdateCategoryRequest) (response UpdateCategoryResponse, err error) {
-	var category models.Category
-	collection := service.db.Collection("categories")
+func (service CategoryService) SetCategoryTranslation(ctx context.Context, categoryId string, lang string, req SetCategoryTranslationRequest) (response UpdateCategoryResponse, err error) {
+	if !utils.IsSupportedLanguage(lang) || lang == utils.DefaultLanguage {
+		err = NewValidationError("unsupported_language", "idioma no soportado: %s", lang)
+		return
+	}
 
-	id, err := primitive.ObjectIDFromHex(categoryId)
+	if req.Name == "" {
+		err = NewValidationError("translation_name_required", "el nombre traducido es requerido")
+		return
+	}
+
+	category, err := service.findCategory(ctx, categoryId)
 	if err != nil {
 		return
 	}
 
-	filter := bson.M{"_id": id}
+	if category.Translations == nil {
+		category.Translations = map[string]models.CategoryTranslation{}
+	}
+	category.Translations[lang] = models.CategoryTranslation{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	if err = categoryError(service.store.Categories().Update(ctx, category)); err != nil {
+		return
+	}
+
+	response.Category = category
+	return
+}
 
-	count, err := collection.CountDocuments(ctx, filter)
+/** Elimina la traducción de una categoría
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param categoryId string "El id de la categoría"
+ * @param lang string "El código del idioma de la traducción"
+ * @return err error "El error de la operación"
+ */
+func (service CategoryService) DeleteCategoryTranslation(ctx context.Context, categoryId string, lang string) (err error) {
+	category, err := service.findCategory(ctx, categoryId)
 	if err != nil {
 		return
-	} else if count == 0 {
-		err = errors.New("categoría no encontrada")
+	}
+
+	if _, ok := category.Translations[lang]; !ok {
 		return
 	}
 
-	result := collection.FindOne(ctx, filter)
-	if err = result.Err(); err != nil {
+	delete(category.Translations, lang)
+	return categoryError(service.store.Categories().Update(ctx, category))
+}
+
+/** Obtiene las categorías a las que les falta alguna traducción
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @return response GetMissingTranslationsResponse "Las categorías y los idiomas faltantes"
+ * @return err error "El error de la operación"
+ */
+func (service CategoryService) GetMissingTranslations(ctx context.Context) (response GetMissingTranslationsResponse, err error) {
+	categories, err := service.store.Categories().Find(ctx, repository.CategoryFilter{})
+	if err != nil {
 		return
 	}
 
-	if err = result.Decode(&category); err != nil {
+	response.Categories = []MissingCategoryTranslation{}
+	for _, category := range categories {
+		var missing []string
+		for _, lang := range utils.SupportedLanguages {
+			if lang == utils.DefaultLanguage {
+				continue
+			}
+
+			if translation, ok := category.Translations[lang]; !ok || translation.Name == "" {
+				missing = append(missing, lang)
+			}
+		}
+
+		if len(missing) > 0 {
+			response.Categories = append(response.Categories, MissingCategoryTranslation{
+				CategoryID: category.ID.Hex(),
+				Name:       category.Name,
+				Languages:  missing,
+			})
+		}
+	}
+
+	return
+}
+
+/** Fusiona una categoría duplicada en otra
+ *
+ * Reasigna las citas, las habilidades de los ayudantes y las subcategorías a la
+ * categoría destino, que conserva el id y los slugs de la categoría fusionada para
+ * seguir resolviéndolos. La operación se ejecuta en una transacción, por lo que
+ * MongoDB debe estar configurado como replica set.
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param categoryId string "El id de la categoría a fusionar"
+ * @param targetId string "El id de la categoría destino"
+ * @return response MergeCategoryResponse "La categoría destino y la cantidad de referencias reasignadas"
+ * @return err error "El error de la operación"
+ */
+func (service CategoryService) MergeCategory(ctx context.Context, categoryId string, targetId string) (response MergeCategoryResponse, err error) {
+	sourceID, err := parseID(categoryId)
+	if err != nil {
 		return
 	}
 
-	updatedValues, err := utils.ToDoc(req)
+	targetID, err := parseID(targetId)
 	if err != nil {
 		return
 	}
 
-	update := bson.D{{"$set", updatedValues}}
-	if _, err = collection.UpdateOne(ctx, filter, update); err != nil {
+	if sourceID == targetID {
+		err = NewValidationError("category_merge_self", "no se puede fusionar una categoría consigo misma")
 		return
 	}
 
-	response.Category = category
+	err = service.store.WithTransaction(ctx, func(tx context.Context) error {
+		response = MergeCategoryResponse{}
+		return service.mergeCategory(tx, sourceID, targetID, &response)
+	})
+
 	return
 }
 
-/** Elimina una categoría
+/** Ejecuta la fusión de categorías dentro de una transacción
  *
- * @param categoryId string "El id de la categoría"
+ * @param tx context.Context "El contexto de la transacción"
+ * @param sourceID primitive.ObjectID "El id de la categoría a fusionar"
+ * @param targetID primitive.ObjectID "El id de la categoría destino"
+ * @param response *MergeCategoryResponse "El resultado de la fusión"
  * @return err error "El error de la operación"
  */
-func (service CategoryService) DeleteCategory(categoryId string) (err error) {
-	collection := service.db.Collection("categories")
+func (service CategoryService) mergeCategory(tx context.Context, sourceID, targetID primitive.ObjectID, response *MergeCategoryResponse) (err error) {
+	categories := service.store.Categories()
+
+	source, err := categories.FindByID(tx, sourceID)
+	if err != nil {
+		return categoryError(err)
+	}
+
+	target, err := categories.FindByID(tx, targetID)
+	if err == repository.ErrNotFound {
+		return ErrTargetCategoryNotFound
+	} else if err != nil {
+		return
+	}
+
+	if containsObjectID(target.Ancestors, sourceID) {
+		err = NewValidationError("category_cycle", "no se puede fusionar una categoría en una de sus subcategorías")
+		return
+	}
+
+	// Citas
+	if response.ReassignedAppointments, err = service.store.Appointments().ReassignCategory(tx, sourceID, targetID); err != nil {
+		return
+	}
+
+	// Habilidades de los ayudantes
+	if response.ReassignedUsers, err = service.store.Users().ReplaceSkill(tx, sourceID, targetID); err != nil {
+		return
+	}
+
+	// Subcategorías, que pasan después de las de la categoría destino
+	targetChildren, err := categories.Find(tx, repository.CategoryFilter{ParentID: parentFilter(&targetID)})
+	if err != nil {
+		return
+	}
+	sourceChildren, err := categories.Find(tx, repository.CategoryFilter{ParentID: parentFilter(&sourceID)})
+	if err != nil {
+		return
+	}
 
-	id, err := primitive.ObjectIDFromHex(categoryId)
+	childIDs := make([]primitive.ObjectID, 0, len(targetChildren)+len(sourceChildren))
+	for _, child := range append(targetChildren, sourceChildren...) {
+		childIDs = append(childIDs, child.ID)
+	}
+
+	descendants, err := categories.FindDescendants(tx, sourceID)
 	if err != nil {
 		return
 	}
 
-	filter := bson.M{"_id": id}
-	count, err := collection.CountDocuments(ctx, filter)
+	for _, descendant := range descendants {
+		ancestors := append([]primitive.ObjectID{}, target.Ancestors...)
+		ancestors = append(ancestors, targetID)
+		for i, ancestor := range descendant.Ancestors {
+			if ancestor == sourceID {
+				ancestors = append(ancestors, descendant.Ancestors[i+1:]...)
+				break
+			}
+		}
+
+		descendant.Ancestors = ancestors
+		if descendant.ParentID != nil && *descendant.ParentID == sourceID {
+			descendant.ParentID = &targetID
+			response.ReassignedChildren++
+		}
+
+		if err = categories.Update(tx, descendant); err != nil {
+			return
+		}
+	}
+
+	if _, err = categories.SetSortOrders(tx, &targetID, childIDs); err != nil {
+		return
+	}
+
+	// Alias de la categoría fusionada
+	if err = categories.Delete(tx, sourceID); err != nil {
+		return
+	}
+
+	if _, err = service.renumberChildren(tx, source.ParentID, nil); err != nil {
+		return
+	}
+
+	for _, id := range append([]primitive.ObjectID{sourceID}, source.MergedIDs...) {
+		if !containsObjectID(target.MergedIDs, id) {
+			target.MergedIDs = append(target.MergedIDs, id)
+		}
+	}
+
+	previousSlugs := append([]string{}, source.PreviousSlugs...)
+	if source.Slug != "" {
+		previousSlugs = append(previousSlugs, source.Slug)
+	}
+	for _, slug := range previousSlugs {
+		if !containsString(target.PreviousSlugs, slug) {
+			target.PreviousSlugs = append(target.PreviousSlugs, slug)
+		}
+	}
+
+	if err = categories.Update(tx, target); err != nil {
+		return
+	}
+
+	response.Category = target
+	return
+}
+
+/** Verifica que no exista otra categoría con el mismo nombre, sin distinguir mayúsculas
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param name string "El nombre de la categoría"
+ * @param excludeID primitive.ObjectID "El id de la categoría que se está modificando"
+ * @return err error "El error si el nombre ya está en uso"
+ */
+func (service CategoryService) checkNameAvailable(ctx context.Context, name string, excludeID primitive.ObjectID) (err error) {
+	exists, err := service.store.Categories().NameExists(ctx, name, excludeID)
 	if err != nil {
 		return
-	} else if count == 0 {
-		err = errors.New("categoría no encontrada")
+	}
+
+	if exists {
+		err = ErrCategoryNameTaken
+	}
+
+	return
+}
+
+/** Genera un slug a partir del nombre que no esté en uso por otra categoría
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param name string "El nombre de la categoría"
+ * @param excludeID primitive.ObjectID "El id de la categoría que se está modificando"
+ * @return slug string "El slug disponible"
+ * @return err error "El error de la operación"
+ */
+func (service CategoryService) uniqueSlug(ctx context.Context, name string, excludeID primitive.ObjectID) (slug string, err error) {
+	base := utils.Slugify(name)
+	if base == "" {
+		base = "categoria"
+	}
+
+	slug = base
+	for i := 2; ; i++ {
+		var exists bool
+		if exists, err = service.store.Categories().SlugExists(ctx, slug, excludeID); err != nil || !exists {
+			return
+		}
+
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+/** Obtiene una categoría por su id
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param categoryId string "El id de la categoría"
+ * @return category models.Category "La categoría"
+ * @return err error "El error de la operación"
+ */
+func (service CategoryService) findCategory(ctx context.Context, categoryId string) (category models.Category, err error) {
+	id, err := parseID(categoryId)
+	if err != nil {
 		return
 	}
 
-	result := collection.FindOneAndDelete(ctx, filter)
-	if err = result.Err(); err != nil {
+	category, err = service.store.Categories().FindByID(ctx, id)
+	err = categoryError(err)
+	return
+}
+
+/** Obtiene la categoría padre indicada
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param parentId string "El id de la categoría padre"
+ * @return parent models.Category "La categoría padre"
+ * @return err error "El error de la operación"
+ */
+func (service CategoryService) findParent(ctx context.Context, parentId string) (parent models.Category, err error) {
+	id, err := parseID(parentId)
+	if err != nil {
 		return
 	}
 
+	parent, err = service.store.Categories().FindByID(ctx, id)
+	if err == repository.ErrNotFound {
+		err = ErrParentCategoryNotFound
+	}
+
 	return
+}
+
+// Reemplaza los errores del repositorio de categorías por mensajes descriptivos
+func categoryError(err error) error {
+	switch err {
+	case repository.ErrNotFound:
+		return ErrCategoryNotFound
+	case repository.ErrDuplicate:
+		return ErrCategoryNameTaken
+	default:
+		return err
+	}
+}
+
+func buildCategoryTree(categories []models.Category, children map[primitive.ObjectID][]models.Category) []CategoryTreeNode {
+	nodes := make([]CategoryTreeNode, 0, len(categories))
+	for _, category := range categories {
+		nodes = append(nodes, CategoryTreeNode{
+			Category: category,
+			Children: buildCategoryTree(children[category.ID], children),
+		})
+	}
+
+	return nodes
+}
+
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, item := range ids {
+		if item == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Agrega a la lista los idiomas que todavía no contiene
+func appendLanguages(languages []string, add ...string) []string {
+	for _, lang := range add {
+		if !containsString(languages, lang) {
+			languages = append(languages, lang)
+		}
+	}
+
+	return languages
+}
+
+// Convierte el id de una categoría padre en el filtro de sus subcategorías
+func parentFilter(parentID *primitive.ObjectID) string {
+	if parentID == nil {
+		return "root"
+	}
+
+	return parentID.Hex()
+}
+
+func sameParent(a, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
 
+	return *a == *b
 }
 
-func NewCategoryService(db *mongo.Database) ICategoryService {
+func NewCategoryService(store repository.IStore) ICategoryService {
 	return &CategoryService{
-		db: db,
+		store: store,
 	}
 }
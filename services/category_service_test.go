@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Crea las categorías indicadas en orden, dentro de la categoría padre
+func createCategories(t *testing.T, service ICategoryService, parentId string, names ...string) (ids []string) {
+	t.Helper()
+
+	for _, name := range names {
+		response, err := service.CreateCategory(context.Background(), CreateCategoryRequest{Name: name, ParentID: parentId})
+		if err != nil {
+			t.Fatalf("CreateCategory(%q) error = %v", name, err)
+		}
+		ids = append(ids, response.CategoryID)
+	}
+
+	return
+}
+
+// Devuelve los nombres de las subcategorías con su posición, en orden
+func childNames(t *testing.T, store repository.IStore, parentFilter string) (names []string, sortOrders []int) {
+	t.Helper()
+
+	categories, err := store.Categories().Find(context.Background(), repository.CategoryFilter{ParentID: parentFilter})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, category := range categories {
+		names = append(names, category.Name)
+		sortOrders = append(sortOrders, category.SortOrder)
+	}
+
+	return
+}
+
+// Verifica el orden de las subcategorías y que sus posiciones sean consecutivas desde 0
+func checkChildren(t *testing.T, store repository.IStore, parentFilter string, want []string) {
+	t.Helper()
+
+	names, sortOrders := childNames(t, store, parentFilter)
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("subcategorías de %s = %q, want %q", parentFilter, names, want)
+	}
+	for i, sortOrder := range sortOrders {
+		if sortOrder != i {
+			t.Errorf("posiciones de %s = %v, want consecutivas desde 0", parentFilter, sortOrders)
+			break
+		}
+	}
+}
+
+func TestMoveCategory(t *testing.T) {
+	intPtr := func(value int) *int { return &value }
+
+	tests := []struct {
+		name      string
+		move      int
+		toParent  bool
+		sortOrder *int
+		wantRoot  []string
+		wantChild []string
+	}{
+		{
+			name:      "posición explícita entre hermanas",
+			move:      3,
+			sortOrder: intPtr(1),
+			wantRoot:  []string{"A", "D", "B", "C", "P"},
+			wantChild: []string{"X", "Y"},
+		},
+		{
+			name:      "al principio",
+			move:      2,
+			sortOrder: intPtr(0),
+			wantRoot:  []string{"C", "A", "B", "D", "P"},
+			wantChild: []string{"X", "Y"},
+		},
+		{
+			name:      "sin posición conserva el lugar",
+			move:      1,
+			wantRoot:  []string{"A", "B", "C", "D", "P"},
+			wantChild: []string{"X", "Y"},
+		},
+		{
+			name:      "posición mayor que la cantidad de hermanas",
+			move:      0,
+			sortOrder: intPtr(10),
+			wantRoot:  []string{"B", "C", "D", "P", "A"},
+			wantChild: []string{"X", "Y"},
+		},
+		{
+			name:      "a otra categoría padre sin posición",
+			move:      1,
+			toParent:  true,
+			wantRoot:  []string{"A", "C", "D", "P"},
+			wantChild: []string{"X", "Y", "B"},
+		},
+		{
+			name:      "a otra categoría padre en una posición",
+			move:      2,
+			toParent:  true,
+			sortOrder: intPtr(1),
+			wantRoot:  []string{"A", "B", "D", "P"},
+			wantChild: []string{"X", "C", "Y"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := repository.NewMemoryStore()
+			service := NewCategoryService(store)
+
+			root := createCategories(t, service, "", "A", "B", "C", "D")
+			parent := createCategories(t, service, "", "P")[0]
+			createCategories(t, service, parent, "X", "Y")
+
+			req := MoveCategoryRequest{SortOrder: test.sortOrder}
+			if test.toParent {
+				req.ParentID = parent
+			}
+			if _, err := service.MoveCategory(context.Background(), root[test.move], req); err != nil {
+				t.Fatalf("MoveCategory() error = %v", err)
+			}
+
+			checkChildren(t, store, "root", test.wantRoot)
+			checkChildren(t, store, parent, test.wantChild)
+		})
+	}
+}
+
+func TestMoveCategoryCycle(t *testing.T) {
+	store := repository.NewMemoryStore()
+	service := NewCategoryService(store)
+
+	parent := createCategories(t, service, "", "P")[0]
+	child := createCategories(t, service, parent, "X")[0]
+
+	_, err := service.MoveCategory(context.Background(), parent, MoveCategoryRequest{ParentID: child})
+
+	var validationError *ValidationError
+	if !errors.As(err, &validationError) || validationError.Code != "category_cycle" {
+		t.Fatalf("MoveCategory() error = %v, want category_cycle", err)
+	}
+}
+
+func TestReorderCategories(t *testing.T) {
+	store := repository.NewMemoryStore()
+	service := NewCategoryService(store)
+
+	ids := createCategories(t, service, "", "A", "B", "C", "D")
+	parent := createCategories(t, service, "", "P")[0]
+	other := createCategories(t, service, parent, "X")[0]
+
+	if err := service.ReorderCategories(context.Background(), ReorderCategoriesRequest{CategoryIDs: []string{ids[2], ids[0]}}); err != nil {
+		t.Fatalf("ReorderCategories() error = %v", err)
+	}
+
+	want := []string{"C", "A", "B", "D", "P"}
+	checkChildren(t, store, "root", want)
+
+	// Una categoría de otra categoría padre no modifica el orden
+	err := service.ReorderCategories(context.Background(), ReorderCategoriesRequest{CategoryIDs: []string{ids[3], other}})
+
+	var validationError *ValidationError
+	if !errors.As(err, &validationError) || validationError.Code != "category_parent_mismatch" {
+		t.Fatalf("ReorderCategories() error = %v, want category_parent_mismatch", err)
+	}
+
+	checkChildren(t, store, "root", want)
+}
+
+func TestMergeCategory(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	service := NewCategoryService(store)
+
+	root := createCategories(t, service, "", "A", "B", "C")
+	createCategories(t, service, root[0], "X", "Y")
+	z := createCategories(t, service, root[1], "Z")[0]
+	w := createCategories(t, service, z, "W")[0]
+
+	targetID, _ := primitive.ObjectIDFromHex(root[0])
+	sourceID, _ := primitive.ObjectIDFromHex(root[1])
+	zID, _ := primitive.ObjectIDFromHex(z)
+	wID, _ := primitive.ObjectIDFromHex(w)
+
+	appointment := models.Appointment{Category: sourceID}
+	if err := store.Appointments().Insert(ctx, &appointment); err != nil {
+		t.Fatal(err)
+	}
+	helper := models.User{Email: "ana@ayudapp.test", Skills: []primitive.ObjectID{sourceID}}
+	if err := store.Users().Insert(ctx, &helper); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := service.MergeCategory(ctx, root[1], root[0])
+	if err != nil {
+		t.Fatalf("MergeCategory() error = %v", err)
+	}
+	if response.ReassignedAppointments != 1 || response.ReassignedUsers != 1 || response.ReassignedChildren != 1 {
+		t.Errorf("MergeCategory() = %+v, want 1 cita, 1 ayudante y 1 subcategoría", response)
+	}
+
+	checkChildren(t, store, "root", []string{"A", "C"})
+	checkChildren(t, store, root[0], []string{"X", "Y", "Z"})
+
+	if appointment, err = store.Appointments().FindByID(ctx, appointment.ID); err != nil || appointment.Category != targetID {
+		t.Errorf("categoría de la cita = %s, %v; want %s", appointment.Category.Hex(), err, root[0])
+	}
+	if helper, err = store.Users().FindByID(ctx, helper.ID); err != nil || !reflect.DeepEqual(helper.Skills, []primitive.ObjectID{targetID}) {
+		t.Errorf("habilidades del ayudante = %v, %v; want [%s]", helper.Skills, err, root[0])
+	}
+
+	resolved, err := store.Categories().Resolve(ctx, root[1])
+	if err != nil || resolved.ID != targetID {
+		t.Errorf("Resolve(%s) = %s, %v; want %s", root[1], resolved.ID.Hex(), err, root[0])
+	}
+
+	descendant, err := store.Categories().FindByID(ctx, wID)
+	if err != nil || !reflect.DeepEqual(descendant.Ancestors, []primitive.ObjectID{targetID, zID}) {
+		t.Errorf("ancestros de W = %v, %v; want [%s %s]", descendant.Ancestors, err, root[0], z)
+	}
+}
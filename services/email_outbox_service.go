@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/textproto"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+)
+
+const (
+	// Tiempo durante el cual una instancia reserva un correo para entregarlo
+	emailLeaseDuration = 2 * time.Minute
+	// Cantidad máxima de intentos antes de descartar un correo
+	emailMaxAttempts = 8
+	// Demora del primer reintento, que se duplica en cada intento fallido
+	emailBaseBackoff = 30 * time.Second
+	// Demora máxima entre reintentos
+	emailMaxBackoff = 2 * time.Hour
+)
+
+type GetEmailsResponse struct {
+	Emails []models.OutboundEmail `json:"emails"`
+}
+
+type GetEmailResponse struct {
+	Email models.OutboundEmail `json:"email"`
+}
+
+type ResendEmailResponse struct {
+	Email models.OutboundEmail `json:"email"`
+}
+
+type IEmailOutbox interface {
+	utils.IEmailService
+
+	GetEmails(status string) (response GetEmailsResponse, err error)
+	GetEmail(id string) (response GetEmailResponse, err error)
+	ResendEmail(id string) (response ResendEmailResponse, err error)
+
+	Start(ctx context.Context)
+}
+
+type EmailOutbox struct {
+	store      repository.IStore
+	transport  utils.IEmailService
+	config     utils.Config
+	instanceID string
+}
+
+/** Encola un correo electrónico para entregarlo en segundo plano
+ *
+ * @param req utils.SendEmailRequest "Los datos del correo"
+ * @return resp utils.SendEmailResponse "Indica que el correo quedó en cola"
+ * @return err error "El error de la operación"
+ */
+func (outbox *EmailOutbox) SendEmail(req utils.SendEmailRequest) (resp utils.SendEmailResponse, err error) {
+	if req.Recipient == "" {
+		err = NewValidationError("recipient_required", "el destinatario es requerido")
+		return
+	}
+
+	now := time.Now()
+	email := models.OutboundEmail{
+		Sender:        req.Sender,
+		Recipient:     req.Recipient,
+		Subject:       req.Subject,
+		Message:       req.Message,
+		HTMLMessage:   req.HTMLMessage,
+		Status:        models.EmailStatusQueued,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if email.Sender == "" {
+		email.Sender = outbox.config.SMTPSender
+	}
+
+	if err = outbox.store.Emails().Insert(ctx, &email); err != nil {
+		return
+	}
+
+	resp.Queued = true
+	return
+}
+
+/** Obtiene los correos electrónicos, opcionalmente filtrados por estado
+ *
+ * @param status string "El estado de entrega, vacío para todos"
+ * @return response GetEmailsResponse "Los correos"
+ * @return err error "El error de la operación"
+ */
+func (outbox *EmailOutbox) GetEmails(status string) (response GetEmailsResponse, err error) {
+	response.Emails, err = outbox.store.Emails().Find(ctx, status)
+	return
+}
+
+/** Obtiene un correo electrónico con sus intentos de entrega
+ *
+ * @param emailId string "El id del correo"
+ * @return response GetEmailResponse "El correo"
+ * @return err error "El error de la operación"
+ */
+func (outbox *EmailOutbox) GetEmail(emailId string) (response GetEmailResponse, err error) {
+	id, err := parseID(emailId)
+	if err != nil {
+		return
+	}
+
+	response.Email, err = outbox.store.Emails().FindByID(ctx, id)
+	if err == repository.ErrNotFound {
+		err = ErrEmailNotFound
+	}
+
+	return
+}
+
+/** Vuelve a encolar un correo descartado o ya enviado
+ *
+ * Los intentos se reinician, pero se conserva el registro de entregas anteriores. Un
+ * correo que todavía está en cola no se puede reenviar.
+ *
+ * @param emailId string "El id del correo"
+ * @return response ResendEmailResponse "El correo encolado"
+ * @return err error "El error de la operación"
+ */
+func (outbox *EmailOutbox) ResendEmail(emailId string) (response ResendEmailResponse, err error) {
+	id, err := parseID(emailId)
+	if err != nil {
+		return
+	}
+
+	if _, err = outbox.store.Emails().FindByID(ctx, id); err == repository.ErrNotFound {
+		err = ErrEmailNotFound
+	}
+	if err != nil {
+		return
+	}
+
+	response.Email, err = outbox.store.Emails().Requeue(ctx, id, time.Now())
+	if err == repository.ErrNotFound {
+		err = ErrEmailQueued
+	}
+
+	return
+}
+
+/** Inicia la entrega de correos en segundo plano hasta que se cancele el contexto
+ *
+ * Los correos se reservan con un tiempo de expiración, igual que las tareas
+ * programadas, por lo que varias instancias pueden compartir la cola.
+ *
+ * @param ctx context.Context "El contexto que detiene la entrega"
+ */
+func (outbox *EmailOutbox) Start(ctx context.Context) {
+	interval := outbox.config.SchedulerPollInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			outbox.deliverDueEmails(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+/** Entrega todos los correos pendientes
+ *
+ * @param ctx context.Context "El contexto de la entrega"
+ */
+func (outbox *EmailOutbox) deliverDueEmails(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now()
+		email, err := outbox.store.Emails().Claim(ctx, outbox.instanceID, now, now.Add(emailLeaseDuration))
+		if err == repository.ErrNotFound {
+			return
+		}
+		if err != nil {
+			log.Printf("Error al obtener correos pendientes: %v", err)
+			return
+		}
+
+		_, err = outbox.transport.SendEmail(utils.SendEmailRequest{
+			Sender:      email.Sender,
+			Recipient:   email.Recipient,
+			Subject:     email.Subject,
+			Message:     email.Message,
+			HTMLMessage: email.HTMLMessage,
+		})
+
+		outbox.finishEmail(ctx, email, err)
+	}
+}
+
+/** Registra el intento de entrega y marca el correo como enviado, lo reprograma o lo descarta
+ *
+ * Los rechazos permanentes del servidor SMTP (códigos 5xx) descartan el correo sin
+ * reintentarlo.
+ *
+ * @param ctx context.Context "El contexto de la entrega"
+ * @param email models.OutboundEmail "El correo entregado"
+ * @param sendErr error "El error de la entrega"
+ */
+func (outbox *EmailOutbox) finishEmail(ctx context.Context, email models.OutboundEmail, sendErr error) {
+	now := time.Now()
+	attempt := models.EmailDeliveryAttempt{At: now}
+	email.UpdatedAt = now
+
+	if sendErr == nil {
+		email.Status = models.EmailStatusSent
+		email.SentAt = &now
+	} else {
+		log.Printf("Error al enviar el correo %s a %s: %v", email.ID.Hex(), email.Recipient, sendErr)
+
+		attempt.Error = sendErr.Error()
+		attempt.Permanent = isPermanentEmailError(sendErr)
+
+		if attempt.Permanent || email.Attempts >= emailMaxAttempts {
+			email.Status = models.EmailStatusDead
+		} else {
+			email.Status = models.EmailStatusQueued
+			email.NextAttemptAt = now.Add(emailBackoff(email.Attempts))
+		}
+	}
+
+	// Si la reserva expiró, otra instancia ya volvió a reservar el correo
+	err := outbox.store.Emails().Finish(ctx, email, outbox.instanceID, attempt)
+	if err == repository.ErrNotFound {
+		log.Printf("La reserva del correo %s expiró antes de terminar la entrega", email.ID.Hex())
+	} else if err != nil {
+		log.Printf("Error al actualizar el correo %s: %v", email.ID.Hex(), err)
+	}
+}
+
+func isPermanentEmailError(err error) bool {
+	var protocolErr *textproto.Error
+	return errors.As(err, &protocolErr) && protocolErr.Code >= 500
+}
+
+func emailBackoff(attempts int) time.Duration {
+	backoff := emailBaseBackoff << uint(attempts-1)
+	if backoff <= 0 || backoff > emailMaxBackoff {
+		return emailMaxBackoff
+	}
+
+	return backoff
+}
+
+func NewEmailOutbox(store repository.IStore, transport utils.IEmailService, config utils.Config) IEmailOutbox {
+	return &EmailOutbox{
+		store:      store,
+		transport:  transport,
+		config:     config,
+		instanceID: newInstanceID(),
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils/smtptest"
+)
+
+func TestEmailBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{8, 64 * time.Minute},
+		{9, 2 * time.Hour},
+		{100, 2 * time.Hour},
+	}
+
+	for _, test := range tests {
+		if got := emailBackoff(test.attempts); got != test.want {
+			t.Errorf("emailBackoff(%d) = %v, want %v", test.attempts, got, test.want)
+		}
+	}
+}
+
+func TestIsPermanentEmailError(t *testing.T) {
+	tests := []struct {
+		name    string
+		failure *smtptest.Failure
+		want    bool
+	}{
+		{"enviado", nil, false},
+		{"buzón lleno", &smtptest.Failure{Code: 452, Message: "4.2.2 Mailbox full"}, false},
+		{"destinatario inexistente", &smtptest.Failure{Code: 550, Message: "5.1.1 User unknown"}, true},
+		{"rechazo por política", &smtptest.Failure{Code: 554, Message: "5.7.1 Rejected"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, err := smtptest.NewServer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer server.Close()
+
+			if test.failure != nil {
+				server.Fail(*test.failure)
+			}
+
+			transport := utils.NewEmailService(utils.Config{SMTPHost: server.Host(), SMTPPort: server.Port()})
+			_, err = transport.SendEmail(utils.SendEmailRequest{Sender: "no-reply@ayudapp.com", Recipient: "ana@example.com"})
+			if (err != nil) != (test.failure != nil) {
+				t.Fatalf("SendEmail() error = %v", err)
+			}
+
+			if got := isPermanentEmailError(err); got != test.want {
+				t.Errorf("isPermanentEmailError(%v) = %v, want %v", err, got, test.want)
+			}
+		})
+	}
+
+	if isPermanentEmailError(errors.New("dial tcp: connection refused")) {
+		t.Error("un error de conexión no debe ser permanente")
+	}
+}
+
+func TestDeliverDueEmails(t *testing.T) {
+	tests := []struct {
+		name       string
+		failure    *smtptest.Failure
+		wantStatus string
+		permanent  bool
+	}{
+		{"enviado", nil, models.EmailStatusSent, false},
+		{"error temporal", &smtptest.Failure{Code: 451, Message: "4.3.0 Try again later"}, models.EmailStatusQueued, false},
+		{"rechazo permanente", &smtptest.Failure{Code: 550, Message: "5.1.1 User unknown"}, models.EmailStatusDead, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, err := smtptest.NewServer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer server.Close()
+
+			if test.failure != nil {
+				server.Fail(*test.failure)
+			}
+
+			config := utils.Config{SMTPHost: server.Host(), SMTPPort: server.Port()}
+			outbox := NewEmailOutbox(repository.NewMemoryStore(), utils.NewEmailService(config), config).(*EmailOutbox)
+
+			resp, err := outbox.SendEmail(utils.SendEmailRequest{Sender: "no-reply@ayudapp.com", Recipient: "ana@example.com", Subject: "Hola"})
+			if err != nil || !resp.Queued {
+				t.Fatalf("SendEmail() = %+v, %v", resp, err)
+			}
+
+			// Las fechas se guardan con precisión de milisegundos
+			start := time.Now().Truncate(time.Millisecond)
+			outbox.deliverDueEmails(context.Background())
+
+			emails, err := outbox.GetEmails("")
+			if err != nil || len(emails.Emails) != 1 {
+				t.Fatalf("GetEmails() = %+v, %v", emails, err)
+			}
+			email := emails.Emails[0]
+
+			if email.Status != test.wantStatus || email.Attempts != 1 || len(email.Deliveries) != 1 {
+				t.Fatalf("correo después de la entrega = %+v, want estado %s y un intento", email, test.wantStatus)
+			}
+			if delivery := email.Deliveries[0]; delivery.Permanent != test.permanent || (delivery.Error != "") != (test.failure != nil) {
+				t.Errorf("intento de entrega = %+v", delivery)
+			}
+			if (email.SentAt != nil) != (test.wantStatus == models.EmailStatusSent) {
+				t.Errorf("SentAt = %v con estado %s", email.SentAt, email.Status)
+			}
+			if test.failure == nil && len(server.Messages()) != 1 {
+				t.Errorf("el servidor recibió %d mensajes, want 1", len(server.Messages()))
+			}
+
+			// El reintento espera la demora del primer intento fallido
+			if test.wantStatus == models.EmailStatusQueued {
+				if email.NextAttemptAt.Before(start.Add(emailBackoff(1))) {
+					t.Errorf("NextAttemptAt = %v, want al menos %v después de %v", email.NextAttemptAt, emailBackoff(1), start)
+				}
+
+				outbox.deliverDueEmails(context.Background())
+				if emails, _ = outbox.GetEmails(models.EmailStatusQueued); len(emails.Emails) != 1 || emails.Emails[0].Attempts != 1 {
+					t.Errorf("el correo se reintentó antes de tiempo: %+v", emails.Emails)
+				}
+			}
+		})
+	}
+}
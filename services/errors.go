@@ -0,0 +1,154 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Error de los servicios con un código estable, que los clientes pueden usar en lugar del mensaje
+type DomainError struct {
+	Code    string
+	Message string
+}
+
+func (err *DomainError) Error() string {
+	return err.Message
+}
+
+func (err *DomainError) ErrorCode() string {
+	return err.Code
+}
+
+// El registro solicitado no existe
+type NotFoundError struct{ DomainError }
+
+// Conflicto con un registro existente, como un correo electrónico repetido
+type ConflictError struct{ DomainError }
+
+// Los datos de la solicitud son inválidos
+type ValidationError struct{ DomainError }
+
+// El usuario no tiene permiso para realizar la operación
+type ForbiddenError struct{ DomainError }
+
+// Las credenciales o la sesión son inválidas
+type UnauthorizedError struct{ DomainError }
+
+// El id indicado no tiene el formato de un ObjectID
+type InvalidIDError struct{ DomainError }
+
+// El archivo o el cuerpo de la solicitud supera el tamaño permitido
+type TooLargeError struct{ DomainError }
+
+// El registro fue modificado desde que el cliente lo obtuvo
+type PreconditionFailedError struct{ DomainError }
+
+// La operación requiere indicar la versión del registro con la cabecera If-Match
+type PreconditionRequiredError struct{ DomainError }
+
+// Catálogo de errores de los servicios
+var (
+	ErrInvalidID = &InvalidIDError{DomainError{Code: "invalid_id", Message: "el id es inválido"}}
+
+	ErrVersionMismatch = &PreconditionFailedError{DomainError{Code: "version_mismatch", Message: "el registro fue modificado por otra persona, vuelve a obtenerlo e intenta de nuevo"}}
+	ErrIfMatchRequired = &PreconditionRequiredError{DomainError{Code: "if_match_required", Message: "se requiere la cabecera If-Match con el ETag del registro"}}
+
+	ErrUserNotFound       = &NotFoundError{DomainError{Code: "user_not_found", Message: "no se encontró el usuario"}}
+	ErrEmailTaken         = &ConflictError{DomainError{Code: "email_taken", Message: "el correo electrónico ya está ingresado en la base de datos"}}
+	ErrInvalidCredentials = &UnauthorizedError{DomainError{Code: "invalid_credentials", Message: "el correo electrónico o la contraseña son incorrectos"}}
+	ErrSessionNotFound    = &NotFoundError{DomainError{Code: "session_not_found", Message: "no se encontró la sesión"}}
+
+	ErrAppointmentNotFound = &NotFoundError{DomainError{Code: "appointment_not_found", Message: "no se encontró la cita"}}
+
+	ErrCategoryNotFound       = &NotFoundError{DomainError{Code: "category_not_found", Message: "categoría no encontrada"}}
+	ErrParentCategoryNotFound = &NotFoundError{DomainError{Code: "parent_category_not_found", Message: "categoría padre no encontrada"}}
+	ErrTargetCategoryNotFound = &NotFoundError{DomainError{Code: "target_category_not_found", Message: "categoría destino no encontrada"}}
+	ErrCategoryNameTaken      = &ConflictError{DomainError{Code: "category_name_taken", Message: "ya existe una categoría con ese nombre"}}
+	ErrCategoryHasChildren    = &ConflictError{DomainError{Code: "category_has_children", Message: "la categoría tiene subcategorías"}}
+
+	ErrReviewExists   = &ConflictError{DomainError{Code: "review_exists", Message: "la cita ya tiene una reseña de este autor"}}
+	ErrReviewNotFound = &NotFoundError{DomainError{Code: "review_not_found", Message: "no se encontró la reseña"}}
+
+	ErrNoteNotFound              = &NotFoundError{DomainError{Code: "note_not_found", Message: "no se encontró la nota"}}
+	ErrAttachmentNotFound        = &NotFoundError{DomainError{Code: "attachment_not_found", Message: "no se encontró el archivo adjunto"}}
+	ErrAttachmentContentNotFound = &NotFoundError{DomainError{Code: "attachment_content_not_found", Message: "el contenido del archivo adjunto ya no existe"}}
+
+	ErrImportNotFound = &NotFoundError{DomainError{Code: "import_not_found", Message: "no se encontró la importación"}}
+	ErrExportNotFound = &NotFoundError{DomainError{Code: "export_not_found", Message: "no se encontró la exportación"}}
+	ErrExportNotReady = &ConflictError{DomainError{Code: "export_not_ready", Message: "la exportación no está lista"}}
+
+	ErrEmailNotFound = &NotFoundError{DomainError{Code: "email_not_found", Message: "no se encontró el correo"}}
+	ErrEmailQueued   = &ConflictError{DomainError{Code: "email_queued", Message: "el correo todavía está en cola"}}
+
+	ErrEmailTemplateNotFound = &NotFoundError{DomainError{Code: "email_template_not_found", Message: "la plantilla de correo electrónico no existe"}}
+)
+
+/** Crea un error de validación
+ *
+ * @param code string "El código del error"
+ * @param format string "El mensaje, con el formato de fmt.Sprintf"
+ * @param args ...interface{} "Los valores del mensaje"
+ * @return error "El error de validación"
+ */
+func NewValidationError(code string, format string, args ...interface{}) error {
+	return &ValidationError{DomainError{Code: code, Message: fmt.Sprintf(format, args...)}}
+}
+
+/** Crea un error de tamaño excedido
+ *
+ * @param code string "El código del error"
+ * @param format string "El mensaje, con el formato de fmt.Sprintf"
+ * @param args ...interface{} "Los valores del mensaje"
+ * @return error "El error de tamaño excedido"
+ */
+func NewTooLargeError(code string, format string, args ...interface{}) error {
+	return &TooLargeError{DomainError{Code: code, Message: fmt.Sprintf(format, args...)}}
+}
+
+/** Convierte los errores de clave duplicada en el error de conflicto indicado
+ *
+ * @param err error "El error del repositorio o de MongoDB"
+ * @param conflict *ConflictError "El error de conflicto"
+ * @return error "El error de conflicto, o el error original si no es de clave duplicada"
+ */
+func conflictError(err error, conflict *ConflictError) error {
+	if err == repository.ErrDuplicate || mongo.IsDuplicateKeyError(err) {
+		return conflict
+	}
+
+	return err
+}
+
+// Versiones de un registro que acepta el cliente con la cabecera If-Match; Any corresponde a If-Match: *
+type VersionMatch struct {
+	Any      bool
+	Versions []int64
+}
+
+// Verifica que la versión guardada del registro sea una de las que indicó el cliente
+func checkVersion(current int64, match VersionMatch) error {
+	if match.Any {
+		return nil
+	}
+
+	for _, version := range match.Versions {
+		if version == current {
+			return nil
+		}
+	}
+
+	return ErrVersionMismatch
+}
+
+// Convierte un id en ObjectID, devolviendo ErrInvalidID si el formato es inválido
+func parseID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return objectID, ErrInvalidID
+	}
+
+	return objectID, nil
+}
@@ -0,0 +1,567 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"github.com/maferuy/ayudapp-admin-backend-core/storage"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatXLSX   = "xlsx"
+	ExportFormatNDJSON = "ndjson"
+)
+
+const (
+	ExportResourceUsers        = "users"
+	ExportResourceAppointments = "appointments"
+	ExportResourceCategories   = "categories"
+)
+
+// Cantidad máxima de filas que se exportan directamente en la respuesta; las
+// exportaciones más grandes se generan en segundo plano
+const exportSyncMaxRows = 5000
+
+var exportContentTypes = map[string]string{
+	ExportFormatCSV:    "text/csv; charset=utf-8",
+	ExportFormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	ExportFormatNDJSON: "application/x-ndjson",
+}
+
+// Filtros de un listado que se pueden convertir en una consulta
+type ListFilter interface {
+	Query() (bson.M, error)
+}
+
+type ExportRequest struct {
+	Resource string
+	Format   string
+	Filter   ListFilter
+	// Parámetros originales de la consulta, para registrar qué se exportó
+	Params    string
+	Async     bool
+	CreatedBy string
+}
+
+type StartExportResponse struct {
+	ExportID  string `json:"export_id"`
+	StatusURL string `json:"status_url"`
+}
+
+type GetExportResponse struct {
+	Export      models.Export `json:"export"`
+	DownloadURL string        `json:"download_url,omitempty"`
+}
+
+type IExportService interface {
+	RunsInBackground(req ExportRequest) (async bool, err error)
+	WriteExport(w io.Writer, req ExportRequest) (rows int, err error)
+	StartExport(req ExportRequest) (response StartExportResponse, err error)
+	GetExport(id string) (response GetExportResponse, err error)
+	OpenExport(id string) (export models.Export, body io.ReadCloser, err error)
+}
+
+type ExportService struct {
+	store   repository.IStore
+	storage storage.IStorage
+}
+
+// Recibe las celdas y el documento de cada fila exportada
+type exportRowFunc func(cells []interface{}, document interface{}) error
+
+// Columnas, conteo y recorrido de los documentos de un recurso exportable
+type exportResource struct {
+	columns []string
+	count   func(store repository.IStore, filter ListFilter, limit int64) (int64, error)
+	each    func(store repository.IStore, filter ListFilter, fn exportRowFunc) error
+}
+
+var exportResources = map[string]exportResource{
+	ExportResourceUsers: {
+		columns: []string{"id", "first_name", "last_name", "email", "phone", "type", "status", "language", "skills", "rating_average", "rating_count", "created_at"},
+		count: func(store repository.IStore, filter ListFilter, limit int64) (int64, error) {
+			userFilter, _ := filter.(repository.UserFilter)
+			return store.Users().Count(ctx, userFilter, limit)
+		},
+		each: func(store repository.IStore, filter ListFilter, fn exportRowFunc) error {
+			userFilter, _ := filter.(repository.UserFilter)
+			return store.Users().Each(ctx, userFilter, func(user models.User) error {
+				return fn(userExportRow(user))
+			})
+		},
+	},
+	ExportResourceAppointments: {
+		columns: []string{"id", "date", "duration_minutes", "address", "status", "created_by", "helper", "category", "created_at"},
+		count: func(store repository.IStore, filter ListFilter, limit int64) (int64, error) {
+			appointmentFilter, _ := filter.(repository.AppointmentFilter)
+			return store.Appointments().Count(ctx, appointmentFilter, limit)
+		},
+		each: func(store repository.IStore, filter ListFilter, fn exportRowFunc) error {
+			appointmentFilter, _ := filter.(repository.AppointmentFilter)
+			return store.Appointments().Each(ctx, appointmentFilter, func(appointment models.Appointment) error {
+				return fn(appointmentExportRow(appointment))
+			})
+		},
+	},
+	ExportResourceCategories: {
+		columns: []string{"id", "name", "slug", "description", "parent_id", "sort_order"},
+		count: func(store repository.IStore, filter ListFilter, limit int64) (int64, error) {
+			categoryFilter, _ := filter.(repository.CategoryFilter)
+			categories, err := store.Categories().Find(ctx, categoryFilter)
+			return int64(len(categories)), err
+		},
+		each: func(store repository.IStore, filter ListFilter, fn exportRowFunc) error {
+			categoryFilter, _ := filter.(repository.CategoryFilter)
+			categories, err := store.Categories().Find(ctx, categoryFilter)
+			if err != nil {
+				return err
+			}
+
+			for _, category := range categories {
+				if err = fn(categoryExportRow(category)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+}
+
+/** Indica si una exportación debe generarse en segundo plano
+ *
+ * Se generan en segundo plano las exportaciones pedidas con async y las que
+ * superan la cantidad máxima de filas de una exportación directa.
+ *
+ * @param req ExportRequest "El recurso, el formato y los filtros"
+ * @return async bool "Si la exportación debe generarse en segundo plano"
+ * @return err error "Error si el formato, el recurso o los filtros son inválidos"
+ */
+func (service *ExportService) RunsInBackground(req ExportRequest) (async bool, err error) {
+	resource, err := exportResourceFor(req)
+	if err != nil {
+		return
+	}
+
+	if req.Async {
+		return true, nil
+	}
+
+	count, err := resource.count(service.store, req.Filter, exportSyncMaxRows+1)
+	if err != nil {
+		return
+	}
+
+	async = count > exportSyncMaxRows
+	return
+}
+
+/** Escribe la exportación a medida que se leen los documentos
+ *
+ * Los documentos nunca incluyen la contraseña. Los CSV incluyen BOM para que las
+ * planillas de cálculo reconozcan UTF-8 y las celdas que empiezan con =, +, - o @
+ * se escapan para que no se interpreten como fórmulas.
+ *
+ * @param w io.Writer "El destino de la exportación"
+ * @param req ExportRequest "El recurso, el formato y los filtros"
+ * @return rows int "La cantidad de filas exportadas"
+ * @return err error "El error de la operación"
+ */
+func (service *ExportService) WriteExport(w io.Writer, req ExportRequest) (rows int, err error) {
+	resource, err := exportResourceFor(req)
+	if err != nil {
+		return
+	}
+
+	writer, err := newExportWriter(w, req.Format, req.Resource, resource.columns)
+	if err != nil {
+		return
+	}
+
+	err = resource.each(service.store, req.Filter, func(cells []interface{}, document interface{}) error {
+		if err := writer.Write(cells, document); err != nil {
+			return err
+		}
+		rows++
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	err = writer.Close()
+	return
+}
+
+/** Inicia una exportación en segundo plano
+ *
+ * El archivo se guarda en el almacenamiento y se descarga desde el endpoint de la
+ * exportación cuando termina.
+ *
+ * @param req ExportRequest "El recurso, el formato y los filtros"
+ * @return response StartExportResponse "El id de la exportación para consultar su estado"
+ * @return err error "El error de la operación"
+ */
+func (service *ExportService) StartExport(req ExportRequest) (response StartExportResponse, err error) {
+	if _, err = exportResourceFor(req); err != nil {
+		return
+	}
+
+	suffix := make([]byte, 8)
+	if _, err = rand.Read(suffix); err != nil {
+		return
+	}
+
+	now := time.Now()
+	job := models.Export{
+		ID:        primitive.NewObjectIDFromTimestamp(now),
+		Resource:  req.Resource,
+		Format:    req.Format,
+		Params:    req.Params,
+		Status:    models.ExportStatusRunning,
+		FileName:  ExportFileName(req, now),
+		CreatedBy: req.CreatedBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	job.Key = fmt.Sprintf("exports/%s/%s.%s", hex.EncodeToString(suffix), job.ID.Hex(), req.Format)
+
+	if err = service.store.Exports().Insert(ctx, &job); err != nil {
+		return
+	}
+
+	go service.runExport(job, req)
+
+	response.ExportID = job.ID.Hex()
+	response.StatusURL = "/api/admin/exports/" + response.ExportID
+	return
+}
+
+/** Obtiene el estado de una exportación
+ *
+ * @param exportId string "El id de la exportación"
+ * @return response GetExportResponse "La exportación y el enlace de descarga si terminó"
+ * @return err error "El error de la operación"
+ */
+func (service *ExportService) GetExport(exportId string) (response GetExportResponse, err error) {
+	if response.Export, err = service.findExport(exportId); err != nil {
+		return
+	}
+
+	if response.Export.Status == models.ExportStatusDone {
+		response.DownloadURL = "/api/admin/exports/" + exportId + "/download"
+	}
+
+	return
+}
+
+/** Abre el archivo de una exportación terminada
+ *
+ * @param exportId string "El id de la exportación"
+ * @return export models.Export "La exportación"
+ * @return body io.ReadCloser "El contenido del archivo, que debe cerrarse"
+ * @return err error "Error si la exportación no existe o no terminó"
+ */
+func (service *ExportService) OpenExport(exportId string) (export models.Export, body io.ReadCloser, err error) {
+	if export, err = service.findExport(exportId); err != nil {
+		return
+	}
+
+	if export.Status != models.ExportStatusDone {
+		err = ErrExportNotReady
+		return
+	}
+
+	// El archivo pudo haberse eliminado del almacenamiento
+	body, err = service.storage.Get(ctx, export.Key)
+	if err == storage.ErrNotFound {
+		err = ErrExportNotFound
+	}
+
+	return
+}
+
+/** Busca una exportación por id
+ *
+ * @param exportId string "El id de la exportación"
+ * @return export models.Export "La exportación"
+ * @return err error "El error de la operación"
+ */
+func (service *ExportService) findExport(exportId string) (export models.Export, err error) {
+	id, err := parseID(exportId)
+	if err != nil {
+		return
+	}
+
+	export, err = service.store.Exports().FindByID(ctx, id)
+	if err == repository.ErrNotFound {
+		err = ErrExportNotFound
+	}
+
+	return
+}
+
+/** Genera el archivo de una exportación y lo guarda en el almacenamiento
+ *
+ * @param job models.Export "La exportación"
+ * @param req ExportRequest "El recurso, el formato y los filtros"
+ */
+func (service *ExportService) runExport(job models.Export, req ExportRequest) {
+	reader, writer := io.Pipe()
+	counter := &countingWriter{writer: writer}
+
+	type result struct {
+		rows int
+		err  error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		rows, err := service.WriteExport(counter, req)
+		writer.CloseWithError(err)
+		done <- result{rows, err}
+	}()
+
+	err := service.storage.Put(context.Background(), job.Key, reader, exportContentTypes[req.Format])
+	reader.CloseWithError(err)
+	written := <-done
+	if written.err != nil {
+		err = written.err
+	}
+
+	now := time.Now()
+	job.Rows = written.rows
+	job.Size = counter.size
+	job.Status = models.ExportStatusDone
+	job.UpdatedAt = now
+	job.FinishedAt = &now
+	if err != nil {
+		log.Printf("Error en la exportación %s: %v", job.ID.Hex(), err)
+		job.Status = models.ExportStatusFailed
+		job.Error = err.Error()
+	}
+
+	if err = service.store.Exports().Update(context.Background(), job); err != nil {
+		log.Printf("Error al guardar la exportación %s: %v", job.ID.Hex(), err)
+	}
+}
+
+/** Obtiene el recurso de una exportación y valida sus filtros
+ *
+ * @param req ExportRequest "El recurso, el formato y los filtros"
+ * @return resource exportResource "El recurso"
+ * @return err error "Error si el formato, el recurso o los filtros son inválidos"
+ */
+func exportResourceFor(req ExportRequest) (resource exportResource, err error) {
+	if _, ok := exportContentTypes[req.Format]; !ok {
+		err = NewValidationError("invalid_export_format", "el formato debe ser csv, xlsx o ndjson")
+		return
+	}
+
+	resource, ok := exportResources[req.Resource]
+	if !ok {
+		err = NewValidationError("invalid_export_resource", "el recurso %s no se puede exportar", req.Resource)
+		return
+	}
+
+	if req.Filter != nil {
+		_, err = req.Filter.Query()
+	}
+
+	return
+}
+
+/** Devuelve el tipo de contenido de un formato de exportación
+ *
+ * @param format string "El formato"
+ * @return string "El tipo de contenido"
+ */
+func ExportContentType(format string) string {
+	return exportContentTypes[format]
+}
+
+/** Devuelve el nombre del archivo de una exportación, por ejemplo users-20220301-153000.csv
+ *
+ * @param req ExportRequest "El recurso y el formato"
+ * @param now time.Time "La fecha de la exportación"
+ * @return string "El nombre del archivo"
+ */
+func ExportFileName(req ExportRequest, now time.Time) string {
+	return fmt.Sprintf("%s-%s.%s", req.Resource, now.Format("20060102-150405"), req.Format)
+}
+
+func userExportRow(user models.User) (cells []interface{}, document interface{}) {
+	user.Password = ""
+	user.PushSubscriptions = nil
+	user.ProfileImageKeys = nil
+
+	skills := make([]string, len(user.Skills))
+	for i, skill := range user.Skills {
+		skills[i] = skill.Hex()
+	}
+
+	var ratingAverage, ratingCount interface{}
+	if user.Rating != nil {
+		ratingAverage, ratingCount = user.Rating.Average, user.Rating.Count
+	}
+
+	cells = []interface{}{
+		user.ID.Hex(), user.FirstName, user.LastName, user.Email, user.Phone, user.Type, user.Status,
+		user.Language, strings.Join(skills, "|"), ratingAverage, ratingCount, user.CreatedAt,
+	}
+	document = user
+	return
+}
+
+func appointmentExportRow(appointment models.Appointment) (cells []interface{}, document interface{}) {
+	cells = []interface{}{
+		appointment.ID.Hex(), appointment.Date, int64(appointment.Duration / time.Minute), appointment.Address, appointment.Status,
+		exportObjectID(appointment.CreatedBy), exportObjectID(appointment.Helper), exportObjectID(appointment.Category), appointment.CreatedAt,
+	}
+	document = appointment
+	return
+}
+
+func categoryExportRow(category models.Category) (cells []interface{}, document interface{}) {
+	parentID := ""
+	if category.ParentID != nil {
+		parentID = category.ParentID.Hex()
+	}
+
+	cells = []interface{}{category.ID.Hex(), category.Name, category.Slug, category.Description, parentID, category.SortOrder}
+	document = category
+	return
+}
+
+// Convierte un id opcional en texto, vacío si no está asignado
+func exportObjectID(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
+
+// Formato de salida de una exportación
+type exportWriter interface {
+	Write(cells []interface{}, document interface{}) error
+	Close() error
+}
+
+/** Crea el escritor del formato indicado y escribe los encabezados
+ *
+ * @param w io.Writer "El destino de la exportación"
+ * @param format string "El formato"
+ * @param name string "El nombre de la hoja en XLSX"
+ * @param columns []string "Los encabezados de las columnas"
+ * @return exportWriter "El escritor"
+ * @return error "El error de escritura"
+ */
+func newExportWriter(w io.Writer, format string, name string, columns []string) (exportWriter, error) {
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+
+	switch format {
+	case ExportFormatXLSX:
+		writer, err := utils.NewXLSXWriter(w, name)
+		if err != nil {
+			return nil, err
+		}
+		return &xlsxExportWriter{writer}, writer.WriteRow(header)
+	case ExportFormatNDJSON:
+		return &ndjsonExportWriter{json.NewEncoder(w)}, nil
+	default:
+		if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+			return nil, err
+		}
+		writer := &csvExportWriter{csv.NewWriter(w)}
+		return writer, writer.Write(header, nil)
+	}
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+func (export *csvExportWriter) Write(cells []interface{}, document interface{}) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		record[i] = utils.FormatCell(cell)
+		if _, ok := cell.(string); ok {
+			record[i] = escapeFormula(record[i])
+		}
+	}
+
+	return export.writer.Write(record)
+}
+
+func (export *csvExportWriter) Close() error {
+	export.writer.Flush()
+	return export.writer.Error()
+}
+
+type xlsxExportWriter struct {
+	writer *utils.XLSXWriter
+}
+
+func (export *xlsxExportWriter) Write(cells []interface{}, document interface{}) error {
+	return export.writer.WriteRow(cells)
+}
+
+func (export *xlsxExportWriter) Close() error {
+	return export.writer.Close()
+}
+
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+}
+
+func (export *ndjsonExportWriter) Write(cells []interface{}, document interface{}) error {
+	return export.encoder.Encode(document)
+}
+
+func (export *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+/** Escapa el texto de una celda CSV que una planilla de cálculo interpretaría como fórmula
+ *
+ * @param value string "El texto de la celda"
+ * @return string "El texto escapado con una comilla simple al inicio"
+ */
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// Cuenta los bytes escritos en una exportación
+type countingWriter struct {
+	writer io.Writer
+	size   int64
+}
+
+func (counter *countingWriter) Write(p []byte) (n int, err error) {
+	n, err = counter.writer.Write(p)
+	counter.size += int64(n)
+	return
+}
+
+func NewExportService(store repository.IStore, storage storage.IStorage) IExportService {
+	return &ExportService{store: store, storage: storage}
+}
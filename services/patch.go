@@ -0,0 +1,38 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+)
+
+/** Aplica un parche JSON Merge Patch a los campos editables de un registro y valida el resultado
+ *
+ * Los campos que el parche no menciona conservan su valor; los campos con valor null
+ * quedan vacíos. Los campos que no son editables se rechazan.
+ *
+ * @param current interface{} "Los campos editables actuales del registro"
+ * @param patch []byte "El parche JSON Merge Patch"
+ * @param merged interface{} "Puntero a la estructura donde se guardan los campos resultantes"
+ * @return error "El error de validación si el parche o el resultado son inválidos"
+ */
+func applyMergePatch(current interface{}, patch []byte, merged interface{}) (err error) {
+	document, err := json.Marshal(current)
+	if err != nil {
+		return
+	}
+
+	result, err := utils.MergePatch(document, patch)
+	if err != nil {
+		return NewValidationError("invalid_patch", "el parche no es JSON válido: %v", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(result))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(merged); err != nil {
+		return NewValidationError("invalid_patch", "el parche es inválido: %v", err)
+	}
+
+	return utils.ValidateStruct(merged)
+}
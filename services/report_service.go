@@ -0,0 +1,263 @@
+package services
+
+import (
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Período y filtros del informe de ayudantes; ambas fechas están incluidas
+type HelperReportRequest struct {
+	From     time.Time `form:"from" json:"from" time_format:"2006-01-02"`
+	To       time.Time `form:"to" json:"to" time_format:"2006-01-02"`
+	Helper   string    `form:"helper" json:"helper"`
+	Category string    `form:"category" json:"category"`
+}
+
+// Trabajo de un ayudante en una categoría
+type HelperCategoryReport struct {
+	CategoryID     string  `json:"category_id"`
+	Name           string  `json:"name"`
+	Appointments   int     `json:"appointments"`
+	BookedHours    float64 `json:"booked_hours"`
+	CompletedHours float64 `json:"completed_hours"`
+	NoShows        int     `json:"no_shows"`
+	Cancellations  int     `json:"cancellations"`
+}
+
+// Trabajo de un ayudante en el período
+type HelperReport struct {
+	HelperID       string                 `json:"helper_id"`
+	FirstName      string                 `json:"first_name"`
+	LastName       string                 `json:"last_name"`
+	Email          string                 `json:"email"`
+	Appointments   int                    `json:"appointments"`
+	BookedHours    float64                `json:"booked_hours"`
+	CompletedHours float64                `json:"completed_hours"`
+	NoShows        int                    `json:"no_shows"`
+	Cancellations  int                    `json:"cancellations"`
+	Categories     []HelperCategoryReport `json:"categories"`
+}
+
+type GetHelperReportResponse struct {
+	From    string         `json:"from"`
+	To      string         `json:"to"`
+	Helpers []HelperReport `json:"helpers"`
+}
+
+type IReportService interface {
+	GetHelperReport(req HelperReportRequest) (response GetHelperReportResponse, err error)
+}
+
+type ReportService struct {
+	store repository.IStore
+	now   func() time.Time
+}
+
+/** Obtiene la carga de trabajo de los ayudantes en un período
+ *
+ * Para cada ayudante con citas en el período se informa la cantidad de citas, las
+ * horas reservadas (la suma de las duraciones de las citas no canceladas), las horas
+ * de citas completadas, las ausencias y las cancelaciones, en total y por categoría.
+ * Por defecto el período es el mes en curso.
+ *
+ * @param req HelperReportRequest "El período y los filtros"
+ * @return response GetHelperReportResponse "El informe"
+ * @return err error "Error si el período o los filtros son inválidos"
+ */
+func (service *ReportService) GetHelperReport(req HelperReportRequest) (response GetHelperReportResponse, err error) {
+	from, to, err := service.reportRange(req.From, req.To)
+	if err != nil {
+		return
+	}
+
+	if req.Helper != "" && !primitive.IsValidObjectID(req.Helper) {
+		err = NewValidationError("invalid_helper", "el ayudante es inválido")
+		return
+	}
+	if req.Category != "" && !primitive.IsValidObjectID(req.Category) {
+		err = NewValidationError("invalid_category", "la categoría es inválida")
+		return
+	}
+
+	filter := repository.AppointmentFilter{From: from, To: to, Helper: req.Helper, Category: req.Category}
+	workloads, err := service.store.Appointments().HelperWorkloads(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	categories, err := service.store.Categories().Find(ctx, repository.CategoryFilter{})
+	if err != nil {
+		return
+	}
+
+	categoryNames := map[primitive.ObjectID]string{}
+	for _, category := range categories {
+		categoryNames[category.ID] = category.Name
+	}
+
+	response = GetHelperReportResponse{
+		From:    from.Format("2006-01-02"),
+		To:      to.Format("2006-01-02"),
+		Helpers: []HelperReport{},
+	}
+
+	// Las horas de cada ayudante se redondean después de sumar las duraciones
+	indexes := map[primitive.ObjectID]int{}
+	var booked, completed []time.Duration
+	for _, workload := range workloads {
+		i, ok := indexes[workload.Helper]
+		if !ok {
+			var helper HelperReport
+			if helper, err = service.helperReport(workload.Helper); err != nil {
+				return
+			}
+
+			i = len(response.Helpers)
+			indexes[workload.Helper] = i
+			response.Helpers = append(response.Helpers, helper)
+			booked = append(booked, 0)
+			completed = append(completed, 0)
+		}
+
+		category := HelperCategoryReport{
+			CategoryID:     exportObjectID(workload.Category),
+			Name:           categoryNames[workload.Category],
+			Appointments:   workload.Appointments,
+			BookedHours:    durationHours(int64(workload.BookedDuration)),
+			CompletedHours: durationHours(int64(workload.CompletedDuration)),
+			NoShows:        workload.NoShows,
+			Cancellations:  workload.Cancellations,
+		}
+
+		booked[i] += workload.BookedDuration
+		completed[i] += workload.CompletedDuration
+
+		helper := &response.Helpers[i]
+		helper.Appointments += workload.Appointments
+		helper.BookedHours = durationHours(int64(booked[i]))
+		helper.CompletedHours = durationHours(int64(completed[i]))
+		helper.NoShows += workload.NoShows
+		helper.Cancellations += workload.Cancellations
+		helper.Categories = append(helper.Categories, category)
+	}
+
+	for i := range response.Helpers {
+		categories := response.Helpers[i].Categories
+		sort.SliceStable(categories, func(a, b int) bool { return categories[a].Name < categories[b].Name })
+	}
+
+	sort.SliceStable(response.Helpers, func(i, j int) bool {
+		a, b := response.Helpers[i], response.Helpers[j]
+		if a.LastName != b.LastName {
+			return a.LastName < b.LastName
+		}
+		if a.FirstName != b.FirstName {
+			return a.FirstName < b.FirstName
+		}
+		return a.HelperID < b.HelperID
+	})
+
+	return
+}
+
+/** Crea el informe vacío de un ayudante con sus datos
+ *
+ * Un ayudante eliminado se informa sólo con su id.
+ *
+ * @param id primitive.ObjectID "El id del ayudante"
+ * @return report HelperReport "El informe del ayudante"
+ * @return err error "El error de la consulta"
+ */
+func (service *ReportService) helperReport(id primitive.ObjectID) (report HelperReport, err error) {
+	report = HelperReport{HelperID: id.Hex(), Categories: []HelperCategoryReport{}}
+
+	user, err := service.store.Users().FindByID(ctx, id)
+	if err == repository.ErrNotFound {
+		return report, nil
+	}
+	if err != nil {
+		return
+	}
+
+	report.FirstName = user.FirstName
+	report.LastName = user.LastName
+	report.Email = user.Email
+	return
+}
+
+/** Valida el período de un informe y completa los valores por defecto
+ *
+ * @param from time.Time "El primer día pedido, por defecto el primero del mes en curso"
+ * @param to time.Time "El último día pedido, por defecto hoy"
+ * @return time.Time "El primer día del período"
+ * @return time.Time "El último día del período"
+ * @return error "Error si el período es inválido"
+ */
+func (service *ReportService) reportRange(from time.Time, to time.Time) (time.Time, time.Time, error) {
+	if to.IsZero() {
+		now := service.now().UTC()
+		to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	if from.IsZero() {
+		from = time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	if from.After(to) {
+		return from, to, NewValidationError("invalid_date_range", "la fecha inicial no puede ser posterior a la final")
+	}
+
+	if to.Sub(from) >= statsMaxDays*24*time.Hour {
+		return from, to, NewValidationError("date_range_too_long", "el período no puede superar %d días", statsMaxDays)
+	}
+
+	return from, to, nil
+}
+
+/** Escribe el informe de ayudantes en CSV, con una fila por ayudante y categoría
+ *
+ * Los totales de cada ayudante son la suma de sus filas.
+ *
+ * @param w io.Writer "El destino del archivo"
+ * @param report GetHelperReportResponse "El informe"
+ * @return error "El error de escritura"
+ */
+func WriteHelperReportCSV(w io.Writer, report GetHelperReportResponse) error {
+	columns := []string{
+		"helper_id", "first_name", "last_name", "email", "category_id", "category",
+		"appointments", "booked_hours", "completed_hours", "no_shows", "cancellations",
+	}
+
+	writer, err := newExportWriter(w, ExportFormatCSV, "helpers", columns)
+	if err != nil {
+		return err
+	}
+
+	for _, helper := range report.Helpers {
+		for _, category := range helper.Categories {
+			err = writer.Write([]interface{}{
+				helper.HelperID, helper.FirstName, helper.LastName, helper.Email, category.CategoryID, category.Name,
+				category.Appointments, category.BookedHours, category.CompletedHours, category.NoShows, category.Cancellations,
+			}, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return writer.Close()
+}
+
+// Convierte una duración en nanosegundos en horas con dos decimales
+func durationHours(duration int64) float64 {
+	return math.Round(time.Duration(duration).Hours()*100) / 100
+}
+
+func NewReportService(store repository.IStore) IReportService {
+	return &ReportService{store: store, now: time.Now}
+}
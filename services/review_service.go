@@ -0,0 +1,272 @@
+package services
+
+import (
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CreateReviewRequest struct {
+	AuthorRole string `json:"author_role"`
+	Score      int    `json:"score"`
+	Comment    string `json:"comment"`
+}
+
+type ModerateReviewRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+type CreateReviewResponse struct {
+	ReviewID string `json:"review_id"`
+}
+
+type GetReviewsResponse struct {
+	Reviews []models.Review `json:"reviews"`
+}
+
+type GetReviewResponse struct {
+	Review models.Review `json:"review"`
+}
+
+type ModerateReviewResponse struct {
+	Review models.Review `json:"review"`
+}
+
+type IReviewService interface {
+	CreateReview(appointmentId string, req CreateReviewRequest) (response CreateReviewResponse, err error)
+	GetAppointmentReviews(appointmentId string) (response GetReviewsResponse, err error)
+
+	GetReviews(status string) (response GetReviewsResponse, err error)
+	GetReview(id string) (response GetReviewResponse, err error)
+	ModerateReview(id string, req ModerateReviewRequest, moderator string) (response ModerateReviewResponse, err error)
+	DeleteReview(id string) (err error)
+}
+
+type ReviewService struct {
+	store repository.IStore
+}
+
+/** Crea la reseña de una cita completada
+ *
+ * La reseña del solicitante califica al ayudante y la del ayudante califica al
+ * solicitante. Cada cita admite una sola reseña por autor.
+ *
+ * @param appointmentId string "El id de la cita"
+ * @param req CreateReviewRequest "Los datos de la reseña"
+ * @return response CreateReviewResponse "El id de la reseña creada"
+ * @return err error "El error de la operación"
+ */
+func (service *ReviewService) CreateReview(appointmentId string, req CreateReviewRequest) (response CreateReviewResponse, err error) {
+	if req.Score < 1 || req.Score > 5 {
+		err = NewValidationError("invalid_score", "la calificación debe estar entre 1 y 5")
+		return
+	}
+
+	id, err := parseID(appointmentId)
+	if err != nil {
+		return
+	}
+
+	appointment, err := service.store.Appointments().FindByID(ctx, id)
+	if err == repository.ErrNotFound {
+		err = ErrAppointmentNotFound
+	}
+	if err != nil {
+		return
+	}
+
+	if appointment.Status != models.AppointmentStatusCompleted {
+		err = NewValidationError("appointment_not_completed", "sólo se pueden reseñar citas completadas")
+		return
+	}
+
+	review := models.Review{
+		AppointmentID: appointment.ID,
+		AuthorRole:    req.AuthorRole,
+		Score:         req.Score,
+		Comment:       req.Comment,
+		Status:        models.ReviewStatusPending,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	switch req.AuthorRole {
+	case models.ReviewRoleRequester:
+		review.AuthorID = appointment.CreatedBy
+		review.SubjectID = appointment.Helper
+	case models.ReviewRoleHelper:
+		review.AuthorID = appointment.Helper
+		review.SubjectID = appointment.CreatedBy
+	default:
+		err = NewValidationError("invalid_author_role", "el autor de la reseña debe ser requester o helper")
+		return
+	}
+
+	// Sin ayudante o sin solicitante la reseña no tendría autor o destinatario
+	if appointment.Helper.IsZero() {
+		err = NewValidationError("appointment_without_helper", "la cita no tiene un ayudante asignado")
+		return
+	}
+	if appointment.CreatedBy.IsZero() {
+		err = NewValidationError("appointment_without_requester", "la cita no tiene un solicitante")
+		return
+	}
+
+	if err = conflictError(service.store.Reviews().Insert(ctx, &review), ErrReviewExists); err != nil {
+		return
+	}
+
+	response.ReviewID = review.ID.Hex()
+	return
+}
+
+/** Obtiene las reseñas de una cita
+ *
+ * @param appointmentId string "El id de la cita"
+ * @return response GetReviewsResponse "Las reseñas"
+ * @return err error "El error de la operación"
+ */
+func (service *ReviewService) GetAppointmentReviews(appointmentId string) (response GetReviewsResponse, err error) {
+	id, err := parseID(appointmentId)
+	if err != nil {
+		return
+	}
+
+	response.Reviews, err = service.store.Reviews().Find(ctx, repository.ReviewFilter{AppointmentID: id})
+	return
+}
+
+/** Obtiene las reseñas, opcionalmente filtradas por estado de moderación
+ *
+ * @param status string "El estado de moderación, vacío para todas"
+ * @return response GetReviewsResponse "Las reseñas"
+ * @return err error "El error de la operación"
+ */
+func (service *ReviewService) GetReviews(status string) (response GetReviewsResponse, err error) {
+	response.Reviews, err = service.store.Reviews().Find(ctx, repository.ReviewFilter{Status: status})
+	return
+}
+
+/** Obtiene una reseña
+ *
+ * @param reviewId string "El id de la reseña"
+ * @return response GetReviewResponse "La reseña"
+ * @return err error "El error de la operación"
+ */
+func (service *ReviewService) GetReview(reviewId string) (response GetReviewResponse, err error) {
+	response.Review, err = service.findReview(reviewId)
+	return
+}
+
+/** Aprueba o rechaza una reseña y actualiza la calificación del usuario reseñado
+ *
+ * @param reviewId string "El id de la reseña"
+ * @param req ModerateReviewRequest "El nuevo estado y una nota opcional"
+ * @param moderator string "El correo electrónico del administrador"
+ * @return response ModerateReviewResponse "La reseña moderada"
+ * @return err error "El error de la operación"
+ */
+func (service *ReviewService) ModerateReview(reviewId string, req ModerateReviewRequest, moderator string) (response ModerateReviewResponse, err error) {
+	switch req.Status {
+	case models.ReviewStatusPending, models.ReviewStatusApproved, models.ReviewStatusRejected:
+	default:
+		err = NewValidationError("invalid_review_status", "el estado de moderación es inválido")
+		return
+	}
+
+	review, err := service.findReview(reviewId)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	review.Status = req.Status
+	review.ModerationNote = req.Note
+	review.ModeratedBy = moderator
+	review.ModeratedAt = &now
+	review.UpdatedAt = now
+
+	err = service.store.Reviews().Update(ctx, review)
+	if err == repository.ErrNotFound {
+		err = ErrReviewNotFound
+	}
+	if err != nil {
+		return
+	}
+
+	if err = service.updateUserRating(review.SubjectID); err != nil {
+		return
+	}
+
+	response.Review = review
+	return
+}
+
+/** Elimina una reseña y actualiza la calificación del usuario reseñado
+ *
+ * @param reviewId string "El id de la reseña"
+ * @return err error "El error de la operación"
+ */
+func (service *ReviewService) DeleteReview(reviewId string) (err error) {
+	review, err := service.findReview(reviewId)
+	if err != nil {
+		return
+	}
+
+	err = service.store.Reviews().Delete(ctx, review.ID)
+	if err == repository.ErrNotFound {
+		err = ErrReviewNotFound
+	}
+	if err != nil {
+		return
+	}
+
+	return service.updateUserRating(review.SubjectID)
+}
+
+/** Obtiene una reseña por su id
+ *
+ * @param reviewId string "El id de la reseña"
+ * @return review models.Review "La reseña"
+ * @return err error "El error de la operación"
+ */
+func (service *ReviewService) findReview(reviewId string) (review models.Review, err error) {
+	id, err := parseID(reviewId)
+	if err != nil {
+		return
+	}
+
+	review, err = service.store.Reviews().FindByID(ctx, id)
+	if err == repository.ErrNotFound {
+		err = ErrReviewNotFound
+	}
+
+	return
+}
+
+/** Recalcula la calificación promedio de un ayudante a partir de sus reseñas aprobadas
+ *
+ * @param userID primitive.ObjectID "El id del usuario reseñado"
+ * @return err error "El error de la operación"
+ */
+func (service *ReviewService) updateUserRating(userID primitive.ObjectID) (err error) {
+	rating, err := service.store.Reviews().Rating(ctx, userID)
+	if err != nil {
+		return
+	}
+
+	// El usuario reseñado pudo haberse eliminado después de la cita
+	err = service.store.Users().SetRating(ctx, userID, rating)
+	if err == repository.ErrNotFound {
+		err = nil
+	}
+
+	return
+}
+
+func NewReviewService(store repository.IStore) IReviewService {
+	return &ReviewService{store: store}
+}
@@ -0,0 +1,376 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"os"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/notifications"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// Tiempo durante el cual una instancia reserva una tarea para ejecutarla
+	jobLeaseDuration = 2 * time.Minute
+	// Cantidad máxima de intentos antes de marcar una tarea como fallida
+	jobMaxAttempts = 5
+	// Demora máxima entre reintentos
+	jobMaxBackoff = time.Hour
+)
+
+// Recordatorios que se programan antes de la fecha de cada cita
+var appointmentReminders = []struct {
+	JobType string
+	Before  time.Duration
+}{
+	{models.JobTypeAppointmentReminder24h, 24 * time.Hour},
+	{models.JobTypeAppointmentReminder1h, time.Hour},
+}
+
+type IScheduler interface {
+	ScheduleAppointmentReminders(ctx context.Context, appointment models.Appointment) (err error)
+	NotifyAppointmentChange(ctx context.Context, previous models.Appointment, current models.Appointment) (err error)
+	CancelAppointmentJobs(ctx context.Context, appointmentID primitive.ObjectID) (err error)
+
+	Start(ctx context.Context)
+}
+
+type Scheduler struct {
+	store      repository.IStore
+	notifier   notifications.IDispatcher
+	config     utils.Config
+	instanceID string
+}
+
+/** Programa los recordatorios de una cita
+ *
+ * Sólo se programan los recordatorios cuya fecha de envío todavía no pasó.
+ *
+ * @param ctx context.Context "El contexto de la operación, que puede ser el de una transacción"
+ * @param appointment models.Appointment "La cita"
+ * @return err error "El error de la operación"
+ */
+func (scheduler *Scheduler) ScheduleAppointmentReminders(ctx context.Context, appointment models.Appointment) (err error) {
+	for _, reminder := range appointmentReminders {
+		runAt := appointment.Date.Add(-reminder.Before)
+		if runAt.Before(time.Now()) {
+			continue
+		}
+
+		job := newJob(reminder.JobType, appointment.ID, runAt)
+		if err = scheduler.store.Jobs().Insert(ctx, &job); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+/** Programa los avisos que corresponden a los cambios de una cita
+ *
+ * Avisa inmediatamente la confirmación, la cancelación o el cambio de ayudante, y
+ * reprograma los recordatorios si cambió la fecha.
+ *
+ * @param ctx context.Context "El contexto de la operación, que puede ser el de una transacción"
+ * @param previous models.Appointment "La cita antes del cambio"
+ * @param current models.Appointment "La cita después del cambio"
+ * @return err error "El error de la operación"
+ */
+func (scheduler *Scheduler) NotifyAppointmentChange(ctx context.Context, previous models.Appointment, current models.Appointment) (err error) {
+	jobs := scheduler.store.Jobs()
+	now := time.Now()
+
+	if current.Status != previous.Status {
+		switch current.Status {
+		case models.AppointmentStatusConfirmed:
+			job := newJob(models.JobTypeAppointmentConfirmed, current.ID, now)
+			if err = jobs.Insert(ctx, &job); err != nil {
+				return
+			}
+		case models.AppointmentStatusCancelled:
+			if err = scheduler.cancelReminders(ctx, current.ID); err != nil {
+				return
+			}
+
+			job := newJob(models.JobTypeAppointmentCancelled, current.ID, now)
+			err = jobs.Insert(ctx, &job)
+			return
+		}
+	}
+
+	if current.Helper != previous.Helper {
+		job := newJob(models.JobTypeAppointmentReassigned, current.ID, now)
+		job.PreviousHelper = previous.Helper
+		if err = jobs.Insert(ctx, &job); err != nil {
+			return
+		}
+	}
+
+	if !current.Date.Equal(previous.Date) {
+		if err = scheduler.cancelReminders(ctx, current.ID); err != nil {
+			return
+		}
+
+		err = scheduler.ScheduleAppointmentReminders(ctx, current)
+	}
+
+	return
+}
+
+/** Cancela todas las tareas pendientes de una cita
+ *
+ * @param ctx context.Context "El contexto de la operación, que puede ser el de una transacción"
+ * @param appointmentID primitive.ObjectID "El id de la cita"
+ * @return err error "El error de la operación"
+ */
+func (scheduler *Scheduler) CancelAppointmentJobs(ctx context.Context, appointmentID primitive.ObjectID) (err error) {
+	return scheduler.store.Jobs().CancelPending(ctx, appointmentID, nil, time.Now())
+}
+
+/** Inicia el procesamiento de tareas en segundo plano hasta que se cancele el contexto
+ *
+ * Las tareas se reservan con un tiempo de expiración, por lo que varias instancias
+ * pueden procesar la misma colección sin enviar dos veces el mismo aviso, y las
+ * tareas que quedaron a medias tras un reinicio se vuelven a intentar.
+ *
+ * @param ctx context.Context "El contexto que detiene el procesamiento"
+ */
+func (scheduler *Scheduler) Start(ctx context.Context) {
+	interval := scheduler.config.SchedulerPollInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			scheduler.runDueJobs(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+/** Ejecuta todas las tareas vencidas
+ *
+ * @param ctx context.Context "El contexto del procesamiento"
+ */
+func (scheduler *Scheduler) runDueJobs(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now()
+		job, err := scheduler.store.Jobs().Claim(ctx, scheduler.instanceID, now, now.Add(jobLeaseDuration))
+		if err == repository.ErrNotFound {
+			return
+		}
+		if err != nil {
+			log.Printf("Error al obtener tareas programadas: %v", err)
+			return
+		}
+
+		scheduler.finishJob(ctx, job, scheduler.runJob(ctx, &job))
+	}
+}
+
+/** Envía los avisos de una tarea a cada destinatario que todavía no lo recibió
+ *
+ * @param ctx context.Context "El contexto del procesamiento"
+ * @param job *models.Job "La tarea reservada"
+ * @return err error "El error del envío"
+ */
+func (scheduler *Scheduler) runJob(ctx context.Context, job *models.Job) (err error) {
+	appointment, err := scheduler.store.Appointments().FindByID(ctx, job.AppointmentID)
+	if err == repository.ErrNotFound {
+		// La cita fue eliminada, no hay nada que avisar
+		return nil
+	}
+	if err != nil {
+		return
+	}
+
+	if isReminder(job.Type) && (appointment.Status == models.AppointmentStatusCancelled || appointment.Date.Before(time.Now())) {
+		return nil
+	}
+
+	recipients := []primitive.ObjectID{appointment.CreatedBy, appointment.Helper}
+	if job.Type == models.JobTypeAppointmentReassigned && !job.PreviousHelper.IsZero() {
+		recipients = append(recipients, job.PreviousHelper)
+	}
+
+	for _, userID := range recipients {
+		if userID.IsZero() {
+			continue
+		}
+
+		var user models.User
+		if user, err = scheduler.store.Users().FindByID(ctx, userID); err != nil {
+			if err == repository.ErrNotFound {
+				err = nil
+				continue
+			}
+			return
+		}
+
+		if containsString(job.SentTo, user.Email) {
+			continue
+		}
+
+		var email utils.RenderedEmail
+		if email, err = appointmentMessage(job, appointment, user); err != nil {
+			return
+		}
+
+		if _, err = scheduler.notifier.Notify(ctx, user, notifications.Notification{
+			Subject: email.Subject,
+			Text:    email.Text,
+			HTML:    email.HTML,
+			Urgent:  isUrgent(job.Type),
+		}); err != nil {
+			return
+		}
+
+		// Registra el envío para no repetirlo si la tarea se reintenta
+		job.SentTo = append(job.SentTo, user.Email)
+		if err = scheduler.store.Jobs().AddSentTo(ctx, job.ID, user.Email); err != nil {
+			return
+		}
+	}
+
+	return nil
+}
+
+/** Marca una tarea como terminada o la reprograma con espera exponencial si falló
+ *
+ * @param ctx context.Context "El contexto del procesamiento"
+ * @param job models.Job "La tarea ejecutada"
+ * @param jobErr error "El error de la ejecución"
+ */
+func (scheduler *Scheduler) finishJob(ctx context.Context, job models.Job, jobErr error) {
+	now := time.Now()
+	job.UpdatedAt = now
+	job.Status = models.JobStatusDone
+
+	if jobErr != nil {
+		log.Printf("Error al ejecutar la tarea %s (%s): %v", job.ID.Hex(), job.Type, jobErr)
+
+		job.LastError = jobErr.Error()
+		if job.Attempts >= jobMaxAttempts {
+			job.Status = models.JobStatusFailed
+		} else {
+			job.Status = models.JobStatusPending
+			job.RunAt = now.Add(jobBackoff(job.Attempts))
+		}
+	}
+
+	// Si la reserva expiró, otra instancia ya volvió a reservar la tarea
+	err := scheduler.store.Jobs().Finish(ctx, job, scheduler.instanceID)
+	if err == repository.ErrNotFound {
+		log.Printf("La reserva de la tarea %s expiró antes de terminarla", job.ID.Hex())
+	} else if err != nil {
+		log.Printf("Error al actualizar la tarea %s: %v", job.ID.Hex(), err)
+	}
+}
+
+/** Cancela los recordatorios pendientes de una cita
+ *
+ * @param ctx context.Context "El contexto de la operación"
+ * @param appointmentID primitive.ObjectID "El id de la cita"
+ * @return err error "El error de la operación"
+ */
+func (scheduler *Scheduler) cancelReminders(ctx context.Context, appointmentID primitive.ObjectID) (err error) {
+	types := []string{models.JobTypeAppointmentReminder24h, models.JobTypeAppointmentReminder1h}
+	return scheduler.store.Jobs().CancelPending(ctx, appointmentID, types, time.Now())
+}
+
+/** Genera el aviso de una tarea a partir de su plantilla, en el idioma del destinatario
+ *
+ * @param job *models.Job "La tarea"
+ * @param appointment models.Appointment "La cita"
+ * @param user models.User "El destinatario"
+ * @return utils.RenderedEmail "El asunto y los cuerpos del aviso"
+ * @return error "El error al generar el aviso"
+ */
+func appointmentMessage(job *models.Job, appointment models.Appointment, user models.User) (utils.RenderedEmail, error) {
+	date := appointment.Date.Local()
+
+	return utils.RenderEmail(job.Type, user.Language, utils.EmailTemplateData{
+		RecipientName:  user.FirstName,
+		Date:           date.Format("02/01/2006"),
+		Time:           date.Format("15:04"),
+		Address:        appointment.Address,
+		PreviousHelper: user.ID == job.PreviousHelper,
+	})
+}
+
+func newJob(jobType string, appointmentID primitive.ObjectID, runAt time.Time) models.Job {
+	return models.Job{
+		Type:          jobType,
+		AppointmentID: appointmentID,
+		Status:        models.JobStatusPending,
+		RunAt:         runAt,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+}
+
+func isReminder(jobType string) bool {
+	return jobType == models.JobTypeAppointmentReminder24h || jobType == models.JobTypeAppointmentReminder1h
+}
+
+// Los avisos urgentes se envían aunque el usuario esté en horario de silencio
+func isUrgent(jobType string) bool {
+	return jobType == models.JobTypeAppointmentReminder1h || jobType == models.JobTypeAppointmentCancelled
+}
+
+func jobBackoff(attempts int) time.Duration {
+	backoff := time.Minute << uint(attempts-1)
+	if backoff <= 0 || backoff > jobMaxBackoff {
+		return jobMaxBackoff
+	}
+
+	return backoff
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "instance"
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return hostname
+	}
+
+	return hostname + "-" + hex.EncodeToString(suffix)
+}
+
+func NewScheduler(store repository.IStore, notifier notifications.IDispatcher, config utils.Config) IScheduler {
+	return &Scheduler{
+		store:      store,
+		notifier:   notifier,
+		config:     config,
+		instanceID: newInstanceID(),
+	}
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/notifications"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Registra los avisos enviados y falla los de los correos indicados
+type fakeNotifier struct {
+	sent   []string
+	urgent []bool
+	fail   map[string]bool
+}
+
+func (notifier *fakeNotifier) Notify(ctx context.Context, user models.User, notification notifications.Notification) (result notifications.DeliveryResult, err error) {
+	if notifier.fail[user.Email] {
+		return result, errors.New("canal no disponible")
+	}
+
+	notifier.sent = append(notifier.sent, user.Email)
+	notifier.urgent = append(notifier.urgent, notification.Urgent)
+	return
+}
+
+// Crea un programador sobre un almacenamiento en memoria con una cita de un solicitante y un ayudante
+func newTestScheduler(t *testing.T, date time.Time, status string) (*Scheduler, *repository.MemoryStore, *fakeNotifier, models.Appointment) {
+	t.Helper()
+
+	store := repository.NewMemoryStore()
+	notifier := &fakeNotifier{fail: map[string]bool{}}
+	scheduler := NewScheduler(store, notifier, utils.Config{}).(*Scheduler)
+
+	var ids []primitive.ObjectID
+	for _, email := range []string{"luis@ayudapp.test", "ana@ayudapp.test", "eva@ayudapp.test"} {
+		user := models.User{FirstName: "Usuario", Email: email}
+		if err := store.Users().Insert(context.Background(), &user); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, user.ID)
+	}
+
+	appointment := models.Appointment{Date: date, Address: "Av. 18 de Julio 1234", Status: status, CreatedBy: ids[0], Helper: ids[1]}
+	if err := store.Appointments().Insert(context.Background(), &appointment); err != nil {
+		t.Fatal(err)
+	}
+
+	return scheduler, store, notifier, appointment
+}
+
+// Devuelve los tipos y estados de las tareas de una cita, en orden de ejecución
+func jobStates(t *testing.T, store repository.IStore, appointmentID primitive.ObjectID) (states []string) {
+	t.Helper()
+
+	jobs, err := store.Jobs().Find(context.Background(), appointmentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, job := range jobs {
+		states = append(states, job.Type+" "+job.Status)
+	}
+
+	return
+}
+
+func TestJobBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{6, 32 * time.Minute},
+		{7, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, test := range tests {
+		if got := jobBackoff(test.attempts); got != test.want {
+			t.Errorf("jobBackoff(%d) = %v, want %v", test.attempts, got, test.want)
+		}
+	}
+}
+
+func TestScheduleAppointmentReminders(t *testing.T) {
+	tests := []struct {
+		name  string
+		until time.Duration
+		want  []string
+	}{
+		{"faltan dos días", 48 * time.Hour, []string{"appointment_reminder_24h pending", "appointment_reminder_1h pending"}},
+		{"faltan dos horas", 2 * time.Hour, []string{"appointment_reminder_1h pending"}},
+		{"falta media hora", 30 * time.Minute, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler, store, _, appointment := newTestScheduler(t, time.Now().Add(test.until), models.AppointmentStatusPending)
+
+			if err := scheduler.ScheduleAppointmentReminders(context.Background(), appointment); err != nil {
+				t.Fatalf("ScheduleAppointmentReminders() error = %v", err)
+			}
+
+			if got := jobStates(t, store, appointment.ID); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("tareas = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNotifyAppointmentChange(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(appointment *models.Appointment)
+		want   []string
+	}{
+		{
+			name:   "confirmación",
+			change: func(appointment *models.Appointment) { appointment.Status = models.AppointmentStatusConfirmed },
+			want:   []string{"appointment_confirmed pending", "appointment_reminder_24h pending", "appointment_reminder_1h pending"},
+		},
+		{
+			name:   "cancelación",
+			change: func(appointment *models.Appointment) { appointment.Status = models.AppointmentStatusCancelled },
+			want:   []string{"appointment_cancelled pending", "appointment_reminder_24h cancelled", "appointment_reminder_1h cancelled"},
+		},
+		{
+			name:   "cambio de ayudante",
+			change: func(appointment *models.Appointment) { appointment.Helper = primitive.NewObjectID() },
+			want:   []string{"appointment_reassigned pending", "appointment_reminder_24h pending", "appointment_reminder_1h pending"},
+		},
+		{
+			name:   "cambio de fecha",
+			change: func(appointment *models.Appointment) { appointment.Date = appointment.Date.Add(-46 * time.Hour) },
+			want:   []string{"appointment_reminder_1h pending", "appointment_reminder_24h cancelled", "appointment_reminder_1h cancelled"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler, store, _, previous := newTestScheduler(t, time.Now().Add(48*time.Hour), models.AppointmentStatusPending)
+			if err := scheduler.ScheduleAppointmentReminders(context.Background(), previous); err != nil {
+				t.Fatal(err)
+			}
+
+			current := previous
+			test.change(&current)
+			if err := scheduler.NotifyAppointmentChange(context.Background(), previous, current); err != nil {
+				t.Fatalf("NotifyAppointmentChange() error = %v", err)
+			}
+
+			if got := jobStates(t, store, previous.ID); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("tareas = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRunDueJobs(t *testing.T) {
+	tests := []struct {
+		name     string
+		date     time.Duration
+		status   string
+		jobType  string
+		previous bool
+		want     []string
+		urgent   bool
+	}{
+		{"confirmación", 48 * time.Hour, models.AppointmentStatusConfirmed, models.JobTypeAppointmentConfirmed, false, []string{"luis@ayudapp.test", "ana@ayudapp.test"}, false},
+		{"cancelación urgente", 48 * time.Hour, models.AppointmentStatusCancelled, models.JobTypeAppointmentCancelled, false, []string{"luis@ayudapp.test", "ana@ayudapp.test"}, true},
+		{"cambio de ayudante avisa al anterior", 48 * time.Hour, models.AppointmentStatusConfirmed, models.JobTypeAppointmentReassigned, true, []string{"luis@ayudapp.test", "ana@ayudapp.test", "eva@ayudapp.test"}, false},
+		{"recordatorio", 30 * time.Minute, models.AppointmentStatusConfirmed, models.JobTypeAppointmentReminder1h, false, []string{"luis@ayudapp.test", "ana@ayudapp.test"}, true},
+		{"recordatorio de una cita cancelada", 30 * time.Minute, models.AppointmentStatusCancelled, models.JobTypeAppointmentReminder1h, false, nil, false},
+		{"recordatorio de una cita pasada", -time.Hour, models.AppointmentStatusConfirmed, models.JobTypeAppointmentReminder24h, false, nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler, store, notifier, appointment := newTestScheduler(t, time.Now().Add(test.date), test.status)
+
+			job := newJob(test.jobType, appointment.ID, time.Now())
+			if test.previous {
+				previous, err := store.Users().FindByEmail(context.Background(), "eva@ayudapp.test")
+				if err != nil {
+					t.Fatal(err)
+				}
+				job.PreviousHelper = previous.ID
+			}
+			if err := store.Jobs().Insert(context.Background(), &job); err != nil {
+				t.Fatal(err)
+			}
+
+			scheduler.runDueJobs(context.Background())
+
+			if !reflect.DeepEqual(notifier.sent, test.want) {
+				t.Errorf("avisos a %q, want %q", notifier.sent, test.want)
+			}
+			for _, urgent := range notifier.urgent {
+				if urgent != test.urgent {
+					t.Errorf("urgente = %t, want %t", urgent, test.urgent)
+				}
+			}
+
+			jobs, err := store.Jobs().Find(context.Background(), appointment.ID)
+			if err != nil || len(jobs) != 1 {
+				t.Fatalf("Find() = %+v, %v", jobs, err)
+			}
+			if jobs[0].Status != models.JobStatusDone || jobs[0].Attempts != 1 || jobs[0].LockedBy != "" {
+				t.Errorf("tarea = %+v, want terminada en un intento y sin reserva", jobs[0])
+			}
+		})
+	}
+}
+
+func TestRunDueJobsRetry(t *testing.T) {
+	ctx := context.Background()
+	scheduler, store, notifier, appointment := newTestScheduler(t, time.Now().Add(48*time.Hour), models.AppointmentStatusConfirmed)
+	notifier.fail["ana@ayudapp.test"] = true
+
+	job := newJob(models.JobTypeAppointmentConfirmed, appointment.ID, time.Now())
+	if err := store.Jobs().Insert(ctx, &job); err != nil {
+		t.Fatal(err)
+	}
+
+	// Las fechas se guardan con precisión de milisegundos
+	start := time.Now().Truncate(time.Millisecond)
+	scheduler.runDueJobs(ctx)
+
+	jobs, err := store.Jobs().Find(ctx, appointment.ID)
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("Find() = %+v, %v", jobs, err)
+	}
+	job = jobs[0]
+	if job.Status != models.JobStatusPending || job.Attempts != 1 || job.LastError == "" {
+		t.Fatalf("tarea después del error = %+v, want pendiente con un intento", job)
+	}
+	if !reflect.DeepEqual(job.SentTo, []string{"luis@ayudapp.test"}) {
+		t.Errorf("SentTo = %q, want sólo el aviso enviado", job.SentTo)
+	}
+	if job.RunAt.Before(start.Add(jobBackoff(1))) {
+		t.Errorf("RunAt = %v, want al menos %v después de %v", job.RunAt, jobBackoff(1), start)
+	}
+
+	// La tarea no se reintenta antes de tiempo
+	scheduler.runDueJobs(ctx)
+	if jobs, _ = store.Jobs().Find(ctx, appointment.ID); jobs[0].Attempts != 1 {
+		t.Errorf("la tarea se reintentó antes de tiempo: %+v", jobs[0])
+	}
+
+	// El reintento no repite el aviso ya enviado
+	notifier.fail = map[string]bool{}
+	if err = scheduler.runJob(ctx, &job); err != nil {
+		t.Fatalf("runJob() error = %v", err)
+	}
+	if want := []string{"luis@ayudapp.test", "ana@ayudapp.test"}; !reflect.DeepEqual(notifier.sent, want) {
+		t.Errorf("avisos a %q, want %q", notifier.sent, want)
+	}
+}
+
+func TestRunDueJobsLastAttempt(t *testing.T) {
+	ctx := context.Background()
+	scheduler, store, notifier, appointment := newTestScheduler(t, time.Now().Add(48*time.Hour), models.AppointmentStatusConfirmed)
+	notifier.fail["luis@ayudapp.test"] = true
+
+	job := newJob(models.JobTypeAppointmentConfirmed, appointment.ID, time.Now())
+	job.Attempts = jobMaxAttempts - 1
+	if err := store.Jobs().Insert(ctx, &job); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduler.runDueJobs(ctx)
+
+	if got := jobStates(t, store, appointment.ID); !reflect.DeepEqual(got, []string{"appointment_confirmed failed"}) {
+		t.Errorf("tareas = %q, want la tarea fallida", got)
+	}
+}
+
+func TestRunDueJobsLease(t *testing.T) {
+	tests := []struct {
+		name        string
+		lockedUntil time.Duration
+		want        int
+	}{
+		{"reservada por otra instancia", time.Minute, 0},
+		{"reserva vencida", -time.Minute, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			scheduler, store, notifier, appointment := newTestScheduler(t, time.Now().Add(48*time.Hour), models.AppointmentStatusConfirmed)
+
+			lockedUntil := time.Now().Add(test.lockedUntil)
+			job := newJob(models.JobTypeAppointmentConfirmed, appointment.ID, time.Now())
+			job.Status = models.JobStatusRunning
+			job.LockedBy = "otra-instancia"
+			job.LockedUntil = &lockedUntil
+			job.Attempts = 1
+			if err := store.Jobs().Insert(ctx, &job); err != nil {
+				t.Fatal(err)
+			}
+
+			scheduler.runDueJobs(ctx)
+
+			if len(notifier.sent) != test.want {
+				t.Errorf("avisos a %q, want %d", notifier.sent, test.want)
+			}
+		})
+	}
+}
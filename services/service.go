@@ -2,4 +2,6 @@ package services
 
 import "context"
 
+// Contexto de las operaciones en segundo plano y de los servicios que todavía no
+// reciben el contexto de la solicitud
 var ctx = context.Background()
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// Cantidad de días de las estadísticas si no se indica el rango
+	statsDefaultDays = 30
+	// Cantidad máxima de días de las estadísticas
+	statsMaxDays = 366
+	// Cantidad de categorías en el ranking de las más pedidas
+	statsTopCategories = 10
+)
+
+// Rango de fechas de las estadísticas; ambas fechas están incluidas
+type StatsRequest struct {
+	From time.Time `form:"from" json:"from" time_format:"2006-01-02"`
+	To   time.Time `form:"to" json:"to" time_format:"2006-01-02"`
+}
+
+type StatsCount struct {
+	Key   string `bson:"_id" json:"key"`
+	Count int    `bson:"count" json:"count"`
+}
+
+type WeeklySignups struct {
+	Week  string `json:"week"`
+	Count int    `json:"count"`
+}
+
+type DailyAppointments struct {
+	Date     string         `json:"date"`
+	Total    int            `json:"total"`
+	ByStatus map[string]int `json:"by_status"`
+}
+
+type CategoryStats struct {
+	ID    primitive.ObjectID `bson:"_id" json:"id"`
+	Name  string             `bson:"name" json:"name"`
+	Slug  string             `bson:"slug" json:"slug"`
+	Count int                `bson:"count" json:"count"`
+}
+
+type UserStats struct {
+	Total          int             `json:"total"`
+	ByType         []StatsCount    `json:"by_type"`
+	ByStatus       []StatsCount    `json:"by_status"`
+	SignupsPerWeek []WeeklySignups `json:"signups_per_week"`
+}
+
+type AppointmentStats struct {
+	Total                  int                 `json:"total"`
+	ByStatus               []StatsCount        `json:"by_status"`
+	PerDay                 []DailyAppointments `json:"per_day"`
+	CompletionRate         float64             `json:"completion_rate"`
+	CancellationRate       float64             `json:"cancellation_rate"`
+	AverageDurationMinutes float64             `json:"average_duration_minutes"`
+	TopCategories          []CategoryStats     `json:"top_categories"`
+}
+
+type GetStatsResponse struct {
+	From         string           `json:"from"`
+	To           string           `json:"to"`
+	Users        UserStats        `json:"users"`
+	Appointments AppointmentStats `json:"appointments"`
+	GeneratedAt  time.Time        `json:"generated_at"`
+}
+
+type IStatsService interface {
+	GetStats(req StatsRequest) (response GetStatsResponse, err error)
+}
+
+type StatsService struct {
+	store    repository.IStore
+	cacheTTL time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]statsCacheEntry
+}
+
+type statsCacheEntry struct {
+	response  GetStatsResponse
+	expiresAt time.Time
+}
+
+/** Obtiene las estadísticas del panel de administración
+ *
+ * Los totales de usuarios por tipo y estado son del momento de la consulta; las
+ * altas por semana y las estadísticas de citas corresponden al rango de fechas, por
+ * defecto los últimos 30 días. Las tasas de finalización y cancelación se calculan
+ * sobre todas las citas del rango. Los resultados se guardan en memoria por un
+ * tiempo corto para no repetir las agregaciones en cada carga del panel.
+ *
+ * @param req StatsRequest "El rango de fechas"
+ * @return response GetStatsResponse "Las estadísticas"
+ * @return err error "Error si el rango es inválido"
+ */
+func (service *StatsService) GetStats(req StatsRequest) (response GetStatsResponse, err error) {
+	from, to, err := service.statsRange(req)
+	if err != nil {
+		return
+	}
+
+	key := from.Format("2006-01-02") + "/" + to.Format("2006-01-02")
+	if cached, ok := service.cached(key); ok {
+		return cached, nil
+	}
+
+	// El final del rango es exclusivo para incluir todo el último día
+	end := to.AddDate(0, 0, 1)
+
+	response = GetStatsResponse{
+		From:        from.Format("2006-01-02"),
+		To:          to.Format("2006-01-02"),
+		GeneratedAt: service.now(),
+	}
+
+	if response.Users, err = service.userStats(from, end); err != nil {
+		return
+	}
+	if response.Appointments, err = service.appointmentStats(from, end); err != nil {
+		return
+	}
+
+	service.save(key, response)
+	return
+}
+
+/** Valida el rango de fechas y completa los valores por defecto
+ *
+ * @param req StatsRequest "El rango pedido"
+ * @return from time.Time "El primer día del rango"
+ * @return to time.Time "El último día del rango"
+ * @return err error "Error si el rango es inválido"
+ */
+func (service *StatsService) statsRange(req StatsRequest) (from time.Time, to time.Time, err error) {
+	to = req.To
+	if to.IsZero() {
+		now := service.now().UTC()
+		to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	from = req.From
+	if from.IsZero() {
+		from = to.AddDate(0, 0, 1-statsDefaultDays)
+	}
+
+	if from.After(to) {
+		err = NewValidationError("invalid_date_range", "la fecha inicial no puede ser posterior a la final")
+		return
+	}
+
+	if to.Sub(from) >= statsMaxDays*24*time.Hour {
+		err = NewValidationError("date_range_too_long", "el rango no puede superar %d días", statsMaxDays)
+	}
+
+	return
+}
+
+/** Calcula las estadísticas de usuarios
+ *
+ * @param from time.Time "El inicio del rango"
+ * @param end time.Time "El final del rango, exclusivo"
+ * @return stats UserStats "Las estadísticas de usuarios"
+ * @return err error "El error de la operación"
+ */
+func (service *StatsService) userStats(from time.Time, end time.Time) (stats UserStats, err error) {
+	summary, err := service.store.Users().Summarize(ctx, from, end)
+	if err != nil {
+		return
+	}
+
+	stats.ByType = statsCounts(summary.ByType)
+	stats.ByStatus = statsCounts(summary.ByStatus)
+	for _, count := range stats.ByType {
+		stats.Total += count.Count
+	}
+
+	stats.SignupsPerWeek = make([]WeeklySignups, len(summary.SignupsPerWeek))
+	for i, signups := range summary.SignupsPerWeek {
+		stats.SignupsPerWeek[i] = WeeklySignups{
+			Week:  fmt.Sprintf("%d-W%02d", signups.Year, signups.Week),
+			Count: signups.Count,
+		}
+	}
+
+	return
+}
+
+/** Calcula las estadísticas de las citas del rango
+ *
+ * @param from time.Time "El inicio del rango"
+ * @param end time.Time "El final del rango, exclusivo"
+ * @return stats AppointmentStats "Las estadísticas de citas"
+ * @return err error "El error de la operación"
+ */
+func (service *StatsService) appointmentStats(from time.Time, end time.Time) (stats AppointmentStats, err error) {
+	summary, err := service.store.Appointments().Summarize(ctx, from, end, statsTopCategories)
+	if err != nil {
+		return
+	}
+
+	stats.ByStatus = statsCounts(summary.ByStatus)
+
+	var completed, cancelled int
+	for _, count := range stats.ByStatus {
+		stats.Total += count.Count
+		switch count.Key {
+		case models.AppointmentStatusCompleted:
+			completed = count.Count
+		case models.AppointmentStatusCancelled:
+			cancelled = count.Count
+		}
+	}
+	if stats.Total > 0 {
+		stats.CompletionRate = float64(completed) / float64(stats.Total)
+		stats.CancellationRate = float64(cancelled) / float64(stats.Total)
+	}
+
+	stats.AverageDurationMinutes = float64(summary.AverageDuration) / float64(time.Minute)
+
+	stats.PerDay = []DailyAppointments{}
+	for _, count := range summary.PerDay {
+		last := len(stats.PerDay) - 1
+		if last < 0 || stats.PerDay[last].Date != count.Date {
+			stats.PerDay = append(stats.PerDay, DailyAppointments{Date: count.Date, ByStatus: map[string]int{}})
+			last++
+		}
+
+		stats.PerDay[last].Total += count.Count
+		stats.PerDay[last].ByStatus[count.Status] = count.Count
+	}
+
+	// Una categoría eliminada se muestra sin nombre
+	for _, top := range summary.TopCategories {
+		category, err := service.store.Categories().FindByID(ctx, top.ID)
+		if err != nil && err != repository.ErrNotFound {
+			return stats, err
+		}
+
+		stats.TopCategories = append(stats.TopCategories, CategoryStats{ID: top.ID, Name: category.Name, Slug: category.Slug, Count: top.Count})
+	}
+
+	return
+}
+
+// Convierte los conteos del repositorio en los de la respuesta
+func statsCounts(counts []repository.Count) []StatsCount {
+	result := make([]StatsCount, len(counts))
+	for i, count := range counts {
+		result[i] = StatsCount(count)
+	}
+	return result
+}
+
+/** Obtiene las estadísticas guardadas de un rango si no expiraron
+ *
+ * @param key string "El rango de fechas"
+ * @return response GetStatsResponse "Las estadísticas guardadas"
+ * @return ok bool "Si había estadísticas vigentes"
+ */
+func (service *StatsService) cached(key string) (response GetStatsResponse, ok bool) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	entry, ok := service.cache[key]
+	if !ok || !service.now().Before(entry.expiresAt) {
+		return response, false
+	}
+
+	return entry.response, true
+}
+
+/** Guarda las estadísticas de un rango y descarta las expiradas
+ *
+ * @param key string "El rango de fechas"
+ * @param response GetStatsResponse "Las estadísticas"
+ */
+func (service *StatsService) save(key string, response GetStatsResponse) {
+	if service.cacheTTL <= 0 {
+		return
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	now := service.now()
+	for cachedKey, entry := range service.cache {
+		if !now.Before(entry.expiresAt) {
+			delete(service.cache, cachedKey)
+		}
+	}
+
+	service.cache[key] = statsCacheEntry{response: response, expiresAt: now.Add(service.cacheTTL)}
+}
+
+func NewStatsService(store repository.IStore, cacheTTL time.Duration) IStatsService {
+	return &StatsService{store: store, cacheTTL: cacheTTL, now: time.Now, cache: map[string]statsCacheEntry{}}
+}
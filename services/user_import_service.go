@@ -0,0 +1,553 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// Cantidad máxima de filas de un archivo de importación
+	userImportMaxRows = 5000
+	// Cada cuántas filas se guarda el progreso de una importación
+	userImportProgressEvery = 25
+	// Tiempo sin guardar progreso tras el cual una importación se considera interrumpida
+	userImportStaleAfter = 10 * time.Minute
+)
+
+// Campos que se pueden importar; por defecto se buscan columnas con el mismo nombre
+var userImportFields = []string{"first_name", "last_name", "email", "password", "type", "status", "phone", "language", "skills"}
+
+// Tipos de usuario que se pueden importar; los administradores sólo se crean a mano
+var importableUserTypes = map[string]bool{
+	models.UserTypeUser:   true,
+	models.UserTypeHelper: true,
+}
+
+var userStatuses = map[string]bool{
+	models.UserStatusActive:   true,
+	models.UserStatusInactive: true,
+	models.UserStatusPending:  true,
+}
+
+type UserImportRequest struct {
+	FileName string
+	Data     []byte
+	// Encabezado del archivo para cada campo, por ejemplo {"first_name": "Nombre"}
+	Mapping   map[string]string
+	CreatedBy string
+}
+
+type UserImportRowReport struct {
+	Row    int                     `json:"row"`
+	Email  string                  `json:"email"`
+	Valid  bool                    `json:"valid"`
+	Errors []models.ImportRowError `json:"errors,omitempty"`
+}
+
+type UserImportReport struct {
+	Total   int                   `json:"total"`
+	Valid   int                   `json:"valid"`
+	Invalid int                   `json:"invalid"`
+	Columns map[string]string     `json:"columns"`
+	Rows    []UserImportRowReport `json:"rows"`
+}
+
+type StartUserImportResponse struct {
+	ImportID string `json:"import_id"`
+}
+
+type GetUserImportResponse struct {
+	Import models.UserImport `json:"import"`
+}
+
+type IUserImportService interface {
+	PreviewImport(req UserImportRequest) (response UserImportReport, err error)
+	StartImport(req UserImportRequest) (response StartUserImportResponse, err error)
+	GetImport(id string) (response GetUserImportResponse, err error)
+
+	Start(ctx context.Context)
+}
+
+type UserImportService struct {
+	store       repository.IStore
+	userService IUserService
+	config      utils.Config
+}
+
+// Fila del archivo convertida en los datos de un usuario
+type userImportRow struct {
+	Row     int
+	Request CreateUserRequest
+	Skills  []string
+}
+
+/** Valida un archivo de importación sin crear usuarios
+ *
+ * El informe incluye cada fila con sus errores: campos requeridos, correos inválidos
+ * o repetidos en el archivo o en la base de datos, tipos, estados, idiomas y
+ * habilidades inválidos.
+ *
+ * @param req UserImportRequest "El archivo y el mapeo de columnas"
+ * @return response UserImportReport "El informe de validación"
+ * @return err error "Error si el archivo no se puede leer"
+ */
+func (service *UserImportService) PreviewImport(req UserImportRequest) (response UserImportReport, err error) {
+	rows, columns, err := parseUserImport(req)
+	if err != nil {
+		return
+	}
+
+	reports, err := service.validateRows(rows)
+	if err != nil {
+		return
+	}
+
+	response = UserImportReport{Total: len(rows), Columns: columns, Rows: reports}
+	for _, report := range reports {
+		if report.Valid {
+			response.Valid++
+		} else {
+			response.Invalid++
+		}
+	}
+
+	return
+}
+
+/** Inicia la importación de un archivo en segundo plano
+ *
+ * Las filas válidas se crean con la misma lógica que el alta de usuarios y las
+ * inválidas se registran como errores de la importación.
+ *
+ * @param req UserImportRequest "El archivo y el mapeo de columnas"
+ * @return response StartUserImportResponse "El id de la importación para consultar su progreso"
+ * @return err error "Error si el archivo no se puede leer"
+ */
+func (service *UserImportService) StartImport(req UserImportRequest) (response StartUserImportResponse, err error) {
+	rows, _, err := parseUserImport(req)
+	if err != nil {
+		return
+	}
+
+	reports, err := service.validateRows(rows)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	job := models.UserImport{
+		FileName:  req.FileName,
+		Status:    models.UserImportStatusRunning,
+		Total:     len(rows),
+		CreatedBy: req.CreatedBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err = service.store.UserImports().Insert(ctx, &job); err != nil {
+		return
+	}
+
+	go service.runImport(context.Background(), job, rows, reports)
+
+	response.ImportID = job.ID.Hex()
+	return
+}
+
+/** Obtiene el progreso y los errores de una importación
+ *
+ * @param importId string "El id de la importación"
+ * @return response GetUserImportResponse "La importación"
+ * @return err error "El error de la operación"
+ */
+func (service *UserImportService) GetImport(importId string) (response GetUserImportResponse, err error) {
+	id, err := parseID(importId)
+	if err != nil {
+		return
+	}
+
+	response.Import, err = service.store.UserImports().FindByID(ctx, id)
+	if err == repository.ErrNotFound {
+		err = ErrImportNotFound
+	}
+
+	return
+}
+
+/** Crea los usuarios de las filas válidas y guarda el progreso periódicamente
+ *
+ * @param ctx context.Context "El contexto de la importación"
+ * @param job models.UserImport "La importación"
+ * @param rows []userImportRow "Las filas del archivo"
+ * @param reports []UserImportRowReport "El resultado de la validación de cada fila"
+ */
+func (service *UserImportService) runImport(ctx context.Context, job models.UserImport, rows []userImportRow, reports []UserImportRowReport) {
+	// Un pánico no debe detener el servidor ni dejar la importación en curso para siempre
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Error inesperado en la importación %s: %v", job.ID.Hex(), r)
+
+			finishedAt := time.Now()
+			job.Status = models.UserImportStatusFailed
+			job.FinishedAt = &finishedAt
+			job.Errors = append(job.Errors, models.ImportRowError{Message: "la importación se interrumpió por un error interno"})
+			service.saveImport(ctx, job)
+		}
+	}()
+
+	for i, row := range rows {
+		if !reports[i].Valid {
+			job.Failed++
+			job.Errors = append(job.Errors, reports[i].Errors...)
+		} else if _, err := service.userService.CreateUser(ctx, row.Request); err != nil {
+			job.Failed++
+			job.Errors = append(job.Errors, models.ImportRowError{Row: row.Row, Message: err.Error()})
+		} else {
+			job.Created++
+		}
+
+		job.Processed++
+		if job.Processed%userImportProgressEvery == 0 {
+			service.saveImport(ctx, job)
+		}
+	}
+
+	finishedAt := time.Now()
+	job.Status = models.UserImportStatusDone
+	job.FinishedAt = &finishedAt
+	service.saveImport(ctx, job)
+}
+
+/** Marca periódicamente como fallidas las importaciones interrumpidas hasta que se cancele el contexto
+ *
+ * Una importación queda en curso si el servidor se detiene mientras se ejecuta. Como
+ * el progreso se guarda cada pocas filas, las que no se actualizan durante
+ * userImportStaleAfter se consideran interrumpidas, aunque las haya iniciado otra instancia.
+ *
+ * @param ctx context.Context "El contexto que detiene la revisión"
+ */
+func (service *UserImportService) Start(ctx context.Context) {
+	interval := service.config.SchedulerPollInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := service.failStaleImports(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				log.Printf("Error al revisar las importaciones interrumpidas: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+/** Marca como fallidas las importaciones en curso que no se actualizan desde hace userImportStaleAfter
+ *
+ * @param ctx context.Context "El contexto de la consulta"
+ * @param now time.Time "La hora actual"
+ * @return err error "El error de la actualización"
+ */
+func (service *UserImportService) failStaleImports(ctx context.Context, now time.Time) (err error) {
+	failure := models.ImportRowError{Message: "la importación se interrumpió antes de terminar"}
+	failed, err := service.store.UserImports().FailStale(ctx, now.Add(-userImportStaleAfter), failure)
+	if err == nil && failed > 0 {
+		log.Printf("Se marcaron %d importaciones interrumpidas como fallidas", failed)
+	}
+
+	return
+}
+
+func (service *UserImportService) saveImport(ctx context.Context, job models.UserImport) {
+	job.UpdatedAt = time.Now()
+
+	// Una importación marcada como interrumpida no vuelve a quedar en curso
+	err := service.store.UserImports().SaveProgress(ctx, job)
+	if err == repository.ErrNotFound {
+		log.Printf("La importación %s se marcó como interrumpida antes de terminar", job.ID.Hex())
+	} else if err != nil {
+		log.Printf("Error al guardar el progreso de la importación %s: %v", job.ID.Hex(), err)
+	}
+}
+
+/** Valida las filas de un archivo de importación
+ *
+ * Las habilidades se pueden indicar por id o por slug de la categoría y se
+ * reemplazan por sus ids en las filas válidas.
+ *
+ * @param rows []userImportRow "Las filas del archivo"
+ * @return reports []UserImportRowReport "El resultado de cada fila"
+ * @return err error "El error de la consulta a la base de datos"
+ */
+func (service *UserImportService) validateRows(rows []userImportRow) (reports []UserImportRowReport, err error) {
+	existingEmails, err := service.existingEmails(rows)
+	if err != nil {
+		return
+	}
+
+	skills, err := service.resolveSkills(rows)
+	if err != nil {
+		return
+	}
+
+	seen := map[string]int{}
+	for i := range rows {
+		row := &rows[i]
+		user := &row.Request
+		report := UserImportRowReport{Row: row.Row, Email: user.Email}
+		addError := func(field string, message string) {
+			report.Errors = append(report.Errors, models.ImportRowError{Row: row.Row, Field: field, Message: message})
+		}
+
+		if user.FirstName == "" {
+			addError("first_name", "el nombre es requerido")
+		}
+
+		email := strings.ToLower(user.Email)
+		switch {
+		case user.Email == "":
+			addError("email", "el correo electrónico es requerido")
+		case !isPlainEmail(user.Email):
+			addError("email", "el correo electrónico es inválido")
+		case seen[email] > 0:
+			addError("email", fmt.Sprintf("el correo electrónico está repetido en la fila %d", seen[email]))
+		case existingEmails[email]:
+			addError("email", "el correo electrónico ya está ingresado en la base de datos")
+		}
+		if email != "" && seen[email] == 0 {
+			seen[email] = row.Row
+		}
+
+		switch length := utf8.RuneCountInString(user.Password); {
+		case length == 0:
+			addError("password", "la contraseña es requerida")
+		case length < 6 || length > 72:
+			addError("password", "la contraseña debe tener entre 6 y 72 caracteres")
+		}
+
+		if !importableUserTypes[user.Type] {
+			addError("type", fmt.Sprintf("el tipo %q es inválido", user.Type))
+		}
+		if !userStatuses[user.Status] {
+			addError("status", fmt.Sprintf("el estado %q es inválido", user.Status))
+		}
+		if user.Language != "" && !utils.IsSupportedLanguage(user.Language) {
+			addError("language", fmt.Sprintf("el idioma %q no está disponible", user.Language))
+		}
+
+		user.Skills = nil
+		for _, skill := range row.Skills {
+			id, ok := skills[skill]
+			if !ok {
+				addError("skills", fmt.Sprintf("la habilidad %q no existe", skill))
+				continue
+			}
+			user.Skills = append(user.Skills, id.Hex())
+		}
+
+		report.Valid = len(report.Errors) == 0
+		reports = append(reports, report)
+	}
+
+	return
+}
+
+/** Busca cuáles de los correos del archivo ya están registrados, sin distinguir mayúsculas
+ *
+ * @param rows []userImportRow "Las filas del archivo"
+ * @return emails map[string]bool "Los correos registrados, en minúsculas"
+ * @return err error "El error de la consulta"
+ */
+func (service *UserImportService) existingEmails(rows []userImportRow) (emails map[string]bool, err error) {
+	emails = map[string]bool{}
+
+	var candidates []string
+	for _, row := range rows {
+		if row.Request.Email != "" {
+			candidates = append(candidates, row.Request.Email)
+		}
+	}
+
+	users, err := service.store.Users().FindByEmails(ctx, candidates)
+	if err != nil {
+		return
+	}
+
+	for _, user := range users {
+		emails[strings.ToLower(user.Email)] = true
+	}
+
+	return
+}
+
+/** Resuelve las habilidades del archivo, indicadas por id o slug, a ids de categorías existentes
+ *
+ * Como en el resto de la API, los ids de categorías fusionadas y los slugs anteriores
+ * se resuelven a la categoría actual.
+ *
+ * @param rows []userImportRow "Las filas del archivo"
+ * @return skills map[string]primitive.ObjectID "El id de cada habilidad encontrada"
+ * @return err error "El error de la consulta"
+ */
+func (service *UserImportService) resolveSkills(rows []userImportRow) (skills map[string]primitive.ObjectID, err error) {
+	skills = map[string]primitive.ObjectID{}
+
+	resolved := map[string]bool{}
+	for _, row := range rows {
+		for _, skill := range row.Skills {
+			if resolved[skill] {
+				continue
+			}
+			resolved[skill] = true
+
+			category, err := service.store.Categories().Resolve(ctx, skill)
+			if err == repository.ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			skills[skill] = category.ID
+		}
+	}
+
+	return
+}
+
+/** Lee un archivo de importación y convierte cada fila en los datos de un usuario
+ *
+ * @param req UserImportRequest "El archivo y el mapeo de columnas"
+ * @return rows []userImportRow "Las filas no vacías del archivo"
+ * @return columns map[string]string "El encabezado usado para cada campo"
+ * @return err error "Error si el archivo o el mapeo son inválidos"
+ */
+func parseUserImport(req UserImportRequest) (rows []userImportRow, columns map[string]string, err error) {
+	table, err := utils.ReadSpreadsheet(req.Data)
+	if err != nil {
+		err = NewValidationError("invalid_import_file", "%v", err)
+		return
+	}
+
+	if len(table) == 0 {
+		err = NewValidationError("empty_import_file", "el archivo está vacío")
+		return
+	}
+
+	if len(table)-1 > userImportMaxRows {
+		err = NewValidationError("too_many_import_rows", "el archivo no puede tener más de %d filas", userImportMaxRows)
+		return
+	}
+
+	for field := range req.Mapping {
+		if !containsString(userImportFields, field) {
+			err = NewValidationError("invalid_import_field", "el campo %s no se puede importar", field)
+			return
+		}
+	}
+
+	headers := map[string]int{}
+	for i, header := range table[0] {
+		headers[utils.NormalizeHeader(header)] = i
+	}
+
+	columns = map[string]string{}
+	indexes := map[string]int{}
+	for _, field := range userImportFields {
+		header, mapped := req.Mapping[field]
+		if !mapped {
+			header = field
+		}
+
+		index, found := headers[utils.NormalizeHeader(header)]
+		if !found {
+			if mapped {
+				err = NewValidationError("import_column_not_found", "no se encontró la columna %q para el campo %s", header, field)
+				return
+			}
+			continue
+		}
+
+		columns[field] = table[0][index]
+		indexes[field] = index
+	}
+
+	for _, field := range []string{"first_name", "email", "password"} {
+		if _, found := indexes[field]; !found {
+			err = NewValidationError("import_column_required", "falta la columna del campo %s", field)
+			return
+		}
+	}
+
+	for i, record := range table[1:] {
+		value := func(field string) string {
+			index, found := indexes[field]
+			if !found || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		row := userImportRow{
+			Row: i + 2,
+			Request: CreateUserRequest{
+				FirstName: value("first_name"),
+				LastName:  value("last_name"),
+				Email:     utils.NormalizeEmail(value("email")),
+				Password:  value("password"),
+				Type:      strings.ToLower(value("type")),
+				Status:    strings.ToLower(value("status")),
+				Phone:     value("phone"),
+				Language:  strings.ToLower(value("language")),
+			},
+		}
+
+		if row.Request.Type == "" {
+			row.Request.Type = models.UserTypeUser
+		}
+		if row.Request.Status == "" {
+			row.Request.Status = models.UserStatusActive
+		}
+
+		for _, skill := range strings.FieldsFunc(value("skills"), func(r rune) bool { return r == '|' || r == ',' || r == ';' }) {
+			if skill = strings.TrimSpace(skill); skill != "" {
+				row.Skills = append(row.Skills, skill)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return
+}
+
+// Indica si un texto es una dirección de correo sin nombre, como "ana@example.com"
+func isPlainEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
+}
+
+func NewUserImportService(store repository.IStore, userService IUserService, config utils.Config) IUserImportService {
+	return &UserImportService{store: store, userService: userService, config: config}
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestParseUserImportRequiresPassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"con contraseña", "first_name,email,password\nAna,ana@example.com,secreto1\n", false},
+		{"sin columna de contraseña", "first_name,email\nAna,ana@example.com\n", true},
+		{"sin columna de correo", "first_name,password\nAna,secreto1\n", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rows, _, err := parseUserImport(UserImportRequest{Data: []byte(test.data)})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseUserImport() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if err == nil && (len(rows) != 1 || rows[0].Request.Password != "secreto1") {
+				t.Errorf("parseUserImport() = %+v, want una fila con la contraseña del archivo", rows)
+			}
+		})
+	}
+}
@@ -1,38 +1,75 @@
 package services
 
 import (
-	"errors"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"github.com/maferuy/ayudapp-admin-backend-core/notifications"
+	"github.com/maferuy/ayudapp-admin-backend-core/repository"
+	"github.com/maferuy/ayudapp-admin-backend-core/storage"
 	"github.com/maferuy/ayudapp-admin-backend-core/utils"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CreateUserRequest struct {
-	FirstName    string `json:"first_name"`
-	LastName     string `json:"last_name"`
-	Email        string `json:"email"`
-	Password     string `json:"password"`
-	ProfileImage string `json:"profile_image"`
-	Type         string `json:"type"`
-	Status       string `json:"status"`
+	FirstName    string   `json:"first_name" binding:"required,max=100"`
+	LastName     string   `json:"last_name" binding:"required,max=100"`
+	Email        string   `json:"email" binding:"required,email,max=254"`
+	Password     string   `json:"password" binding:"required,min=6,max=72"`
+	ProfileImage string   `json:"profile_image" binding:"omitempty,url"`
+	Type         string   `json:"type" binding:"required,user_type"`
+	Status       string   `json:"status" binding:"required,user_status"`
+	Phone        string   `json:"phone" binding:"omitempty,max=30"`
+	Language     string   `json:"language" binding:"omitempty,language"`
+	Skills       []string `json:"skills" binding:"omitempty,dive,objectid"`
 }
 
 type UpdateUserRequest struct {
-	FirstName    string `json:"first_name"`
-	LastName     string `json:"last_name"`
-	Email        string `json:"email"`
-	ProfileImage string `json:"profile_image"`
-	Type         string `json:"type"`
-	Status       string `json:"status"`
+	FirstName string   `json:"first_name" binding:"omitempty,max=100"`
+	LastName  string   `json:"last_name" binding:"omitempty,max=100"`
+	Email     string   `json:"email" binding:"omitempty,email,max=254"`
+	Type      string   `json:"type" binding:"omitempty,user_type"`
+	Status    string   `json:"status" binding:"omitempty,user_status"`
+	Phone     string   `json:"phone" binding:"omitempty,max=30"`
+	Language  string   `json:"language" binding:"omitempty,language"`
+	Skills    []string `json:"skills" binding:"omitempty,dive,objectid"`
+}
+
+// Campos editables de un usuario, a los que se aplican las actualizaciones parciales
+type userFields struct {
+	FirstName string   `json:"first_name" binding:"required,max=100"`
+	LastName  string   `json:"last_name" binding:"required,max=100"`
+	Email     string   `json:"email" binding:"required,email,max=254"`
+	Type      string   `json:"type" binding:"required,user_type"`
+	Status    string   `json:"status" binding:"required,user_status"`
+	Phone     string   `json:"phone" binding:"omitempty,max=30"`
+	Language  string   `json:"language" binding:"omitempty,language"`
+	Skills    []string `json:"skills" binding:"omitempty,dive,objectid"`
 }
 
 type ChangePasswordRequest struct {
-	Password             string `json:"password"`
-	PasswordConfirmation string `json:"password_confirmation"`
+	Password             string `json:"password" binding:"required,min=6,max=72"`
+	PasswordConfirmation string `json:"password_confirmation" binding:"required,eqfield=Password"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Channels   []string           `json:"channels" binding:"required,min=1,dive,notification_channel"`
+	QuietHours *models.QuietHours `json:"quiet_hours"`
+}
+
+type AddPushSubscriptionRequest struct {
+	Endpoint string                      `json:"endpoint" binding:"required,url"`
+	Keys     models.PushSubscriptionKeys `json:"keys"`
+}
+
+type RemovePushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint" binding:"required"`
 }
 
 type CreateUserResponse struct {
@@ -51,42 +88,66 @@ type UpdateUserResponse struct {
 	User models.User `json:"user"`
 }
 
+type UploadProfileImageResponse struct {
+	ProfileImage      string            `json:"profile_image"`
+	ProfileThumbnails map[string]string `json:"profile_thumbnails"`
+}
+
+type GetNotificationPreferencesResponse struct {
+	Preferences models.NotificationPreferences `json:"preferences"`
+}
+
 type IUserService interface {
-	GetUsers() (response GetUsersResponse, err error)
-	CreateUser(req CreateUserRequest) (response CreateUserResponse, err error)
+	GetUsers(ctx context.Context, filter repository.UserFilter) (response GetUsersResponse, err error)
+	CreateUser(ctx context.Context, req CreateUserRequest) (response CreateUserResponse, err error)
 
-	GetUser(id string) (response GetUserResponse, err error)
-	UpdateUser(id string, req UpdateUserRequest) (response UpdateUserResponse, err error)
-	DeleteUser(id string) (err error)
+	GetUser(ctx context.Context, id string) (response GetUserResponse, err error)
+	UpdateUser(ctx context.Context, id string, match VersionMatch, req UpdateUserRequest) (response UpdateUserResponse, err error)
+	PatchUser(ctx context.Context, id string, match VersionMatch, patch []byte) (response UpdateUserResponse, err error)
+	DeleteUser(ctx context.Context, id string, match VersionMatch) (err error)
 
-	ChangePassword(id string, req ChangePasswordRequest) (err error)
-	SetSuperadmin(id string, enable bool) (err error)
+	ChangePassword(ctx context.Context, id string, req ChangePasswordRequest) (err error)
+	SetSuperadmin(ctx context.Context, id string, enable bool) (err error)
 
-	GetUserByEmail(email string) (response GetUserResponse, err error)
+	GetUserByEmail(ctx context.Context, email string) (response GetUserResponse, err error)
+
+	GetNotificationPreferences(ctx context.Context, id string) (response GetNotificationPreferencesResponse, err error)
+	UpdateNotificationPreferences(ctx context.Context, id string, req UpdateNotificationPreferencesRequest) (response GetNotificationPreferencesResponse, err error)
+	AddPushSubscription(ctx context.Context, id string, req AddPushSubscriptionRequest) (err error)
+	RemovePushSubscription(ctx context.Context, id string, endpoint string) (err error)
+
+	UploadProfileImage(ctx context.Context, id string, data []byte) (response UploadProfileImageResponse, err error)
+	DeleteProfileImage(ctx context.Context, id string) (err error)
 }
 
 type UserService struct {
-	db *mongo.Database
+	users   repository.IUserRepository
+	storage storage.IStorage
+}
+
+// Tamaños en píxeles de las miniaturas de la imagen de perfil; la imagen principal es la "large"
+var profileThumbnailSizes = []struct {
+	Name string
+	Size int
+}{
+	{"large", 512},
+	{"medium", 256},
+	{"small", 64},
 }
 
 /** Obtiene todos los usuarios
  *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param filter repository.UserFilter "Los filtros del listado"
  * @return GetUsersResponse "Los usuarios"
  * @return err error "El error de la operación"
  */
-func (service *UserService) GetUsers() (response GetUsersResponse, err error) {
-	var users []models.User
-	collection := service.db.Collection("users")
-
-	cursor, err := collection.Find(ctx, bson.M{})
+func (service *UserService) GetUsers(ctx context.Context, filter repository.UserFilter) (response GetUsersResponse, err error) {
+	users, err := service.users.Find(ctx, filter)
 	if err != nil {
 		return
 	}
 
-	if err = cursor.All(ctx, &users); err != nil {
-		return
-	}
-
 	for i := 0; i < len(users); i++ {
 		users[i].Password = ""
 	}
@@ -97,22 +158,26 @@ func (service *UserService) GetUsers() (response GetUsersResponse, err error) {
 
 /** Crea un usuario
  *
+ * @param ctx context.Context "El contexto de la solicitud"
  * @param req CreateUserRequest "Los valores del usuario a crear"
  * @return CreateUserResponse "El id del usuario creado"
  * @return err error "El error de la operación"
  */
-func (service *UserService) CreateUser(req CreateUserRequest) (response CreateUserResponse, err error) {
-	collection := service.db.Collection("users")
-
-	filter := bson.M{"email": req.Email}
-	existingUser, err := collection.CountDocuments(ctx, filter)
-	if err != nil {
+func (service *UserService) CreateUser(ctx context.Context, req CreateUserRequest) (response CreateUserResponse, err error) {
+	email := utils.NormalizeEmail(req.Email)
+	if email == "" {
+		err = NewValidationError("email_required", "el correo electrónico es requerido")
 		return
 	}
 
-	if existingUser > 0 {
-		err = errors.New("el correo electrónico ya está ingresado en la base de datos")
-		return
+	var skills []primitive.ObjectID
+	for _, skill := range req.Skills {
+		var categoryID primitive.ObjectID
+		if categoryID, err = primitive.ObjectIDFromHex(skill); err != nil {
+			err = NewValidationError("invalid_skill", "la habilidad es inválida")
+			return
+		}
+		skills = append(skills, categoryID)
 	}
 
 	password, err := utils.HashPassword(req.Password)
@@ -123,258 +188,525 @@ func (service *UserService) CreateUser(req CreateUserRequest) (response CreateUs
 	user := models.User{
 		FirstName:         req.FirstName,
 		LastName:          req.LastName,
-		Email:             req.Email,
+		Email:             email,
 		Password:          password,
 		Type:              req.Type,
 		Status:            req.Status,
+		Phone:             req.Phone,
 		ProfileImage:      req.ProfileImage,
+		Language:          req.Language,
+		Skills:            skills,
 		PasswordChangedAt: time.Now(),
 		CreatedAt:         time.Now(),
 		UpdatedAt:         time.Now(),
 	}
 
-	result, err := collection.InsertOne(ctx, user)
-	if err != nil {
+	// El índice único de correos evita duplicados aunque haya solicitudes simultáneas
+	if err = userError(service.users.Insert(ctx, &user)); err != nil {
 		return
 	}
 
-	response.UserID = result.InsertedID.(primitive.ObjectID).Hex()
+	response.UserID = user.ID.Hex()
 	return
 }
 
 /** Obtiene un usuario
  *
+ * @param ctx context.Context "El contexto de la solicitud"
  * @param id string "El ID del usuario"
  * @return GetUserResponse "El usuario"
  * @return err error "El error de la operación"
  */
-func (service *UserService) GetUser(userId string) (response GetUserResponse, err error) {
-	collection := service.db.Collection("users")
-	var user models.User
-
-	id, err := primitive.ObjectIDFromHex(userId)
+func (service *UserService) GetUser(ctx context.Context, userId string) (response GetUserResponse, err error) {
+	user, err := service.findUser(ctx, userId)
 	if err != nil {
 		return
 	}
 
-	filter := bson.M{"_id": id}
+	user.Password = ""
+
+	response.User = user
+	return
+}
 
-	count, err := collection.CountDocuments(ctx, filter)
+/** Actualiza un usuario
+ *
+ * Sólo se modifican los campos que tienen valor en la solicitud.
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param id string "El id del usuario"
+ * @param match VersionMatch "Las versiones del usuario que acepta el cliente"
+ * @param req UpdateUserRequest "Los valores del usuario a actualizar"
+ * @return UpdateUserResponse "El usuario actualizado"
+ * @return err error "El error de la operación"
+ */
+func (service *UserService) UpdateUser(ctx context.Context, userId string, match VersionMatch, req UpdateUserRequest) (response UpdateUserResponse, err error) {
+	user, err := service.findUser(ctx, userId)
 	if err != nil {
 		return
 	}
-	if count == 0 {
-		err = errors.New("no se encontró el usuario")
+
+	if err = checkVersion(user.Version, match); err != nil {
 		return
 	}
 
-	result := collection.FindOne(ctx, filter)
-	if err = result.Err(); err != nil {
+	fields := newUserFields(user)
+	if req.FirstName != "" {
+		fields.FirstName = req.FirstName
+	}
+	if req.LastName != "" {
+		fields.LastName = req.LastName
+	}
+	if req.Email != "" {
+		fields.Email = req.Email
+	}
+	if req.Type != "" {
+		fields.Type = req.Type
+	}
+	if req.Status != "" {
+		fields.Status = req.Status
+	}
+	if req.Phone != "" {
+		fields.Phone = req.Phone
+	}
+	if req.Language != "" {
+		fields.Language = req.Language
+	}
+	if req.Skills != nil {
+		fields.Skills = req.Skills
+	}
+
+	if err = utils.ValidateStruct(fields); err != nil {
 		return
 	}
 
-	err = result.Decode(&user)
+	return service.saveUser(ctx, user, fields)
+}
+
+/** Actualiza parcialmente un usuario con un parche JSON Merge Patch (RFC 7396)
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param id string "El id del usuario"
+ * @param match VersionMatch "Las versiones del usuario que acepta el cliente"
+ * @param patch []byte "El parche con los campos a modificar; null vacía un campo"
+ * @return UpdateUserResponse "El usuario actualizado"
+ * @return err error "El error de la operación"
+ */
+func (service *UserService) PatchUser(ctx context.Context, userId string, match VersionMatch, patch []byte) (response UpdateUserResponse, err error) {
+	user, err := service.findUser(ctx, userId)
 	if err != nil {
 		return
 	}
 
+	if err = checkVersion(user.Version, match); err != nil {
+		return
+	}
+
+	var fields userFields
+	if err = applyMergePatch(newUserFields(user), patch, &fields); err != nil {
+		return
+	}
+
+	return service.saveUser(ctx, user, fields)
+}
+
+// Guarda los campos editables del usuario y lo devuelve como quedó en la base de datos
+func (service *UserService) saveUser(ctx context.Context, user models.User, fields userFields) (response UpdateUserResponse, err error) {
+	skills := make([]primitive.ObjectID, 0, len(fields.Skills))
+	for _, skill := range fields.Skills {
+		var categoryID primitive.ObjectID
+		if categoryID, err = primitive.ObjectIDFromHex(skill); err != nil {
+			err = NewValidationError("invalid_skill", "la habilidad es inválida")
+			return
+		}
+		skills = append(skills, categoryID)
+	}
+
+	user.FirstName = fields.FirstName
+	user.LastName = fields.LastName
+	user.Email = utils.NormalizeEmail(fields.Email)
+	user.Type = fields.Type
+	user.Status = fields.Status
+	user.Phone = fields.Phone
+	user.Language = fields.Language
+	user.Skills = skills
+	user.UpdatedAt = time.Now()
+
+	if err = userError(service.users.Update(ctx, user)); err != nil {
+		return
+	}
+
+	if user, err = service.users.FindByID(ctx, user.ID); err != nil {
+		err = userError(err)
+		return
+	}
+
 	user.Password = ""
 
 	response.User = user
 	return
+}
+
+func newUserFields(user models.User) userFields {
+	skills := make([]string, 0, len(user.Skills))
+	for _, skill := range user.Skills {
+		skills = append(skills, skill.Hex())
+	}
 
+	return userFields{
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		Type:      user.Type,
+		Status:    user.Status,
+		Phone:     user.Phone,
+		Language:  user.Language,
+		Skills:    skills,
+	}
 }
 
-/** Actualiza un usuario
+/** Elimina un usuario
  *
- * @param req UpdateUserRequest "Los valores del usuario a actualizar"
+ * @param ctx context.Context "El contexto de la solicitud"
  * @param id string "El id del usuario"
- * @return UpdateUserResponse "Los datos del usuario actualizado"
+ * @param match VersionMatch "Las versiones del usuario que acepta el cliente"
  * @return err error "El error de la operación"
  */
-func (service *UserService) UpdateUser(userId string, req UpdateUserRequest) (response UpdateUserResponse, err error) {
-	collection := service.db.Collection("users")
-	var user models.User
-
-	id, err := primitive.ObjectIDFromHex(userId)
+func (service *UserService) DeleteUser(ctx context.Context, userId string, match VersionMatch) (err error) {
+	user, err := service.findUser(ctx, userId)
 	if err != nil {
 		return
 	}
 
-	filter := bson.M{"_id": id}
+	if err = checkVersion(user.Version, match); err != nil {
+		return
+	}
+
+	return userError(service.users.Delete(ctx, user.ID, user.Version))
+}
 
-	count, err := collection.CountDocuments(ctx, filter)
+/** Cambia la contraseña de un usuario
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param id string "El id del usuario"
+ * @param req ChangePasswordRequest "Los valores de la contraseña"
+ * @return err error "El error de la operación"
+ */
+func (service *UserService) ChangePassword(ctx context.Context, userId string, req ChangePasswordRequest) (err error) {
+	id, err := parseID(userId)
 	if err != nil {
 		return
 	}
-	if count == 0 {
-		err = errors.New("no se encontró el usuario")
+
+	if req.Password == "" {
+		err = NewValidationError("password_required", "la contraseña no puede estar vacía")
 		return
 	}
-
-	cursor, err := collection.Find(ctx, filter)
-	if err != nil {
+	if req.Password != req.PasswordConfirmation {
+		err = NewValidationError("password_mismatch", "las contraseñas no coinciden")
 		return
 	}
 
-	if err = cursor.Decode(&user); err != nil {
+	password, err := utils.HashPassword(req.Password)
+	if err != nil {
 		return
 	}
 
-	user.UpdatedAt = time.Now()
+	return userError(service.users.SetPassword(ctx, id, password, time.Now()))
+}
 
-	updatedValues, err := utils.ToDoc(user)
+/** Configura un usuario como super usuario
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param id string "El id del usuario"
+ * @param enable bool "Si se desea habilitar o deshabilitar"
+ * @return err error "El error de la operación"
+ */
+func (service *UserService) SetSuperadmin(ctx context.Context, userId string, enable bool) (err error) {
+	user, err := service.findUser(ctx, userId)
 	if err != nil {
 		return
 	}
 
-	if req.ProfileImage != "" && req.ProfileImage != user.ProfileImage {
-		// guardar imagen, etc
+	if enable {
+		user.Type = "superadmin"
+	} else {
+		user.Type = "user"
 	}
 
-	update := bson.D{{"$set", updatedValues}}
-	if _, err = collection.UpdateOne(ctx, filter, update); err != nil {
+	user.UpdatedAt = time.Now()
+
+	return userError(service.users.Update(ctx, user))
+}
+
+/** Obtiene un usuario por su email
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param email string "El email del usuario"
+ * @return GetUserResponse "El usuario"
+ * @return err error "El error de la operación"
+ */
+func (service *UserService) GetUserByEmail(ctx context.Context, email string) (response GetUserResponse, err error) {
+	user, err := service.users.FindByEmail(ctx, utils.NormalizeEmail(email))
+	if err = userError(err); err != nil {
 		return
 	}
 
-	user.Password = ""
-
 	response.User = user
 	return
 }
 
-/** Elimina un usuario
+/** Obtiene los canales de aviso y el horario de silencio de un usuario
+ *
+ * Si el usuario no configuró sus preferencias se devuelve el correo electrónico como único canal.
  *
+ * @param ctx context.Context "El contexto de la solicitud"
  * @param id string "El id del usuario"
+ * @return GetNotificationPreferencesResponse "Las preferencias"
  * @return err error "El error de la operación"
  */
-func (service *UserService) DeleteUser(userId string) (err error) {
-	collection := service.db.Collection("users")
-
-	id, err := primitive.ObjectIDFromHex(userId)
+func (service *UserService) GetNotificationPreferences(ctx context.Context, userId string) (response GetNotificationPreferencesResponse, err error) {
+	user, err := service.GetUser(ctx, userId)
 	if err != nil {
 		return
 	}
 
-	filter := bson.M{"id": id}
-	result := collection.FindOneAndDelete(ctx, filter)
-	if err = result.Err(); err != nil {
-		return
+	response.Preferences = models.NotificationPreferences{Channels: []string{models.NotificationChannelEmail}}
+	if user.User.NotificationPreferences != nil {
+		response.Preferences = *user.User.NotificationPreferences
 	}
 
 	return
 }
 
-/** Cambia la contraseña de un usuario
+/** Actualiza los canales de aviso y el horario de silencio de un usuario
  *
+ * @param ctx context.Context "El contexto de la solicitud"
  * @param id string "El id del usuario"
- * @param req ChangePasswordRequest "Los valores de la contraseña"
+ * @param req UpdateNotificationPreferencesRequest "Las preferencias"
+ * @return GetNotificationPreferencesResponse "Las preferencias actualizadas"
  * @return err error "El error de la operación"
  */
-func (service *UserService) ChangePassword(userId string, req ChangePasswordRequest) (err error) {
-	collection := service.db.Collection("users")
-	var user models.User
-
-	id, err := primitive.ObjectIDFromHex(userId)
+func (service *UserService) UpdateNotificationPreferences(ctx context.Context, userId string, req UpdateNotificationPreferencesRequest) (response GetNotificationPreferencesResponse, err error) {
+	id, err := parseID(userId)
 	if err != nil {
 		return
 	}
 
-	if req.Password == "" {
-		err = errors.New("la contraseña no puede estar vacía")
+	if len(req.Channels) == 0 {
+		err = NewValidationError("notification_channel_required", "se requiere al menos un canal de aviso")
 		return
 	}
-	if req.Password != req.PasswordConfirmation {
-		err = errors.New("las contraseñas no coinciden")
+
+	for _, channel := range req.Channels {
+		switch channel {
+		case models.NotificationChannelEmail, models.NotificationChannelSMS, models.NotificationChannelPush:
+		default:
+			err = NewValidationError("invalid_notification_channel", "el canal de aviso %s es inválido", channel)
+			return
+		}
+	}
+
+	if req.QuietHours != nil {
+		if _, err = notifications.ParseClock(req.QuietHours.Start); err != nil {
+			err = NewValidationError("invalid_quiet_hours", "%v", err)
+			return
+		}
+		if _, err = notifications.ParseClock(req.QuietHours.End); err != nil {
+			err = NewValidationError("invalid_quiet_hours", "%v", err)
+			return
+		}
+		if req.QuietHours.TimeZone == "" {
+			req.QuietHours.TimeZone = "UTC"
+		}
+		if _, err = time.LoadLocation(req.QuietHours.TimeZone); err != nil {
+			err = NewValidationError("invalid_time_zone", "la zona horaria %s es inválida", req.QuietHours.TimeZone)
+			return
+		}
+	}
+
+	preferences := models.NotificationPreferences{Channels: req.Channels, QuietHours: req.QuietHours}
+	if err = userError(service.users.SetNotificationPreferences(ctx, id, preferences)); err != nil {
 		return
 	}
 
-	filter := bson.M{"_id": id}
+	response.Preferences = preferences
+	return
+}
 
-	result := collection.FindOne(ctx, filter)
-	if err = result.Err(); err != nil {
+/** Registra la suscripción web push de un navegador del usuario
+ *
+ * Si ya existe una suscripción con el mismo endpoint se reemplazan sus claves. El
+ * endpoint debe ser una URL https de un host público.
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param id string "El id del usuario"
+ * @param req AddPushSubscriptionRequest "La suscripción"
+ * @return err error "El error de la operación"
+ */
+func (service *UserService) AddPushSubscription(ctx context.Context, userId string, req AddPushSubscriptionRequest) (err error) {
+	id, err := parseID(userId)
+	if err != nil {
 		return
 	}
 
-	password, err := utils.HashPassword(req.Password)
-	if err != nil {
+	if req.Endpoint == "" || req.Keys.P256dh == "" || req.Keys.Auth == "" {
+		err = NewValidationError("invalid_push_subscription", "la suscripción requiere endpoint y claves p256dh y auth")
 		return
 	}
 
-	user.Password = password
-	user.PasswordChangedAt = time.Now()
-
-	updatedValues, err := utils.ToDoc(user)
-	if err != nil {
+	if endpointErr := notifications.ValidatePushEndpoint(req.Endpoint); endpointErr != nil {
+		err = NewValidationError("invalid_push_endpoint", "%s", endpointErr.Error())
 		return
 	}
 
-	update := bson.D{{"$set", updatedValues}}
-	if _, err = collection.UpdateOne(ctx, filter, update); err != nil {
+	if err = service.RemovePushSubscription(ctx, userId, req.Endpoint); err != nil {
 		return
 	}
 
-	return
+	subscription := models.PushSubscription{Endpoint: req.Endpoint, Keys: req.Keys, CreatedAt: time.Now()}
+	return userError(service.users.AddPushSubscription(ctx, id, subscription))
 }
 
-/** Configura un usuario como super usuario
+/** Elimina una suscripción web push del usuario
  *
+ * @param ctx context.Context "El contexto de la solicitud"
  * @param id string "El id del usuario"
- * @param enable bool "Si se desea habilitar o deshabilitar"
+ * @param endpoint string "El endpoint de la suscripción"
  * @return err error "El error de la operación"
  */
-func (service *UserService) SetSuperadmin(userId string, enable bool) (err error) {
-	collection := service.db.Collection("users")
-	var user models.User
+func (service *UserService) RemovePushSubscription(ctx context.Context, userId string, endpoint string) (err error) {
+	id, err := parseID(userId)
+	if err != nil {
+		return
+	}
+
+	return userError(service.users.RemovePushSubscription(ctx, id, endpoint))
+}
 
-	id, err := primitive.ObjectIDFromHex(userId)
+/** Guarda la imagen de perfil de un usuario en sus tamaños estándar y elimina la anterior
+ *
+ * La imagen se vuelve a codificar como JPEG, lo que además descarta los metadatos
+ * que pudiera contener el archivo original.
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param id string "El id del usuario"
+ * @param data []byte "El contenido del archivo subido"
+ * @return UploadProfileImageResponse "Las URLs de la imagen y sus miniaturas"
+ * @return err error "El error de la operación"
+ */
+func (service *UserService) UploadProfileImage(ctx context.Context, userId string, data []byte) (response UploadProfileImageResponse, err error) {
+	user, err := service.findUser(ctx, userId)
 	if err != nil {
 		return
 	}
+	id := user.ID
 
-	filter := bson.M{"_id": id}
-	err = collection.FindOne(ctx, filter).Decode(&user)
+	img, err := utils.DecodeImage(data)
 	if err != nil {
+		err = NewValidationError("invalid_image", err.Error())
 		return
 	}
 
-	if enable {
-		user.Type = "superadmin"
-	} else {
-		user.Type = "user"
+	suffix := make([]byte, 8)
+	if _, err = rand.Read(suffix); err != nil {
+		return
 	}
+	prefix := fmt.Sprintf("%s/%s/%s", storage.ProfileImagePrefix, id.Hex(), hex.EncodeToString(suffix))
 
-	user.UpdatedAt = time.Now()
+	var keys []string
+	thumbnails := map[string]string{}
+	for _, thumbnail := range profileThumbnailSizes {
+		var encoded []byte
+		if encoded, err = utils.SquareThumbnail(img, thumbnail.Size); err != nil {
+			break
+		}
+
+		key := fmt.Sprintf("%s/%s.jpg", prefix, thumbnail.Name)
+		if err = service.storage.Put(ctx, key, bytes.NewReader(encoded), "image/jpeg"); err != nil {
+			break
+		}
+
+		keys = append(keys, key)
+		thumbnails[thumbnail.Name] = service.storage.URL(key)
+	}
+
+	if err == nil {
+		err = userError(service.users.SetProfileImage(ctx, id, thumbnails["large"], thumbnails, keys))
+	}
 
-	updatedValues, err := utils.ToDoc(user)
 	if err != nil {
+		service.deleteStoredImages(keys)
 		return
 	}
-	update := bson.D{{"$set", updatedValues}}
-	_, err = collection.UpdateOne(ctx, filter, update)
 
-	return err
+	service.deleteStoredImages(user.ProfileImageKeys)
+
+	response.ProfileImage = thumbnails["large"]
+	response.ProfileThumbnails = thumbnails
+	return
 }
 
-/** Obtiene un usuario por su email
+/** Elimina la imagen de perfil de un usuario
  *
- * @param email string "El email del usuario"
- * @return GetUserResponse "El usuario"
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param id string "El id del usuario"
  * @return err error "El error de la operación"
  */
-func (service *UserService) GetUserByEmail(email string) (response GetUserResponse, err error) {
-	collection := service.db.Collection("users")
-	var user models.User
+func (service *UserService) DeleteProfileImage(ctx context.Context, userId string) (err error) {
+	id, err := parseID(userId)
+	if err != nil {
+		return
+	}
 
-	filter := bson.M{"email": email}
-	if err = collection.FindOne(ctx, filter).Decode(&user); err != nil {
+	user, err := service.users.ClearProfileImage(ctx, id)
+	if err = userError(err); err != nil {
 		return
 	}
 
-	response.User = user
+	service.deleteStoredImages(user.ProfileImageKeys)
 	return
 }
 
-func NewUserService(db *mongo.Database) IUserService {
-	return &UserService{db: db}
+// Elimina imágenes guardadas; los errores sólo se registran porque la imagen ya no está en uso
+func (service *UserService) deleteStoredImages(keys []string) {
+	for _, key := range keys {
+		if err := service.storage.Delete(ctx, key); err != nil {
+			log.Printf("Error al eliminar la imagen %s: %v", key, err)
+		}
+	}
+}
+
+/** Busca un usuario por su id
+ *
+ * @param ctx context.Context "El contexto de la solicitud"
+ * @param userId string "El id del usuario"
+ * @return user models.User "El usuario, incluida la contraseña"
+ * @return err error "El error de la operación"
+ */
+func (service *UserService) findUser(ctx context.Context, userId string) (user models.User, err error) {
+	id, err := parseID(userId)
+	if err != nil {
+		return
+	}
+
+	user, err = service.users.FindByID(ctx, id)
+	err = userError(err)
+	return
+}
+
+// Reemplaza los errores de usuario inexistente, versión y correo repetido del repositorio por unos descriptivos
+func userError(err error) error {
+	switch err {
+	case repository.ErrNotFound:
+		return ErrUserNotFound
+	case repository.ErrVersionConflict:
+		return ErrVersionMismatch
+	}
+
+	return conflictError(err, ErrEmailTaken)
+}
+
+func NewUserService(users repository.IUserRepository, storage storage.IStorage) IUserService {
+	return &UserService{users: users, storage: storage}
 }
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Almacenamiento en un directorio del sistema de archivos local
+type LocalStorage struct {
+	root      string
+	publicURL string
+}
+
+/** Guarda un archivo, creando los directorios necesarios
+ *
+ * El archivo se escribe primero con un nombre temporal para que nunca quede a medias.
+ *
+ * @param ctx context.Context "El contexto de la operación"
+ * @param key string "La clave del archivo"
+ * @param body io.Reader "El contenido"
+ * @param contentType string "El tipo de contenido, no se usa en el almacenamiento local"
+ * @return err error "El error de la operación"
+ */
+func (storage *LocalStorage) Put(ctx context.Context, key string, body io.Reader, contentType string) (err error) {
+	path, err := storage.path(key)
+	if err != nil {
+		return
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = io.Copy(file, body); err != nil {
+		file.Close()
+		return
+	}
+
+	if err = file.Close(); err != nil {
+		return
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+/** Abre un archivo para leerlo
+ *
+ * @param ctx context.Context "El contexto de la operación"
+ * @param key string "La clave del archivo"
+ * @return body io.ReadCloser "El contenido, que debe cerrarse"
+ * @return err error "ErrNotFound si el archivo no existe"
+ */
+func (storage *LocalStorage) Get(ctx context.Context, key string) (body io.ReadCloser, err error) {
+	path, err := storage.path(key)
+	if err != nil {
+		return
+	}
+
+	body, err = os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		err = ErrNotFound
+	}
+
+	return
+}
+
+/** Elimina un archivo, sin error si no existe
+ *
+ * @param ctx context.Context "El contexto de la operación"
+ * @param key string "La clave del archivo"
+ * @return err error "El error de la operación"
+ */
+func (storage *LocalStorage) Delete(ctx context.Context, key string) (err error) {
+	path, err := storage.path(key)
+	if err != nil {
+		return
+	}
+
+	if err = os.Remove(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return
+}
+
+// Devuelve la URL pública de un archivo
+func (storage *LocalStorage) URL(key string) string {
+	return storage.publicURL + "/" + key
+}
+
+// Devuelve la ruta de un archivo, impidiendo que la clave salga del directorio raíz
+func (storage *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", errors.New("la clave del archivo es inválida")
+	}
+
+	return filepath.Join(storage.root, filepath.FromSlash(clean)), nil
+}
+
+// Devuelve el directorio raíz del almacenamiento
+func (storage *LocalStorage) Root() string {
+	return storage.root
+}
+
+func NewLocalStorage(root string, publicURL string) (*LocalStorage, error) {
+	if root == "" {
+		return nil, errors.New("se requiere el directorio del almacenamiento local")
+	}
+
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &LocalStorage{root: root, publicURL: strings.TrimRight(publicURL, "/")}, nil
+}
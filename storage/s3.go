@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Configuración de un almacenamiento compatible con S3
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// Usa URLs del tipo endpoint/bucket/clave en lugar de bucket.endpoint/clave, como requieren MinIO y otros
+	PathStyle bool
+	PublicURL string
+}
+
+// Almacenamiento en un bucket compatible con S3, con firma AWS Signature Version 4
+type S3Storage struct {
+	config S3Config
+	base   *url.URL
+	client *http.Client
+}
+
+/** Sube un archivo al bucket
+ *
+ * @param ctx context.Context "El contexto de la operación"
+ * @param key string "La clave del archivo"
+ * @param body io.Reader "El contenido"
+ * @param contentType string "El tipo de contenido"
+ * @return err error "El error de la operación"
+ */
+func (storage *S3Storage) Put(ctx context.Context, key string, body io.Reader, contentType string) (err error) {
+	payload, err := io.ReadAll(body)
+	if err != nil {
+		return
+	}
+
+	headers := http.Header{}
+	headers.Set("Content-Type", contentType)
+
+	return storage.do(ctx, http.MethodPut, key, payload, headers)
+}
+
+/** Descarga un archivo del bucket
+ *
+ * @param ctx context.Context "El contexto de la operación"
+ * @param key string "La clave del archivo"
+ * @return body io.ReadCloser "El contenido, que debe cerrarse"
+ * @return err error "ErrNotFound si el archivo no existe"
+ */
+func (storage *S3Storage) Get(ctx context.Context, key string) (body io.ReadCloser, err error) {
+	resp, err := storage.send(ctx, http.MethodGet, key, nil, http.Header{})
+	if err != nil {
+		return
+	}
+
+	return resp.Body, nil
+}
+
+/** Elimina un archivo del bucket
+ *
+ * @param ctx context.Context "El contexto de la operación"
+ * @param key string "La clave del archivo"
+ * @return err error "El error de la operación"
+ */
+func (storage *S3Storage) Delete(ctx context.Context, key string) (err error) {
+	return storage.do(ctx, http.MethodDelete, key, nil, http.Header{})
+}
+
+// Devuelve la URL pública de un archivo
+func (storage *S3Storage) URL(key string) string {
+	if storage.config.PublicURL != "" {
+		return strings.TrimRight(storage.config.PublicURL, "/") + "/" + key
+	}
+
+	return storage.objectURL(key).String()
+}
+
+/** Envía una petición firmada al bucket y descarta la respuesta
+ *
+ * @param ctx context.Context "El contexto de la operación"
+ * @param method string "El método HTTP"
+ * @param key string "La clave del archivo"
+ * @param payload []byte "El contenido de la petición"
+ * @param headers http.Header "Las cabeceras adicionales"
+ * @return error "Error si el servicio no acepta la petición"
+ */
+func (storage *S3Storage) do(ctx context.Context, method string, key string, payload []byte, headers http.Header) error {
+	resp, err := storage.send(ctx, method, key, payload, headers)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+/** Envía una petición firmada al bucket
+ *
+ * @param ctx context.Context "El contexto de la operación"
+ * @param method string "El método HTTP"
+ * @param key string "La clave del archivo"
+ * @param payload []byte "El contenido de la petición"
+ * @param headers http.Header "Las cabeceras adicionales"
+ * @return *http.Response "La respuesta exitosa, cuyo cuerpo debe cerrarse"
+ * @return error "Error si el servicio no acepta la petición"
+ */
+func (storage *S3Storage) send(ctx context.Context, method string, key string, payload []byte, headers http.Header) (*http.Response, error) {
+	objectURL := storage.objectURL(key)
+
+	req, err := http.NewRequestWithContext(ctx, method, objectURL.String(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+
+	storage.sign(req, payload, time.Now().UTC())
+
+	resp, err := storage.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("el almacenamiento S3 respondió %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	return resp, nil
+}
+
+// Devuelve la URL de un archivo en el bucket
+func (storage *S3Storage) objectURL(key string) *url.URL {
+	objectURL := *storage.base
+	path := strings.TrimRight(objectURL.Path, "/") + "/" + key
+
+	if storage.config.PathStyle {
+		path = strings.TrimRight(objectURL.Path, "/") + "/" + storage.config.Bucket + "/" + key
+	} else {
+		objectURL.Host = storage.config.Bucket + "." + objectURL.Host
+	}
+
+	objectURL.Path = path
+	objectURL.RawPath = uriEncodePath(path)
+	return &objectURL
+}
+
+/** Firma una petición según AWS Signature Version 4
+ *
+ * @param req *http.Request "La petición"
+ * @param payload []byte "El contenido de la petición"
+ * @param now time.Time "El momento de la firma, en UTC"
+ */
+func (storage *S3Storage) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// El cliente HTTP envía la cabecera Host a partir de la URL
+	names := []string{"host"}
+	canonicalHeaders := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		names = append(names, lower)
+		canonicalHeaders[lower] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	sort.Strings(names)
+
+	var headerLines strings.Builder
+	for _, name := range names {
+		headerLines.WriteString(name + ":" + canonicalHeaders[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		headerLines.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + storage.config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+storage.config.SecretKey), date)
+	key = hmacSHA256(key, storage.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		storage.config.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+// Codifica una ruta según las reglas de S3, sin codificar las barras
+func uriEncodePath(path string) string {
+	var encoded strings.Builder
+	for _, b := range []byte(path) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~', b == '/':
+			encoded.WriteByte(b)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+
+	return encoded.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func NewS3Storage(config S3Config) (*S3Storage, error) {
+	if config.Endpoint == "" || config.Bucket == "" || config.AccessKey == "" || config.SecretKey == "" {
+		return nil, errors.New("el almacenamiento S3 requiere endpoint, bucket y credenciales")
+	}
+
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+
+	base, err := url.Parse(config.Endpoint)
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("el endpoint S3 %q es inválido", config.Endpoint)
+	}
+
+	return &S3Storage{
+		config: config,
+		base:   base,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
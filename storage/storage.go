@@ -0,0 +1,57 @@
+// Package storage guarda archivos subidos, como las imágenes de perfil, en el
+// sistema de archivos local o en un servicio compatible con S3.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/utils"
+)
+
+// Prefijo de las imágenes de perfil, los únicos archivos que se publican sin autenticación.
+// Los demás, como los adjuntos y las exportaciones, sólo se descargan desde endpoints
+// autenticados; con S3, la política del bucket debe permitir la lectura anónima sólo
+// de este prefijo.
+const ProfileImagePrefix = "users"
+
+// Prefijo de los archivos que nunca se publican, como los adjuntos de las citas
+const PrivatePrefix = "private"
+
+// Indica que el archivo no existe en el almacenamiento
+var ErrNotFound = errors.New("el archivo no existe")
+
+// Almacenamiento de archivos identificados por una clave con forma de ruta, como "users/id/imagen.jpg"
+type IStorage interface {
+	Put(ctx context.Context, key string, body io.Reader, contentType string) (err error)
+	Get(ctx context.Context, key string) (body io.ReadCloser, err error)
+	Delete(ctx context.Context, key string) (err error)
+	URL(key string) string
+}
+
+/** Crea el almacenamiento indicado en la configuración
+ *
+ * @param config utils.Config "Configuración de la aplicación"
+ * @return IStorage "El almacenamiento"
+ * @return error "Error si el tipo de almacenamiento no existe o falta configuración"
+ */
+func New(config utils.Config) (IStorage, error) {
+	switch config.StorageDriver {
+	case "", "local":
+		return NewLocalStorage(config.StorageLocalPath, config.StoragePublicURL)
+	case "s3":
+		return NewS3Storage(S3Config{
+			Endpoint:  config.S3Endpoint,
+			Region:    config.S3Region,
+			Bucket:    config.S3Bucket,
+			AccessKey: config.S3AccessKey,
+			SecretKey: config.S3SecretKey,
+			PathStyle: config.S3PathStyle,
+			PublicURL: config.StoragePublicURL,
+		})
+	default:
+		return nil, fmt.Errorf("el almacenamiento %s no existe", config.StorageDriver)
+	}
+}
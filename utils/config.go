@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -8,8 +12,14 @@ import (
 
 // Config guarda toda la configuración de la aplicación
 type Config struct {
+	Environment               string        `mapstructure:"APP_ENV"`
 	Port                      string        `mapstructure:"APP_PORT"`
 	MongoURI                  string        `mapstructure:"MONGO_URI"`
+	DatabaseName              string        `mapstructure:"MONGO_DATABASE"`
+	MigrateOnStart            bool          `mapstructure:"MIGRATE_ON_START"`
+	DatabaseTimeout           time.Duration `mapstructure:"DB_TIMEOUT"`
+	ShutdownTimeout           time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	CorsAllowedOrigins        []string      `mapstructure:"CORS_ALLOWED_ORIGINS"`
 	SecretKey                 string        `mapstructure:"SESSION_SECRET_KEY"`
 	AccessTokenDuration       time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
 	RefreshTokenDuration      time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
@@ -20,26 +30,174 @@ type Config struct {
 	SMTPPort                  int           `mapstructure:"SMTP_PORT"`
 	SMTPUser                  string        `mapstructure:"SMTP_USER"`
 	SMTPPassword              string        `mapstructure:"SMTP_PASSWORD"`
+	SMTPSender                string        `mapstructure:"SMTP_SENDER"`
+	SchedulerPollInterval     time.Duration `mapstructure:"SCHEDULER_POLL_INTERVAL"`
+	NotificationsDriver       string        `mapstructure:"NOTIFICATIONS_DRIVER"`
+	SMSProviderURL            string        `mapstructure:"SMS_PROVIDER_URL"`
+	SMSProviderToken          string        `mapstructure:"SMS_PROVIDER_TOKEN"`
+	SMSSender                 string        `mapstructure:"SMS_SENDER"`
+	VAPIDPrivateKey           string        `mapstructure:"VAPID_PRIVATE_KEY"`
+	VAPIDSubject              string        `mapstructure:"VAPID_SUBJECT"`
+	StorageDriver             string        `mapstructure:"STORAGE_DRIVER"`
+	StorageLocalPath          string        `mapstructure:"STORAGE_LOCAL_PATH"`
+	StoragePublicURL          string        `mapstructure:"STORAGE_PUBLIC_URL"`
+	S3Endpoint                string        `mapstructure:"S3_ENDPOINT"`
+	S3Region                  string        `mapstructure:"S3_REGION"`
+	S3Bucket                  string        `mapstructure:"S3_BUCKET"`
+	S3AccessKey               string        `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey               string        `mapstructure:"S3_SECRET_KEY"`
+	S3PathStyle               bool          `mapstructure:"S3_PATH_STYLE"`
+	ProfileImageMaxSize       int64         `mapstructure:"PROFILE_IMAGE_MAX_SIZE"`
+	AttachmentMaxSize         int64         `mapstructure:"ATTACHMENT_MAX_SIZE"`
+	StatsCacheTTL             time.Duration `mapstructure:"STATS_CACHE_TTL"`
+	IdempotencyTTL            time.Duration `mapstructure:"IDEMPOTENCY_TTL"`
+}
+
+// Entornos de ejecución de la aplicación
+const (
+	EnvDevelopment = "dev"
+	EnvStaging     = "staging"
+	EnvProduction  = "prod"
+)
+
+// Valores predeterminados de cada entorno, que se aplican si la variable no está definida
+var environmentDefaults = map[string]map[string]interface{}{
+	EnvDevelopment: {
+		"MONGO_DATABASE":       "users-dev",
+		"MIGRATE_ON_START":     true,
+		"NOTIFICATIONS_DRIVER": "log",
+		"CORS_ALLOWED_ORIGINS": "*",
+	},
+	EnvStaging: {
+		"MONGO_DATABASE":       "ayudapp-staging",
+		"MIGRATE_ON_START":     true,
+		"NOTIFICATIONS_DRIVER": "live",
+	},
+	EnvProduction: {
+		"MONGO_DATABASE":       "ayudapp",
+		"MIGRATE_ON_START":     false,
+		"NOTIFICATIONS_DRIVER": "live",
+	},
 }
 
 /** Lee la configuración del archivo o de las variables de entorno
+ *
+ * El archivo app.env es opcional; en los contenedores la configuración se toma de las
+ * variables de entorno. Los valores no definidos toman el valor predeterminado del
+ * entorno indicado en APP_ENV y la configuración resultante se valida antes de
+ * devolverla.
  *
  * @param path string "Ruta del archivo de configuración"
  * @return config Config "Configuración leída"
- * @return err error "Error de lectura de la configuración"
+ * @return err error "Error de lectura o de validación de la configuración"
  */
 func LoadConfig(path string) (config Config, err error) {
 	viper.AddConfigPath(path)
 	viper.SetConfigName("app")
 	viper.SetConfigType("env")
 
-	viper.AutomaticEnv()
+	// Registra todas las variables para leerlas del entorno aunque no exista app.env
+	fields := reflect.TypeOf(config)
+	for i := 0; i < fields.NumField(); i++ {
+		if err = viper.BindEnv(fields.Field(i).Tag.Get("mapstructure")); err != nil {
+			return
+		}
+	}
+
+	viper.SetDefault("APP_ENV", EnvDevelopment)
+	viper.SetDefault("APP_PORT", "8080")
+	viper.SetDefault("DB_TIMEOUT", 10*time.Second)
+	viper.SetDefault("SHUTDOWN_TIMEOUT", 15*time.Second)
+	viper.SetDefault("SCHEDULER_POLL_INTERVAL", 30*time.Second)
+	viper.SetDefault("STORAGE_DRIVER", "local")
+	viper.SetDefault("STORAGE_LOCAL_PATH", "uploads")
+	viper.SetDefault("STORAGE_PUBLIC_URL", "/uploads")
+	viper.SetDefault("PROFILE_IMAGE_MAX_SIZE", 5<<20)
+	viper.SetDefault("ATTACHMENT_MAX_SIZE", 10<<20)
+	viper.SetDefault("STATS_CACHE_TTL", time.Minute)
+	viper.SetDefault("IDEMPOTENCY_TTL", 24*time.Hour)
+
+	if err = viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return
+		}
+	}
+
+	for key, value := range environmentDefaults[viper.GetString("APP_ENV")] {
+		viper.SetDefault(key, value)
+	}
 
-	err = viper.ReadInConfig()
-	if err != nil {
+	if err = viper.Unmarshal(&config); err != nil {
 		return
 	}
 
-	err = viper.Unmarshal(&config)
+	err = config.Validate()
+	return
+}
+
+// Indica si la aplicación se ejecuta en producción
+func (config Config) IsProduction() bool {
+	return config.Environment == EnvProduction
+}
+
+/** Valida que la configuración esté completa y sea segura para el entorno
+ *
+ * En staging y producción además exige orígenes CORS explícitos y el envío real de
+ * avisos.
+ *
+ * @return err error "Los problemas encontrados en la configuración"
+ */
+func (config Config) Validate() (err error) {
+	var problems []string
+
+	if _, ok := environmentDefaults[config.Environment]; !ok {
+		problems = append(problems, fmt.Sprintf("APP_ENV debe ser %s, %s o %s", EnvDevelopment, EnvStaging, EnvProduction))
+	}
+
+	if config.MongoURI == "" {
+		problems = append(problems, "MONGO_URI es requerido")
+	}
+
+	if config.DatabaseName == "" {
+		problems = append(problems, "MONGO_DATABASE es requerido")
+	}
+
+	if len(config.SecretKey) < 32 {
+		problems = append(problems, "SESSION_SECRET_KEY debe tener al menos 32 caracteres")
+	}
+
+	if config.AccessTokenDuration <= 0 || config.RefreshTokenDuration <= 0 {
+		problems = append(problems, "ACCESS_TOKEN_DURATION y REFRESH_TOKEN_DURATION son requeridos")
+	}
+
+	if config.IdempotencyTTL <= 0 {
+		problems = append(problems, "IDEMPOTENCY_TTL debe ser mayor que cero")
+	}
+
+	if config.Environment == EnvStaging || config.Environment == EnvProduction {
+		if len(config.CorsAllowedOrigins) == 0 {
+			problems = append(problems, "CORS_ALLOWED_ORIGINS es requerido fuera de desarrollo")
+		}
+
+		for _, origin := range config.CorsAllowedOrigins {
+			if origin == "*" {
+				problems = append(problems, "CORS_ALLOWED_ORIGINS no puede permitir cualquier origen fuera de desarrollo")
+				break
+			}
+		}
+
+		if config.NotificationsDriver == "log" {
+			problems = append(problems, "NOTIFICATIONS_DRIVER=log sólo se permite en desarrollo")
+		}
+
+		if config.SMTPHost == "" {
+			problems = append(problems, "SMTP_HOST es requerido fuera de desarrollo")
+		}
+	}
+
+	if len(problems) > 0 {
+		err = errors.New("configuración inválida: " + strings.Join(problems, "; "))
+	}
+
 	return
 }
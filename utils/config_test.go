@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Configuración válida de producción, que cada caso modifica
+func validConfig() Config {
+	return Config{
+		Environment:          EnvProduction,
+		MongoURI:             "mongodb://localhost:27017",
+		DatabaseName:         "ayudapp",
+		CorsAllowedOrigins:   []string{"https://admin.ayudapp.test"},
+		SecretKey:            "12345678901234567890123456789012",
+		AccessTokenDuration:  15 * time.Minute,
+		RefreshTokenDuration: 24 * time.Hour,
+		SMTPHost:             "smtp.ayudapp.test",
+		NotificationsDriver:  "live",
+		IdempotencyTTL:       24 * time.Hour,
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(config *Config)
+		// Texto que debe incluir el error; vacío si la configuración es válida
+		want string
+	}{
+		{
+			name:   "válida",
+			modify: func(config *Config) {},
+		},
+		{
+			name: "desarrollo permite cualquier origen y avisos registrados",
+			modify: func(config *Config) {
+				config.Environment = EnvDevelopment
+				config.CorsAllowedOrigins = []string{"*"}
+				config.NotificationsDriver = "log"
+				config.SMTPHost = ""
+			},
+		},
+		{
+			name:   "entorno desconocido",
+			modify: func(config *Config) { config.Environment = "test" },
+			want:   "APP_ENV",
+		},
+		{
+			name:   "sin base de datos",
+			modify: func(config *Config) { config.MongoURI = "" },
+			want:   "MONGO_URI",
+		},
+		{
+			name:   "sin nombre de base de datos",
+			modify: func(config *Config) { config.DatabaseName = "" },
+			want:   "MONGO_DATABASE",
+		},
+		{
+			name:   "clave secreta corta",
+			modify: func(config *Config) { config.SecretKey = "secreto" },
+			want:   "SESSION_SECRET_KEY",
+		},
+		{
+			name:   "sin duración de tokens",
+			modify: func(config *Config) { config.RefreshTokenDuration = 0 },
+			want:   "REFRESH_TOKEN_DURATION",
+		},
+		{
+			name:   "sin orígenes CORS en producción",
+			modify: func(config *Config) { config.CorsAllowedOrigins = nil },
+			want:   "CORS_ALLOWED_ORIGINS es requerido",
+		},
+		{
+			name: "cualquier origen en staging",
+			modify: func(config *Config) {
+				config.Environment = EnvStaging
+				config.CorsAllowedOrigins = []string{"https://admin.ayudapp.test", "*"}
+			},
+			want: "no puede permitir cualquier origen",
+		},
+		{
+			name:   "avisos registrados en producción",
+			modify: func(config *Config) { config.NotificationsDriver = "log" },
+			want:   "NOTIFICATIONS_DRIVER",
+		},
+		{
+			name:   "sin SMTP en producción",
+			modify: func(config *Config) { config.SMTPHost = "" },
+			want:   "SMTP_HOST",
+		},
+		{
+			name:   "sin duración de las claves de idempotencia",
+			modify: func(config *Config) { config.IdempotencyTTL = 0 },
+			want:   "IDEMPOTENCY_TTL",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := validConfig()
+			test.modify(&config)
+
+			err := config.Validate()
+			if test.want == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Validate() error = %v, want it to mention %q", err, test.want)
+			}
+		})
+	}
+}
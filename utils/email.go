@@ -1,19 +1,32 @@
 package utils
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
 	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
 )
 
 type SendEmailRequest struct {
-	Sender    string `json:"sender"`
-	Recipient string `json:"recipient"`
-	Subject   string `json:"subject"`
-	Message   string `json:"message"`
+	Sender      string `json:"sender"`
+	Recipient   string `json:"recipient"`
+	Subject     string `json:"subject"`
+	Message     string `json:"message"`
+	HTMLMessage string `json:"html_message"`
 }
 
 type SendEmailResponse struct {
-	Sent bool `json:"sent"`
+	Sent   bool `json:"sent"`
+	Queued bool `json:"queued"`
 }
 
 type EmailService struct {
@@ -29,12 +42,17 @@ func (service *EmailService) SendEmail(req SendEmailRequest) (resp SendEmailResp
 
 	smtpAddr := fmt.Sprintf("%s:%d", service.config.SMTPHost, service.config.SMTPPort)
 
+	message, err := formatMessage(req)
+	if err != nil {
+		return
+	}
+
 	err = smtp.SendMail(
 		smtpAddr,
 		auth,
-		req.Recipient,
-		[]string{req.Sender},
-		formatMessage(req.Sender, req.Recipient, req.Subject, req.Message),
+		envelopeAddress(req.Sender),
+		[]string{envelopeAddress(req.Recipient)},
+		message,
 	)
 	resp.Sent = err == nil
 
@@ -46,11 +64,18 @@ func NewEmailService(config Config) IEmailService {
 }
 
 /** Devuelve la autenticación del servicio SMTP
+ *
+ * Si no hay usuario configurado se envía sin autenticación, como en un servidor
+ * SMTP local de pruebas.
  *
  * @param config Config La configuración de la aplicación
  * @return smtp.Auth La autenticación
  */
 func smtpAuthenticate(config Config) smtp.Auth {
+	if config.SMTPUser == "" {
+		return nil
+	}
+
 	return smtp.PlainAuth(
 		"",
 		config.SMTPUser,
@@ -59,17 +84,138 @@ func smtpAuthenticate(config Config) smtp.Auth {
 	)
 }
 
-/** Devuelve una cadena de texto con el cuerpo del mensaje
+/** Devuelve el mensaje completo con sus cabeceras según RFC 5322 y MIME
+ *
+ * El asunto se codifica según RFC 2047 para admitir acentos y los cuerpos se envían
+ * en UTF-8 con codificación quoted-printable. Si el mensaje tiene versión HTML se
+ * envía como multipart/alternative.
+ *
+ * @param req SendEmailRequest Los datos del mensaje
+ * @return []byte El mensaje
+ * @return error El error al generar el mensaje
+ */
+func formatMessage(req SendEmailRequest) ([]byte, error) {
+	var message bytes.Buffer
+
+	headers := []string{
+		"From: " + formatAddress(req.Sender),
+		"To: " + formatAddress(req.Recipient),
+		"Subject: " + mime.QEncoding.Encode("utf-8", req.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + newMessageID(req.Sender),
+		"MIME-Version: 1.0",
+	}
+
+	if req.HTMLMessage == "" {
+		headers = append(headers,
+			"Content-Type: text/plain; charset=UTF-8",
+			"Content-Transfer-Encoding: quoted-printable",
+		)
+		message.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+		if err := writeQuotedPrintable(&message, req.Message); err != nil {
+			return nil, err
+		}
+
+		return message.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	headers = append(headers, fmt.Sprintf("Content-Type: multipart/alternative; boundary=%q", writer.Boundary()))
+	message.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", req.Message},
+		{"text/html; charset=UTF-8", req.HTMLMessage},
+	}
+
+	for _, part := range parts {
+		partWriter, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err = writeQuotedPrintable(partWriter, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+/** Escribe un texto con codificación quoted-printable y saltos de línea CRLF
+ *
+ * @param w io.Writer El destino
+ * @param content string El texto
+ * @return error El error de escritura
+ */
+func writeQuotedPrintable(w io.Writer, content string) error {
+	encoder := quotedprintable.NewWriter(w)
+	if _, err := encoder.Write([]byte(content)); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+/** Codifica una dirección de correo con nombre según RFC 2047
+ *
+ * @param address string La dirección, con o sin nombre
+ * @return string La dirección codificada
+ */
+func formatAddress(address string) string {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return address
+	}
+
+	return parsed.String()
+}
+
+/** Devuelve sólo la dirección de correo, sin el nombre, para el sobre SMTP
  *
- * @param from string Remitente
- * @param to string Receptor
- * @param subject string Asunto del mensaje
- * @param message string Contenido del mensaje
- * @return []byte El cuerpo del mensaje
+ * @param address string La dirección, con o sin nombre
+ * @return string La dirección de correo
  */
-func formatMessage(from, to, subject, body string) []byte {
-	return []byte("From: " + from + "\r\n" +
-		"To: " + to + "\r\n" +
-		"Subject: " + subject + "\r\n" +
-		body + "\r\n")
+func envelopeAddress(address string) string {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return address
+	}
+
+	return parsed.Address
+}
+
+/** Genera un identificador único de mensaje en el dominio del remitente
+ *
+ * @param sender string La dirección del remitente
+ * @return string El identificador del mensaje
+ */
+func newMessageID(sender string) string {
+	domain := "localhost"
+	if parsed, err := mail.ParseAddress(sender); err == nil {
+		if at := strings.LastIndex(parsed.Address, "@"); at >= 0 {
+			domain = parsed.Address[at+1:]
+		}
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("<%d@%s>", time.Now().UnixNano(), domain)
+	}
+
+	return fmt.Sprintf("<%s.%d@%s>", hex.EncodeToString(id), time.Now().Unix(), domain)
 }
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed email_templates
+var emailTemplatesFS embed.FS
+
+// Datos disponibles en las plantillas de correo electrónico
+type EmailTemplateData struct {
+	RecipientName  string `json:"recipient_name"`
+	Date           string `json:"date"`
+	Time           string `json:"time"`
+	Address        string `json:"address"`
+	PreviousHelper bool   `json:"previous_helper"`
+}
+
+// Correo electrónico generado a partir de una plantilla
+type RenderedEmail struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+type emailTemplateContext struct {
+	EmailTemplateData
+	Lang    string
+	Subject string
+}
+
+/** Genera el asunto y los cuerpos de texto y HTML de una plantilla de correo electrónico
+ *
+ * Si la plantilla no existe en el idioma indicado se usa el idioma predeterminado.
+ *
+ * @param name string "El nombre de la plantilla"
+ * @param lang string "El código del idioma"
+ * @param data EmailTemplateData "Los datos de la plantilla"
+ * @return email RenderedEmail "El correo electrónico generado"
+ * @return err error "El error de la operación"
+ */
+func RenderEmail(name string, lang string, data EmailTemplateData) (email RenderedEmail, err error) {
+	if !IsSupportedLanguage(lang) || !emailTemplateExists(name, lang) {
+		lang = DefaultLanguage
+	}
+
+	if !emailTemplateExists(name, lang) {
+		err = fmt.Errorf("la plantilla de correo electrónico %s no existe", name)
+		return
+	}
+
+	templateContext := emailTemplateContext{EmailTemplateData: data, Lang: lang}
+
+	textTemplate, err := texttemplate.ParseFS(emailTemplatesFS, path.Join("email_templates", lang, name+".txt"))
+	if err != nil {
+		return
+	}
+
+	var buffer bytes.Buffer
+	if err = textTemplate.ExecuteTemplate(&buffer, "subject", templateContext); err != nil {
+		return
+	}
+	email.Subject = strings.TrimSpace(buffer.String())
+	templateContext.Subject = email.Subject
+
+	buffer.Reset()
+	if err = textTemplate.ExecuteTemplate(&buffer, "text", templateContext); err != nil {
+		return
+	}
+	email.Text = strings.TrimSpace(buffer.String())
+
+	htmlTemplate, err := htmltemplate.ParseFS(emailTemplatesFS,
+		path.Join("email_templates", "layout.html"),
+		path.Join("email_templates", lang, name+".html"),
+	)
+	if err != nil {
+		return
+	}
+
+	buffer.Reset()
+	if err = htmlTemplate.ExecuteTemplate(&buffer, "layout", templateContext); err != nil {
+		return
+	}
+	email.HTML = buffer.String()
+
+	return
+}
+
+/** Obtiene los nombres de las plantillas de correo electrónico disponibles
+ *
+ * @return []string "Los nombres de las plantillas"
+ */
+func EmailTemplateNames() []string {
+	entries, err := fs.ReadDir(emailTemplatesFS, path.Join("email_templates", DefaultLanguage))
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if name := strings.TrimSuffix(entry.Name(), ".txt"); name != entry.Name() {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Devuelve datos de ejemplo para previsualizar las plantillas
+func SampleEmailTemplateData() EmailTemplateData {
+	return EmailTemplateData{
+		RecipientName: "María",
+		Date:          "24/06/2022",
+		Time:          "15:30",
+		Address:       "Av. 18 de Julio 1234, Montevideo",
+	}
+}
+
+// Indica si existe una plantilla de correo electrónico, al menos en el idioma predeterminado
+func EmailTemplateExists(name string) bool {
+	return emailTemplateExists(name, DefaultLanguage)
+}
+
+func emailTemplateExists(name string, lang string) bool {
+	_, err := fs.Stat(emailTemplatesFS, path.Join("email_templates", lang, name+".txt"))
+	return err == nil
+}
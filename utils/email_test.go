@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/maferuy/ayudapp-admin-backend-core/utils/smtptest"
+)
+
+// Inicia un servidor SMTP de pruebas y un EmailService que envía a él
+func newTestEmailService(t *testing.T) (*smtptest.Server, IEmailService) {
+	t.Helper()
+
+	server, err := smtptest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	return server, NewEmailService(Config{SMTPHost: server.Host(), SMTPPort: server.Port()})
+}
+
+// Decodifica un cuerpo quoted-printable
+func decodeQuotedPrintable(t *testing.T, r io.Reader) string {
+	t.Helper()
+
+	data, err := io.ReadAll(quotedprintable.NewReader(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestEmailServiceSendPlainText(t *testing.T) {
+	server, service := newTestEmailService(t)
+
+	resp, err := service.SendEmail(SendEmailRequest{
+		Sender:    "Ayudapp <no-reply@ayudapp.com>",
+		Recipient: "José Pérez <jose@example.com>",
+		Subject:   "Tu cita está confirmada",
+		Message:   "Hola José, tu cita del miércoles está confirmada.",
+	})
+	if err != nil || !resp.Sent {
+		t.Fatalf("SendEmail() = %+v, %v, want sent", resp, err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("mensajes recibidos = %d, want 1", len(messages))
+	}
+
+	// El sobre SMTP lleva sólo las direcciones, sin los nombres
+	if messages[0].From != "no-reply@ayudapp.com" || !reflect.DeepEqual(messages[0].To, []string{"jose@example.com"}) {
+		t.Errorf("sobre = %q -> %q, want no-reply@ayudapp.com -> [jose@example.com]", messages[0].From, messages[0].To)
+	}
+
+	message, err := mail.ReadMessage(bytes.NewReader(messages[0].Data))
+	if err != nil {
+		t.Fatalf("el mensaje no cumple RFC 5322: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil || subject != "Tu cita está confirmada" {
+		t.Errorf("Subject = %q, %v, want %q", subject, err, "Tu cita está confirmada")
+	}
+
+	to, err := message.Header.AddressList("To")
+	if err != nil || len(to) != 1 || to[0].Name != "José Pérez" || to[0].Address != "jose@example.com" {
+		t.Errorf("To = %v, %v, want José Pérez <jose@example.com>", to, err)
+	}
+
+	for header, want := range map[string]string{
+		"MIME-Version":              "1.0",
+		"Content-Type":              "text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding": "quoted-printable",
+	} {
+		if got := message.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	if id := message.Header.Get("Message-ID"); !strings.HasSuffix(id, "@ayudapp.com>") {
+		t.Errorf("Message-ID = %q, want en el dominio del remitente", id)
+	}
+	if _, err = message.Header.Date(); err != nil {
+		t.Errorf("Date inválida: %v", err)
+	}
+
+	// El cliente SMTP termina el contenido con un salto de línea
+	body := strings.TrimSuffix(decodeQuotedPrintable(t, message.Body), "\r\n")
+	if body != "Hola José, tu cita del miércoles está confirmada." {
+		t.Errorf("cuerpo = %q", body)
+	}
+}
+
+func TestEmailServiceSendHTML(t *testing.T) {
+	server, service := newTestEmailService(t)
+
+	_, err := service.SendEmail(SendEmailRequest{
+		Sender:      "no-reply@ayudapp.com",
+		Recipient:   "ana@example.com",
+		Subject:     "Bienvenida",
+		Message:     "Hola Ana",
+		HTMLMessage: "<p>Hola <b>Ana</b></p>",
+	})
+	if err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+
+	message, err := mail.ReadMessage(bytes.NewReader(server.Messages()[0].Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, %v, want multipart/alternative", mediaType, err)
+	}
+
+	want := []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", "Hola Ana"},
+		{"text/html; charset=UTF-8", "<p>Hola <b>Ana</b></p>"},
+	}
+
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for i := 0; ; i++ {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			if i != len(want) {
+				t.Errorf("partes = %d, want %d", i, len(want))
+			}
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if i >= len(want) {
+			t.Fatalf("parte de más: %q", part.Header.Get("Content-Type"))
+		}
+
+		if got := part.Header.Get("Content-Type"); got != want[i].contentType {
+			t.Errorf("parte %d Content-Type = %q, want %q", i, got, want[i].contentType)
+		}
+		if body := decodeQuotedPrintable(t, part); body != want[i].body {
+			t.Errorf("parte %d = %q, want %q", i, body, want[i].body)
+		}
+	}
+}
+
+func TestEmailServiceRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		failure smtptest.Failure
+	}{
+		{"error temporal", smtptest.Failure{Code: 451, Message: "4.3.0 Try again later"}},
+		{"rechazo permanente", smtptest.Failure{Code: 550, Message: "5.1.1 User unknown"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, service := newTestEmailService(t)
+			server.Fail(test.failure)
+
+			resp, err := service.SendEmail(SendEmailRequest{Sender: "no-reply@ayudapp.com", Recipient: "ana@example.com"})
+
+			// El código SMTP se conserva para distinguir los errores temporales de los permanentes
+			var protocolErr *textproto.Error
+			if !errors.As(err, &protocolErr) || protocolErr.Code != test.failure.Code {
+				t.Errorf("SendEmail() error = %v, want código %d", err, test.failure.Code)
+			}
+			if resp.Sent {
+				t.Error("SendEmail() Sent = true, want false")
+			}
+			if messages := server.Messages(); len(messages) != 0 {
+				t.Errorf("mensajes recibidos = %d, want 0", len(messages))
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+
+	// Decodificadores de los formatos de imagen admitidos
+	_ "image/gif"
+	_ "image/png"
+)
+
+// Tamaño máximo en píxeles de una imagen subida, para evitar imágenes que ocupen demasiada memoria al decodificarse
+const maxImagePixels = 40 * 1000 * 1000
+
+// Tipos de imagen admitidos, detectados a partir del contenido y no de la extensión
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+/** Decodifica una imagen subida, comprobando su tipo real y sus dimensiones
+ *
+ * @param data []byte "El contenido del archivo"
+ * @return image.Image "La imagen"
+ * @return error "Error si el archivo no es una imagen admitida"
+ */
+func DecodeImage(data []byte) (image.Image, error) {
+	if !allowedImageTypes[http.DetectContentType(data)] {
+		return nil, errors.New("el archivo debe ser una imagen JPEG, PNG o GIF")
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("la imagen está dañada")
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxImagePixels {
+		return nil, errors.New("las dimensiones de la imagen son demasiado grandes")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("la imagen está dañada")
+	}
+
+	return img, nil
+}
+
+/** Genera una miniatura cuadrada de una imagen, recortada al centro, en formato JPEG
+ *
+ * Las imágenes más chicas que el tamaño pedido no se agrandan. Las zonas
+ * transparentes se rellenan de blanco.
+ *
+ * @param img image.Image "La imagen"
+ * @param size int "El lado de la miniatura en píxeles"
+ * @return []byte "La miniatura en formato JPEG"
+ * @return error "El error de codificación"
+ */
+func SquareThumbnail(img image.Image, size int) ([]byte, error) {
+	bounds := img.Bounds()
+
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	if size > side {
+		size = side
+	}
+
+	// Recorte cuadrado centrado, sobre fondo blanco
+	crop := image.Rect(0, 0, side, side)
+	offset := image.Pt(bounds.Min.X+(bounds.Dx()-side)/2, bounds.Min.Y+(bounds.Dy()-side)/2)
+	source := image.NewRGBA(crop)
+	draw.Draw(source, crop, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(source, crop, img, offset, draw.Over)
+
+	thumbnail := resizeArea(source, size)
+
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, thumbnail, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+/** Reduce una imagen cuadrada promediando el área de píxeles que cubre cada píxel de destino
+ *
+ * @param source *image.RGBA "La imagen cuadrada"
+ * @param size int "El lado de la imagen reducida"
+ * @return *image.RGBA "La imagen reducida"
+ */
+func resizeArea(source *image.RGBA, size int) *image.RGBA {
+	side := source.Bounds().Dx()
+	if size == side {
+		return source
+	}
+
+	resized := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := y*side/size, (y+1)*side/size
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < size; x++ {
+			x0, x1 := x*side/size, (x+1)*side/size
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				row := source.Pix[sy*source.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					pixel := row[sx*4 : sx*4+4]
+					r += uint64(pixel[0])
+					g += uint64(pixel[1])
+					b += uint64(pixel[2])
+					a += uint64(pixel[3])
+					count++
+				}
+			}
+
+			offset := y*resized.Stride + x*4
+			resized.Pix[offset] = uint8(r / count)
+			resized.Pix[offset+1] = uint8(g / count)
+			resized.Pix[offset+2] = uint8(b / count)
+			resized.Pix[offset+3] = uint8(a / count)
+		}
+	}
+
+	return resized
+}
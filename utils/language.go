@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// Idioma predeterminado de los contenidos, usado cuando no existe traducción
+const DefaultLanguage = "es"
+
+// Idiomas en los que se pueden traducir los contenidos
+var SupportedLanguages = []string{DefaultLanguage, "pt"}
+
+var languageMatcher = language.NewMatcher([]language.Tag{
+	language.Spanish,
+	language.Portuguese,
+})
+
+/** Obtiene el idioma de la petición a partir del parámetro "lang" o de la cabecera Accept-Language
+ *
+ * @param ctx *gin.Context "El contexto de la petición"
+ * @return string "El código del idioma soportado, o el idioma predeterminado"
+ */
+func ResolveLanguage(ctx *gin.Context) string {
+	if lang := ctx.Query("lang"); lang != "" {
+		if tag, err := language.Parse(lang); err == nil {
+			if _, index, confidence := languageMatcher.Match(tag); confidence != language.No {
+				return SupportedLanguages[index]
+			}
+		}
+
+		return DefaultLanguage
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(ctx.GetHeader("Accept-Language"))
+	if err != nil || len(tags) == 0 {
+		return DefaultLanguage
+	}
+
+	if _, index, confidence := languageMatcher.Match(tags...); confidence != language.No {
+		return SupportedLanguages[index]
+	}
+
+	return DefaultLanguage
+}
+
+/** Indica si un idioma está soportado
+ *
+ * @param lang string "El código del idioma"
+ * @return bool "Si el idioma está soportado"
+ */
+func IsSupportedLanguage(lang string) bool {
+	for _, supported := range SupportedLanguages {
+		if supported == lang {
+			return true
+		}
+	}
+
+	return false
+}
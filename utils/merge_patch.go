@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"encoding/json"
+)
+
+// Tipo de contenido de los parches JSON Merge Patch (RFC 7396)
+const MergePatchContentType = "application/merge-patch+json"
+
+/** Aplica un parche JSON Merge Patch (RFC 7396) a un documento JSON
+ *
+ * Los campos del parche reemplazan a los del documento, los campos con valor null se
+ * eliminan y los objetos se combinan recursivamente. Un parche que no es un objeto
+ * reemplaza al documento completo.
+ *
+ * @param document []byte "El documento JSON original"
+ * @param patch []byte "El parche JSON"
+ * @return []byte "El documento con el parche aplicado"
+ * @return error "El error si el documento o el parche no son JSON válido"
+ */
+func MergePatch(document []byte, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(document, &target); err != nil {
+		return nil, err
+	}
+
+	var changes interface{}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergePatch(target, changes))
+}
+
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	result, ok := target.(map[string]interface{})
+	if !ok {
+		result = make(map[string]interface{}, len(changes))
+	}
+
+	for key, value := range changes {
+		if value == nil {
+			delete(result, key)
+		} else {
+			result[key] = mergePatch(result[key], value)
+		}
+	}
+
+	return result
+}
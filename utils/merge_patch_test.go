@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// Ejemplos del apéndice A del RFC 7396
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		document string
+		patch    string
+		want     string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, test := range tests {
+		got, err := MergePatch([]byte(test.document), []byte(test.patch))
+		if err != nil {
+			t.Errorf("MergePatch(%s, %s) error = %v", test.document, test.patch, err)
+			continue
+		}
+
+		var gotValue, wantValue interface{}
+		if err = json.Unmarshal(got, &gotValue); err != nil {
+			t.Fatal(err)
+		}
+		if err = json.Unmarshal([]byte(test.want), &wantValue); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(gotValue, wantValue) {
+			t.Errorf("MergePatch(%s, %s) = %s, want %s", test.document, test.patch, got, test.want)
+		}
+	}
+}
+
+func TestMergePatchInvalidJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		patch    string
+	}{
+		{"documento inválido", `{"a":`, `{}`},
+		{"parche inválido", `{}`, `{"a":}`},
+		{"parche vacío", `{}`, ``},
+	}
+
+	for _, test := range tests {
+		if _, err := MergePatch([]byte(test.document), []byte(test.patch)); err == nil {
+			t.Errorf("%s: MergePatch() error = nil, want error", test.name)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+/** Genera un identificador apto para URLs a partir de un texto
+ *
+ * Quita los acentos y diacríticos ("Limpieza Básica" -> "limpieza-basica"), pasa
+ * el texto a minúsculas y reemplaza cualquier otro carácter por guiones.
+ *
+ * @param text string "El texto original"
+ * @return string "El slug generado"
+ */
+func Slugify(text string) string {
+	folder := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(folder, text)
+	if err != nil {
+		folded = text
+	}
+
+	var builder strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(folded) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			builder.WriteRune(r)
+			dash = false
+		} else if !dash && builder.Len() > 0 {
+			builder.WriteRune('-')
+			dash = true
+		}
+	}
+
+	return strings.TrimSuffix(builder.String(), "-")
+}
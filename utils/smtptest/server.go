@@ -0,0 +1,243 @@
+// Package smtptest provee un servidor SMTP en memoria para probar el envío de correos
+// sin depender de un servidor externo.
+package smtptest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Mensaje recibido por el servidor
+type Message struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+// Respuesta de error que el servidor devuelve en lugar de aceptar un mensaje
+type Failure struct {
+	Code    int
+	Message string
+}
+
+type Server struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	messages []Message
+	failures []Failure
+	wg       sync.WaitGroup
+}
+
+/** Inicia un servidor SMTP en una dirección local con un puerto libre
+ *
+ * @return *Server "El servidor"
+ * @return error "El error al abrir el puerto"
+ */
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{listener: listener}
+
+	server.wg.Add(1)
+	go server.serve()
+
+	return server, nil
+}
+
+// Devuelve la dirección del servidor en formato host:puerto
+func (server *Server) Addr() string {
+	return server.listener.Addr().String()
+}
+
+// Devuelve el host del servidor
+func (server *Server) Host() string {
+	host, _, _ := net.SplitHostPort(server.Addr())
+	return host
+}
+
+// Devuelve el puerto del servidor
+func (server *Server) Port() int {
+	_, port, _ := net.SplitHostPort(server.Addr())
+	number, _ := strconv.Atoi(port)
+	return number
+}
+
+// Devuelve una copia de los mensajes recibidos
+func (server *Server) Messages() []Message {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	messages := make([]Message, len(server.messages))
+	copy(messages, server.messages)
+	return messages
+}
+
+/** Hace que los próximos mensajes se rechacen con las respuestas indicadas, en orden
+ *
+ * Un código 4xx simula un error temporal y un código 5xx un rechazo permanente.
+ *
+ * @param failures ...Failure "Las respuestas de error"
+ */
+func (server *Server) Fail(failures ...Failure) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.failures = append(server.failures, failures...)
+}
+
+// Detiene el servidor y espera a que terminen las conexiones abiertas
+func (server *Server) Close() error {
+	err := server.listener.Close()
+	server.wg.Wait()
+	return err
+}
+
+func (server *Server) serve() {
+	defer server.wg.Done()
+
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		server.wg.Add(1)
+		go func() {
+			defer server.wg.Done()
+			server.handle(conn)
+		}()
+	}
+}
+
+/** Atiende una sesión SMTP con los comandos básicos de RFC 5321
+ *
+ * @param conn net.Conn "La conexión del cliente"
+ */
+func (server *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(format string, args ...interface{}) {
+		fmt.Fprintf(conn, format+"\r\n", args...)
+	}
+
+	var message Message
+	reply("220 smtptest ESMTP")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"):
+			reply("250-smtptest")
+			reply("250-8BITMIME")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(command, "HELO"):
+			reply("250 smtptest")
+		case strings.HasPrefix(command, "AUTH"):
+			reply("235 2.7.0 Authentication successful")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			message = Message{From: trimPath(line[len("MAIL FROM:"):])}
+			reply("250 2.1.0 Ok")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			if failure, ok := server.nextFailure(); ok {
+				reply("%d %s", failure.Code, failure.Message)
+				continue
+			}
+
+			message.To = append(message.To, trimPath(line[len("RCPT TO:"):]))
+			reply("250 2.1.5 Ok")
+		case command == "DATA":
+			if len(message.To) == 0 {
+				reply("503 5.5.1 No valid recipients")
+				continue
+			}
+
+			reply("354 End data with <CR><LF>.<CR><LF>")
+
+			data, err := readData(reader)
+			if err != nil {
+				return
+			}
+
+			message.Data = data
+			server.mu.Lock()
+			server.messages = append(server.messages, message)
+			server.mu.Unlock()
+
+			message = Message{}
+			reply("250 2.0.0 Ok: queued")
+		case command == "RSET":
+			message = Message{}
+			reply("250 2.0.0 Ok")
+		case command == "NOOP":
+			reply("250 2.0.0 Ok")
+		case command == "QUIT":
+			reply("221 2.0.0 Bye")
+			return
+		default:
+			reply("502 5.5.2 Command not recognized")
+		}
+	}
+}
+
+func (server *Server) nextFailure() (Failure, bool) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if len(server.failures) == 0 {
+		return Failure{}, false
+	}
+
+	failure := server.failures[0]
+	server.failures = server.failures[1:]
+	return failure, true
+}
+
+/** Lee el contenido de un mensaje hasta la línea con un único punto
+ *
+ * @param reader *bufio.Reader "La conexión del cliente"
+ * @return []byte "El contenido del mensaje"
+ * @return error "El error de lectura"
+ */
+func readData(reader *bufio.Reader) ([]byte, error) {
+	var data []byte
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		if line == ".\r\n" || line == ".\n" {
+			return data, nil
+		}
+
+		// Quita el punto agregado por el cliente al inicio de las líneas
+		line = strings.TrimPrefix(line, ".")
+		data = append(data, line...)
+	}
+}
+
+func trimPath(path string) string {
+	path = strings.TrimSpace(path)
+	if end := strings.Index(path, ">"); end >= 0 {
+		path = path[:end+1]
+	}
+
+	return strings.Trim(path, "<>")
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+)
+
+/** Lee las filas de un archivo CSV o XLSX
+ *
+ * El formato se detecta por el contenido. En los CSV se admite la coma o el punto
+ * y coma como separador, como los que exporta Excel en español.
+ *
+ * @param data []byte "El contenido del archivo"
+ * @return [][]string "Las filas del archivo, incluida la de encabezados"
+ * @return error "Error si el archivo no se puede leer"
+ */
+func ReadSpreadsheet(data []byte) ([][]string, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return ReadXLSX(data)
+	}
+
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	firstLine := data
+	if end := bytes.IndexByte(data, '\n'); end >= 0 {
+		firstLine = data[:end]
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.New("el archivo CSV es inválido: " + err.Error())
+	}
+
+	return rows, nil
+}
+
+// Normaliza un encabezado de columna para compararlo sin distinguir mayúsculas, espacios ni acentos
+func NormalizeHeader(header string) string {
+	return strings.ReplaceAll(Slugify(header), "-", "_")
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 )
@@ -19,6 +21,11 @@ func SuccessResponse(data interface{}) gin.H {
 	}
 }
 
+// Normaliza un correo electrónico para guardarlo y compararlo: sin espacios y en minúsculas
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 // Convierte un modelo en una documento BSON
 func ToDoc(v interface{}) (doc *bson.D, err error) {
 	data, err := bson.Marshal(v)
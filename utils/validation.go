@@ -0,0 +1,288 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	esTranslations "github.com/go-playground/validator/v10/translations/es"
+	"github.com/maferuy/ayudapp-admin-backend-core/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/language"
+)
+
+// Error de validación de un campo de la solicitud
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Valores permitidos de los campos enumerados, por nombre de validación
+var validationEnums = map[string][]string{
+	"user_type": {
+		models.UserTypeUser,
+		models.UserTypeHelper,
+		models.UserTypeAdmin,
+		models.UserTypeSuperadmin,
+	},
+	"user_status": {
+		models.UserStatusActive,
+		models.UserStatusInactive,
+		models.UserStatusPending,
+	},
+	"appointment_status": {
+		models.AppointmentStatusPending,
+		models.AppointmentStatusConfirmed,
+		models.AppointmentStatusCompleted,
+		models.AppointmentStatusCancelled,
+		models.AppointmentStatusNoShow,
+	},
+	"notification_channel": {
+		models.NotificationChannelEmail,
+		models.NotificationChannelSMS,
+		models.NotificationChannelPush,
+	},
+}
+
+// Mensajes de las validaciones propias, por idioma
+var validationMessages = map[string]map[string]string{
+	"es": {
+		"objectid": "{0} debe ser un id válido",
+		"future":   "{0} debe ser una fecha futura",
+		"language": "{0} debe ser un idioma soportado: {1}",
+		"enum":     "{0} debe ser uno de: {1}",
+	},
+	"en": {
+		"objectid": "{0} must be a valid id",
+		"future":   "{0} must be a future date",
+		"language": "{0} must be a supported language: {1}",
+		"enum":     "{0} must be one of: {1}",
+	},
+}
+
+// Idioma predeterminado de los mensajes de validación
+const defaultValidationLanguage = "es"
+
+var validationLanguageMatcher = language.NewMatcher([]language.Tag{
+	language.Spanish,
+	language.English,
+})
+
+var (
+	validationTranslator *ut.UniversalTranslator
+	validationOnce       sync.Once
+	validationErr        error
+)
+
+/** Registra las validaciones propias y los mensajes en español e inglés en el validador de Gin
+ *
+ * Se puede llamar varias veces; el registro sólo se hace la primera vez.
+ *
+ * @return error "El error del registro"
+ */
+func RegisterValidators() error {
+	validationOnce.Do(func() {
+		validationErr = registerValidators()
+	})
+
+	return validationErr
+}
+
+func registerValidators() error {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return fmt.Errorf("el validador de Gin no es compatible")
+	}
+
+	// Los errores usan el nombre JSON de los campos
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		} else if name == "" {
+			return field.Name
+		}
+
+		return name
+	})
+
+	validations := map[string]validator.Func{
+		"objectid": validateObjectID,
+		"future":   validateFuture,
+		"language": validateLanguage,
+	}
+	for tag, values := range validationEnums {
+		validations[tag] = validateEnum(values)
+	}
+
+	for tag, fn := range validations {
+		if err := validate.RegisterValidation(tag, fn); err != nil {
+			return err
+		}
+	}
+
+	esLocale := es.New()
+	validationTranslator = ut.New(esLocale, esLocale, en.New())
+
+	defaults := map[string]func(*validator.Validate, ut.Translator) error{
+		"es": esTranslations.RegisterDefaultTranslations,
+		"en": enTranslations.RegisterDefaultTranslations,
+	}
+	for lang, register := range defaults {
+		translator, _ := validationTranslator.GetTranslator(lang)
+		if err := register(validate, translator); err != nil {
+			return err
+		}
+
+		if err := registerTranslations(validate, translator, validationMessages[lang]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Registra los mensajes de las validaciones propias en un idioma
+func registerTranslations(validate *validator.Validate, translator ut.Translator, messages map[string]string) error {
+	tags := map[string]string{
+		"objectid": "objectid",
+		"future":   "future",
+		"language": "language",
+	}
+	for tag := range validationEnums {
+		tags[tag] = "enum"
+	}
+
+	for tag, key := range tags {
+		tag, key := tag, key
+		if err := translator.Add(tag, messages[key], false); err != nil {
+			return err
+		}
+
+		err := validate.RegisterTranslation(tag, translator, func(ut.Translator) error {
+			return nil
+		}, func(translator ut.Translator, fe validator.FieldError) string {
+			var allowed string
+			switch {
+			case tag == "language":
+				allowed = strings.Join(SupportedLanguages, ", ")
+			case validationEnums[tag] != nil:
+				allowed = strings.Join(validationEnums[tag], ", ")
+			}
+
+			message, err := translator.T(tag, fe.Field(), allowed)
+			if err != nil {
+				return fe.Error()
+			}
+
+			return message
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateObjectID(fl validator.FieldLevel) bool {
+	return primitive.IsValidObjectID(fl.Field().String())
+}
+
+func validateFuture(fl validator.FieldLevel) bool {
+	date, ok := fl.Field().Interface().(time.Time)
+	return ok && date.After(time.Now())
+}
+
+func validateLanguage(fl validator.FieldLevel) bool {
+	return IsSupportedLanguage(fl.Field().String())
+}
+
+func validateEnum(values []string) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		for _, allowed := range values {
+			if allowed == value {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+/** Obtiene el idioma de los mensajes de validación a partir de la cabecera Accept-Language
+ *
+ * @param ctx *gin.Context "El contexto de la petición"
+ * @return string "El código del idioma, es o en"
+ */
+func ValidationLanguage(ctx *gin.Context) string {
+	tags, _, err := language.ParseAcceptLanguage(ctx.GetHeader("Accept-Language"))
+	if err != nil || len(tags) == 0 {
+		return defaultValidationLanguage
+	}
+
+	if tag, _, confidence := validationLanguageMatcher.Match(tags...); confidence != language.No {
+		base, _ := tag.Base()
+		return base.String()
+	}
+
+	return defaultValidationLanguage
+}
+
+/** Convierte los errores del validador en errores por campo con mensajes en el idioma indicado
+ *
+ * @param errs validator.ValidationErrors "Los errores del validador"
+ * @param lang string "El código del idioma de los mensajes"
+ * @return []FieldError "Los errores por campo"
+ */
+func TranslateValidationErrors(errs validator.ValidationErrors, lang string) []FieldError {
+	var translator ut.Translator
+	if validationTranslator != nil {
+		translator, _ = validationTranslator.GetTranslator(lang)
+	}
+
+	fields := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		message := fe.Error()
+		if translator != nil {
+			message = fe.Translate(translator)
+		}
+
+		fields = append(fields, FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Code:    fe.Tag(),
+			Message: message,
+		})
+	}
+
+	return fields
+}
+
+// Quita el nombre de la estructura de la ruta del campo, por ejemplo "CreateUserRequest.skills[0]"
+func fieldPath(namespace string) string {
+	if index := strings.Index(namespace, "."); index >= 0 {
+		return namespace[index+1:]
+	}
+
+	return namespace
+}
+
+/** Valida una estructura con las reglas de sus etiquetas binding
+ *
+ * @param value interface{} "La estructura a validar"
+ * @return error "Los errores de validación, de tipo validator.ValidationErrors"
+ */
+func ValidateStruct(value interface{}) error {
+	return binding.Validator.ValidateStruct(value)
+}
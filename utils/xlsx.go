@@ -0,0 +1,344 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tamaño máximo descomprimido de una parte de un libro XLSX, para evitar archivos que se expandan sin límite
+const maxXLSXPartSize = 64 << 20
+
+// Cantidad máxima de columnas de una hoja de Excel, hasta la columna XFD
+const maxXLSXColumns = 16384
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string       `xml:"r,attr"`
+			Type   string       `xml:"t,attr"`
+			Value  string       `xml:"v"`
+			Inline xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func (text xlsxRichText) String() string {
+	if len(text.Runs) == 0 {
+		return text.Text
+	}
+
+	var builder strings.Builder
+	for _, run := range text.Runs {
+		builder.WriteString(run.Text)
+	}
+
+	return builder.String()
+}
+
+/** Lee las filas de la primera hoja de un libro XLSX
+ *
+ * Las celdas se devuelven como texto; las fórmulas se leen con su último valor calculado.
+ *
+ * @param data []byte "El contenido del archivo"
+ * @return [][]string "Las filas de la hoja"
+ * @return error "Error si el archivo no es un libro XLSX válido"
+ */
+func ReadXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("el archivo no es un libro XLSX válido")
+	}
+
+	var workbook xlsxWorkbook
+	if err = decodeXLSXPart(archive, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, errors.New("el libro XLSX no tiene hojas")
+	}
+
+	var relationships xlsxRelationships
+	if err = decodeXLSXPart(archive, "xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, err
+	}
+
+	sheetPath := ""
+	for _, relationship := range relationships.Relationships {
+		if relationship.ID == workbook.Sheets[0].ID {
+			sheetPath = relationship.Target
+		}
+	}
+	if sheetPath == "" {
+		return nil, errors.New("no se encontró la primera hoja del libro XLSX")
+	}
+	if strings.HasPrefix(sheetPath, "/") {
+		sheetPath = strings.TrimPrefix(sheetPath, "/")
+	} else {
+		sheetPath = path.Join("xl", sheetPath)
+	}
+
+	var sharedStrings xlsxSharedStrings
+	if findXLSXPart(archive, "xl/sharedStrings.xml") != nil {
+		if err = decodeXLSXPart(archive, "xl/sharedStrings.xml", &sharedStrings); err != nil {
+			return nil, err
+		}
+	}
+
+	var worksheet xlsxWorksheet
+	if err = decodeXLSXPart(archive, sheetPath, &worksheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(worksheet.Rows))
+	for _, row := range worksheet.Rows {
+		var values []string
+		for position, cell := range row.Cells {
+			column := position
+			if cell.Ref != "" {
+				var err error
+				if column, err = xlsxColumnIndex(cell.Ref); err != nil {
+					return nil, err
+				}
+			}
+
+			for len(values) <= column {
+				values = append(values, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(sharedStrings.Items) {
+					return nil, errors.New("el libro XLSX tiene una referencia de texto inválida")
+				}
+				values[column] = sharedStrings.Items[index].String()
+			case "inlineStr":
+				values[column] = cell.Inline.String()
+			default:
+				values[column] = cell.Value
+			}
+		}
+
+		rows = append(rows, values)
+	}
+
+	return rows, nil
+}
+
+func findXLSXPart(archive *zip.Reader, name string) *zip.File {
+	for _, file := range archive.File {
+		if file.Name == name {
+			return file
+		}
+	}
+
+	return nil
+}
+
+func decodeXLSXPart(archive *zip.Reader, name string, v interface{}) error {
+	file := findXLSXPart(archive, name)
+	if file == nil {
+		return errors.New("el libro XLSX no tiene la parte " + name)
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err = xml.NewDecoder(io.LimitReader(reader, maxXLSXPartSize)).Decode(v); err != nil {
+		return errors.New("el libro XLSX tiene una parte dañada: " + name)
+	}
+
+	return nil
+}
+
+/** Convierte la referencia de una celda, como "AB12", en el índice de su columna empezando en 0
+ *
+ * @param ref string "La referencia de la celda"
+ * @return int "El índice de la columna"
+ * @return error "Error si la referencia no empieza con una columna de la A a la XFD"
+ */
+func xlsxColumnIndex(ref string) (int, error) {
+	column := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+
+		column = column*26 + int(r-'A'+1)
+		if column > maxXLSXColumns {
+			return 0, fmt.Errorf("el libro XLSX tiene una referencia de celda inválida: %.20q", ref)
+		}
+	}
+
+	if column == 0 {
+		return 0, fmt.Errorf("el libro XLSX tiene una referencia de celda inválida: %.20q", ref)
+	}
+
+	return column - 1, nil
+}
+
+// Escribe un libro XLSX de una sola hoja fila por fila, sin guardar las filas en memoria
+type XLSXWriter struct {
+	archive *zip.Writer
+	sheet   io.Writer
+}
+
+/** Crea un libro XLSX con una hoja y deja la hoja abierta para escribir filas
+ *
+ * @param w io.Writer "El destino del archivo"
+ * @param sheetName string "El nombre de la hoja"
+ * @return *XLSXWriter "El escritor del libro"
+ * @return error "El error de escritura"
+ */
+func NewXLSXWriter(w io.Writer, sheetName string) (*XLSXWriter, error) {
+	archive := zip.NewWriter(w)
+
+	if len(sheetName) > 31 {
+		sheetName = sheetName[:31]
+	}
+
+	var escapedName bytes.Buffer
+	if err := xml.EscapeText(&escapedName, []byte(sheetName)); err != nil {
+		return nil, err
+	}
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + escapedName.String() + `" sheetId="1" r:id="rId1"/></sheets>` +
+			`</workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+	}
+
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = io.WriteString(file, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = io.WriteString(sheet, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return nil, err
+	}
+
+	return &XLSXWriter{archive: archive, sheet: sheet}, nil
+}
+
+/** Escribe una fila en la hoja
+ *
+ * Los números se guardan como números y el resto de los valores como texto.
+ *
+ * @param values []interface{} "Los valores de la fila"
+ * @return error "El error de escritura"
+ */
+func (writer *XLSXWriter) WriteRow(values []interface{}) error {
+	var row bytes.Buffer
+	row.WriteString("<row>")
+
+	for _, value := range values {
+		switch number := value.(type) {
+		case int, int32, int64, float32, float64:
+			fmt.Fprintf(&row, "<c><v>%v</v></c>", number)
+		default:
+			row.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(&row, []byte(FormatCell(value))); err != nil {
+				return err
+			}
+			row.WriteString("</t></is></c>")
+		}
+	}
+
+	row.WriteString("</row>")
+	_, err := writer.sheet.Write(row.Bytes())
+	return err
+}
+
+// Cierra la hoja y el libro
+func (writer *XLSXWriter) Close() error {
+	if _, err := io.WriteString(writer.sheet, "</sheetData></worksheet>"); err != nil {
+		return err
+	}
+
+	return writer.archive.Close()
+}
+
+/** Convierte el valor de una celda en texto
+ *
+ * Las fechas se escriben en formato RFC 3339 y los valores nulos como texto vacío.
+ *
+ * @param value interface{} "El valor"
+ * @return string "El texto de la celda"
+ */
+func FormatCell(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case time.Time:
+		if typed.IsZero() {
+			return ""
+		}
+		return typed.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(typed)
+	}
+}
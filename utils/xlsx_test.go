@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+const (
+	testWorkbookXML = `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Hoja1" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	testRelsXML     = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`
+)
+
+// Arma un archivo XLSX con las partes indicadas, por nombre
+func buildXLSX(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for name, content := range parts {
+		writer, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = writer.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func worksheetXML(rows string) string {
+	return `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + rows + `</sheetData></worksheet>`
+}
+
+func TestReadXLSX(t *testing.T) {
+	tests := []struct {
+		name    string
+		sheet   string
+		strings string
+		want    [][]string
+	}{
+		{
+			name:    "textos compartidos",
+			sheet:   `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>`,
+			strings: `<sst><si><t>email</t></si><si><r><t>nom</t></r><r><t>bre</t></r></si></sst>`,
+			want:    [][]string{{"email", "nombre"}},
+		},
+		{
+			name:  "textos en línea y números",
+			sheet: `<row r="1"><c r="A1" t="inlineStr"><is><t>Ana</t></is></c><c r="B1"><v>42</v></c></row>`,
+			want:  [][]string{{"Ana", "42"}},
+		},
+		{
+			name:  "celdas vacías intermedias",
+			sheet: `<row r="1"><c r="A1"><v>1</v></c><c r="C1"><v>3</v></c></row><row r="2"><c r="AB2"><v>x</v></c></row>`,
+			want:  [][]string{{"1", "", "3"}, append(make([]string, 27), "x")},
+		},
+		{
+			name:  "celdas sin referencia",
+			sheet: `<row><c><v>a</v></c><c><v>b</v></c></row>`,
+			want:  [][]string{{"a", "b"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parts := map[string]string{
+				"xl/workbook.xml":            testWorkbookXML,
+				"xl/_rels/workbook.xml.rels": testRelsXML,
+				"xl/worksheets/sheet1.xml":   worksheetXML(test.sheet),
+			}
+			if test.strings != "" {
+				parts["xl/sharedStrings.xml"] = test.strings
+			}
+
+			rows, err := ReadXLSX(buildXLSX(t, parts))
+			if err != nil {
+				t.Fatalf("ReadXLSX() error = %v", err)
+			}
+			if !reflect.DeepEqual(rows, test.want) {
+				t.Errorf("ReadXLSX() = %q, want %q", rows, test.want)
+			}
+		})
+	}
+}
+
+func TestReadXLSXInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data func(t *testing.T) []byte
+	}{
+		{
+			name: "no es un zip",
+			data: func(t *testing.T) []byte { return []byte("email,nombre") },
+		},
+		{
+			name: "sin libro",
+			data: func(t *testing.T) []byte {
+				return buildXLSX(t, map[string]string{"xl/worksheets/sheet1.xml": worksheetXML("")})
+			},
+		},
+		{
+			name: "sin hojas",
+			data: func(t *testing.T) []byte {
+				return buildXLSX(t, map[string]string{
+					"xl/workbook.xml":            `<workbook><sheets></sheets></workbook>`,
+					"xl/_rels/workbook.xml.rels": testRelsXML,
+				})
+			},
+		},
+		{
+			name: "texto compartido inexistente",
+			data: func(t *testing.T) []byte {
+				return buildXLSX(t, map[string]string{
+					"xl/workbook.xml":            testWorkbookXML,
+					"xl/_rels/workbook.xml.rels": testRelsXML,
+					"xl/worksheets/sheet1.xml":   worksheetXML(`<row><c r="A1" t="s"><v>5</v></c></row>`),
+				})
+			},
+		},
+		{
+			name: "referencia de celda en minúsculas",
+			data: func(t *testing.T) []byte {
+				return buildXLSX(t, map[string]string{
+					"xl/workbook.xml":            testWorkbookXML,
+					"xl/_rels/workbook.xml.rels": testRelsXML,
+					"xl/worksheets/sheet1.xml":   worksheetXML(`<row><c r="a1"><v>1</v></c></row>`),
+				})
+			},
+		},
+		{
+			name: "referencia de celda fuera de la hoja",
+			data: func(t *testing.T) []byte {
+				return buildXLSX(t, map[string]string{
+					"xl/workbook.xml":            testWorkbookXML,
+					"xl/_rels/workbook.xml.rels": testRelsXML,
+					"xl/worksheets/sheet1.xml":   worksheetXML(`<row><c r="ZZZZZZZZ1"><v>1</v></c></row>`),
+				})
+			},
+		},
+		{
+			name: "hoja dañada",
+			data: func(t *testing.T) []byte {
+				return buildXLSX(t, map[string]string{
+					"xl/workbook.xml":            testWorkbookXML,
+					"xl/_rels/workbook.xml.rels": testRelsXML,
+					"xl/worksheets/sheet1.xml":   `<worksheet><sheetData><row>`,
+				})
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadXLSX(test.data(t)); err == nil {
+				t.Error("ReadXLSX() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestXLSXColumnIndex(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want int
+	}{
+		{"A1", 0},
+		{"Z10", 25},
+		{"AA3", 26},
+		{"AB12", 27},
+		{"XFD1048576", 16383},
+		{"B", 1},
+	}
+
+	for _, test := range tests {
+		got, err := xlsxColumnIndex(test.ref)
+		if err != nil || got != test.want {
+			t.Errorf("xlsxColumnIndex(%q) = %d, %v, want %d", test.ref, got, err, test.want)
+		}
+	}
+}
+
+func TestXLSXColumnIndexInvalid(t *testing.T) {
+	refs := []string{
+		"a1",
+		"1",
+		"",
+		"$A$1",
+		"ñ1",
+		"XFE1",
+		"ZZZZ1",
+		"ZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZ1",
+	}
+
+	for _, ref := range refs {
+		if got, err := xlsxColumnIndex(ref); err == nil {
+			t.Errorf("xlsxColumnIndex(%q) = %d, want error", ref, got)
+		}
+	}
+}